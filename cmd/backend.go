@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+	"github.com/mfenderov/claude-sync/internal/signing"
+	"github.com/mfenderov/claude-sync/internal/sync"
+	"github.com/mfenderov/claude-sync/internal/ui"
+)
+
+const (
+	backendAuto  = "auto"
+	backendGit   = "git"
+	backendGoGit = "go-git"
+)
+
+// backendEnv selects the git backend when --git-backend is not given
+const backendEnv = "CLAUDE_SYNC_GIT_BACKEND"
+
+var (
+	gitBackend           string
+	allowUnapprovedHooks bool
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&gitBackend, "git-backend", "",
+		"Git implementation: auto (git if installed, else go-git), git, or go-git (default $"+backendEnv+" or auto)")
+	rootCmd.PersistentFlags().BoolVar(&allowUnapprovedHooks, "allow-unapproved-hooks", false,
+		"Without git installed, apply pulled hooks and commands without holding them for approval")
+}
+
+// selectBackend resolves --git-backend and $CLAUDE_SYNC_GIT_BACKEND to git or
+// go-git, and reports whether the git binary is installed
+func selectBackend() (backend string, hasGit bool, err error) {
+	backend = gitBackend
+	if backend == "" {
+		backend = os.Getenv(backendEnv)
+	}
+	_, err = exec.LookPath("git")
+	hasGit = err == nil
+
+	switch backend {
+	case "", backendAuto:
+		backend = backendGit
+		if !hasGit {
+			backend = backendGoGit
+		}
+	case backendGit:
+		if !hasGit {
+			return "", false, fmt.Errorf("git is not installed - install it or use --git-backend %s", backendGoGit)
+		}
+	case backendGoGit:
+	default:
+		return "", false, fmt.Errorf("unknown git backend %q (use %q, %q, or %q)", backend, backendAuto, backendGit, backendGoGit)
+	}
+	return backend, hasGit, nil
+}
+
+// newGitOperator returns the GitOperator of the selected backend, with the
+// hook and signature checks when git is installed: both run the git binary.
+// Without git, syncing fails rather than apply pulled hooks unapproved,
+// unless --allow-unapproved-hooks is given, and a repository that lists
+// allowed signers never syncs.
+func newGitOperator() (sync.GitOperator, []sync.Option, error) {
+	backend, hasGit, err := selectBackend()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var operator sync.GitOperator = sync.NewGitAdapter()
+	if backend == backendGoGit {
+		operator = sync.NewGoGitAdapter()
+	}
+	if hasGit {
+		return operator, []sync.Option{
+			sync.WithHookGate(sync.NewHookGateAdapter()),
+			sync.WithSignatureVerifier(sync.NewSignatureVerifierAdapter()),
+		}, nil
+	}
+
+	if claudeDir, err := git.GetClaudeDir(); err == nil {
+		if _, err := os.Stat(signing.Path(claudeDir)); err == nil {
+			return nil, nil, fmt.Errorf("%s lists allowed signers, and verifying incoming commits needs git - install git to sync", signing.FileName)
+		}
+	}
+	if !allowUnapprovedHooks {
+		return nil, nil, errors.New("holding pulled hooks for approval needs git - install git, or pass --allow-unapproved-hooks to apply them without approval")
+	}
+	if !quiet {
+		fmt.Fprintln(os.Stderr, ui.RenderWarning("⚠️", "git is not installed: pulled hooks and commands apply without approval"))
+	}
+	return operator, nil, nil
+}
+
+// newGitBinaryOperator is newGitOperator for commands that only the git
+// backend implements; they fail up front when go-git is selected
+func newGitBinaryOperator(command string) (sync.GitOperator, []sync.Option, error) {
+	backend, hasGit, err := selectBackend()
+	if err != nil {
+		return nil, nil, err
+	}
+	if backend == backendGoGit {
+		if !hasGit {
+			return nil, nil, fmt.Errorf("%s needs git, which is not installed", command)
+		}
+		return nil, nil, fmt.Errorf("%s needs git and does not work with --git-backend %s", command, backendGoGit)
+	}
+	return newGitOperator()
+}
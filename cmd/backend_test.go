@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNewGitOperator_WithoutGitFailsClosed(t *testing.T) {
+	// Neither git nor a repository is found
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(backendEnv, "")
+	quiet = true
+	t.Cleanup(func() { quiet = false })
+
+	if _, _, err := newGitOperator(); err == nil {
+		t.Fatal("newGitOperator() without git succeeded, want an error")
+	}
+
+	allowUnapprovedHooks = true
+	t.Cleanup(func() { allowUnapprovedHooks = false })
+	operator, opts, err := newGitOperator()
+	if err != nil {
+		t.Fatalf("newGitOperator() with --allow-unapproved-hooks error = %v", err)
+	}
+	if operator == nil || len(opts) != 0 {
+		t.Errorf("newGitOperator() = %v, %d options, want the go-git operator without checks", operator, len(opts))
+	}
+}
+
+func TestNewGitBinaryOperator_RejectsGoGit(t *testing.T) {
+	t.Setenv(backendEnv, backendGoGit)
+
+	if _, _, err := newGitBinaryOperator("restore"); err == nil {
+		t.Fatal("newGitBinaryOperator() with the go-git backend succeeded, want an error")
+	}
+}
+
+func TestMCPRestore_RejectsGoGit(t *testing.T) {
+	t.Setenv(backendEnv, backendGoGit)
+
+	args := json.RawMessage(`{"path": "settings.json", "at": "HEAD~1"}`)
+	if _, err := mcpRestore(context.Background(), args); err == nil || !strings.Contains(err.Error(), "restore needs git") {
+		t.Fatalf("mcpRestore() with the go-git backend error = %v, want restore to need git", err)
+	}
+}
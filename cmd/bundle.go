@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/mfenderov/claude-sync/internal/sync"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export --bundle <file>",
+	Short: "Write your configuration to a bundle file for an offline machine",
+	Long: `Commits local changes and writes them to a git bundle file, which can be
+carried to a machine without network access and applied there with
+'claude-sync import'.
+
+The bundle leaves out the commits imported from the other machine's bundles,
+since that machine already has them. Use --full to write the whole history,
+e.g. for a machine that has never synced.
+
+To sync through a directory, such as one on a USB drive, instead of passing
+bundles by hand, see 'claude-sync remote set-bundle-dir'.`,
+	Example: `  claude-sync export --bundle /media/usb/laptop.bundle
+  claude-sync export --bundle config.bundle --full`,
+	Args: cobra.NoArgs,
+	RunE: runExport,
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Apply a bundle file written by 'claude-sync export'",
+	Long: `Commits local changes, verifies a bundle written by 'claude-sync export' on
+another machine, and applies it like a pull: local commits are rebased onto
+the bundle's, with the same conflict handling, signature checks, and hook
+approval. Nothing is pushed.`,
+	Example: `  claude-sync import /media/usb/laptop.bundle`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runImport,
+}
+
+var (
+	exportBundle string
+	exportFull   bool
+)
+
+func init() {
+	rootCmd.AddCommand(exportCmd, importCmd)
+	exportCmd.Flags().StringVar(&exportBundle, "bundle", "", "Bundle file to write")
+	exportCmd.Flags().BoolVar(&exportFull, "full", false, "Write the whole history, not just the commits the other machine is missing")
+	_ = exportCmd.MarkFlagRequired("bundle")
+	addOutputFlag(exportCmd)
+	addOutputFlag(importCmd)
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	service, err := newBundleService()
+	if err != nil {
+		return err
+	}
+	return service.Export(cmd.Context(), exportBundle, exportFull)
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	service, err := newBundleService()
+	if err != nil {
+		return err
+	}
+	return service.Import(cmd.Context(), args[0])
+}
+
+// newBundleService returns a service set up like a pull's
+func newBundleService() (*sync.Service, error) {
+	structured, err := jsonOutput()
+	if err != nil {
+		return nil, err
+	}
+	prompter, err := newPrompter(structured)
+	if err != nil {
+		return nil, err
+	}
+
+	gitAdapter, gitOpts, err := newGitOperator()
+	if err != nil {
+		return nil, err
+	}
+	return sync.NewService(gitAdapter, prompter, newLogger(structured), append([]sync.Option{
+		sync.WithConflictResolver(newConflictResolver(structured)),
+		sync.WithLocker(newLocker()),
+		sync.WithSecretScanner(newSecretScanner()),
+		sync.WithSecretStore(sync.NewSecretStoreAdapter(gitAdapter)),
+		sync.WithOverlays(sync.NewOverlayAdapter(gitAdapter)),
+		sync.WithJournal(sync.NewJournalAdapter()),
+	}, gitOpts...)...), nil
+}
@@ -0,0 +1,356 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+	"github.com/mfenderov/claude-sync/internal/jsondoc"
+	"github.com/mfenderov/claude-sync/internal/textdiff"
+	"github.com/mfenderov/claude-sync/internal/ui"
+	"github.com/mfenderov/claude-sync/internal/vault"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [path...]",
+	Short: "Show local changes and the incoming changes a sync would apply",
+	Long: `Shows what the next sync would do: the uncommitted changes on this machine,
+and, after fetching, the changes on the remote that it would pull.
+
+JSON files such as settings.json are compared by structure, listing the keys
+that were added, removed, or changed by their path. Other files are shown as
+a line diff. Paths limit the output to those files or directories.`,
+	Example: `  claude-sync diff
+  claude-sync diff settings.json
+  claude-sync diff --incoming -o json`,
+	RunE: runDiff,
+}
+
+var (
+	diffLocal    bool
+	diffIncoming bool
+)
+
+// diffContext is the number of unchanged lines shown around line changes
+const diffContext = 3
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().BoolVar(&diffLocal, "local", false, "Only show uncommitted local changes")
+	diffCmd.Flags().BoolVar(&diffIncoming, "incoming", false, "Only show incoming changes from the remote")
+	addOutputFlag(diffCmd)
+}
+
+// fileDiff is the change to one file
+type fileDiff struct {
+	Path string `json:"path"`
+	// Status is added, modified, or deleted
+	Status string `json:"status"`
+	Binary bool   `json:"binary,omitempty"`
+	// Encrypted marks secret sidecars, whose content is not shown
+	Encrypted bool `json:"encrypted,omitempty"`
+	// Changes is the structural diff of a JSON file
+	Changes []jsondoc.Change `json:"changes,omitempty"`
+	// Diff holds the unified diff lines of any other text file
+	Diff []string `json:"diff,omitempty"`
+}
+
+// diffReport is the machine-readable document for 'diff -o json'
+type diffReport struct {
+	Local           []fileDiff `json:"local"`
+	IncomingCommits []string   `json:"incoming_commits"`
+	Incoming        []fileDiff `json:"incoming"`
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	structured, err := jsonOutput()
+	if err != nil {
+		return err
+	}
+
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+	if !git.IsGitRepo(claudeDir) {
+		return fmt.Errorf("%s is not a git repository - run claude-sync once to set up sync", claudeDir)
+	}
+
+	paths, err := claudePaths(claudeDir, args)
+	if err != nil {
+		return err
+	}
+	showLocal, showIncoming := diffLocal || !diffIncoming, diffIncoming || !diffLocal
+
+	report, upstream, err := collectDiff(ctx, claudeDir, paths, showLocal, showIncoming)
+	if err != nil {
+		return err
+	}
+
+	if structured {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	if showLocal {
+		fmt.Println(ui.HeaderStyle.Render("📝 Local changes"))
+		if len(report.Local) == 0 {
+			fmt.Println(ui.RenderMuted("  No uncommitted changes"))
+		}
+		for _, d := range report.Local {
+			fmt.Print(renderFileDiff(d))
+		}
+	}
+	if showIncoming {
+		fmt.Println(ui.HeaderStyle.Render("📥 Incoming changes"))
+		switch {
+		case !upstream:
+			fmt.Println(ui.RenderMuted("  The branch does not track a remote branch yet"))
+		case len(report.IncomingCommits) == 0:
+			fmt.Println(ui.RenderMuted("  Up to date with the remote"))
+		default:
+			for _, c := range report.IncomingCommits {
+				fmt.Println(ui.ListItemStyle.Render(c))
+			}
+			fmt.Println()
+			for _, d := range report.Incoming {
+				fmt.Print(renderFileDiff(d))
+			}
+		}
+	}
+	return nil
+}
+
+// claudePaths turns path arguments into paths relative to the Claude
+// directory; relative arguments already are
+func claudePaths(claudeDir string, args []string) ([]string, error) {
+	paths := make([]string, 0, len(args))
+	for _, arg := range args {
+		path := filepath.ToSlash(filepath.Clean(arg))
+		if filepath.IsAbs(arg) {
+			var err error
+			if path, err = repoRelative(claudeDir, arg); err != nil {
+				return nil, err
+			}
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// collectDiff gathers the local and, after fetching, the incoming changes to
+// paths. It reports false when the branch does not track a remote branch.
+func collectDiff(ctx context.Context, claudeDir string, paths []string, local, incoming bool) (diffReport, bool, error) {
+	var report diffReport
+	var err error
+	if local {
+		if report.Local, err = localDiffs(ctx, claudeDir, paths); err != nil {
+			return report, false, err
+		}
+	}
+	upstream := true
+	if incoming {
+		if err := git.Fetch(ctx, claudeDir); err != nil {
+			fmt.Fprintln(os.Stderr, ui.RenderWarning("⚠️", "Could not fetch; showing the remote as of the last sync"))
+		}
+		if _, err := git.RevParse(ctx, claudeDir, "@{upstream}"); err != nil {
+			upstream = false
+		} else if report.IncomingCommits, report.Incoming, err = incomingDiffs(ctx, claudeDir, paths); err != nil {
+			return report, false, err
+		}
+	}
+	report.Local, report.Incoming = nonNil(report.Local), nonNil(report.Incoming)
+	report.IncomingCommits = nonNil(report.IncomingCommits)
+	return report, upstream, nil
+}
+
+// localDiffs diffs the uncommitted changes the sync rules allow against HEAD
+func localDiffs(ctx context.Context, claudeDir string, paths []string) ([]fileDiff, error) {
+	files, err := git.GetChangedFiles(ctx, claudeDir)
+	if err != nil {
+		return nil, err
+	}
+	_, headErr := git.RevParse(ctx, claudeDir, "HEAD")
+
+	var diffs []fileDiff
+	for _, file := range filterPaths(files, paths) {
+		var old []byte
+		if headErr == nil {
+			if old, err = git.ReadFileAt(ctx, claudeDir, "HEAD", file); err != nil {
+				return nil, err
+			}
+		}
+		current, err := os.ReadFile(filepath.Join(claudeDir, file))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if d, ok := diffFile(file, old, current); ok {
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs, nil
+}
+
+// incomingDiffs lists the commits on the upstream branch that HEAD lacks and
+// diffs what they changed since the branches diverged
+func incomingDiffs(ctx context.Context, claudeDir string, paths []string) ([]string, []fileDiff, error) {
+	commits, err := git.GetCommitsInRange(ctx, claudeDir, "HEAD..@{upstream}")
+	if err != nil || len(commits) == 0 {
+		return commits, nil, err
+	}
+	base, err := git.MergeBase(ctx, claudeDir, "HEAD", "@{upstream}")
+	if err != nil {
+		return nil, nil, err
+	}
+	files, err := git.GetFilesInRange(ctx, claudeDir, "HEAD...@{upstream}")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var diffs []fileDiff
+	for _, file := range filterPaths(files, paths) {
+		old, err := git.ReadFileAt(ctx, claudeDir, base, file)
+		if err != nil {
+			return nil, nil, err
+		}
+		incoming, err := git.ReadFileAt(ctx, claudeDir, "@{upstream}", file)
+		if err != nil {
+			return nil, nil, err
+		}
+		if d, ok := diffFile(file, old, incoming); ok {
+			diffs = append(diffs, d)
+		}
+	}
+	return commits, diffs, nil
+}
+
+// filterPaths keeps the files that are one of paths or below one of them
+func filterPaths(files, paths []string) []string {
+	if len(paths) == 0 {
+		return files
+	}
+	var kept []string
+	for _, file := range files {
+		for _, p := range paths {
+			if p == "." || file == p || strings.HasPrefix(file, p+"/") {
+				kept = append(kept, file)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// diffFile compares two versions of a file; nil means the file is missing on
+// that side. It reports false when the versions are the same.
+func diffFile(path string, old, current []byte) (fileDiff, bool) {
+	d := fileDiff{Path: path, Status: "modified"}
+	switch {
+	case (old == nil) == (current == nil) && bytes.Equal(old, current):
+		return d, false
+	case old == nil:
+		d.Status = "added"
+	case current == nil:
+		d.Status = "deleted"
+	}
+
+	switch {
+	case strings.HasSuffix(path, vault.SidecarSuffix):
+		d.Encrypted = true
+	case bytes.IndexByte(old, 0) >= 0 || bytes.IndexByte(current, 0) >= 0:
+		d.Binary = true
+	case strings.HasSuffix(path, ".json") && jsonDiff(&d, old, current):
+	default:
+		for _, h := range textdiff.Hunks(textdiff.Lines(textdiff.SplitLines(old), textdiff.SplitLines(current)), diffContext) {
+			d.Diff = append(d.Diff, fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines))
+			for _, l := range h.Lines {
+				d.Diff = append(d.Diff, [...]string{" ", "-", "+"}[l.Kind]+l.Text)
+			}
+		}
+	}
+	return d, true
+}
+
+// jsonDiff fills in the structural diff of a JSON file. It reports false when
+// either side is not valid JSON, so the file is shown as text instead.
+func jsonDiff(d *fileDiff, old, current []byte) bool {
+	parse := func(data []byte) (any, error) {
+		if data == nil {
+			return jsondoc.NewObject(), nil
+		}
+		return jsondoc.Parse(data)
+	}
+	a, err := parse(old)
+	if err != nil {
+		return false
+	}
+	b, err := parse(current)
+	if err != nil {
+		return false
+	}
+	// Non-nil even when empty, so a reformatted file is not shown as text
+	d.Changes = append([]jsondoc.Change{}, jsondoc.Diff(a, b)...)
+	return true
+}
+
+// renderFileDiff formats the change to one file
+func renderFileDiff(d fileDiff) string {
+	var b strings.Builder
+	header := d.Path
+	switch d.Status {
+	case "added":
+		header = ui.DiffAddedStyle.Render("+ " + d.Path + " (new)")
+	case "deleted":
+		header = ui.DiffRemovedStyle.Render("- " + d.Path + " (deleted)")
+	}
+	b.WriteString("  " + ui.PrimaryStyle.Render(header) + "\n")
+
+	switch {
+	case d.Encrypted:
+		b.WriteString(ui.ListItemStyle.Render(ui.RenderMuted("encrypted secrets changed")) + "\n")
+	case d.Binary:
+		b.WriteString(ui.ListItemStyle.Render(ui.RenderMuted("binary file changed")) + "\n")
+	case d.Changes != nil:
+		if len(d.Changes) == 0 {
+			b.WriteString(ui.ListItemStyle.Render(ui.RenderMuted("formatting only")) + "\n")
+		}
+		for _, c := range d.Changes {
+			path := c.Path
+			if path == "" {
+				path = "(document)"
+			}
+			var line string
+			switch c.Kind {
+			case jsondoc.Added:
+				line = ui.DiffAddedStyle.Render("+ " + path + ": " + c.New)
+			case jsondoc.Removed:
+				line = ui.DiffRemovedStyle.Render("- " + path + ": " + c.Old)
+			default:
+				line = "~ " + path + ": " + ui.DiffRemovedStyle.Render(c.Old) + " → " + ui.DiffAddedStyle.Render(c.New)
+			}
+			b.WriteString(ui.ListItemStyle.Render(line) + "\n")
+		}
+	default:
+		for _, line := range d.Diff {
+			switch {
+			case strings.HasPrefix(line, "@@"):
+				line = ui.MutedStyle.Render(line)
+			case strings.HasPrefix(line, "+"):
+				line = ui.DiffAddedStyle.Render(line)
+			case strings.HasPrefix(line, "-"):
+				line = ui.DiffRemovedStyle.Render(line)
+			}
+			b.WriteString(ui.ListItemStyle.Render(line) + "\n")
+		}
+	}
+	return b.String()
+}
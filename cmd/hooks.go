@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+	"github.com/mfenderov/claude-sync/internal/hookgate"
+	"github.com/mfenderov/claude-sync/internal/ui"
+)
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Review hooks that arrived from other machines",
+	Long: `Claude Code runs hooks automatically, so a bad push to the config repository
+would run on every machine. Each machine keeps a local record of the hook
+scripts under hooks/ and the hook commands in settings.json it approved.
+
+When a sync pulls a script or command that is new or changed, it is disabled
+on this machine until it is approved: scripts are replaced by a stub that
+does nothing, and commands by a no-op. The stubs are never committed. Hooks
+edited on this machine are approved when they are synced.`,
+}
+
+var hooksListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List hooks waiting for approval",
+	Args:  cobra.NoArgs,
+	RunE:  runHooksList,
+}
+
+var hooksApproveCmd = &cobra.Command{
+	Use:   "approve",
+	Short: "Show the changes to quarantined hooks and enable them",
+	Long: `Shows how each quarantined script differs from the version last approved on
+this machine and which commands were added, then asks to approve them. Once
+approved they are put back in place and run again.`,
+	Example: `  claude-sync hooks approve
+  claude-sync hooks approve --yes   # Approve without prompting`,
+	Args: cobra.NoArgs,
+	RunE: runHooksApprove,
+}
+
+func init() {
+	rootCmd.AddCommand(hooksCmd)
+	hooksCmd.AddCommand(hooksListCmd, hooksApproveCmd)
+	addOutputFlag(hooksListCmd)
+	addOutputFlag(hooksApproveCmd)
+}
+
+// hooksReport is the machine-readable document for 'hooks approve -o json'
+type hooksReport struct {
+	Hooks    []hookgate.Hook `json:"hooks"`
+	Approved bool            `json:"approved"`
+}
+
+func runHooksList(cmd *cobra.Command, args []string) error {
+	structured, err := jsonOutput()
+	if err != nil {
+		return err
+	}
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+	pending, err := hookgate.Pending(claudeDir)
+	if err != nil {
+		return err
+	}
+
+	if structured {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(nonNil(pending))
+	}
+
+	var content strings.Builder
+	if len(pending) == 0 {
+		content.WriteString(ui.MutedStyle.Render("None - every hook on this machine is approved"))
+	}
+	for _, h := range pending {
+		content.WriteString(ui.ListItemStyle.Render("• "+h.String()) + "\n")
+	}
+	fmt.Println(ui.RenderBox("🚧 Hooks waiting for approval", strings.TrimRight(content.String(), "\n")))
+	if len(pending) > 0 {
+		fmt.Println(ui.RenderMuted("  Review and enable them with 'claude-sync hooks approve'"))
+	}
+	return nil
+}
+
+func runHooksApprove(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	structured, err := jsonOutput()
+	if err != nil {
+		return err
+	}
+	prompter, err := newPrompter(structured)
+	if err != nil {
+		return err
+	}
+
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+	if !git.IsGitRepo(claudeDir) {
+		return fmt.Errorf("%s is not a git repository - run claude-sync once to set up sync", claudeDir)
+	}
+	unlock, err := newLocker().Lock(ctx, claudeDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	pending, err := hookgate.Pending(claudeDir)
+	if err != nil {
+		return err
+	}
+	report := hooksReport{Hooks: nonNil(pending)}
+	if len(pending) == 0 {
+		if structured {
+			return encodeHooksReport(report)
+		}
+		fmt.Println(ui.RenderSuccess("✓", "No hooks waiting for approval"))
+		return nil
+	}
+
+	if !structured {
+		preview, err := hooksPreview(ctx, claudeDir, pending)
+		if err != nil {
+			return err
+		}
+		fmt.Println(ui.HeaderStyle.Render("🚧 Quarantined hooks"))
+		fmt.Print(preview)
+		fmt.Println()
+	}
+
+	approve, err := prompter.Confirm("Approve and enable these hooks?")
+	if err != nil {
+		return err
+	}
+	if !approve {
+		if structured {
+			return encodeHooksReport(report)
+		}
+		fmt.Println(ui.RenderInfo("ℹ️", "Nothing approved - the hooks stay disabled"))
+		return nil
+	}
+
+	approved, err := hookgate.Approve(ctx, claudeDir)
+	if err != nil {
+		return err
+	}
+	if structured {
+		return encodeHooksReport(hooksReport{Hooks: nonNil(approved), Approved: true})
+	}
+	fmt.Println(ui.RenderSuccess("✓", fmt.Sprintf("Approved and enabled %d hook(s)", len(approved))))
+	return nil
+}
+
+// hooksPreview shows each quarantined script as a diff against the version
+// last approved here, and each quarantined command as an added line
+func hooksPreview(ctx context.Context, claudeDir string, pending []hookgate.Hook) (string, error) {
+	var b strings.Builder
+	file := ""
+	for _, h := range pending {
+		if !h.IsCommand() {
+			old, err := hookgate.ApprovedVersion(ctx, claudeDir, h.File)
+			if err != nil {
+				return "", err
+			}
+			current, err := git.ReadFileAt(ctx, claudeDir, "HEAD", h.File)
+			if err != nil {
+				return "", err
+			}
+			if d, ok := diffFile(h.File, old, current); ok {
+				b.WriteString(renderFileDiff(d))
+			}
+			continue
+		}
+
+		if h.File != file {
+			file = h.File
+			b.WriteString("  " + ui.PrimaryStyle.Render(file) + "\n")
+		}
+		when := h.Event
+		if h.Matcher != "" {
+			when += " [" + h.Matcher + "]"
+		}
+		b.WriteString(ui.ListItemStyle.Render(ui.DiffAddedStyle.Render("+ "+when+": "+h.Command)) + "\n")
+	}
+	return b.String(), nil
+}
+
+func encodeHooksReport(report hooksReport) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+	"github.com/mfenderov/claude-sync/internal/ui"
+)
+
+var ignoreCmd = &cobra.Command{
+	Use:   "ignore",
+	Short: "Manage .gitignore patterns",
+	Long: `Manage the .gitignore of your Claude Code configuration.
+
+The top of .gitignore is a block of default patterns managed by claude-sync.
+It is updated automatically when a new release adds defaults. Your own
+patterns live below the block and are never touched by upgrades.`,
+}
+
+var ignoreAddCmd = &cobra.Command{
+	Use:   "add <pattern>...",
+	Short: "Add patterns to .gitignore",
+	Long: `Adds patterns below the managed block of .gitignore.
+
+Files that are already committed stay synced until you remove them from the
+repository with 'git rm --cached <file>'.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runIgnoreAdd,
+}
+
+var ignoreRemoveCmd = &cobra.Command{
+	Use:   "remove <pattern>...",
+	Short: "Remove patterns from .gitignore",
+	Long: `Removes your own patterns from .gitignore.
+
+Default patterns from the managed block cannot be removed; add a negated
+pattern instead, for example 'claude-sync ignore add "!*.log"'.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runIgnoreRemove,
+}
+
+var ignoreListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List .gitignore patterns",
+	Args:  cobra.NoArgs,
+	RunE:  runIgnoreList,
+}
+
+var ignoreCheckCmd = &cobra.Command{
+	Use:   "check <path>...",
+	Short: "Show whether paths are ignored and by which pattern",
+	Long: `Shows whether each path is ignored and which pattern decides it, whether
+from .gitignore or from the excludes in .claude-sync.yaml. Paths are
+relative to ~/.claude.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runIgnoreCheck,
+}
+
+func init() {
+	rootCmd.AddCommand(ignoreCmd)
+	ignoreCmd.AddCommand(ignoreAddCmd, ignoreRemoveCmd, ignoreListCmd, ignoreCheckCmd)
+	addOutputFlag(ignoreListCmd)
+	addOutputFlag(ignoreCheckCmd)
+}
+
+// ignoreList is the machine-readable form of 'ignore list'
+type ignoreList struct {
+	Managed []string `json:"managed"`
+	User    []string `json:"user"`
+	Version int      `json:"version"`
+}
+
+// ignoreCheck is the machine-readable result of 'ignore check' for one path
+type ignoreCheck struct {
+	Path    string `json:"path"`
+	Source  string `json:"source,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Ignored bool   `json:"ignored"`
+	Managed bool   `json:"managed"`
+}
+
+func runIgnoreAdd(cmd *cobra.Command, args []string) error {
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+
+	added, err := git.AddIgnorePatterns(claudeDir, args)
+	if err != nil {
+		return err
+	}
+	if len(added) == 0 {
+		fmt.Println(ui.RenderInfo("ℹ️", "Already in .gitignore, nothing to add"))
+		return nil
+	}
+	for _, pattern := range added {
+		fmt.Println(ui.RenderSuccess("✓", "Added "+pattern))
+	}
+	fmt.Println(ui.RenderMuted("  The change is committed on the next sync"))
+	return nil
+}
+
+func runIgnoreRemove(cmd *cobra.Command, args []string) error {
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+
+	removed, err := git.RemoveIgnorePatterns(claudeDir, args)
+	if err != nil {
+		return err
+	}
+	if len(removed) == 0 {
+		fmt.Println(ui.RenderInfo("ℹ️", "Not in .gitignore, nothing to remove"))
+		return nil
+	}
+	for _, pattern := range removed {
+		fmt.Println(ui.RenderSuccess("✓", "Removed "+pattern))
+	}
+	fmt.Println(ui.RenderMuted("  The change is committed on the next sync"))
+	return nil
+}
+
+func runIgnoreList(cmd *cobra.Command, args []string) error {
+	structured, err := jsonOutput()
+	if err != nil {
+		return err
+	}
+
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+	gitignore, err := git.ReadGitignore(claudeDir)
+	if err != nil {
+		return err
+	}
+
+	var user []string
+	for _, line := range gitignore.User {
+		if line != "" && !strings.HasPrefix(line, "#") {
+			user = append(user, line)
+		}
+	}
+
+	if structured {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(ignoreList{
+			Managed: nonNil(gitignore.Managed),
+			User:    nonNil(user),
+			Version: gitignore.Version,
+		})
+	}
+
+	var managed strings.Builder
+	if gitignore.Version == 0 {
+		managed.WriteString(ui.MutedStyle.Render("No managed block yet - it is added on the next sync"))
+	}
+	for _, pattern := range gitignore.Managed {
+		managed.WriteString(ui.ListItemStyle.Render("• " + pattern))
+		managed.WriteString("\n")
+	}
+	title := "🛡️  claude-sync defaults"
+	if gitignore.Version > 0 {
+		title += fmt.Sprintf(" (v%d)", gitignore.Version)
+	}
+	fmt.Println(ui.RenderBox(title, strings.TrimRight(managed.String(), "\n")))
+
+	var own strings.Builder
+	if len(user) == 0 {
+		own.WriteString(ui.MutedStyle.Render("None - add one with 'claude-sync ignore add <pattern>'"))
+	}
+	for _, pattern := range user {
+		own.WriteString(ui.ListItemStyle.Render("• " + pattern))
+		own.WriteString("\n")
+	}
+	fmt.Println(ui.RenderBox("✏️  Your patterns", strings.TrimRight(own.String(), "\n")))
+	return nil
+}
+
+func runIgnoreCheck(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	structured, err := jsonOutput()
+	if err != nil {
+		return err
+	}
+
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+	if !git.IsGitRepo(claudeDir) {
+		return fmt.Errorf("%s is not a git repository", claudeDir)
+	}
+	// Refresh the excludes generated from .claude-sync.yaml before asking git
+	if _, err := git.ApplyRules(claudeDir); err != nil {
+		return err
+	}
+
+	matches, err := git.ExplainIgnored(ctx, claudeDir, args)
+	if err != nil {
+		return err
+	}
+
+	if structured {
+		checks := make([]ignoreCheck, 0, len(matches))
+		for _, m := range matches {
+			checks = append(checks, ignoreCheck{
+				Path:    m.Path,
+				Source:  m.Source,
+				Pattern: m.Pattern,
+				Line:    m.Line,
+				Ignored: m.Ignored,
+				Managed: m.Managed,
+			})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(checks)
+	}
+
+	for _, m := range matches {
+		if m.Pattern == "" {
+			fmt.Println(ui.RenderSuccess("✓", m.Path+" is not ignored"))
+			continue
+		}
+		rule := fmt.Sprintf("%s:%d: %s", m.Source, m.Line, m.Pattern)
+		if m.Managed {
+			rule += ", claude-sync default"
+		}
+		if m.Ignored {
+			fmt.Println(ui.RenderWarning("✗", m.Path+" is ignored") + ui.RenderMuted(" ("+rule+")"))
+		} else {
+			fmt.Println(ui.RenderSuccess("✓", m.Path+" is not ignored") + ui.RenderMuted(" ("+rule+")"))
+		}
+	}
+	return nil
+}
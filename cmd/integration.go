@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+	"github.com/mfenderov/claude-sync/internal/integration"
+	"github.com/mfenderov/claude-sync/internal/ui"
+)
+
+var installHooksCmd = &cobra.Command{
+	Use:   "install-hooks",
+	Short: "Sync automatically from Claude Code sessions",
+	Long: `Adds hooks to ~/.claude/settings.json that keep the configuration in sync
+while you use Claude Code:
+  - SessionStart runs 'claude-sync pull --quiet' to get the latest config
+  - SessionEnd runs 'claude-sync sync --quiet' to commit and push changes
+
+Entries that are already there are left alone, so running it again is safe,
+and the rest of settings.json keeps its content and key order. The hooks
+sync to your other machines like any other setting, where they are disabled
+until approved with 'claude-sync hooks approve'.
+
+The hooks apply incoming changes without a review. With --review they run
+with --review too: nobody can answer in a hook, so incoming changes are
+declined until you run 'claude-sync --review'. Running install-hooks again
+with or without --review switches the installed hooks.`,
+	Example: `  claude-sync install-hooks
+  claude-sync install-hooks --push-on-stop   # Also push after every response
+  claude-sync install-hooks --review         # Never apply unreviewed changes`,
+	Args: cobra.NoArgs,
+	RunE: runInstallHooks,
+}
+
+var uninstallHooksCmd = &cobra.Command{
+	Use:   "uninstall-hooks",
+	Short: "Remove the hooks added by install-hooks",
+	Long: `Removes the hooks that run claude-sync from ~/.claude/settings.json and
+leaves every other hook and setting as it is.`,
+	Args: cobra.NoArgs,
+	RunE: runUninstallHooks,
+}
+
+var (
+	pushOnStop  bool
+	hooksReview bool
+)
+
+func init() {
+	rootCmd.AddCommand(installHooksCmd, uninstallHooksCmd)
+	installHooksCmd.Flags().BoolVar(&pushOnStop, "push-on-stop", false, "Also commit and push after every Claude response (Stop hook)")
+	installHooksCmd.Flags().BoolVar(&hooksReview, "review", false, "Decline incoming changes in the hooks until they are reviewed")
+	addOutputFlag(installHooksCmd)
+	addOutputFlag(uninstallHooksCmd)
+}
+
+// integrationReport is the machine-readable document for install-hooks and
+// uninstall-hooks with -o json
+type integrationReport struct {
+	Changed   []integration.Hook `json:"changed"`
+	Installed []integration.Hook `json:"installed"`
+}
+
+func runInstallHooks(cmd *cobra.Command, args []string) error {
+	structured, err := jsonOutput()
+	if err != nil {
+		return err
+	}
+	path, err := settingsPath()
+	if err != nil {
+		return err
+	}
+
+	added, err := integration.Install(path, integration.Hooks(pushOnStop, hooksReview))
+	if err != nil {
+		return err
+	}
+	if structured {
+		return encodeIntegrationReport(path, added)
+	}
+
+	if len(added) == 0 {
+		fmt.Println(ui.RenderSuccess("✓", "Claude Code hooks are already installed"))
+	} else {
+		fmt.Println(ui.RenderSuccess("🪝", fmt.Sprintf("Installed %d hook(s) in %s", len(added), path)))
+		for _, h := range added {
+			fmt.Println(ui.ListItemStyle.Render("• " + h.String()))
+		}
+	}
+	if _, err := exec.LookPath("claude-sync"); err != nil {
+		fmt.Println()
+		fmt.Println(ui.RenderWarning("⚠️", "claude-sync is not on your PATH, so the hooks cannot run it"))
+		fmt.Println(ui.RenderMuted("  Install it somewhere on the PATH Claude Code sees, e.g. /usr/local/bin"))
+	}
+	return nil
+}
+
+func runUninstallHooks(cmd *cobra.Command, args []string) error {
+	structured, err := jsonOutput()
+	if err != nil {
+		return err
+	}
+	path, err := settingsPath()
+	if err != nil {
+		return err
+	}
+
+	removed, err := integration.Uninstall(path)
+	if err != nil {
+		return err
+	}
+	if structured {
+		return encodeIntegrationReport(path, removed)
+	}
+
+	if len(removed) == 0 {
+		fmt.Println(ui.RenderInfo("ℹ️", "No claude-sync hooks found in "+path))
+		return nil
+	}
+	fmt.Println(ui.RenderSuccess("✓", fmt.Sprintf("Removed %d hook(s) from %s", len(removed), path)))
+	for _, h := range removed {
+		fmt.Println(ui.ListItemStyle.Render("• " + h.String()))
+	}
+	return nil
+}
+
+// settingsPath returns the settings file that holds the hooks
+func settingsPath() (string, error) {
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(claudeDir, integration.SettingsFile), nil
+}
+
+// integrationStatus describes whether the Claude Code hooks are installed
+func integrationStatus(hooks []integration.Hook) string {
+	if len(hooks) == 0 {
+		return "off - 'claude-sync install-hooks' syncs from Claude Code sessions"
+	}
+	events := make([]string, 0, len(hooks))
+	for _, h := range hooks {
+		events = append(events, h.Event)
+	}
+	return "active (" + strings.Join(events, ", ") + ")"
+}
+
+func encodeIntegrationReport(path string, changed []integration.Hook) error {
+	installed, err := integration.Installed(path)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(integrationReport{Changed: nonNil(changed), Installed: nonNil(installed)})
+}
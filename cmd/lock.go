@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mfenderov/claude-sync/internal/lock"
+	"github.com/mfenderov/claude-sync/internal/sync"
+	"github.com/mfenderov/claude-sync/internal/ui"
+)
+
+var (
+	lockWait    bool
+	lockTimeout time.Duration
+)
+
+func init() {
+	flags := rootCmd.PersistentFlags()
+	flags.BoolVar(&lockWait, "wait", false, "Wait for another running sync to finish instead of failing")
+	flags.DurationVar(&lockTimeout, "timeout", 0, "Give up waiting for another sync after this long (implies --wait)")
+}
+
+// newLocker returns the repository lock used by commands that modify the repo.
+// Waiting notices go to stderr so structured output on stdout stays clean.
+func newLocker() sync.Locker {
+	return sync.NewLockAdapter(lock.Options{
+		Wait:    lockWait || lockTimeout > 0,
+		Timeout: lockTimeout,
+		OnWait: func(holder lock.Info) {
+			fmt.Fprintln(os.Stderr, ui.RenderInfo("⏳", "Waiting for another sync to finish: "+holder.String()))
+		},
+	})
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+	"github.com/mfenderov/claude-sync/internal/ui"
+)
+
+var logCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Browse the sync history with the files each commit changed",
+	Long: `Lists the history of your configuration, newest first, with the files each
+commit changed. Auto-sync commits show the machine they came from and when.
+
+Filters combine:
+  --host     commits synced from one machine
+  --since    a date or a relative time such as "yesterday" or "2 days ago"
+  --path     commits that changed a file or directory under ~/.claude
+  --grep     commits whose message matches a regular expression`,
+	Example: `  claude-sync log --path hooks --since yesterday   # Which machine broke my hooks?
+  claude-sync log --host laptop -n 5
+  claude-sync log --grep "undo" -o json`,
+	Args: cobra.NoArgs,
+	RunE: runLog,
+}
+
+var logOptions git.LogOptions
+
+func init() {
+	rootCmd.AddCommand(logCmd)
+	logCmd.Flags().StringVar(&logOptions.Host, "host", "", "Only commits synced from this machine")
+	logCmd.Flags().StringVar(&logOptions.Since, "since", "", "Only commits after this date or relative time (e.g. \"2 days ago\")")
+	logCmd.Flags().StringVar(&logOptions.Path, "path", "", "Only commits that changed this file or directory")
+	logCmd.Flags().StringVar(&logOptions.Grep, "grep", "", "Only commits whose message matches this regular expression")
+	logCmd.Flags().IntVarP(&logOptions.Limit, "max-count", "n", 20, "Show at most this many commits (0 for all)")
+	addOutputFlag(logCmd)
+}
+
+func runLog(cmd *cobra.Command, args []string) error {
+	structured, err := jsonOutput()
+	if err != nil {
+		return err
+	}
+
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+	if !git.IsGitRepo(claudeDir) {
+		return fmt.Errorf("%s is not a git repository - run claude-sync once to set up sync", claudeDir)
+	}
+
+	opts := logOptions
+	if filepath.IsAbs(opts.Path) {
+		if opts.Path, err = repoRelative(claudeDir, opts.Path); err != nil {
+			return err
+		}
+	}
+	commits, err := git.Log(cmd.Context(), claudeDir, opts)
+	if err != nil {
+		return err
+	}
+
+	if structured {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(nonNil(commits))
+	}
+
+	if len(commits) == 0 {
+		fmt.Println(ui.RenderMuted("No commits match"))
+		return nil
+	}
+	for _, c := range commits {
+		fmt.Println(renderCommit(c))
+	}
+	return nil
+}
+
+// renderCommit formats a commit with its origin and changed files
+func renderCommit(c git.Commit) string {
+	var b strings.Builder
+	b.WriteString(ui.PrimaryStyle.Render(c.SHA[:7]) + "  ")
+	when := c.Time.Local().Format("2006-01-02 15:04:05")
+	if c.Host != "" {
+		b.WriteString(ui.InfoStyle.Render("🖥  "+c.Host) + "  " + when)
+	} else {
+		b.WriteString(c.Subject + "  " + ui.MutedStyle.Render(c.Author+", "+when))
+	}
+	b.WriteString("\n")
+
+	for _, f := range c.Files {
+		line := f.Status + " " + f.Path
+		if f.OldPath != "" {
+			line = f.Status + " " + f.OldPath + " → " + f.Path
+		}
+		switch f.Status {
+		case "A":
+			line = ui.DiffAddedStyle.Render(line)
+		case "D":
+			line = ui.DiffRemovedStyle.Render(line)
+		}
+		b.WriteString(ui.ListItemStyle.Render(line) + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,341 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+	"github.com/mfenderov/claude-sync/internal/logger"
+	"github.com/mfenderov/claude-sync/internal/mcp"
+	"github.com/mfenderov/claude-sync/internal/sync"
+	"github.com/mfenderov/claude-sync/internal/version"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Run an MCP server so Claude can check and sync the configuration",
+	Long: `Runs a Model Context Protocol server on stdin and stdout. Added to Claude
+Code, it lets Claude answer questions such as "is my config in sync?" or
+"restore the skill I deleted yesterday" inside a session.
+
+Tools:
+  status   Whether the configuration is in sync, with branch, files, and plugins
+  diff     Local changes and the incoming changes a sync would apply
+  log      The sync history, filtered by machine, date, path, or message
+  restore  Bring back a file or directory as it was at a commit or date
+  sync     Commit, pull, and push
+
+Tools never prompt. Questions a sync cannot answer on its own fail the tool
+with the question's key, which can be answered in advance with --answers or
+CLAUDE_SYNC_ANSWER_* variables as in scripts. Progress goes to stderr.`,
+	Example: `  claude mcp add claude-sync -- claude-sync mcp   # Add it to Claude Code`,
+	Args:    cobra.NoArgs,
+	RunE:    runMCP,
+}
+
+func init() {
+	rootCmd.AddCommand(mcpCmd)
+}
+
+// mcpInstructions tells the client what the tools are for
+const mcpInstructions = `These tools manage the Claude Code configuration in ~/.claude, which claude-sync keeps in sync across machines through a git remote. Use status to check whether it is in sync, diff and log to see what changed and where, restore to bring back files from history (find the version with log first), and sync to commit, pull, and push.`
+
+func runMCP(cmd *cobra.Command, args []string) error {
+	server := mcp.NewServer("claude-sync", version.Get().Version, mcpInstructions)
+	for _, tool := range mcpTools() {
+		server.AddTool(tool)
+	}
+	return server.Serve(cmd.Context(), os.Stdin, os.Stdout)
+}
+
+// mcpTools returns the tools offered by 'claude-sync mcp'
+func mcpTools() []mcp.Tool {
+	return []mcp.Tool{
+		{
+			Name:        "status",
+			Description: "Show whether the configuration is in sync with the remote: branch, commits ahead and behind, uncommitted and untracked files, plugins, hooks, and skills.",
+			InputSchema: objectSchema(map[string]any{
+				"fetch": property("boolean", "Fetch first so ahead and behind reflect the remote (default true)"),
+			}),
+			Handler: mcpStatus,
+		},
+		{
+			Name:        "diff",
+			Description: "Show the uncommitted local changes and, after fetching, the incoming changes a sync would pull. JSON files such as settings.json are compared key by key.",
+			InputSchema: objectSchema(map[string]any{
+				"paths":    map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Limit the diff to these files or directories, relative to ~/.claude"},
+				"local":    property("boolean", "Only show uncommitted local changes"),
+				"incoming": property("boolean", "Only show incoming changes from the remote"),
+			}),
+			Handler: mcpDiff,
+		},
+		{
+			Name:        "log",
+			Description: "List the sync history, newest first, with the machine each commit came from and the files it changed.",
+			InputSchema: objectSchema(map[string]any{
+				"host":      property("string", "Only commits synced from this machine"),
+				"since":     property("string", "Only commits after this date or relative time, e.g. \"2 days ago\""),
+				"path":      property("string", "Only commits that changed this file or directory, relative to ~/.claude"),
+				"grep":      property("string", "Only commits whose message matches this regular expression"),
+				"max_count": property("integer", "Return at most this many commits (default 20, 0 for all)"),
+			}),
+			Handler: mcpLog,
+		},
+		{
+			Name:        "restore",
+			Description: "Restore a file or directory under ~/.claude as it was at a commit or date, e.g. a skill that was deleted. Files that did not exist in that version are kept.",
+			InputSchema: objectSchema(map[string]any{
+				"path": property("string", "File or directory to restore, relative to ~/.claude"),
+				"at":   property("string", "Commit SHA or date to restore from, e.g. \"yesterday\"; use log with path to find one"),
+				"sync": property("boolean", "Commit and sync the restored files right away instead of on the next sync"),
+			}, "path", "at"),
+			Handler: mcpRestore,
+		},
+		{
+			Name:        "sync",
+			Description: "Sync the configuration: commit local changes, pull from the remote, and push. Conflicts that need a decision abort the sync without changes.",
+			InputSchema: objectSchema(map[string]any{
+				"dry_run": property("boolean", "Only report what a sync would commit, pull, and push"),
+			}),
+			Handler: mcpSync,
+		},
+	}
+}
+
+func objectSchema(properties map[string]any, required ...string) map[string]any {
+	schema := map[string]any{"type": "object", "properties": properties, "additionalProperties": false}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func property(kind, description string) map[string]any {
+	return map[string]any{"type": kind, "description": description}
+}
+
+// mcpClaudeDir returns the Claude directory, which must be a repository
+func mcpClaudeDir() (string, error) {
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return "", err
+	}
+	if !git.IsGitRepo(claudeDir) {
+		return "", fmt.Errorf("%s is not a git repository - run claude-sync once to set up sync", claudeDir)
+	}
+	return claudeDir, nil
+}
+
+// mcpStatusReport is the status with a verdict on whether it is in sync
+type mcpStatusReport struct {
+	statusReport
+	// InSync means nothing is waiting to be committed, pulled, or pushed
+	InSync     bool   `json:"in_sync"`
+	FetchError string `json:"fetch_error,omitempty"`
+}
+
+func mcpStatus(ctx context.Context, args json.RawMessage) (any, error) {
+	var a struct {
+		Fetch *bool `json:"fetch"`
+	}
+	if err := mcp.Decode(args, &a); err != nil {
+		return nil, err
+	}
+	claudeDir, err := mcpClaudeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var report mcpStatusReport
+	if a.Fetch == nil || *a.Fetch {
+		if err := git.Fetch(ctx, claudeDir); err != nil {
+			report.FetchError = err.Error()
+		}
+	}
+	if report.statusReport, err = collectStatus(ctx, claudeDir); err != nil {
+		return nil, err
+	}
+	report.InSync = report.Ahead == 0 && report.Behind == 0 && len(report.ModifiedFiles) == 0
+	for _, u := range report.Untracked {
+		report.InSync = report.InSync && !u.Synced
+	}
+	return report, nil
+}
+
+// mcpDiffReport is the diff with whether there is a remote branch to compare
+type mcpDiffReport struct {
+	diffReport
+	Tracking bool `json:"tracking"`
+}
+
+func mcpDiff(ctx context.Context, args json.RawMessage) (any, error) {
+	var a struct {
+		Paths    []string `json:"paths"`
+		Local    bool     `json:"local"`
+		Incoming bool     `json:"incoming"`
+	}
+	if err := mcp.Decode(args, &a); err != nil {
+		return nil, err
+	}
+	claudeDir, err := mcpClaudeDir()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := claudePaths(claudeDir, a.Paths)
+	if err != nil {
+		return nil, err
+	}
+
+	report, tracking, err := collectDiff(ctx, claudeDir, paths, a.Local || !a.Incoming, a.Incoming || !a.Local)
+	if err != nil {
+		return nil, err
+	}
+	return mcpDiffReport{diffReport: report, Tracking: tracking}, nil
+}
+
+func mcpLog(ctx context.Context, args json.RawMessage) (any, error) {
+	var a struct {
+		Host     string `json:"host"`
+		Since    string `json:"since"`
+		Path     string `json:"path"`
+		Grep     string `json:"grep"`
+		MaxCount *int   `json:"max_count"`
+	}
+	if err := mcp.Decode(args, &a); err != nil {
+		return nil, err
+	}
+	claudeDir, err := mcpClaudeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := git.LogOptions{Host: a.Host, Since: a.Since, Grep: a.Grep, Limit: 20}
+	if a.MaxCount != nil {
+		opts.Limit = *a.MaxCount
+	}
+	if a.Path != "" {
+		paths, err := claudePaths(claudeDir, []string{a.Path})
+		if err != nil {
+			return nil, err
+		}
+		opts.Path = paths[0]
+	}
+	commits, err := git.Log(ctx, claudeDir, opts)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"commits": nonNil(commits)}, nil
+}
+
+func mcpRestore(ctx context.Context, args json.RawMessage) (any, error) {
+	var a struct {
+		Path string `json:"path"`
+		At   string `json:"at"`
+		Sync bool   `json:"sync"`
+	}
+	if err := mcp.Decode(args, &a); err != nil {
+		return nil, err
+	}
+	if a.Path == "" || a.At == "" {
+		return nil, &mcp.InvalidArgumentsError{Err: errors.New("path and at are required")}
+	}
+	path := filepath.ToSlash(filepath.Clean(a.Path))
+	if filepath.IsAbs(a.Path) {
+		claudeDir, err := git.GetClaudeDir()
+		if err != nil {
+			return nil, err
+		}
+		if path, err = repoRelative(claudeDir, a.Path); err != nil {
+			return nil, err
+		}
+	}
+
+	// Calling the tool is the confirmation; the arguments answer the rest.
+	// Restore needs the git backend, as on the command line.
+	newOperator := func() (sync.GitOperator, []sync.Option, error) { return newGitBinaryOperator("restore") }
+	return runMCPService(newOperator, map[string]bool{
+		"Restore these files?":                    true,
+		"Commit and sync the restored files now?": a.Sync,
+	}, nil, func(service *sync.Service) error {
+		return service.Restore(ctx, path, a.At)
+	})
+}
+
+func mcpSync(ctx context.Context, args json.RawMessage) (any, error) {
+	var a struct {
+		DryRun bool `json:"dry_run"`
+	}
+	if err := mcp.Decode(args, &a); err != nil {
+		return nil, err
+	}
+	return runMCPService(newGitOperator, nil, []sync.Option{sync.WithDryRun(a.DryRun)}, func(service *sync.Service) error {
+		return service.Run(ctx)
+	})
+}
+
+// serviceResult is what a sync service run reported
+type serviceResult struct {
+	// Data merges the structured fields of the run, such as commit_sha
+	Data   map[string]any `json:"data"`
+	Events []logger.Event `json:"events"`
+}
+
+// runMCPService runs a sync service that never prompts, on the GitOperator
+// newOperator returns: confirmations answers the named questions, and any
+// others come from --answers and CLAUDE_SYNC_ANSWER_* as in scripts. The
+// events the service logged are returned even when it fails.
+func runMCPService(newOperator func() (sync.GitOperator, []sync.Option, error), confirmations map[string]bool,
+	opts []sync.Option, run func(*sync.Service) error,
+) (any, error) {
+	answers, err := sync.LoadAnswers(answersFile, os.Environ())
+	if err != nil {
+		return nil, err
+	}
+	answers.Yes = answers.Yes || assumeYes
+	answers.Choices = append(append([]string{}, choices...), answers.Choices...)
+	for question, yes := range confirmations {
+		answers.Values[sync.QuestionKey(question)] = fmt.Sprint(yes)
+	}
+
+	gitAdapter, gitOpts, err := newOperator()
+	if err != nil {
+		return nil, err
+	}
+
+	var events bytes.Buffer
+	opts = append(append([]sync.Option{
+		sync.WithLocker(newLocker()),
+		sync.WithSecretScanner(newSecretScanner()),
+		sync.WithSecretStore(sync.NewSecretStoreAdapter(gitAdapter)),
+		sync.WithOverlays(sync.NewOverlayAdapter(gitAdapter)),
+		sync.WithJournal(sync.NewJournalAdapter()),
+	}, gitOpts...), opts...)
+	service := sync.NewService(gitAdapter, sync.NewScriptedPrompter(answers, nil, os.Stderr), logger.NewJSON(&events), opts...)
+	runErr := run(service)
+
+	result := serviceResult{Data: map[string]any{}, Events: []logger.Event{}}
+	scanner := bufio.NewScanner(&events)
+	scanner.Buffer(make([]byte, 0, 64<<10), 16<<20)
+	for scanner.Scan() {
+		var e logger.Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("failed to read sync events: %w", err)
+		}
+		if e.Type == "data" {
+			for k, v := range e.Fields {
+				result.Data[k] = v
+			}
+			continue
+		}
+		result.Events = append(result.Events, e)
+	}
+	return result, runErr
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mfenderov/claude-sync/internal/logger"
+	"github.com/mfenderov/claude-sync/internal/sync"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+var (
+	outputFormat string
+	quiet        bool
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Print only errors and never prompt, e.g. when run from a Claude Code hook")
+	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		// A hook shows the error to the user; the usage would only bury it
+		cmd.SilenceUsage = quiet
+	}
+}
+
+// addOutputFlag registers --output on commands that support machine-readable output
+func addOutputFlag(c *cobra.Command) {
+	c.Flags().StringVarP(&outputFormat, "output", "o", outputText, "Output format: text or json (NDJSON events)")
+}
+
+// jsonOutput reports whether --output json was requested, rejecting unknown formats
+func jsonOutput() (bool, error) {
+	switch outputFormat {
+	case outputText, "":
+		return false, nil
+	case outputJSON:
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown output format %q (use %q or %q)", outputFormat, outputText, outputJSON)
+	}
+}
+
+// newLogger returns the styled logger for humans or the NDJSON logger for
+// machines, or one that prints nothing with --quiet
+func newLogger(structured bool) sync.Logger {
+	switch {
+	case structured:
+		return logger.NewJSON(os.Stdout)
+	case quiet:
+		return quietLogger{}
+	}
+	return sync.NewLoggerAdapter(logger.Default())
+}
+
+// quietLogger drops all output; errors still reach stderr as the command's
+// result
+type quietLogger struct{}
+
+func (quietLogger) Title(string)                {}
+func (quietLogger) Success(string, string)      {}
+func (quietLogger) Error(string, string, error) {}
+func (quietLogger) Warning(string, string)      {}
+func (quietLogger) Info(string, string)         {}
+func (quietLogger) Muted(string)                {}
+func (quietLogger) ListItem(string)             {}
+func (quietLogger) Box(string, string)          {}
+func (quietLogger) Newline()                    {}
+
+// progressOutput is where the prompter writes status lines in place of
+// spinners
+func progressOutput(structured bool) io.Writer {
+	switch {
+	case structured:
+		return os.Stderr
+	case quiet:
+		return io.Discard
+	}
+	return os.Stdout
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+	"github.com/mfenderov/claude-sync/internal/sync"
+	"github.com/mfenderov/claude-sync/internal/ui"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage named configuration profiles",
+	Long: `Keeps several Claude Code configurations, such as "work" and "personal",
+in one ~/.claude directory. Each profile syncs with a branch of a remote;
+switching checks out that profile's configuration.
+
+The first profile you create also registers your current setup as the
+"default" profile. Profiles are stored per machine in the repository's git
+config.`,
+}
+
+var profileCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a profile backed by a branch or a remote",
+	Long: `Creates a profile. Without flags it is a new branch named after the profile
+on the current remote. With --remote it syncs with another repository, given
+as the name of an existing remote or a URL.
+
+If the branch does not exist on the remote yet, it starts as a copy of the
+current configuration and is pushed.`,
+	Example: `  claude-sync profile create work
+  claude-sync profile create personal --remote git@github.com:me/claude-personal.git`,
+	Args: cobra.ExactArgs(1),
+	RunE: runProfileCreate,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles",
+	Args:  cobra.NoArgs,
+	RunE:  runProfileList,
+}
+
+var profileSwitchCmd = &cobra.Command{
+	Use:   "switch <name>",
+	Short: "Switch to another profile",
+	Long: `Switches the Claude directory to another profile. Local changes are
+committed to the current profile first (pushed on its next sync), or stashed
+with --stash and restored when you switch back.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runProfileSwitch,
+}
+
+var (
+	profileRemote string
+	profileBranch string
+	profileStash  bool
+)
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+	profileCmd.AddCommand(profileCreateCmd, profileListCmd, profileSwitchCmd)
+	profileCreateCmd.Flags().StringVar(&profileRemote, "remote", "", "Remote name or URL to sync the profile with (default: the current remote)")
+	profileCreateCmd.Flags().StringVar(&profileBranch, "branch", "", "Branch on the remote (default: the profile name, or main for a new remote)")
+	profileSwitchCmd.Flags().BoolVar(&profileStash, "stash", false, "Stash local changes instead of committing them")
+	addOutputFlag(profileListCmd)
+	addOutputFlag(profileSwitchCmd)
+}
+
+// profileReport is the machine-readable form of a profile in 'profile list'
+type profileReport struct {
+	git.Profile
+	Active bool `json:"active"`
+}
+
+func runProfileCreate(cmd *cobra.Command, args []string) error {
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+	if !git.IsGitRepo(claudeDir) {
+		return fmt.Errorf("%s is not a git repository - run claude-sync once to set up sync", claudeDir)
+	}
+
+	profile, err := git.CreateProfile(cmd.Context(), claudeDir, args[0], profileRemote, profileBranch)
+	if err != nil {
+		return err
+	}
+	fmt.Println(ui.RenderSuccess("✓", fmt.Sprintf("Created profile %s (%s/%s)", profile.Name, profile.Remote, profile.Branch)))
+	fmt.Println(ui.RenderMuted("  Switch to it with 'claude-sync profile switch " + profile.Name + "'"))
+	return nil
+}
+
+func runProfileList(cmd *cobra.Command, args []string) error {
+	structured, err := jsonOutput()
+	if err != nil {
+		return err
+	}
+
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+	profiles, active, err := git.ListProfiles(cmd.Context(), claudeDir)
+	if err != nil {
+		return err
+	}
+
+	if structured {
+		reports := make([]profileReport, 0, len(profiles))
+		for _, p := range profiles {
+			reports = append(reports, profileReport{Profile: p, Active: p.Name == active})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reports)
+	}
+
+	var content strings.Builder
+	if len(profiles) == 0 {
+		content.WriteString(ui.MutedStyle.Render("None - create one with 'claude-sync profile create <name>'"))
+	}
+	for _, p := range profiles {
+		marker := "  "
+		if p.Name == active {
+			marker = "▸ "
+		}
+		content.WriteString(ui.ListItemStyle.Render(fmt.Sprintf("%s%s  %s/%s", marker, p.Name, p.Remote, p.Branch)))
+		content.WriteString("\n")
+	}
+	fmt.Println(ui.RenderBox("🎭 Profiles", strings.TrimRight(content.String(), "\n")))
+	return nil
+}
+
+func runProfileSwitch(cmd *cobra.Command, args []string) error {
+	structured, err := jsonOutput()
+	if err != nil {
+		return err
+	}
+	prompter, err := newPrompter(structured)
+	if err != nil {
+		return err
+	}
+
+	gitAdapter, gitOpts, err := newGitBinaryOperator("profile switch")
+	if err != nil {
+		return err
+	}
+	service := sync.NewService(gitAdapter, prompter, newLogger(structured), append([]sync.Option{
+		sync.WithLocker(newLocker()),
+		sync.WithSecretScanner(newSecretScanner()),
+		sync.WithSecretStore(sync.NewSecretStoreAdapter(gitAdapter)),
+		sync.WithOverlays(sync.NewOverlayAdapter(gitAdapter)),
+	}, gitOpts...)...)
+	return service.SwitchProfile(cmd.Context(), args[0], profileStash)
+}
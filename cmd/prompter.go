@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/mfenderov/claude-sync/internal/prompts"
+	"github.com/mfenderov/claude-sync/internal/sync"
+)
+
+var (
+	assumeYes      bool
+	choices        []string
+	answersFile    string
+	nonInteractive bool
+)
+
+func init() {
+	flags := rootCmd.PersistentFlags()
+	flags.BoolVarP(&assumeYes, "yes", "y", false, "Answer yes to all confirmations")
+	flags.StringArrayVar(&choices, "choice", nil, "Answer select prompts with this value when it is one of the options (repeatable)")
+	flags.StringVar(&answersFile, "answers", "", "JSON file mapping question keys to answers (or set CLAUDE_SYNC_ANSWERS)")
+	flags.BoolVar(&nonInteractive, "non-interactive", false, "Never prompt, even when a terminal is attached")
+}
+
+// newPrompter returns a Prompter that uses scripted answers first and falls
+// back to interactive prompts only when a terminal is attached. Structured
+// output never prompts and reports progress on stderr to keep stdout clean;
+// --quiet never prompts and reports nothing.
+func newPrompter(structured bool) (sync.Prompter, error) {
+	answers, err := sync.LoadAnswers(answersFile, os.Environ())
+	if err != nil {
+		return nil, err
+	}
+	answers.Yes = answers.Yes || assumeYes
+	answers.Choices = append(append([]string{}, choices...), answers.Choices...)
+
+	var interactive sync.Prompter
+	if !structured && !quiet && !nonInteractive && prompts.IsTerminal() {
+		interactive = sync.NewPrompterAdapter()
+	}
+	return sync.NewScriptedPrompter(answers, interactive, progressOutput(structured)), nil
+}
+
+// newConflictResolver returns the interactive conflict resolver, or nil when
+// nobody can answer it and conflicts must abort the sync instead
+func newConflictResolver(structured bool) sync.ConflictResolver {
+	if structured || quiet || nonInteractive || !prompts.IsTerminal() {
+		return nil
+	}
+	return sync.NewConflictResolverAdapter()
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/mfenderov/claude-sync/internal/sync"
+)
+
+var pullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Commit local changes and pull, without pushing",
+	Long: `Commits local changes and pulls the latest configuration from the remote,
+without pushing. It is quicker than a full sync and is what the SessionStart
+hook from 'claude-sync install-hooks' runs. The commits are pushed by the
+next full sync. With a bundle directory set, it imports the bundles there
+instead.
+
+Use --review to approve the incoming changes before they are applied, as
+with 'claude-sync --review'. When nobody can answer, as with --quiet in a
+hook, they are declined and wait for the next sync you review.`,
+	Example: `  claude-sync pull
+  claude-sync pull --quiet            # Print only errors
+  claude-sync pull --quiet --review   # Never apply unreviewed changes`,
+	Args: cobra.NoArgs,
+	RunE: runPull,
+}
+
+var pullReview bool
+
+func init() {
+	rootCmd.AddCommand(pullCmd)
+	pullCmd.Flags().BoolVar(&pullReview, "review", false, "Review incoming changes and approve them before they are applied")
+	addOutputFlag(pullCmd)
+}
+
+func runPull(cmd *cobra.Command, args []string) error {
+	structured, err := jsonOutput()
+	if err != nil {
+		return err
+	}
+	prompter, err := newPrompter(structured)
+	if err != nil {
+		return err
+	}
+
+	gitAdapter, gitOpts, err := newGitOperator()
+	if err != nil {
+		return err
+	}
+	service := sync.NewService(gitAdapter, prompter, newLogger(structured), append([]sync.Option{
+		sync.WithReview(pullReview),
+		sync.WithConflictResolver(newConflictResolver(structured)),
+		sync.WithLocker(newLocker()),
+		sync.WithSecretScanner(newSecretScanner()),
+		sync.WithSecretStore(sync.NewSecretStoreAdapter(gitAdapter)),
+		sync.WithOverlays(sync.NewOverlayAdapter(gitAdapter)),
+		sync.WithJournal(sync.NewJournalAdapter()),
+	}, gitOpts...)...)
+	return service.Pull(cmd.Context())
+}
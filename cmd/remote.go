@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+	"github.com/mfenderov/claude-sync/internal/ui"
+)
+
+var remoteCmd = &cobra.Command{
+	Use:   "remote",
+	Short: "Manage the remotes your configuration is pushed to",
+	Long: `Sync pulls from and pushes to one primary remote: the remote the current
+branch tracks, usually origin. Mirror remotes, such as a bare repository on
+a NAS, receive a copy of every push for redundancy. A mirror that cannot be
+reached is reported without failing the sync.
+
+Without network access, machines can instead exchange git bundles through a
+directory, such as one on a USB drive: see set-bundle-dir.`,
+}
+
+var remoteAddMirrorCmd = &cobra.Command{
+	Use:   "add-mirror <name> [url]",
+	Short: "Push every sync to another remote as well",
+	Long: `Adds a mirror remote and pushes the current branch to it. Without a URL,
+an existing remote becomes a mirror.`,
+	Example: `  claude-sync remote add-mirror nas ssh://nas.local/srv/git/claude-config.git
+  claude-sync remote add-mirror backup`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runRemoteAddMirror,
+}
+
+var remoteRemoveMirrorCmd = &cobra.Command{
+	Use:   "remove-mirror <name>",
+	Short: "Stop pushing to a mirror remote",
+	Long: `Stops pushing to a mirror and removes the remote. A remote that a profile
+syncs with is kept.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRemoteRemoveMirror,
+}
+
+var remoteListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List remotes and their roles",
+	Args:  cobra.NoArgs,
+	RunE:  runRemoteList,
+}
+
+var remoteSetBundleDirCmd = &cobra.Command{
+	Use:   "set-bundle-dir <dir>",
+	Short: "Sync through bundle files in a directory instead of the remote",
+	Long: `Makes sync exchange git bundles through a directory, such as one on a USB
+drive, instead of pulling and pushing. Each sync imports the bundles other
+machines left there and then writes this machine's own bundle, named after
+the host (short hostname, or $CLAUDE_SYNC_HOST).
+
+The directory is remembered by path, so mount the drive at the same place
+each time.`,
+	Example: `  claude-sync remote set-bundle-dir /media/usb/claude-sync`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runRemoteSetBundleDir,
+}
+
+var remoteUnsetBundleDirCmd = &cobra.Command{
+	Use:   "unset-bundle-dir",
+	Short: "Go back to syncing with the remote",
+	Args:  cobra.NoArgs,
+	RunE:  runRemoteUnsetBundleDir,
+}
+
+func init() {
+	rootCmd.AddCommand(remoteCmd)
+	remoteCmd.AddCommand(remoteAddMirrorCmd, remoteRemoveMirrorCmd, remoteListCmd, remoteSetBundleDirCmd, remoteUnsetBundleDirCmd)
+	addOutputFlag(remoteListCmd)
+}
+
+func runRemoteAddMirror(cmd *cobra.Command, args []string) error {
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+	if !git.IsGitRepo(claudeDir) {
+		return fmt.Errorf("%s is not a git repository - run claude-sync once to set up sync", claudeDir)
+	}
+
+	name, url := args[0], ""
+	if len(args) == 2 {
+		url = args[1]
+	}
+	if err := git.AddMirror(cmd.Context(), claudeDir, name, url); err != nil {
+		return err
+	}
+	fmt.Println(ui.RenderSuccess("✓", "Added mirror "+name))
+
+	if err := git.PushMirror(cmd.Context(), claudeDir, name); err != nil {
+		fmt.Println(ui.RenderWarning("⚠️", fmt.Sprintf("Could not push to %s yet: %v", name, err)))
+		fmt.Println(ui.RenderMuted("  It is retried on every sync"))
+		return nil
+	}
+	fmt.Println(ui.RenderSuccess("✓", "Pushed to mirror "+name))
+	return nil
+}
+
+func runRemoteRemoveMirror(cmd *cobra.Command, args []string) error {
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+	if err := git.RemoveMirror(cmd.Context(), claudeDir, args[0]); err != nil {
+		return err
+	}
+	fmt.Println(ui.RenderSuccess("✓", "Stopped mirroring to "+args[0]))
+	return nil
+}
+
+func runRemoteSetBundleDir(cmd *cobra.Command, args []string) error {
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+	if !git.IsGitRepo(claudeDir) {
+		return fmt.Errorf("%s is not a git repository - clone it or import a full bundle first", claudeDir)
+	}
+	if err := git.SetBundleDir(cmd.Context(), claudeDir, args[0]); err != nil {
+		return err
+	}
+	dir, err := git.BundleDir(cmd.Context(), claudeDir)
+	if err != nil {
+		return err
+	}
+	fmt.Println(ui.RenderSuccess("✓", "Syncing through bundles in "+dir))
+	return nil
+}
+
+func runRemoteUnsetBundleDir(cmd *cobra.Command, args []string) error {
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+	dir, err := git.BundleDir(cmd.Context(), claudeDir)
+	if err != nil {
+		return err
+	}
+	if dir == "" {
+		fmt.Println(ui.RenderMuted("No bundle directory is set"))
+		return nil
+	}
+	if err := git.SetBundleDir(cmd.Context(), claudeDir, ""); err != nil {
+		return err
+	}
+	fmt.Println(ui.RenderSuccess("✓", "Stopped syncing through "+dir))
+	return nil
+}
+
+func runRemoteList(cmd *cobra.Command, args []string) error {
+	structured, err := jsonOutput()
+	if err != nil {
+		return err
+	}
+
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+	remotes, err := git.ListRemotes(cmd.Context(), claudeDir)
+	if err != nil {
+		return err
+	}
+
+	if structured {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(nonNil(remotes))
+	}
+
+	bundleDir, err := git.BundleDir(cmd.Context(), claudeDir)
+	if err != nil {
+		return err
+	}
+
+	var content strings.Builder
+	if bundleDir != "" {
+		content.WriteString(ui.ListItemStyle.Render(fmt.Sprintf("%-7s  %s", "bundles", bundleDir)))
+		content.WriteString("\n")
+		content.WriteString(ui.MutedStyle.Render("Sync exchanges bundles instead of pulling and pushing"))
+		content.WriteString("\n")
+	}
+	if len(remotes) == 0 {
+		content.WriteString(ui.MutedStyle.Render("None - run claude-sync to set up a remote"))
+	}
+	for _, r := range remotes {
+		role := r.Role
+		if role == "" {
+			role = "unused"
+		}
+		content.WriteString(ui.ListItemStyle.Render(fmt.Sprintf("%-7s  %s  %s", role, r.Name, ui.MutedStyle.Render(r.URL))))
+		content.WriteString("\n")
+	}
+	fmt.Println(ui.RenderBox("🌐 Remotes", strings.TrimRight(content.String(), "\n")))
+	return nil
+}
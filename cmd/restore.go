@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+	"github.com/mfenderov/claude-sync/internal/sync"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <path>",
+	Short: "Restore a file or directory from history",
+	Long: `Brings back a file or directory under ~/.claude as it was at an earlier
+commit, for example a skill that was deleted on another machine.
+
+Restore lists the versions in the history and asks which one to restore, or
+uses --at, which takes a commit or a date such as "yesterday" or
+"2 days ago". After a preview the files are written back; files that did not
+exist in that version are kept. The restored files can then be committed and
+synced straight away, or left for the next sync.`,
+	Example: `  claude-sync restore skills/review
+  claude-sync restore settings.json --at "2 days ago"
+  claude-sync restore hooks --at 3f2a1bc --yes   # Restore and sync without prompting`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRestore,
+}
+
+var restoreAt string
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+	restoreCmd.Flags().StringVar(&restoreAt, "at", "", "Commit or date to restore from (e.g. \"2 days ago\")")
+	addOutputFlag(restoreCmd)
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	structured, err := jsonOutput()
+	if err != nil {
+		return err
+	}
+	prompter, err := newPrompter(structured)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.ToSlash(filepath.Clean(args[0]))
+	if filepath.IsAbs(args[0]) {
+		claudeDir, err := git.GetClaudeDir()
+		if err != nil {
+			return err
+		}
+		if path, err = repoRelative(claudeDir, args[0]); err != nil {
+			return err
+		}
+	}
+
+	gitAdapter, gitOpts, err := newGitBinaryOperator("restore")
+	if err != nil {
+		return err
+	}
+	service := sync.NewService(gitAdapter, prompter, newLogger(structured), append([]sync.Option{
+		sync.WithConflictResolver(newConflictResolver(structured)),
+		sync.WithLocker(newLocker()),
+		sync.WithSecretScanner(newSecretScanner()),
+		sync.WithSecretStore(sync.NewSecretStoreAdapter(gitAdapter)),
+		sync.WithOverlays(sync.NewOverlayAdapter(gitAdapter)),
+		sync.WithJournal(sync.NewJournalAdapter()),
+	}, gitOpts...)...)
+	return service.Restore(cmd.Context(), path, restoreAt)
+}
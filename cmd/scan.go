@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"github.com/mfenderov/claude-sync/internal/sync"
+)
+
+var allowSecret bool
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&allowSecret, "allow-secret", false, "Commit even if the secret scanner finds possible credentials")
+}
+
+// newSecretScanner returns the scanner that guards commits, or nil when
+// --allow-secret turns it off for this run
+func newSecretScanner() sync.SecretScanner {
+	if allowSecret {
+		return nil
+	}
+	return sync.NewSecretScannerAdapter()
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+	"github.com/mfenderov/claude-sync/internal/ui"
+	"github.com/mfenderov/claude-sync/internal/vault"
+)
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Encrypt selected files in the config repository",
+	Long: `Keeps selected files encrypted in the repository.
+
+The plaintext of a secret file stays on this machine and is ignored by git;
+an encrypted copy (<file>.enc) is committed instead. Sync encrypts changed
+files before committing and decrypts pulled ones. Machines without the key
+skip these files.
+
+The key is a key file in your config directory, or a passphrase from
+CLAUDE_SYNC_PASSPHRASE if it is set when the first file is added.`,
+}
+
+var secretsAddCmd = &cobra.Command{
+	Use:   "add <path>...",
+	Short: "Encrypt files and stop committing their plaintext",
+	Long: `Encrypts files and stops committing their plaintext. Paths are relative
+to ~/.claude. A plaintext file that was committed before stays in the
+repository history; rotate the credentials it contains.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runSecretsAdd,
+}
+
+var secretsStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the state of encrypted files",
+	Args:  cobra.NoArgs,
+	RunE:  runSecretsStatus,
+}
+
+var secretsRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Re-encrypt all secret files with a new key",
+	Long: `Re-encrypts all secret files with a new key and updates this machine.
+
+Repositories using a key file get a new one; the old file is kept with a
+".old" suffix. Repositories using a passphrase read the new one from
+CLAUDE_SYNC_NEW_PASSPHRASE. Other machines need the new key to decrypt
+files after their next sync.`,
+	Args: cobra.NoArgs,
+	RunE: runSecretsRotate,
+}
+
+func init() {
+	rootCmd.AddCommand(secretsCmd)
+	secretsCmd.AddCommand(secretsAddCmd, secretsStatusCmd, secretsRotateCmd)
+	addOutputFlag(secretsStatusCmd)
+}
+
+func runSecretsAdd(cmd *cobra.Command, args []string) error {
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+	if !git.IsGitRepo(claudeDir) {
+		return fmt.Errorf("%s is not a git repository", claudeDir)
+	}
+
+	v, created, err := vault.Init(claudeDir)
+	if err != nil {
+		return err
+	}
+	for _, arg := range args {
+		file, err := repoRelative(claudeDir, arg)
+		if err != nil {
+			return err
+		}
+		if err := v.Add(file); err != nil {
+			return err
+		}
+		fmt.Println(ui.RenderSuccess("🔒", "Encrypted "+file+" → "+file+vault.SidecarSuffix))
+	}
+	if err := git.ExcludeSecretFiles(cmd.Context(), claudeDir, v.Files()); err != nil {
+		return err
+	}
+
+	if created {
+		keyFile, err := vault.DefaultKeyFile()
+		if err != nil {
+			return err
+		}
+		fmt.Println()
+		fmt.Println(ui.RenderWarning("🔑", "Created key file "+keyFile))
+		fmt.Println(ui.RenderMuted("  Copy it to the same place on your other machines to decrypt these files there"))
+	}
+	fmt.Println(ui.RenderMuted("  The encrypted copies are committed on the next sync"))
+	return nil
+}
+
+func runSecretsStatus(cmd *cobra.Command, args []string) error {
+	structured, err := jsonOutput()
+	if err != nil {
+		return err
+	}
+
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+	statuses, err := vault.Status(claudeDir)
+	if err != nil {
+		return err
+	}
+
+	if structured {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(nonNil(statuses))
+	}
+
+	var content strings.Builder
+	if len(statuses) == 0 {
+		content.WriteString(ui.MutedStyle.Render("None - add one with 'claude-sync secrets add <path>'"))
+	}
+	for _, st := range statuses {
+		content.WriteString(ui.ListItemStyle.Render(fmt.Sprintf("• %s (%s)", st.Path, st.State)))
+		content.WriteString("\n")
+	}
+	fmt.Println(ui.RenderBox("🔒 Encrypted files", strings.TrimRight(content.String(), "\n")))
+	return nil
+}
+
+func runSecretsRotate(cmd *cobra.Command, args []string) error {
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+	v, err := vault.Open(claudeDir)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return fmt.Errorf("no encrypted files - add one with 'claude-sync secrets add <path>'")
+	}
+
+	if err := v.Rotate(os.Getenv(vault.NewPassphraseEnv)); err != nil {
+		return err
+	}
+	fmt.Println(ui.RenderSuccess("✓", fmt.Sprintf("Re-encrypted %d file(s) with a new key", len(v.Files()))))
+	fmt.Println(ui.RenderWarning("⚠️", "Other machines need the new key to decrypt them after the next sync"))
+	return nil
+}
+
+// repoRelative turns a path given on the command line into a slash-separated
+// path relative to the repository. Relative paths are taken as relative to
+// the repository rather than the working directory, unless they exist there.
+func repoRelative(repoPath, path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		if _, err := os.Stat(filepath.Join(repoPath, path)); err == nil {
+			return filepath.ToSlash(filepath.Clean(path)), nil
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return "", err
+		}
+		path = abs
+	}
+	rel, err := filepath.Rel(repoPath, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is not inside %s", path, repoPath)
+	}
+	return filepath.ToSlash(rel), nil
+}
@@ -0,0 +1,309 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+	"github.com/mfenderov/claude-sync/internal/overlay"
+	"github.com/mfenderov/claude-sync/internal/signing"
+	"github.com/mfenderov/claude-sync/internal/ui"
+)
+
+var signingCmd = &cobra.Command{
+	Use:   "signing",
+	Short: "Sign sync commits and only accept signed ones",
+	Long: `Anyone who can push to the config repository can change how Claude Code
+behaves on every machine. Signing closes that gap: each machine signs its
+commits with an SSH or OpenPGP key, and the keys allowed to sign are listed
+in ` + signing.FileName + ` in the repository.
+
+Once that file is committed, a sync checks every incoming commit before
+rebasing onto it. Unsigned commits and commits signed by a key that is not
+listed stop the sync with a report; nothing is pulled or pushed. The file is
+read from this machine's last commit, so an incoming commit cannot add its
+own key.`,
+}
+
+var signingSetupCmd = &cobra.Command{
+	Use:   "setup <key>",
+	Short: "Sign this machine's commits and add its key to the allowed signers",
+	Long: `Configures the config repository to sign every commit with the given key
+and adds the key to ` + signing.FileName + `. The key is an SSH private
+or public key file, or an OpenPGP key ID from the GnuPG keyring.
+
+The updated file is committed on the next sync. Machines that already
+verify signatures reject commits from a new key until a trusted machine
+adds it with 'claude-sync signing allow'.`,
+	Example: `  claude-sync signing setup ~/.ssh/id_ed25519
+  claude-sync signing setup 0xDEADBEEF --name work-laptop`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSigningSetup,
+}
+
+var signingAllowCmd = &cobra.Command{
+	Use:   "allow <key>",
+	Short: "Trust commits signed by another machine's key",
+	Long: `Adds another machine's key to ` + signing.FileName + `, so its
+commits pass verification once the file is synced. The key is an SSH
+public key file or an OpenPGP key ID from the GnuPG keyring. Run it on a
+machine that is already trusted: a commit that adds a key must itself be
+signed by an allowed signer.`,
+	Example: `  claude-sync signing allow desktop.pub --name desktop`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runSigningAllow,
+}
+
+var signingAcceptCmd = &cobra.Command{
+	Use:   "accept",
+	Short: "Accept incoming commits that failed verification",
+	Long: `Fetches, lists the incoming commits that are unsigned or signed by a key
+that is not allowed, and asks whether to accept them on this machine. Use it
+for commits made before their machine signed its commits. Accepted commits
+are recorded in the repository's .git directory, so each machine decides for
+itself; commits that arrive later are still verified.`,
+	Example: `  claude-sync signing accept
+  claude-sync signing accept --yes   # Accept without prompting`,
+	Args: cobra.NoArgs,
+	RunE: runSigningAccept,
+}
+
+var signingStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show how commits are signed and which signers are allowed",
+	Args:  cobra.NoArgs,
+	RunE:  runSigningStatus,
+}
+
+var signingName string
+
+func init() {
+	rootCmd.AddCommand(signingCmd)
+	signingCmd.AddCommand(signingSetupCmd, signingAllowCmd, signingAcceptCmd, signingStatusCmd)
+	signingSetupCmd.Flags().StringVar(&signingName, "name", "", "Name of the signer in the allowed signers file (default: this host)")
+	signingAllowCmd.Flags().StringVar(&signingName, "name", "", "Name of the signer in the allowed signers file")
+	_ = signingAllowCmd.MarkFlagRequired("name")
+	addOutputFlag(signingAcceptCmd)
+	addOutputFlag(signingStatusCmd)
+}
+
+// signingReport is the machine-readable document for 'signing status -o json'
+type signingReport struct {
+	Sign   bool   `json:"sign"`
+	Format string `json:"format,omitempty"`
+	Key    string `json:"key,omitempty"`
+	// Verifying is true once the allowed signers file is committed
+	Verifying bool             `json:"verifying"`
+	Signers   []signing.Signer `json:"signers"`
+}
+
+// acceptReport is the machine-readable document for 'signing accept -o json'
+type acceptReport struct {
+	Commits  []signing.Rejection `json:"commits"`
+	Accepted bool                `json:"accepted"`
+}
+
+func runSigningSetup(cmd *cobra.Command, args []string) error {
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+	if !git.IsGitRepo(claudeDir) {
+		return fmt.Errorf("%s is not a git repository - run claude-sync once to set up sync", claudeDir)
+	}
+	name := signingName
+	if name == "" {
+		sel, err := overlay.CurrentSelectors()
+		if err != nil {
+			return err
+		}
+		name = sel.Host
+	}
+
+	signer, err := signing.Setup(cmd.Context(), claudeDir, args[0], name)
+	if err != nil {
+		return err
+	}
+	fmt.Println(ui.RenderSuccess("🔏", fmt.Sprintf("Commits in %s are signed with the %s key of %s", claudeDir, signer.Format, signer.Principal)))
+	fmt.Println(ui.RenderMuted("  " + signing.FileName + " is committed on the next sync; from then on"))
+	fmt.Println(ui.RenderMuted("  incoming commits must be signed by one of its keys"))
+	return nil
+}
+
+func runSigningAllow(cmd *cobra.Command, args []string) error {
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+	if !git.IsGitRepo(claudeDir) {
+		return fmt.Errorf("%s is not a git repository - run claude-sync once to set up sync", claudeDir)
+	}
+
+	signer, err := signing.Allow(cmd.Context(), claudeDir, args[0], signingName)
+	if err != nil {
+		return err
+	}
+	fmt.Println(ui.RenderSuccess("✓", fmt.Sprintf("Added the %s key of %s to the allowed signers", signer.Format, signer.Principal)))
+	fmt.Println(ui.RenderMuted("  Commits it signs are accepted once the next sync commits " + signing.FileName))
+	return nil
+}
+
+func runSigningAccept(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	structured, err := jsonOutput()
+	if err != nil {
+		return err
+	}
+	prompter, err := newPrompter(structured)
+	if err != nil {
+		return err
+	}
+
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+	if !git.IsGitRepo(claudeDir) {
+		return fmt.Errorf("%s is not a git repository - run claude-sync once to set up sync", claudeDir)
+	}
+	enabled, err := signing.Enabled(ctx, claudeDir)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return fmt.Errorf("incoming commits are not verified until %s is committed", signing.FileName)
+	}
+	unlock, err := newLocker().Lock(ctx, claudeDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := prompter.SpinWhile("Fetching incoming changes...", func() error {
+		return git.Fetch(ctx, claudeDir)
+	}); err != nil {
+		return err
+	}
+	rejections, err := signing.Verify(ctx, claudeDir, "HEAD..@{upstream}")
+	if err != nil {
+		return err
+	}
+	report := acceptReport{Commits: nonNil(rejections)}
+	if len(rejections) == 0 {
+		if structured {
+			return encodeAcceptReport(report)
+		}
+		fmt.Println(ui.RenderSuccess("✓", "Every incoming commit is signed by an allowed signer"))
+		return nil
+	}
+
+	if !structured {
+		var content strings.Builder
+		for _, r := range rejections {
+			content.WriteString(ui.ListItemStyle.Render(fmt.Sprintf("• %.7s %s — %s", r.SHA, r.Subject, r.Reason)) + "\n")
+		}
+		fmt.Println(ui.RenderBox("Unverified Incoming Commits", strings.TrimRight(content.String(), "\n")))
+		fmt.Println(ui.RenderMuted("  Check them with 'claude-sync diff --incoming' before accepting"))
+		fmt.Println()
+	}
+
+	accept, err := prompter.Confirm("Accept these commits on this machine?")
+	if err != nil {
+		return err
+	}
+	if !accept {
+		if structured {
+			return encodeAcceptReport(report)
+		}
+		fmt.Println(ui.RenderInfo("ℹ️", "Nothing accepted - the sync stays stopped until these commits are signed"))
+		return nil
+	}
+
+	shas := make([]string, 0, len(rejections))
+	for _, r := range rejections {
+		shas = append(shas, r.SHA)
+	}
+	if err := signing.Accept(claudeDir, shas); err != nil {
+		return err
+	}
+	if structured {
+		report.Accepted = true
+		return encodeAcceptReport(report)
+	}
+	fmt.Println(ui.RenderSuccess("✓", fmt.Sprintf("Accepted %d commit(s) - the next sync applies them", len(shas))))
+	return nil
+}
+
+func encodeAcceptReport(report acceptReport) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+func runSigningStatus(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	structured, err := jsonOutput()
+	if err != nil {
+		return err
+	}
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+	if !git.IsGitRepo(claudeDir) {
+		return fmt.Errorf("%s is not a git repository - run claude-sync once to set up sync", claudeDir)
+	}
+
+	cfg, err := git.GetSigningConfig(ctx, claudeDir)
+	if err != nil {
+		return err
+	}
+	verifying, err := signing.Enabled(ctx, claudeDir)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(signing.Path(claudeDir))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	report := signingReport{
+		Sign:      cfg.Sign,
+		Verifying: verifying,
+		Signers:   nonNil(signing.ParseSigners(data)),
+	}
+	if cfg.Sign {
+		report.Format = cfg.Format
+		report.Key = cfg.Key
+	}
+
+	if structured {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	var content strings.Builder
+	if report.Sign {
+		content.WriteString(fmt.Sprintf("Signing:   %s key %s\n", report.Format, report.Key))
+	} else {
+		content.WriteString("Signing:   off\n")
+	}
+	if report.Verifying {
+		content.WriteString("Verifying: incoming commits must be signed by an allowed signer\n")
+	} else {
+		content.WriteString("Verifying: off until " + signing.FileName + " is committed\n")
+	}
+	content.WriteString("\nAllowed signers:\n")
+	if len(report.Signers) == 0 {
+		content.WriteString(ui.MutedStyle.Render("  None - add this machine with 'claude-sync signing setup <key>'"))
+	}
+	for _, s := range report.Signers {
+		content.WriteString(ui.ListItemStyle.Render(fmt.Sprintf("• %s (%s)", s.Principal, s.Format)) + "\n")
+	}
+	fmt.Println(ui.RenderBox("🔏 Commit signing", strings.TrimRight(content.String(), "\n")))
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/mfenderov/claude-sync/internal/git"
+	"github.com/mfenderov/claude-sync/internal/integration"
 	"github.com/mfenderov/claude-sync/internal/logger"
 	"github.com/mfenderov/claude-sync/internal/ui"
 )
@@ -19,16 +20,53 @@ import (
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show configuration status",
-	Long:  `Display the current status of your Claude Code configuration including git status, plugins, and hooks.`,
-	RunE:  runStatus,
+	Long: `Display the current status of your Claude Code configuration including git status, plugins, and hooks.
+
+Untracked paths are listed with the .claude-sync.yaml rule that decides
+whether they will be synced.`,
+	RunE: runStatus,
 }
 
 func init() {
 	rootCmd.AddCommand(statusCmd)
+	addOutputFlag(statusCmd)
+}
+
+// untrackedReport is an untracked path and the sync rule that applies to it
+type untrackedReport struct {
+	Path   string `json:"path"`
+	Rule   string `json:"rule"`
+	Synced bool   `json:"synced"`
+}
+
+// statusReport is the machine-readable status document for --output json
+type statusReport struct {
+	Directory     string            `json:"directory"`
+	Remote        string            `json:"remote"`
+	Branch        string            `json:"branch"`
+	Profile       string            `json:"profile,omitempty"`
+	ModifiedFiles []string          `json:"modified_files"`
+	Untracked     []untrackedReport `json:"untracked"`
+	Plugins       []string          `json:"plugins"`
+	Hooks         []string          `json:"hooks"`
+	Skills        []string          `json:"skills"`
+	Ahead         int               `json:"ahead"`
+	Behind        int               `json:"behind"`
+	// ClaudeCodeHooks are the hooks from 'claude-sync install-hooks'
+	ClaudeCodeHooks []integration.Hook `json:"claude_code_hooks"`
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
+
+	structured, err := jsonOutput()
+	if err != nil {
+		return err
+	}
+	if structured {
+		return runStatusJSON(ctx)
+	}
+
 	log := logger.Default()
 	log.Title("📊 Configuration Status")
 
@@ -52,10 +90,13 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		fmt.Println(ui.RenderError("✗", "Failed to get branch info"))
 		return err
 	}
-	displayRepositoryInfo(claudeDir, branch, ahead, behind)
+	// Profiles are optional; without them the line is left out
+	_, profile, _ := git.ListProfiles(ctx, claudeDir)
+	displayRepositoryInfo(claudeDir, profile, branch, ahead, behind)
 
 	// Display modified files if any
 	displayModifiedFiles(ctx, claudeDir, log)
+	displayUntracked(ctx, claudeDir, log)
 
 	// Display plugins, hooks, and skills
 	displayPlugins(claudeDir)
@@ -66,16 +107,104 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func displayRepositoryInfo(claudeDir, branch string, ahead, behind int) {
+// runStatusJSON writes the status as a single JSON document
+func runStatusJSON(ctx context.Context) error {
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+	if !git.IsGitRepo(claudeDir) {
+		return fmt.Errorf("%s is not a git repository", claudeDir)
+	}
+
+	report, err := collectStatus(ctx, claudeDir)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// collectStatus gathers repository, plugin, hook, and skill information
+func collectStatus(ctx context.Context, claudeDir string) (statusReport, error) {
+	branch, ahead, behind, err := git.GetBranchInfo(ctx, claudeDir)
+	if err != nil {
+		return statusReport{}, err
+	}
+
+	modified, err := git.GetChangedFiles(ctx, claudeDir)
+	if err != nil {
+		return statusReport{}, err
+	}
+
+	paths, err := git.GetUntrackedPaths(ctx, claudeDir)
+	if err != nil {
+		return statusReport{}, err
+	}
+	_, profile, err := git.ListProfiles(ctx, claudeDir)
+	if err != nil {
+		return statusReport{}, err
+	}
+	hooks, err := integration.Installed(filepath.Join(claudeDir, integration.SettingsFile))
+	if err != nil {
+		return statusReport{}, err
+	}
+	untracked := make([]untrackedReport, 0, len(paths))
+	for _, p := range paths {
+		untracked = append(untracked, untrackedReport{
+			Path:   p.Path,
+			Rule:   p.Decision.Rule,
+			Synced: p.Decision.Synced,
+		})
+	}
+
+	return statusReport{
+		Directory:       claudeDir,
+		Remote:          getRemoteURL(claudeDir),
+		Branch:          branch,
+		Profile:         profile,
+		Ahead:           ahead,
+		Behind:          behind,
+		ModifiedFiles:   nonNil(modified),
+		Untracked:       untracked,
+		Plugins:         nonNil(getEnabledPlugins(claudeDir)),
+		Hooks:           nonNil(getHooks(claudeDir)),
+		Skills:          nonNil(getSkills(claudeDir)),
+		ClaudeCodeHooks: nonNil(hooks),
+	}, nil
+}
+
+// nonNil keeps empty lists as [] rather than null in JSON output
+func nonNil[T any](values []T) []T {
+	if values == nil {
+		return []T{}
+	}
+	return values
+}
+
+func displayRepositoryInfo(claudeDir, profile, branch string, ahead, behind int) {
 	remoteURL := getRemoteURL(claudeDir)
 
 	var repoInfo strings.Builder
 	repoInfo.WriteString(ui.InfoStyle.Render("Repository: "))
 	repoInfo.WriteString(remoteURL)
 	repoInfo.WriteString("\n")
+	if profile != "" {
+		repoInfo.WriteString(ui.InfoStyle.Render("Profile:    "))
+		repoInfo.WriteString(profile)
+		repoInfo.WriteString("\n")
+	}
 
 	branchInfo := formatBranchInfo(branch, ahead, behind)
 	repoInfo.WriteString(ui.InfoStyle.Render(branchInfo))
+	// An unreadable settings.json is reported by Claude Code itself
+	if hooks, err := integration.Installed(filepath.Join(claudeDir, integration.SettingsFile)); err == nil {
+		repoInfo.WriteString("\n")
+		repoInfo.WriteString(ui.InfoStyle.Render("Auto-sync:  "))
+		repoInfo.WriteString(integrationStatus(hooks))
+	}
 
 	fmt.Println(ui.BoxStyle.Render(repoInfo.String()))
 }
@@ -125,6 +254,31 @@ func displayModifiedFiles(ctx context.Context, claudeDir string, log *logger.Log
 	fmt.Println(ui.BoxStyle.Render(changeInfo.String()))
 }
 
+// displayUntracked lists untracked paths and the rule deciding whether each is synced
+func displayUntracked(ctx context.Context, claudeDir string, log *logger.Logger) {
+	paths, err := git.GetUntrackedPaths(ctx, claudeDir)
+	if err != nil {
+		log.Warning("⚠️", "Could not check sync rules", "error", err)
+		return
+	}
+	if len(paths) == 0 {
+		return
+	}
+
+	var info strings.Builder
+	info.WriteString(ui.InfoStyle.Render(fmt.Sprintf("🔍 Untracked (%d)", len(paths))))
+	info.WriteString("\n\n")
+	for _, p := range paths {
+		mark := ui.SuccessStyle.Render("✓")
+		if !p.Decision.Synced {
+			mark = ui.MutedStyle.Render("✗")
+		}
+		info.WriteString(ui.ListItemStyle.Render(mark + " " + p.Path + ui.MutedStyle.Render("  ("+p.Decision.Rule+")")))
+		info.WriteString("\n")
+	}
+	fmt.Println(ui.BoxStyle.Render(info.String()))
+}
+
 func displayPlugins(claudeDir string) {
 	plugins := getEnabledPlugins(claudeDir)
 	if len(plugins) == 0 {
@@ -174,8 +328,12 @@ func displaySkills(claudeDir string) {
 }
 
 func getRemoteURL(repoPath string) string {
+	remote, err := git.CurrentRemote(context.Background(), repoPath)
+	if err != nil {
+		return "unknown"
+	}
 	cmd := "git"
-	args := []string{"-C", repoPath, "config", "--get", "remote.origin.url"}
+	args := []string{"-C", repoPath, "config", "--get", "remote." + remote + ".url"}
 	output, err := executeCommand(cmd, args...)
 	if err != nil {
 		return "unknown"
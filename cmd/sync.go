@@ -3,7 +3,6 @@ package cmd
 import (
 	"github.com/spf13/cobra"
 
-	"github.com/mfenderov/claude-sync/internal/logger"
 	"github.com/mfenderov/claude-sync/internal/sync"
 )
 
@@ -15,27 +14,77 @@ var syncCmd = &cobra.Command{
   2. Pulls from remote (with rebase)
   3. Pushes to remote
 
+Use --dry-run to see what would be committed, pulled, and pushed
+without changing anything.
+
+Use --review to see the incoming commits and the files they change, grouped
+by settings, hooks, skills, agents, and commands, and approve them before
+they are applied. Declining leaves the local branch untouched and pushes
+nothing. When nobody can answer, as with --quiet, they are declined.
+
+Changed files are scanned for credentials (API keys, tokens, private keys)
+before they are committed. Allow known values in .claude-sync-allowlist, or
+pass --allow-secret to commit anyway.
+
+Files added with 'claude-sync secrets add' are encrypted before committing
+and decrypted after pulling.
+
+With a bundle directory set by 'claude-sync remote set-bundle-dir', the pull
+and push are replaced by importing the other machines' bundles there and
+writing this machine's own. With --review, each bundle is reviewed before it
+is imported, and a declined bundle is skipped.
+
 This is the default command when running 'claude-sync' without arguments.`,
 	RunE: runSync,
 }
 
+var (
+	syncDryRun bool
+	syncReview bool
+)
+
 func init() {
 	rootCmd.AddCommand(syncCmd)
 
 	// Make sync the default command
 	rootCmd.RunE = runSync
+
+	for _, c := range []*cobra.Command{rootCmd, syncCmd} {
+		c.Flags().BoolVar(&syncDryRun, "dry-run", false, "Show what a sync would do without changing anything")
+		c.Flags().BoolVar(&syncReview, "review", false, "Review incoming changes and approve them before they are applied")
+		addOutputFlag(c)
+	}
 }
 
 func runSync(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 
+	structured, err := jsonOutput()
+	if err != nil {
+		return err
+	}
+
 	// Create adapters to bridge interfaces with real implementations
-	log := logger.Default()
-	logAdapter := sync.NewLoggerAdapter(log)
-	prompterAdapter := sync.NewPrompterAdapter()
-	gitAdapter := sync.NewGitAdapter()
+	logAdapter := newLogger(structured)
+	prompter, err := newPrompter(structured)
+	if err != nil {
+		return err
+	}
+	gitAdapter, gitOpts, err := newGitOperator()
+	if err != nil {
+		return err
+	}
 
 	// Create and run the sync service
-	service := sync.NewService(gitAdapter, prompterAdapter, logAdapter)
+	service := sync.NewService(gitAdapter, prompter, logAdapter, append([]sync.Option{
+		sync.WithDryRun(syncDryRun),
+		sync.WithReview(syncReview),
+		sync.WithConflictResolver(newConflictResolver(structured)),
+		sync.WithLocker(newLocker()),
+		sync.WithSecretScanner(newSecretScanner()),
+		sync.WithSecretStore(sync.NewSecretStoreAdapter(gitAdapter)),
+		sync.WithOverlays(sync.NewOverlayAdapter(gitAdapter)),
+		sync.WithJournal(sync.NewJournalAdapter()),
+	}, gitOpts...)...)
 	return service.Run(ctx)
 }
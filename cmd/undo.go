@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/mfenderov/claude-sync/internal/sync"
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Undo the last sync",
+	Long: `Restores your configuration to the state before the last sync, for example
+after it pulled a broken config from another machine.
+
+Each sync records where the repository was before it started and which
+commits it pulled and pushed. Undo previews those commits and the files it
+would restore, then either:
+  - pushes a commit that restores the previous state, so your other
+    machines get it on their next sync, or
+  - resets this machine only; the next sync brings the changes back.
+
+Undo only works while nothing was committed since the last sync and there
+are no uncommitted changes.`,
+	Example: `  claude-sync undo
+  claude-sync undo --choice revert   # Without prompting`,
+	Args: cobra.NoArgs,
+	RunE: runUndo,
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+	addOutputFlag(undoCmd)
+}
+
+func runUndo(cmd *cobra.Command, args []string) error {
+	structured, err := jsonOutput()
+	if err != nil {
+		return err
+	}
+	prompter, err := newPrompter(structured)
+	if err != nil {
+		return err
+	}
+
+	gitAdapter, gitOpts, err := newGitBinaryOperator("undo")
+	if err != nil {
+		return err
+	}
+	service := sync.NewService(gitAdapter, prompter, newLogger(structured), append([]sync.Option{
+		sync.WithLocker(newLocker()),
+		sync.WithSecretStore(sync.NewSecretStoreAdapter(gitAdapter)),
+		sync.WithOverlays(sync.NewOverlayAdapter(gitAdapter)),
+		sync.WithJournal(sync.NewJournalAdapter()),
+	}, gitOpts...)...)
+	return service.Undo(cmd.Context())
+}
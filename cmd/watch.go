@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+	"github.com/mfenderov/claude-sync/internal/rules"
+	"github.com/mfenderov/claude-sync/internal/sync"
+	"github.com/mfenderov/claude-sync/internal/vault"
+	"github.com/mfenderov/claude-sync/internal/watch"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch for changes and sync automatically",
+	Long: `Watches your Claude Code configuration and keeps it in sync:
+  - Commits local changes as soon as files stop changing
+  - Pulls and pushes on an interval
+  - Retries with backoff while the network is unavailable
+
+Paths ignored by .gitignore and everything inside .git are not watched.
+Stops cleanly on Ctrl+C or SIGTERM, e.g. when run as a service.`,
+	RunE: runWatch,
+}
+
+var (
+	watchInterval time.Duration
+	watchDebounce time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", sync.DefaultWatchInterval, "How often to pull and push")
+	watchCmd.Flags().DurationVar(&watchDebounce, "debounce", watch.DefaultDebounce, "How long files must be quiet before committing")
+	addOutputFlag(watchCmd)
+}
+
+// committedIndirectly returns files that git ignores but whose edits are
+// committed in another form: files built from overlays and the plaintext of
+// encrypted files
+func committedIndirectly(claudeDir string) []string {
+	var files []string
+	if set, err := rules.Load(claudeDir); err == nil {
+		files = append(files, set.Overlays...)
+	}
+	if manifest, err := vault.LoadManifest(claudeDir); err == nil && manifest != nil {
+		files = append(files, manifest.Files...)
+	}
+	return files
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	structured, err := jsonOutput()
+	if err != nil {
+		return err
+	}
+
+	claudeDir, err := git.GetClaudeDir()
+	if err != nil {
+		return err
+	}
+
+	watcher, err := watch.New(claudeDir, watch.Options{
+		Debounce: watchDebounce,
+		Ignored: func(paths []string) []string {
+			// Watching is best effort; on error nothing is filtered and
+			// git add still honours .gitignore when committing
+			ignored, _ := git.CheckIgnored(context.WithoutCancel(ctx), claudeDir, paths)
+			keep := committedIndirectly(claudeDir)
+			return slices.DeleteFunc(ignored, func(path string) bool {
+				return slices.Contains(keep, path)
+			})
+		},
+	})
+	if err != nil {
+		return err
+	}
+	go func() {
+		_ = watcher.Run(ctx)
+	}()
+
+	// Watch runs unattended: never prompt, and leave conflicts for a manual sync
+	answers, err := sync.LoadAnswers(answersFile, os.Environ())
+	if err != nil {
+		return err
+	}
+	prompter := sync.NewScriptedPrompter(answers, nil, progressOutput(structured))
+
+	gitAdapter, gitOpts, err := newGitOperator()
+	if err != nil {
+		return err
+	}
+	service := sync.NewService(gitAdapter, prompter, newLogger(structured), append([]sync.Option{
+		sync.WithLocker(newLocker()),
+		sync.WithSecretScanner(newSecretScanner()),
+		sync.WithSecretStore(sync.NewSecretStoreAdapter(gitAdapter)),
+		sync.WithOverlays(sync.NewOverlayAdapter(gitAdapter)),
+		sync.WithJournal(sync.NewJournalAdapter()),
+	}, gitOpts...)...)
+	return service.Watch(ctx, watcher.Changes(), sync.WatchOptions{Interval: watchInterval})
+}
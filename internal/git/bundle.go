@@ -0,0 +1,156 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// bundleDirKey names the directory bundles are exchanged through, in the
+// repository's local git config
+const bundleDirKey = "claude-sync.bundledir"
+
+// bundleRefPrefix holds the branches fetched from bundles, one namespace
+// per source: refs/bundles/<name>/<branch>
+const bundleRefPrefix = "refs/bundles/"
+
+// BundleExt is the extension of the bundles in a bundle directory
+const BundleExt = ".bundle"
+
+// CreateBundle writes the current branch to a bundle file. Commits already
+// fetched from bundles are left out, since the machines that wrote those
+// bundles have them, unless full is set. It returns the number of commits
+// written; with none it writes nothing, as git refuses empty bundles.
+func CreateBundle(ctx context.Context, repoPath, file string, full bool) (int, error) {
+	branch, err := getCurrentBranch(ctx, repoPath)
+	if err != nil {
+		return 0, err
+	}
+	if branch == "" {
+		return 0, fmt.Errorf("no branch is checked out")
+	}
+
+	var excludes []string
+	if !full {
+		if excludes, err = bundleTips(ctx, repoPath, branch); err != nil {
+			return 0, err
+		}
+	}
+	revs := append([]string{"refs/heads/" + branch}, excludes...)
+
+	output, err := exec.CommandContext(ctx, "git", append([]string{"-C", repoPath, "rev-list", "--count"}, revs...)...).Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count commits to export: %w", err)
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(string(output)))
+	if err != nil || count == 0 {
+		return 0, err
+	}
+
+	// Write next to the target and rename, so a bundle on a removable drive
+	// is never left half written. HEAD lets a new machine clone the bundle
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return 0, err
+	}
+	tmp := abs + ".tmp"
+	args := append([]string{"bundle", "create", "--quiet", tmp, "HEAD"}, revs...)
+	if err := runGit(ctx, repoPath, "failed to create bundle", args...); err != nil {
+		_ = os.Remove(tmp)
+		return 0, err
+	}
+	if err := os.Rename(tmp, abs); err != nil {
+		_ = os.Remove(tmp)
+		return 0, fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return count, nil
+}
+
+// bundleTips returns the branch as fetched from each bundle, as exclusions
+// for rev-list
+func bundleTips(ctx context.Context, repoPath, branch string) ([]string, error) {
+	output, err := exec.CommandContext(ctx, "git", "-C", repoPath, "for-each-ref", "--format=%(refname)", bundleRefPrefix).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list imported bundles: %w", err)
+	}
+	var tips []string
+	for _, ref := range splitLines(string(output)) {
+		if _, rest, _ := strings.Cut(strings.TrimPrefix(ref, bundleRefPrefix), "/"); rest == branch {
+			tips = append(tips, "^"+ref)
+		}
+	}
+	return tips, nil
+}
+
+// FetchBundle verifies a bundle and fetches its copy of the current branch
+// to refs/bundles/<name>/<branch>, returning the commit it points to.
+// Verifying fails when the bundle builds on commits the repository lacks.
+func FetchBundle(ctx context.Context, repoPath, file, name string) (string, error) {
+	branch, err := getCurrentBranch(ctx, repoPath)
+	if err != nil {
+		return "", err
+	}
+	if branch == "" {
+		return "", fmt.Errorf("no branch is checked out")
+	}
+	ref := bundleRefPrefix + name + "/" + branch
+	if err := exec.CommandContext(ctx, "git", "check-ref-format", ref).Run(); err != nil {
+		return "", fmt.Errorf("%q cannot name a bundle source", name)
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "bundle", "verify", abs)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if strings.Contains(string(output), "prerequisite") {
+			return "", fmt.Errorf("%s builds on commits this repository does not have: %w\n\n"+
+				"Import the bundles exported before it first, or export a full bundle\n"+
+				"with 'claude-sync export --full'", file, err)
+		}
+		return "", fmt.Errorf("%s is not a valid bundle: %w\nOutput: %s", file, err, string(output))
+	}
+
+	output, err := exec.CommandContext(ctx, "git", "-C", repoPath, "bundle", "list-heads", abs, "refs/heads/"+branch).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read bundle: %w", err)
+	}
+	sha, _, _ := strings.Cut(strings.TrimSpace(string(output)), " ")
+	if sha == "" {
+		return "", fmt.Errorf("%s has no %s branch", file, branch)
+	}
+	if err := runGit(ctx, repoPath, "failed to fetch bundle", "fetch", "--quiet", "--no-tags", abs, "+refs/heads/"+branch+":"+ref); err != nil {
+		return "", err
+	}
+	return sha, nil
+}
+
+// BundleDir returns the directory bundles are exchanged through, or "" when
+// the repository syncs with its remote
+func BundleDir(ctx context.Context, repoPath string) (string, error) {
+	output, err := gitConfig(ctx, repoPath, "--get", bundleDirKey)
+	return strings.TrimSpace(output), err
+}
+
+// SetBundleDir makes a sync exchange bundles through a directory, such as one
+// on a USB drive, instead of pulling and pushing. An empty dir unsets it.
+func SetBundleDir(ctx context.Context, repoPath, dir string) error {
+	if dir == "" {
+		_, err := gitConfig(ctx, repoPath, "--unset", bundleDirKey)
+		return err
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	_, err = gitConfig(ctx, repoPath, bundleDirKey, abs)
+	return err
+}
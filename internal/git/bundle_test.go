@@ -0,0 +1,104 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBundles(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	laptop := createTestRepo(t)
+	desktop := createTestRepo(t)
+
+	// A full bundle needs nothing on the other side
+	first := filepath.Join(dir, "first.bundle")
+	if count, err := CreateBundle(ctx, laptop, first, false); err != nil || count != 1 {
+		t.Fatalf("CreateBundle() = %d, %v, want 1 commit", count, err)
+	}
+	sha, err := FetchBundle(ctx, desktop, first, "laptop")
+	if err != nil {
+		t.Fatalf("FetchBundle() error = %v", err)
+	}
+	head, _ := exec.Command("git", "-C", laptop, "rev-parse", "HEAD").Output()
+	if sha != strings.TrimSpace(string(head)) {
+		t.Errorf("FetchBundle() = %s, want %s", sha, head)
+	}
+	if output, err := exec.Command("git", "-C", desktop, "reset", "--hard", "refs/bundles/laptop/main").CombinedOutput(); err != nil {
+		t.Fatalf("reset error = %v\n%s", err, output)
+	}
+
+	// The reply leaves out what the laptop already has
+	commitFile(t, desktop, "CLAUDE.md", "notes", "Add notes")
+	reply := filepath.Join(dir, "reply.bundle")
+	if count, err := CreateBundle(ctx, desktop, reply, false); err != nil || count != 1 {
+		t.Fatalf("CreateBundle() reply = %d, %v, want 1 commit", count, err)
+	}
+	if _, err := FetchBundle(ctx, laptop, reply, "desktop"); err != nil {
+		t.Fatalf("FetchBundle() reply error = %v", err)
+	}
+	empty := t.TempDir()
+	if err := exec.Command("git", "init", "--initial-branch=main", empty).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FetchBundle(ctx, empty, reply, "desktop"); err == nil || !strings.Contains(err.Error(), "does not have") {
+		t.Errorf("FetchBundle() without the prerequisites error = %v", err)
+	}
+	if _, err := FetchBundle(ctx, laptop, reply, "bad..name"); err == nil {
+		t.Error("FetchBundle() with an invalid name succeeded")
+	}
+
+	// The laptop has nothing the desktop is missing
+	again := filepath.Join(dir, "again.bundle")
+	if count, err := CreateBundle(ctx, laptop, again, false); err != nil || count != 0 {
+		t.Errorf("CreateBundle() with nothing new = %d, %v, want 0", count, err)
+	}
+	if _, err := os.Stat(again); !os.IsNotExist(err) {
+		t.Error("CreateBundle() with nothing new wrote a file")
+	}
+	if count, err := CreateBundle(ctx, laptop, again, true); err != nil || count != 1 {
+		t.Fatalf("CreateBundle() full = %d, %v, want 1", count, err)
+	}
+
+	// A new machine can clone a bundle
+	clone := filepath.Join(t.TempDir(), "clone")
+	if output, err := exec.Command("git", "clone", "--quiet", again, clone).CombinedOutput(); err != nil {
+		t.Fatalf("clone error = %v\n%s", err, output)
+	}
+	if branch, err := getCurrentBranch(ctx, clone); err != nil || branch != "main" {
+		t.Errorf("cloned branch = %q, %v, want main", branch, err)
+	}
+}
+
+func TestBundleDir(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repoPath := createTestRepo(t)
+	usb := t.TempDir()
+
+	if dir, err := BundleDir(ctx, repoPath); err != nil || dir != "" {
+		t.Errorf("BundleDir() unset = %q, %v", dir, err)
+	}
+	if err := SetBundleDir(ctx, repoPath, filepath.Join(usb, "missing")); err == nil {
+		t.Error("SetBundleDir() with a missing directory succeeded")
+	}
+	if err := SetBundleDir(ctx, repoPath, usb); err != nil {
+		t.Fatalf("SetBundleDir() error = %v", err)
+	}
+	if dir, err := BundleDir(ctx, repoPath); err != nil || dir != usb {
+		t.Errorf("BundleDir() = %q, %v, want %q", dir, err, usb)
+	}
+	if err := SetBundleDir(ctx, repoPath, ""); err != nil {
+		t.Fatalf("SetBundleDir() unset error = %v", err)
+	}
+	if dir, err := BundleDir(ctx, repoPath); err != nil || dir != "" {
+		t.Errorf("BundleDir() after unset = %q, %v", dir, err)
+	}
+}
@@ -0,0 +1,335 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// GitignoreVersion is the version of the default patterns in the managed
+// .gitignore block. Bump it whenever defaultGitignorePatterns changes so
+// existing repositories pick up the new patterns on their next sync.
+const GitignoreVersion = 2
+
+const (
+	gitignoreBegin   = "# BEGIN claude-sync managed block (do not edit, add your own entries below)"
+	gitignoreEnd     = "# END claude-sync managed block"
+	gitignoreVersion = "# claude-sync defaults v"
+)
+
+// defaultGitignorePatterns is the content of the managed .gitignore block
+const defaultGitignorePatterns = `# Credentials and secrets
+.credentials.json
+credentials.json
+*.key
+*.pem
+*.p12
+*-key.json
+service-account*.json
+
+# AWS scripts (may contain credentials)
+aws-*.sh
+
+# Environment files
+.env
+.env.*
+
+# IDE and editor files
+.vscode/
+.idea/
+*.swp
+*.swo
+*~
+
+# OS files
+.DS_Store
+Thumbs.db
+
+# Logs
+*.log`
+
+// legacyGitignore is the unmanaged template written by releases before the
+// managed block (defaults v1). Its lines are dropped when such a file is
+// upgraded, since the managed block now provides them.
+const legacyGitignore = `# Credentials and secrets
+credentials.json
+*.key
+*.pem
+*.p12
+*-key.json
+service-account*.json
+
+# AWS scripts (may contain credentials)
+aws-*.sh
+
+# Environment files
+.env
+.env.*
+
+# IDE and editor files
+.vscode/
+.idea/
+*.swp
+*.swo
+*~
+
+# OS files
+.DS_Store
+Thumbs.db
+
+# Logs
+*.log`
+
+// Gitignore is the parsed .gitignore of a config repository
+type Gitignore struct {
+	// Managed holds the patterns of the managed block, without comments
+	Managed []string
+	// User holds the lines outside the managed block
+	User []string
+	// Version of the managed block; 0 when there is none
+	Version int
+}
+
+// ReadGitignore parses the repository's .gitignore
+func ReadGitignore(repoPath string) (*Gitignore, error) {
+	content, err := os.ReadFile(filepath.Join(repoPath, ".gitignore"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read .gitignore: %w", err)
+	}
+
+	outside, inside, _ := splitManagedBlock(string(content), gitignoreBegin, gitignoreEnd)
+	g := &Gitignore{User: trimBlankLines(outside)}
+	for _, line := range inside {
+		if v, ok := strings.CutPrefix(line, gitignoreVersion); ok {
+			g.Version, _ = strconv.Atoi(v)
+			continue
+		}
+		if isPattern(line) {
+			g.Managed = append(g.Managed, line)
+		}
+	}
+	return g, nil
+}
+
+// UpgradeGitignore writes the managed block of default patterns into
+// .gitignore, keeping every line outside the block. It does nothing when
+// the block is already at GitignoreVersion or newer, and reports whether
+// the file changed. Lines of the pre-block template are replaced by the block.
+func UpgradeGitignore(repoPath string) (bool, error) {
+	path := filepath.Join(repoPath, ".gitignore")
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("failed to read .gitignore: %w", err)
+	}
+
+	g, err := ReadGitignore(repoPath)
+	if err != nil {
+		return false, err
+	}
+	if g.Version >= GitignoreVersion {
+		return false, nil
+	}
+
+	user := g.User
+	if g.Version == 0 {
+		legacy := strings.Split(legacyGitignore, "\n")
+		user = trimBlankLines(slices.DeleteFunc(slices.Clone(user), func(line string) bool {
+			return line != "" && slices.Contains(legacy, line)
+		}))
+	}
+
+	if err := writeGitignore(path, user, content); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// AddIgnorePatterns appends patterns to the user section of .gitignore,
+// skipping patterns that are already listed, and returns the added ones.
+// The managed block is upgraded first if needed.
+func AddIgnorePatterns(repoPath string, patterns []string) ([]string, error) {
+	if _, err := UpgradeGitignore(repoPath); err != nil {
+		return nil, err
+	}
+	g, err := ReadGitignore(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var added []string
+	user := g.User
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if !isPattern(pattern) {
+			return nil, fmt.Errorf("invalid pattern %q", pattern)
+		}
+		if slices.Contains(user, pattern) || slices.Contains(g.Managed, pattern) {
+			continue
+		}
+		user = append(user, pattern)
+		added = append(added, pattern)
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+	return added, rewriteGitignore(repoPath, user)
+}
+
+// RemoveIgnorePatterns removes patterns from the user section of .gitignore
+// and returns the removed ones. Patterns from the managed block cannot be
+// removed; override them with a negated pattern such as "!*.log" instead.
+func RemoveIgnorePatterns(repoPath string, patterns []string) ([]string, error) {
+	if _, err := UpgradeGitignore(repoPath); err != nil {
+		return nil, err
+	}
+	g, err := ReadGitignore(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	user := g.User
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if slices.Contains(user, pattern) {
+			user = slices.DeleteFunc(user, func(line string) bool { return line == pattern })
+			removed = append(removed, pattern)
+			continue
+		}
+		if slices.Contains(g.Managed, pattern) {
+			return nil, fmt.Errorf("%q is a claude-sync default; add \"!%s\" to un-ignore it instead", pattern, pattern)
+		}
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	return removed, rewriteGitignore(repoPath, trimBlankLines(user))
+}
+
+// rewriteGitignore writes user lines back below the existing managed block
+func rewriteGitignore(repoPath string, user []string) error {
+	path := filepath.Join(repoPath, ".gitignore")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read .gitignore: %w", err)
+	}
+	_, block, _ := splitManagedBlock(string(content), gitignoreBegin, gitignoreEnd)
+	return writeFileIfChanged(path, renderGitignore(block, user), content)
+}
+
+// writeGitignore writes the current managed block followed by user lines
+func writeGitignore(path string, user []string, existing []byte) error {
+	block := append([]string{gitignoreVersion + strconv.Itoa(GitignoreVersion)}, strings.Split(defaultGitignorePatterns, "\n")...)
+	return writeFileIfChanged(path, renderGitignore(block, user), existing)
+}
+
+func renderGitignore(block, user []string) string {
+	var b strings.Builder
+	b.WriteString(gitignoreBegin + "\n")
+	for _, line := range block {
+		b.WriteString(line + "\n")
+	}
+	b.WriteString(gitignoreEnd + "\n")
+	if len(user) > 0 {
+		b.WriteString("\n")
+		for _, line := range user {
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
+
+func writeFileIfChanged(path, content string, existing []byte) error {
+	if content == string(existing) {
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// isPattern reports whether a .gitignore line is a pattern rather than a
+// comment or blank line
+func isPattern(line string) bool {
+	return line != "" && !strings.HasPrefix(line, "#")
+}
+
+// trimBlankLines drops leading and trailing blank lines and collapses runs
+// of blank lines into one
+func trimBlankLines(lines []string) []string {
+	var out []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" && (len(out) == 0 || out[len(out)-1] == "") {
+			continue
+		}
+		out = append(out, line)
+	}
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	return out
+}
+
+// IgnoreMatch explains whether a path is ignored and by which pattern
+type IgnoreMatch struct {
+	Path    string
+	Source  string
+	Pattern string
+	Line    int
+	Ignored bool
+	// Managed is set when the pattern comes from the managed .gitignore block
+	Managed bool
+}
+
+// ExplainIgnored reports, for each path, whether git ignores it and which
+// pattern in .gitignore or .git/info/exclude decides it. A negated pattern
+// ("!name") that matches means the path is explicitly not ignored.
+func ExplainIgnored(ctx context.Context, repoPath string, paths []string) ([]IgnoreMatch, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "check-ignore", "-v", "-n", "-z", "--stdin")
+	cmd.Stdin = strings.NewReader(strings.Join(paths, "\x00") + "\x00")
+	output, err := cmd.Output()
+	if err != nil {
+		// Exit status 1 means no path is ignored; output still lists them all
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			return nil, fmt.Errorf("failed to check ignored paths: %w", err)
+		}
+	}
+
+	blockStart, blockEnd := managedBlockLines(repoPath)
+	fields := strings.Split(string(output), "\x00")
+	var matches []IgnoreMatch
+	for i := 0; i+3 < len(fields); i += 4 {
+		m := IgnoreMatch{Source: fields[i], Pattern: fields[i+2], Path: fields[i+3]}
+		m.Line, _ = strconv.Atoi(fields[i+1])
+		m.Ignored = m.Pattern != "" && !strings.HasPrefix(m.Pattern, "!")
+		m.Managed = m.Source == ".gitignore" && m.Line > blockStart && m.Line < blockEnd
+		matches = append(matches, m)
+	}
+	return matches, nil
+}
+
+// managedBlockLines returns the 1-based line numbers of the managed block
+// markers in .gitignore, or zeros when there is no block
+func managedBlockLines(repoPath string) (begin, end int) {
+	content, err := os.ReadFile(filepath.Join(repoPath, ".gitignore"))
+	if err != nil {
+		return 0, 0
+	}
+	for i, line := range strings.Split(string(content), "\n") {
+		switch line {
+		case gitignoreBegin:
+			begin = i + 1
+		case gitignoreEnd:
+			end = i + 1
+		}
+	}
+	return begin, end
+}
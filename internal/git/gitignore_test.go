@@ -0,0 +1,140 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpgradeGitignore(t *testing.T) {
+	t.Parallel()
+
+	repoPath := t.TempDir()
+	path := filepath.Join(repoPath, ".gitignore")
+	// A file written by a release before the managed block, plus a user entry
+	legacy := legacyGitignore + "\n\n# Mine\nscratch/\n"
+	if err := os.WriteFile(path, []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	upgraded, err := UpgradeGitignore(repoPath)
+	if err != nil || !upgraded {
+		t.Fatalf("UpgradeGitignore() = %v, %v; want upgraded", upgraded, err)
+	}
+
+	g, err := ReadGitignore(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Version != GitignoreVersion {
+		t.Errorf("Version = %d, want %d", g.Version, GitignoreVersion)
+	}
+	if strings.Join(g.User, "\n") != "# Mine\nscratch/" {
+		t.Errorf("User = %q, want only the user entries", g.User)
+	}
+	if !strings.Contains(strings.Join(g.Managed, ","), ".credentials.json") {
+		t.Errorf("Managed = %v, want the new default patterns", g.Managed)
+	}
+
+	upgraded, err = UpgradeGitignore(repoPath)
+	if err != nil || upgraded {
+		t.Errorf("second UpgradeGitignore() = %v, %v; want no change", upgraded, err)
+	}
+}
+
+func TestUpgradeGitignoreKeepsNewerBlock(t *testing.T) {
+	t.Parallel()
+
+	repoPath := t.TempDir()
+	newer := gitignoreBegin + "\n" + gitignoreVersion + "99\nfuture-pattern\n" + gitignoreEnd + "\n"
+	if err := os.WriteFile(filepath.Join(repoPath, ".gitignore"), []byte(newer), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	upgraded, err := UpgradeGitignore(repoPath)
+	if err != nil || upgraded {
+		t.Fatalf("UpgradeGitignore() = %v, %v; want a newer block left alone", upgraded, err)
+	}
+	content, _ := os.ReadFile(filepath.Join(repoPath, ".gitignore"))
+	if string(content) != newer {
+		t.Errorf(".gitignore = %q, want unchanged", content)
+	}
+}
+
+func TestAddRemoveIgnorePatterns(t *testing.T) {
+	t.Parallel()
+
+	repoPath := t.TempDir()
+	if _, err := UpgradeGitignore(repoPath); err != nil {
+		t.Fatal(err)
+	}
+
+	added, err := AddIgnorePatterns(repoPath, []string{"scratch/", "*.log", "scratch/"})
+	if err != nil {
+		t.Fatalf("AddIgnorePatterns() error = %v", err)
+	}
+	if strings.Join(added, ",") != "scratch/" {
+		t.Errorf("AddIgnorePatterns() = %v, want only the new pattern", added)
+	}
+	if _, err := AddIgnorePatterns(repoPath, []string{"# comment"}); err == nil {
+		t.Error("AddIgnorePatterns() should reject comments")
+	}
+
+	if _, err := RemoveIgnorePatterns(repoPath, []string{"*.log"}); err == nil {
+		t.Error("RemoveIgnorePatterns() should refuse to remove a default pattern")
+	}
+	removed, err := RemoveIgnorePatterns(repoPath, []string{"scratch/", "missing"})
+	if err != nil {
+		t.Fatalf("RemoveIgnorePatterns() error = %v", err)
+	}
+	if strings.Join(removed, ",") != "scratch/" {
+		t.Errorf("RemoveIgnorePatterns() = %v", removed)
+	}
+
+	g, err := ReadGitignore(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.User) != 0 || g.Version != GitignoreVersion {
+		t.Errorf("ReadGitignore() = %+v, want the managed block only", g)
+	}
+}
+
+func TestExplainIgnored(t *testing.T) {
+	t.Parallel()
+
+	repoPath := createTestRepo(t)
+	if _, err := UpgradeGitignore(repoPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AddIgnorePatterns(repoPath, []string{"scratch/", "!keep.log"}); err != nil {
+		t.Fatal(err)
+	}
+
+	matches, err := ExplainIgnored(context.Background(), repoPath, []string{"debug.log", "scratch/a.md", "keep.log", "settings.json"})
+	if err != nil {
+		t.Fatalf("ExplainIgnored() error = %v", err)
+	}
+	if len(matches) != 4 {
+		t.Fatalf("ExplainIgnored() = %+v, want 4 results", matches)
+	}
+
+	want := []struct {
+		pattern string
+		ignored bool
+		managed bool
+	}{
+		{pattern: "*.log", ignored: true, managed: true},
+		{pattern: "scratch/", ignored: true},
+		{pattern: "!keep.log"},
+		{},
+	}
+	for i, w := range want {
+		m := matches[i]
+		if m.Pattern != w.pattern || m.Ignored != w.ignored || m.Managed != w.managed {
+			t.Errorf("ExplainIgnored()[%s] = %+v, want %+v", m.Path, m, w)
+		}
+	}
+}
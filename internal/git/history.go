@@ -0,0 +1,106 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ResetTo moves the current branch and the working tree to rev, keeping
+// uncommitted changes to files that do not differ between HEAD and rev
+func ResetTo(ctx context.Context, repoPath, rev string) error {
+	return runGit(ctx, repoPath, "failed to reset to "+rev, "reset", "--quiet", "--keep", rev)
+}
+
+// RevertTo undoes everything since rev with a new commit on top of HEAD whose
+// content is that of rev. Unlike a reset it only adds history, so it can be
+// pushed.
+func RevertTo(ctx context.Context, repoPath, rev, message string) error {
+	args := append([]string{"-C", repoPath, "commit-tree", rev + "^{tree}", "-p", "HEAD", "-m", message}, signArgs(ctx, repoPath)...)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to create revert commit: %w", err)
+	}
+	return runGit(ctx, repoPath, "failed to apply revert commit", "merge", "--quiet", "--ff-only", strings.TrimSpace(string(output)))
+}
+
+// ReadFileAt returns the content of a file at a revision, or nil when the
+// file does not exist there. The path is relative to the repository root.
+func ReadFileAt(ctx context.Context, repoPath, rev, path string) ([]byte, error) {
+	spec := rev + ":" + filepath.ToSlash(path)
+	if exec.CommandContext(ctx, "git", "-C", repoPath, "cat-file", "-e", spec).Run() != nil {
+		if _, err := RevParse(ctx, repoPath, rev); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	output, err := exec.CommandContext(ctx, "git", "-C", repoPath, "cat-file", "blob", spec).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s at %s: %w%s", path, rev, err, stderrOf(err))
+	}
+	if output == nil {
+		// An empty file exists, unlike a missing one
+		output = []byte{}
+	}
+	return output, nil
+}
+
+// MergeBase returns the best common ancestor of two revisions
+func MergeBase(ctx context.Context, repoPath, a, b string) (string, error) {
+	output, err := exec.CommandContext(ctx, "git", "-C", repoPath, "merge-base", a, b).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the common ancestor of %s and %s: %w", a, b, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// ResolveRevision resolves a revision such as a commit SHA, or a date git
+// understands such as "2024-05-01" or "2 days ago", which stands for the last
+// commit on the current branch made before it
+func ResolveRevision(ctx context.Context, repoPath, at string) (string, error) {
+	if sha, err := RevParse(ctx, repoPath, at); err == nil {
+		return sha, nil
+	}
+	output, err := exec.CommandContext(ctx, "git", "-C", repoPath, "rev-list", "-1", "--before="+at, "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w%s", at, err, stderrOf(err))
+	}
+	sha := strings.TrimSpace(string(output))
+	if sha == "" {
+		return "", fmt.Errorf("no commit before %s", at)
+	}
+	return sha, nil
+}
+
+// ListFilesAt returns the files at a revision that are path or below it
+func ListFilesAt(ctx context.Context, repoPath, rev, path string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "ls-tree", "-r", "-z", "--name-only", rev, "--", filepath.ToSlash(path))
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files at %s: %w%s", rev, err, stderrOf(err))
+	}
+	return splitNul(string(output)), nil
+}
+
+// RestorePath writes the files at a revision that are path or below it into
+// the working tree. Files that did not exist at the revision are kept.
+func RestorePath(ctx context.Context, repoPath, rev, path string) error {
+	return runGit(ctx, repoPath, "failed to restore "+path,
+		"restore", "--source="+rev, "--worktree", "--overlay", "--", filepath.ToSlash(path))
+}
+
+// CommitPath commits the changes to path, or below it, and nothing else.
+// Without changes it does nothing.
+func CommitPath(ctx context.Context, repoPath, path, message string) error {
+	if err := runGit(ctx, repoPath, "failed to stage "+path, "add", "--all", "--", filepath.ToSlash(path)); err != nil {
+		return err
+	}
+	staged := exec.CommandContext(ctx, "git", "-C", repoPath, "diff", "--cached", "--quiet", "--", filepath.ToSlash(path))
+	if staged.Run() == nil {
+		return nil
+	}
+	return runGit(ctx, repoPath, "failed to commit "+path, "commit", "--quiet", "-m", message, "--", filepath.ToSlash(path))
+}
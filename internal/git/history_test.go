@@ -0,0 +1,135 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResetToAndRevertTo(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repoPath := createTestRepo(t)
+	start, err := RevParse(ctx, repoPath, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, repoPath, "hooks/bad.sh", "rm -rf", "bad hook")
+	commitFile(t, repoPath, "test.txt", "changed", "change")
+
+	if err := RevertTo(ctx, repoPath, start, "Undo"); err != nil {
+		t.Fatalf("RevertTo() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repoPath, "hooks/bad.sh")); !os.IsNotExist(err) {
+		t.Errorf("hooks/bad.sh still exists: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repoPath, "test.txt")); string(data) != "initial content" {
+		t.Errorf("test.txt = %q", data)
+	}
+	count, _ := exec.Command("git", "-C", repoPath, "rev-list", "--count", "HEAD").Output()
+	if strings.TrimSpace(string(count)) != "4" {
+		t.Errorf("RevertTo() left %s commits, want 4", count)
+	}
+	if dirty, err := HasUncommittedChanges(ctx, repoPath); err != nil || dirty {
+		t.Errorf("HasUncommittedChanges() = %v, %v", dirty, err)
+	}
+
+	if err := ResetTo(ctx, repoPath, start); err != nil {
+		t.Fatalf("ResetTo() error = %v", err)
+	}
+	if head, _ := RevParse(ctx, repoPath, "HEAD"); head != start {
+		t.Errorf("HEAD = %s, want %s", head, start)
+	}
+}
+
+func TestReadFileAtAndMergeBase(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repoPath := createTestRepo(t)
+	start, err := RevParse(ctx, repoPath, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, repoPath, "hooks/check.sh", "echo ok", "add hook")
+
+	if data, err := ReadFileAt(ctx, repoPath, "HEAD", "hooks/check.sh"); err != nil || string(data) != "echo ok" {
+		t.Errorf("ReadFileAt(HEAD) = %q, %v", data, err)
+	}
+	if data, err := ReadFileAt(ctx, repoPath, start, "hooks/check.sh"); err != nil || data != nil {
+		t.Errorf("ReadFileAt() of a missing file = %q, %v, want nil, nil", data, err)
+	}
+	if _, err := ReadFileAt(ctx, repoPath, "no-such-rev", "test.txt"); err == nil {
+		t.Error("ReadFileAt() of an unknown revision succeeded")
+	}
+
+	if base, err := MergeBase(ctx, repoPath, start, "HEAD"); err != nil || base != start {
+		t.Errorf("MergeBase() = %s, %v, want %s", base, err, start)
+	}
+}
+
+func TestRestorePathAndCommitPath(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repoPath := createTestRepo(t)
+	commitFile(t, repoPath, "skills/review/SKILL.md", "review", "add skill")
+	commitFile(t, repoPath, "skills/review/notes.md", "notes", "add notes")
+	withSkill, err := RevParse(ctx, repoPath, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(repoPath, "skills")); err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, repoPath, "test.txt", "changed", "delete skill")
+
+	if rev, err := ResolveRevision(ctx, repoPath, "HEAD~1"); err != nil || rev != withSkill {
+		t.Errorf("ResolveRevision(HEAD~1) = %s, %v, want %s", rev, err, withSkill)
+	}
+	if rev, err := ResolveRevision(ctx, repoPath, "tomorrow"); err != nil || len(rev) != 40 {
+		t.Errorf("ResolveRevision(tomorrow) = %s, %v", rev, err)
+	}
+	if _, err := ResolveRevision(ctx, repoPath, "1971-01-01"); err == nil {
+		t.Error("ResolveRevision() before the first commit succeeded")
+	}
+
+	files, err := ListFilesAt(ctx, repoPath, withSkill, "skills/review")
+	if err != nil || len(files) != 2 || files[0] != "skills/review/SKILL.md" {
+		t.Errorf("ListFilesAt() = %v, %v", files, err)
+	}
+	if files, err := ListFilesAt(ctx, repoPath, "HEAD", "skills"); err != nil || len(files) != 0 {
+		t.Errorf("ListFilesAt(HEAD) = %v, %v, want none", files, err)
+	}
+
+	// Local files are kept, and only the restored path is committed
+	if err := os.MkdirAll(filepath.Join(repoPath, "skills/review"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, "skills/review/local.md"), []byte("local"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, "other.txt"), []byte("other"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := RestorePath(ctx, repoPath, withSkill, "skills/review"); err != nil {
+		t.Fatalf("RestorePath() error = %v", err)
+	}
+	for _, name := range []string{"SKILL.md", "notes.md", "local.md"} {
+		if _, err := os.Stat(filepath.Join(repoPath, "skills/review", name)); err != nil {
+			t.Errorf("skills/review/%s missing after restore: %v", name, err)
+		}
+	}
+
+	if err := CommitPath(ctx, repoPath, "skills/review", "Restore skills/review"); err != nil {
+		t.Fatalf("CommitPath() error = %v", err)
+	}
+	changed, err := changedFiles(ctx, repoPath)
+	if err != nil || len(changed) != 1 || changed[0] != "other.txt" {
+		t.Errorf("changed files after CommitPath() = %v, %v, want [other.txt]", changed, err)
+	}
+}
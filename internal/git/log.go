@@ -0,0 +1,136 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// autoSyncPattern matches the subjects written by GenerateAutoCommitMessage
+var autoSyncPattern = regexp.MustCompile(`^Auto-sync from (\S+) at (\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})$`)
+
+// LogOptions filters the history returned by Log
+type LogOptions struct {
+	// Host keeps auto-sync commits made on this machine, ignoring case
+	Host string
+	// Since is a date git understands, such as "2024-05-01" or "2 days ago"
+	Since string
+	// Path keeps commits that changed this file or directory, and only lists
+	// the files below it
+	Path string
+	// Grep keeps commits whose message matches this extended regular
+	// expression, ignoring case
+	Grep string
+	// Limit caps the number of commits; zero means no limit
+	Limit int
+}
+
+// FileChange is a file changed by a commit
+type FileChange struct {
+	// Status is git's status letter: A, M, D, R (renamed), C (copied), or T
+	Status string `json:"status"`
+	Path   string `json:"path"`
+	// OldPath is the previous path of a renamed or copied file
+	OldPath string `json:"old_path,omitempty"`
+}
+
+// Commit is a commit in the history of the repository
+type Commit struct {
+	SHA     string `json:"sha"`
+	Subject string `json:"subject"`
+	Author  string `json:"author"`
+	// Host is the machine an auto-sync commit was made on; empty for others
+	Host string `json:"host,omitempty"`
+	// Time is the time in an auto-sync subject, in the zone of the machine
+	// that made it, or the author date for other commits
+	Time  time.Time    `json:"time"`
+	Files []FileChange `json:"files"`
+}
+
+// Log returns the history of the current branch, newest first, with the
+// files each commit changed
+func Log(ctx context.Context, repoPath string, opts LogOptions) ([]Commit, error) {
+	// Records start with RS, fields are separated by US; the file list follows
+	args := []string{"-C", repoPath, "-c", "core.quotePath=false", "log",
+		"--format=%x1e%H%x1f%an%x1f%aI%x1f%s", "--name-status", "--find-renames",
+		"--regexp-ignore-case", "--extended-regexp"}
+	if opts.Limit > 0 {
+		args = append(args, "--max-count="+strconv.Itoa(opts.Limit))
+	}
+	if opts.Since != "" {
+		args = append(args, "--since="+opts.Since)
+	}
+	if opts.Host != "" {
+		// Narrowed by git so --max-count counts matching commits; checked exactly below
+		args = append(args, "--all-match", "--grep=^Auto-sync from "+regexp.QuoteMeta(opts.Host)+" at ")
+	}
+	if opts.Grep != "" {
+		args = append(args, "--grep="+opts.Grep)
+	}
+	if opts.Path != "" {
+		args = append(args, "--", opts.Path)
+	}
+
+	output, err := exec.CommandContext(ctx, "git", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w%s", err, stderrOf(err))
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(string(output), "\x1e") {
+		if strings.TrimSpace(record) == "" {
+			continue
+		}
+		commit, ok := parseLogRecord(record)
+		if !ok || (opts.Host != "" && !strings.EqualFold(commit.Host, opts.Host)) {
+			continue
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+// parseLogRecord parses the header line and file list of one commit
+func parseLogRecord(record string) (Commit, bool) {
+	lines := strings.Split(strings.TrimRight(record, "\n"), "\n")
+	fields := strings.Split(lines[0], "\x1f")
+	if len(fields) != 4 {
+		return Commit{}, false
+	}
+	commit := Commit{SHA: fields[0], Author: fields[1], Subject: fields[3], Files: []FileChange{}}
+	commit.Time, _ = time.Parse(time.RFC3339, fields[2])
+	if m := autoSyncPattern.FindStringSubmatch(commit.Subject); m != nil {
+		commit.Host = m[1]
+		// The subject has no zone; the author date carries the machine's zone
+		if t, err := time.ParseInLocation("2006-01-02 15:04:05", m[2], commit.Time.Location()); err == nil {
+			commit.Time = t
+		}
+	}
+
+	for _, line := range lines[1:] {
+		parts := strings.Split(line, "\t")
+		if len(parts) < 2 || parts[0] == "" {
+			continue
+		}
+		change := FileChange{Status: parts[0][:1], Path: parts[len(parts)-1]}
+		if len(parts) == 3 {
+			change.OldPath = parts[1]
+		}
+		commit.Files = append(commit.Files, change)
+	}
+	return commit, true
+}
+
+// stderrOf returns the standard error of a failed command, for messages
+func stderrOf(err error) string {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return "\nOutput: " + strings.TrimSpace(string(exitErr.Stderr))
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// commitAt commits files with the given author date
+func commitAt(t *testing.T, repoPath, date, message string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(repoPath, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if content == "" {
+			if err := os.Remove(path); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command("git", "-C", repoPath, "add", "-A")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to add: %v\nOutput: %s", err, output)
+	}
+	cmd = exec.Command("git", "-C", repoPath, "commit", "-m", message)
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to commit: %v\nOutput: %s", err, output)
+	}
+}
+
+func TestLog(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repoPath := createTestRepo(t)
+	commitAt(t, repoPath, "2024-05-01T10:00:00+02:00", "Auto-sync from laptop at 2024-05-01 10:00:00",
+		map[string]string{"hooks/notify.sh": "echo", "settings.json": "{}"})
+	commitAt(t, repoPath, "2024-05-02T09:30:00-07:00", "Auto-sync from Desktop at 2024-05-02 09:30:00",
+		map[string]string{"hooks/notify.sh": ""})
+	commitAt(t, repoPath, "2024-05-03T12:00:00Z", "Add review agent",
+		map[string]string{"agents/review.md": "# review"})
+
+	commits, err := Log(ctx, repoPath, LogOptions{})
+	if err != nil {
+		t.Fatalf("Log() error = %v", err)
+	}
+	if len(commits) != 4 {
+		t.Fatalf("Log() returned %d commits, want 4", len(commits))
+	}
+	desktop := commits[1]
+	if desktop.Host != "Desktop" || !desktop.Time.Equal(time.Date(2024, 5, 2, 16, 30, 0, 0, time.UTC)) {
+		t.Errorf("auto-sync commit = %+v", desktop)
+	}
+	if _, offset := desktop.Time.Zone(); offset != -7*3600 {
+		t.Errorf("auto-sync time zone offset = %d, want the author's", offset)
+	}
+	if !slices.Equal(desktop.Files, []FileChange{{Status: "D", Path: "hooks/notify.sh"}}) {
+		t.Errorf("files = %+v", desktop.Files)
+	}
+	if commits[0].Host != "" || commits[0].Subject != "Add review agent" {
+		t.Errorf("manual commit = %+v", commits[0])
+	}
+
+	tests := []struct {
+		name string
+		opts LogOptions
+		want []string
+	}{
+		{"host ignores case", LogOptions{Host: "desktop"}, []string{"Desktop"}},
+		{"path", LogOptions{Path: "hooks"}, []string{"Desktop", "laptop"}},
+		{"since", LogOptions{Since: "2024-05-02T00:00:00Z"}, []string{"", "Desktop"}},
+		{"grep", LogOptions{Grep: "REVIEW"}, []string{""}},
+		{"host and grep", LogOptions{Host: "laptop", Grep: "05-01"}, []string{"laptop"}},
+		{"limit", LogOptions{Path: "hooks", Limit: 1}, []string{"Desktop"}},
+	}
+	for _, tt := range tests {
+		commits, err := Log(ctx, repoPath, tt.opts)
+		if err != nil {
+			t.Fatalf("%s: Log() error = %v", tt.name, err)
+		}
+		var hosts []string
+		for _, c := range commits {
+			hosts = append(hosts, c.Host)
+		}
+		if !slices.Equal(hosts, tt.want) {
+			t.Errorf("%s: hosts = %q, want %q", tt.name, hosts, tt.want)
+		}
+	}
+}
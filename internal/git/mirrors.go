@@ -0,0 +1,163 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// mirrorKey marks a remote as a mirror in the repository's local git config:
+//
+//	[remote "nas"]
+//		url = nas:/srv/git/claude.git
+//		claude-sync-mirror = true
+const mirrorKey = "claude-sync-mirror"
+
+// Remote roles reported by ListRemotes
+const (
+	// RolePrimary is the remote the current branch pulls from and pushes to
+	RolePrimary = "primary"
+	// RoleMirror is a remote every push is copied to
+	RoleMirror = "mirror"
+)
+
+// Remote is a git remote of the repository
+type Remote struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Role is RolePrimary, RoleMirror, or empty for remotes sync does not use
+	Role string `json:"role,omitempty"`
+}
+
+// ListRemotes returns the remotes of a repository in git's order
+func ListRemotes(ctx context.Context, repoPath string) ([]Remote, error) {
+	output, err := exec.CommandContext(ctx, "git", "-C", repoPath, "remote", "-v").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remotes: %w", err)
+	}
+	primary, err := CurrentRemote(ctx, repoPath)
+	if err != nil {
+		return nil, err
+	}
+	mirrors, err := flaggedMirrors(ctx, repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var remotes []Remote
+	for _, line := range splitLines(string(output)) {
+		// "<name>\t<url> (fetch)"; pushes use the same URL unless pushurl is set
+		name, rest, ok := strings.Cut(line, "\t")
+		url, kind, _ := strings.Cut(rest, " ")
+		if !ok || kind != "(fetch)" {
+			continue
+		}
+		r := Remote{Name: name, URL: url}
+		switch {
+		case name == primary:
+			r.Role = RolePrimary
+		case slices.Contains(mirrors, name):
+			r.Role = RoleMirror
+		}
+		remotes = append(remotes, r)
+	}
+	return remotes, nil
+}
+
+// ListMirrors returns the names of the mirror remotes, leaving out the
+// primary remote when it is also marked as a mirror
+func ListMirrors(ctx context.Context, repoPath string) ([]string, error) {
+	mirrors, err := flaggedMirrors(ctx, repoPath)
+	if err != nil || len(mirrors) == 0 {
+		return nil, err
+	}
+	primary, err := CurrentRemote(ctx, repoPath)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(mirrors, func(name string) bool { return name == primary }), nil
+}
+
+// AddMirror makes a remote a mirror. With a URL the remote is added first;
+// without one it must already exist.
+func AddMirror(ctx context.Context, repoPath, name, url string) error {
+	if url != "" {
+		if remoteExists(ctx, repoPath, name) {
+			return fmt.Errorf("remote %s already exists; run without a URL to mirror it", name)
+		}
+		if err := AddRemote(ctx, repoPath, name, url); err != nil {
+			return err
+		}
+	} else if !remoteExists(ctx, repoPath, name) {
+		return fmt.Errorf("remote %s does not exist; pass its URL to add it", name)
+	}
+
+	primary, err := CurrentRemote(ctx, repoPath)
+	if err != nil {
+		return err
+	}
+	if name == primary {
+		return fmt.Errorf("%s is the primary remote and cannot also be a mirror", name)
+	}
+	_, err = gitConfig(ctx, repoPath, "remote."+name+"."+mirrorKey, "true")
+	return err
+}
+
+// RemoveMirror stops mirroring to a remote and removes it, unless a profile
+// still syncs with it
+func RemoveMirror(ctx context.Context, repoPath, name string) error {
+	mirrors, err := flaggedMirrors(ctx, repoPath)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(mirrors, name) {
+		return fmt.Errorf("%s is not a mirror remote", name)
+	}
+
+	profiles, _, err := ListProfiles(ctx, repoPath)
+	if err != nil {
+		return err
+	}
+	for _, p := range profiles {
+		if p.Remote == name {
+			_, err := gitConfig(ctx, repoPath, "--unset", "remote."+name+"."+mirrorKey)
+			return err
+		}
+	}
+	return runGit(ctx, repoPath, "failed to remove remote", "remote", "remove", name)
+}
+
+// PushMirror pushes the current branch to a mirror, under the name it has on
+// the primary remote
+func PushMirror(ctx context.Context, repoPath, name string) error {
+	branch, err := upstreamBranch(ctx, repoPath)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "push", name, "HEAD:refs/heads/"+branch)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return enhancePushError(err, string(output))
+	}
+	return nil
+}
+
+// flaggedMirrors returns the remotes marked as mirrors, sorted by name
+func flaggedMirrors(ctx context.Context, repoPath string) ([]string, error) {
+	output, err := gitConfig(ctx, repoPath, "--get-regexp", `^remote\..*\.`+mirrorKey+`$`)
+	if err != nil {
+		return nil, err
+	}
+	var mirrors []string
+	for _, line := range splitLines(output) {
+		key, value, _ := strings.Cut(line, " ")
+		if value != "true" {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(key, "remote."), "."+mirrorKey)
+		mirrors = append(mirrors, name)
+	}
+	slices.Sort(mirrors)
+	return mirrors, nil
+}
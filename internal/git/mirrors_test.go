@@ -0,0 +1,59 @@
+package git
+
+import (
+	"context"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMirrors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	primary := createBareRepo(t)
+	repoPath := createRepoWithRemote(t, primary)
+	nas := createBareRepo(t)
+
+	if err := AddMirror(ctx, repoPath, "nas", nas); err != nil {
+		t.Fatalf("AddMirror() error = %v", err)
+	}
+	for name, url := range map[string]string{"origin": "", "nas": nas, "missing": ""} {
+		if err := AddMirror(ctx, repoPath, name, url); err == nil {
+			t.Errorf("AddMirror(%q, %q) succeeded", name, url)
+		}
+	}
+
+	remotes, err := ListRemotes(ctx, repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Remote{{Name: "nas", URL: nas, Role: RoleMirror}, {Name: "origin", URL: primary, Role: RolePrimary}}
+	if !slices.Equal(remotes, want) {
+		t.Errorf("ListRemotes() = %+v, want %+v", remotes, want)
+	}
+	if mirrors, err := ListMirrors(ctx, repoPath); err != nil || !slices.Equal(mirrors, []string{"nas"}) {
+		t.Errorf("ListMirrors() = %v, %v", mirrors, err)
+	}
+
+	commitFile(t, repoPath, "CLAUDE.md", "notes", "notes")
+	if err := PushMirror(ctx, repoPath, "nas"); err != nil {
+		t.Fatalf("PushMirror() error = %v", err)
+	}
+	head, _ := exec.Command("git", "-C", repoPath, "rev-parse", "HEAD").Output()
+	mirrored, err := exec.Command("git", "-C", nas, "rev-parse", "refs/heads/main").Output()
+	if err != nil || strings.TrimSpace(string(mirrored)) != strings.TrimSpace(string(head)) {
+		t.Errorf("mirror main = %q, %v, want %q", mirrored, err, head)
+	}
+
+	if err := RemoveMirror(ctx, repoPath, "nas"); err != nil {
+		t.Fatalf("RemoveMirror() error = %v", err)
+	}
+	if remoteExists(ctx, repoPath, "nas") {
+		t.Error("RemoveMirror() kept the remote")
+	}
+	if err := RemoveMirror(ctx, repoPath, "origin"); err == nil {
+		t.Error("RemoveMirror() of the primary remote succeeded")
+	}
+}
@@ -15,6 +15,7 @@ package git
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -49,10 +50,23 @@ func GetClaudeDir() (string, error) {
 }
 
 // HasUncommittedChanges checks if there are uncommitted changes (tracked or untracked)
+// that the sync rules in .claude-sync.yaml allow to be committed
 func HasUncommittedChanges(ctx context.Context, repoPath string) (bool, error) {
+	set, err := LoadRules(repoPath)
+	if err != nil {
+		return false, err
+	}
+	if !set.Empty() {
+		files, err := GetChangedFiles(ctx, repoPath)
+		if err != nil {
+			return false, err
+		}
+		return len(files) > 0, nil
+	}
+
 	// Check for modified tracked files
 	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "diff-index", "--quiet", "HEAD", "--")
-	err := cmd.Run()
+	err = cmd.Run()
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
 			return true, nil
@@ -81,42 +95,54 @@ func HasUncommittedChanges(ctx context.Context, repoPath string) (bool, error) {
 	return false, nil
 }
 
-// GetChangedFiles returns list of modified and untracked files
+// GetChangedFiles returns list of modified and untracked files that the sync
+// rules in .claude-sync.yaml allow to be committed
 func GetChangedFiles(ctx context.Context, repoPath string) ([]string, error) {
-	var allFiles []string
-
-	// Get modified tracked files
-	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "diff", "--name-only", "HEAD")
-	output, err := cmd.Output()
+	set, err := LoadRules(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	files, err := changedFiles(ctx, repoPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get changed files: %w", err)
+		return nil, err
 	}
-	if tracked := strings.TrimSpace(string(output)); tracked != "" {
-		allFiles = append(allFiles, strings.Split(tracked, "\n")...)
+	return set.Filter(files), nil
+}
+
+// changedFiles returns all modified and untracked files, ignoring the sync rules
+func changedFiles(ctx context.Context, repoPath string) ([]string, error) {
+	var allFiles []string
+
+	// Get modified tracked files; before the first commit there are none
+	if _, err := RevParse(ctx, repoPath, "HEAD"); err == nil {
+		cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "diff", "-z", "--name-only", "HEAD")
+		output, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get changed files: %w", err)
+		}
+		allFiles = append(allFiles, splitNul(string(output))...)
 	}
 
 	// Get untracked files (excluding ignored)
-	cmd = exec.CommandContext(ctx, "git", "-C", repoPath, "ls-files", "--others", "--exclude-standard")
-	output, err = cmd.Output()
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "ls-files", "-z", "--others", "--exclude-standard")
+	output, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get untracked files: %w", err)
 	}
-	if untracked := strings.TrimSpace(string(output)); untracked != "" {
-		allFiles = append(allFiles, strings.Split(untracked, "\n")...)
-	}
+	allFiles = append(allFiles, splitNul(string(output))...)
 
 	return allFiles, nil
 }
 
-// CommitChanges commits all changes (tracked and untracked)
+// CommitChanges commits all changes (tracked and untracked) that the sync
+// rules in .claude-sync.yaml allow
 func CommitChanges(ctx context.Context, repoPath string, message string) error {
-	// Stage all changes including untracked files (respects .gitignore)
-	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "add", "-A")
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to stage changes: %w\nOutput: %s", err, string(output))
+	// Stage all changes including untracked files (respects .gitignore and the rules)
+	if err := stageChanges(ctx, repoPath); err != nil {
+		return err
 	}
 
-	cmd = exec.CommandContext(ctx, "git", "-C", repoPath, "commit", "-m", message)
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "commit", "-m", message)
 	if output, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("failed to commit: %w\nOutput: %s", err, string(output))
 	}
@@ -134,6 +160,17 @@ func PullWithRebase(ctx context.Context, repoPath string) error {
 	return nil
 }
 
+// RebaseOnto rebases the current branch onto a commit without fetching, so
+// only commits that were already fetched, such as reviewed ones, are applied
+func RebaseOnto(ctx context.Context, repoPath, onto string) error {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "rebase", onto)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to rebase onto %s: %w\nOutput: %s", onto, err, string(output))
+	}
+	return nil
+}
+
 // enhancePullError provides contextual help for common pull failures
 func enhancePullError(err error, output string) error {
 	outputLower := strings.ToLower(output)
@@ -161,15 +198,20 @@ func Push(ctx context.Context, repoPath string) error {
 	return nil
 }
 
-// PushWithUpstream pushes to remote and sets upstream tracking
+// PushWithUpstream pushes to the current branch's remote (origin unless the
+// active profile uses another) and sets upstream tracking
 func PushWithUpstream(ctx context.Context, repoPath string) error {
 	// Get current branch name
 	branch, err := getCurrentBranch(ctx, repoPath)
 	if err != nil {
 		return fmt.Errorf("failed to get current branch: %w", err)
 	}
+	remote, err := CurrentRemote(ctx, repoPath)
+	if err != nil {
+		return err
+	}
 
-	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "push", "-u", "origin", branch)
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "push", "-u", remote, branch)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return enhancePushError(err, string(output))
@@ -287,6 +329,84 @@ func GetRecentCommits(ctx context.Context, repoPath string, count int) ([]string
 	return commits, nil
 }
 
+// GetCommitsInRange returns one-line commits ("<sha> <subject>") in a revision range
+// such as "HEAD..@{upstream}"
+func GetCommitsInRange(ctx context.Context, repoPath, revRange string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "log", "-z", "--pretty=format:%h %s", revRange)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits in %s: %w", revRange, err)
+	}
+	return splitNul(string(output)), nil
+}
+
+// GetFilesInRange returns the files changed in a revision range such as "HEAD...@{upstream}"
+func GetFilesInRange(ctx context.Context, repoPath, revRange string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "diff", "-z", "--name-only", revRange)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files in %s: %w", revRange, err)
+	}
+	return splitNul(string(output)), nil
+}
+
+// RevParse resolves a revision such as "HEAD" or "@{upstream}" to a full commit SHA
+func RevParse(ctx context.Context, repoPath, rev string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", rev, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// splitNul splits NUL-terminated command output (from -z) into paths. Paths
+// are not quoted, unlike in line output.
+func splitNul(output string) []string {
+	paths := []string{}
+	for _, p := range strings.Split(output, "\x00") {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// splitLines splits command output into non-empty lines
+func splitLines(output string) []string {
+	trimmed := strings.TrimSpace(output)
+	if trimmed == "" {
+		return []string{}
+	}
+	return strings.Split(trimmed, "\n")
+}
+
+// CheckIgnored returns the subset of paths (relative to the repository) that
+// .gitignore and the repository's exclude files ignore. Tracked files are
+// never reported as ignored.
+func CheckIgnored(ctx context.Context, repoPath string, paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "check-ignore", "-z", "--stdin")
+	cmd.Stdin = strings.NewReader(strings.Join(paths, "\x00") + "\x00")
+	output, err := cmd.Output()
+	if err != nil {
+		// Exit status 1 means none of the paths are ignored
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, &OperationError{
+			Op:   "check-ignore",
+			Path: repoPath,
+			Err:  err,
+		}
+	}
+	return splitNul(string(output)), nil
+}
+
 // GenerateAutoCommitMessage creates a timestamp-based commit message
 func GenerateAutoCommitMessage() string {
 	hostname, err := os.Hostname()
@@ -339,14 +459,13 @@ func AddRemote(ctx context.Context, repoPath, name, url string) error {
 	return nil
 }
 
-// InitialCommit creates the initial commit with all files
+// InitialCommit creates the initial commit with all files the sync rules allow
 func InitialCommit(ctx context.Context, repoPath, message string) error {
-	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "add", ".")
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to stage files: %w\nOutput: %s", err, string(output))
+	if err := stageChanges(ctx, repoPath); err != nil {
+		return err
 	}
 
-	cmd = exec.CommandContext(ctx, "git", "-C", repoPath, "commit", "-m", message)
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "commit", "-m", message)
 	if output, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("failed to create initial commit: %w\nOutput: %s", err, string(output))
 	}
@@ -382,44 +501,18 @@ func ensureDefaultBranch(ctx context.Context, repoPath string) error {
 	return nil
 }
 
-// SetupGitignore creates a .gitignore file with sensible defaults
+// SetupGitignore creates a .gitignore file with sensible defaults, and a
+// .claude-sync.yaml with the default sync rules if there is none yet, and
+// applies the rules
 func SetupGitignore(repoPath string) error {
-	gitignoreContent := `# Credentials and secrets
-credentials.json
-*.key
-*.pem
-*.p12
-*-key.json
-service-account*.json
-
-# AWS scripts (may contain credentials)
-aws-*.sh
-
-# Environment files
-.env
-.env.*
-
-# IDE and editor files
-.vscode/
-.idea/
-*.swp
-*.swo
-*~
-
-# OS files
-.DS_Store
-Thumbs.db
-
-# Logs
-*.log
-`
-
-	gitignorePath := filepath.Join(repoPath, ".gitignore")
-	if err := os.WriteFile(gitignorePath, []byte(gitignoreContent), 0o644); err != nil {
-		return fmt.Errorf("failed to create .gitignore: %w", err)
+	if _, err := UpgradeGitignore(repoPath); err != nil {
+		return err
 	}
-
-	return nil
+	if err := WriteDefaultRules(repoPath); err != nil {
+		return err
+	}
+	_, err := ApplyRules(repoPath)
+	return err
 }
 
 // HasConflicts checks if there are merge conflicts
@@ -447,6 +540,113 @@ func AbortRebase(ctx context.Context, repoPath string) error {
 	return nil
 }
 
+// GetConflictedFiles returns the paths with unresolved merge conflicts
+func GetConflictedFiles(ctx context.Context, repoPath string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "diff", "-z", "--name-only", "--diff-filter=U")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, &OperationError{
+			Op:   "list conflicts",
+			Path: repoPath,
+			Err:  err,
+		}
+	}
+	return splitNul(string(output)), nil
+}
+
+// GetConflictVersions returns the base, ours and theirs versions of a
+// conflicted file from the index. A version is nil when that side does not
+// have the file, e.g. base is nil when both sides added it.
+func GetConflictVersions(ctx context.Context, repoPath, file string) (base, ours, theirs []byte, err error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "ls-files", "-u", "-z", "--", file)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, nil, nil, &OperationError{
+			Op:   "list conflict stages",
+			Path: repoPath,
+			Err:  err,
+		}
+	}
+
+	for _, entry := range strings.Split(string(output), "\x00") {
+		// Format: <mode> <object> <stage>\t<path>
+		meta, _, ok := strings.Cut(entry, "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 3 {
+			continue
+		}
+
+		blob := exec.CommandContext(ctx, "git", "-C", repoPath, "cat-file", "blob", fields[1])
+		content, err := blob.Output()
+		if err != nil {
+			return nil, nil, nil, &OperationError{
+				Op:   "read conflict stage",
+				Path: repoPath,
+				Err:  err,
+			}
+		}
+		if content == nil {
+			// An empty file exists, unlike a missing one
+			content = []byte{}
+		}
+
+		switch fields[2] {
+		case "1":
+			base = content
+		case "2":
+			ours = content
+		case "3":
+			theirs = content
+		}
+	}
+	return base, ours, theirs, nil
+}
+
+// ResolveConflict writes the resolved content of a conflicted file and
+// marks it as resolved. A nil content resolves the conflict by deleting the file.
+func ResolveConflict(ctx context.Context, repoPath, file string, content []byte) error {
+	fullPath := filepath.Join(repoPath, file)
+
+	if content == nil {
+		if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete %s: %w", file, err)
+		}
+		cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "rm", "--cached", "--quiet", "--ignore-unmatch", "--", file)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to mark %s as deleted: %w\nOutput: %s", file, err, string(output))
+		}
+		return nil
+	}
+
+	perm := os.FileMode(0o644)
+	if info, err := os.Stat(fullPath); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", file, err)
+	}
+	if err := os.WriteFile(fullPath, content, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", file, err)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "add", "--", file)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to mark %s as resolved: %w\nOutput: %s", file, err, string(output))
+	}
+	return nil
+}
+
+// ContinueRebase continues a rebase after conflicts have been resolved,
+// keeping the original commit messages
+func ContinueRebase(ctx context.Context, repoPath string) error {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "rebase", "--continue")
+	cmd.Env = append(os.Environ(), "GIT_EDITOR=true")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to continue rebase: %w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
+
 // CloneRepo clones a remote repository to the specified path
 func CloneRepo(ctx context.Context, remoteURL, destPath string) error {
 	cmd := exec.CommandContext(ctx, "git", "clone", remoteURL, destPath)
@@ -542,9 +742,13 @@ func RemoteHasCommits(ctx context.Context, remoteURL string) (bool, error) {
 	return strings.TrimSpace(string(output)) != "", nil
 }
 
-// Fetch fetches from remote without merging
+// Fetch fetches from the current branch's remote without merging
 func Fetch(ctx context.Context, repoPath string) error {
-	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "fetch", "origin")
+	remote, err := CurrentRemote(ctx, repoPath)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "fetch", remote)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("failed to fetch: %w\nOutput: %s", err, string(output))
@@ -726,3 +726,267 @@ func TestRemoveClaudeDir(t *testing.T) {
 		t.Error("RemoveClaudeDir() did not remove the directory")
 	}
 }
+
+// commitFile writes a file and commits it in the given repository
+func commitFile(t *testing.T, repoPath, name, content, message string) {
+	t.Helper()
+
+	filePath := filepath.Join(repoPath, name)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		t.Fatalf("Failed to create parent dir: %v", err)
+	}
+	if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+
+	cmd := exec.Command("git", "-C", repoPath, "add", "-A")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to add: %v\nOutput: %s", err, output)
+	}
+	cmd = exec.Command("git", "-C", repoPath, "commit", "-m", message)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to commit: %v\nOutput: %s", err, output)
+	}
+}
+
+func TestGetCommitsAndFilesInRange(t *testing.T) {
+	t.Parallel()
+
+	bareRepo := createBareRepo(t)
+	localRepo := createRepoWithRemote(t, bareRepo)
+	ctx := context.Background()
+
+	commitFile(t, localRepo, "settings.json", `{"local": true}`, "Local change")
+
+	outgoing, err := GetCommitsInRange(ctx, localRepo, "@{upstream}..HEAD")
+	if err != nil {
+		t.Fatalf("GetCommitsInRange() error = %v", err)
+	}
+	if len(outgoing) != 1 || !strings.Contains(outgoing[0], "Local change") {
+		t.Errorf("GetCommitsInRange() outgoing = %v, want one 'Local change' commit", outgoing)
+	}
+
+	incoming, err := GetCommitsInRange(ctx, localRepo, "HEAD..@{upstream}")
+	if err != nil {
+		t.Fatalf("GetCommitsInRange() error = %v", err)
+	}
+	if len(incoming) != 0 {
+		t.Errorf("GetCommitsInRange() incoming = %v, want none", incoming)
+	}
+
+	files, err := GetFilesInRange(ctx, localRepo, "@{upstream}...HEAD")
+	if err != nil {
+		t.Fatalf("GetFilesInRange() error = %v", err)
+	}
+	if len(files) != 1 || files[0] != "settings.json" {
+		t.Errorf("GetFilesInRange() = %v, want [settings.json]", files)
+	}
+
+	// Paths are not quoted
+	commitFile(t, localRepo, "hooks/déjà.sh", "echo déjà\n", "Add hook")
+	files, err = GetFilesInRange(ctx, localRepo, "HEAD~1..HEAD")
+	if err != nil {
+		t.Fatalf("GetFilesInRange() error = %v", err)
+	}
+	if len(files) != 1 || files[0] != "hooks/déjà.sh" {
+		t.Errorf("GetFilesInRange() = %q, want [hooks/déjà.sh]", files)
+	}
+
+	if _, err := GetCommitsInRange(ctx, createTestRepo(t), "HEAD..@{upstream}"); err == nil {
+		t.Error("GetCommitsInRange() should error without an upstream")
+	}
+}
+
+func TestRebaseOnto(t *testing.T) {
+	t.Parallel()
+
+	bareRepo := createBareRepo(t)
+	localRepo := createRepoWithRemote(t, bareRepo)
+	ctx := context.Background()
+
+	otherRepo := filepath.Join(t.TempDir(), "other")
+	if output, err := exec.Command("git", "clone", bareRepo, otherRepo).CombinedOutput(); err != nil {
+		t.Fatalf("Failed to clone: %v\nOutput: %s", err, output)
+	}
+	for _, kv := range [][]string{{"user.email", "test@example.com"}, {"user.name", "Test User"}} {
+		if err := exec.Command("git", "-C", otherRepo, "config", kv[0], kv[1]).Run(); err != nil {
+			t.Fatalf("Failed to configure git: %v", err)
+		}
+	}
+	commitFile(t, otherRepo, "hooks/reviewed.sh", "echo ok", "Reviewed change")
+	if err := Push(ctx, otherRepo); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if err := Fetch(ctx, localRepo); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	reviewed, err := RevParse(ctx, localRepo, "@{upstream}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A commit pushed after the review is not applied
+	commitFile(t, otherRepo, "hooks/unreviewed.sh", "rm -rf ~", "Unreviewed change")
+	if err := Push(ctx, otherRepo); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	commitFile(t, localRepo, "CLAUDE.md", "local", "Local change")
+
+	if err := RebaseOnto(ctx, localRepo, reviewed); err != nil {
+		t.Fatalf("RebaseOnto() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(localRepo, "hooks/reviewed.sh")); err != nil {
+		t.Errorf("reviewed change missing: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(localRepo, "hooks/unreviewed.sh")); !os.IsNotExist(err) {
+		t.Errorf("unreviewed change applied: %v", err)
+	}
+	if commits, err := GetCommitsInRange(ctx, localRepo, reviewed+"..HEAD"); err != nil || len(commits) != 1 {
+		t.Errorf("commits on top of the reviewed one = %v, %v, want the local change", commits, err)
+	}
+}
+
+func TestResolveRebaseConflict(t *testing.T) {
+	t.Parallel()
+
+	bareRepo := createBareRepo(t)
+	localRepo := createRepoWithRemote(t, bareRepo)
+	ctx := context.Background()
+
+	commitFile(t, localRepo, "settings.json", "base\n", "Add settings")
+	if err := Push(ctx, localRepo); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	otherRepo := filepath.Join(t.TempDir(), "other")
+	if output, err := exec.Command("git", "clone", bareRepo, otherRepo).CombinedOutput(); err != nil {
+		t.Fatalf("Failed to clone: %v\nOutput: %s", err, output)
+	}
+	for _, kv := range [][]string{{"user.email", "test@example.com"}, {"user.name", "Test User"}} {
+		if err := exec.Command("git", "-C", otherRepo, "config", kv[0], kv[1]).Run(); err != nil {
+			t.Fatalf("Failed to configure git: %v", err)
+		}
+	}
+	commitFile(t, otherRepo, "settings.json", "remote\n", "Remote change")
+	if err := Push(ctx, otherRepo); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	commitFile(t, localRepo, "settings.json", "local\n", "Local change")
+	if err := PullWithRebase(ctx, localRepo); err == nil {
+		t.Fatal("PullWithRebase() should fail with a conflict")
+	}
+
+	files, err := GetConflictedFiles(ctx, localRepo)
+	if err != nil {
+		t.Fatalf("GetConflictedFiles() error = %v", err)
+	}
+	if len(files) != 1 || files[0] != "settings.json" {
+		t.Fatalf("GetConflictedFiles() = %v, want [settings.json]", files)
+	}
+
+	base, ours, theirs, err := GetConflictVersions(ctx, localRepo, "settings.json")
+	if err != nil {
+		t.Fatalf("GetConflictVersions() error = %v", err)
+	}
+	// During a rebase "ours" is the upstream and "theirs" the local commit
+	if string(base) != "base\n" || string(ours) != "remote\n" || string(theirs) != "local\n" {
+		t.Errorf("GetConflictVersions() = %q, %q, %q", base, ours, theirs)
+	}
+
+	if err := ResolveConflict(ctx, localRepo, "settings.json", []byte("merged\n")); err != nil {
+		t.Fatalf("ResolveConflict() error = %v", err)
+	}
+	if err := ContinueRebase(ctx, localRepo); err != nil {
+		t.Fatalf("ContinueRebase() error = %v", err)
+	}
+
+	hasConflicts, err := HasConflicts(ctx, localRepo)
+	if err != nil || hasConflicts {
+		t.Errorf("HasConflicts() = %v, %v after rebase", hasConflicts, err)
+	}
+	commits, err := GetCommitsInRange(ctx, localRepo, "@{upstream}..HEAD")
+	if err != nil {
+		t.Fatalf("GetCommitsInRange() error = %v", err)
+	}
+	if len(commits) != 1 || !strings.Contains(commits[0], "Local change") {
+		t.Errorf("GetCommitsInRange() = %v, want the rebased local commit", commits)
+	}
+	content, err := os.ReadFile(filepath.Join(localRepo, "settings.json"))
+	if err != nil || string(content) != "merged\n" {
+		t.Errorf("settings.json = %q, %v; want merged content", content, err)
+	}
+}
+
+func TestGetConflictVersions_EmptyFile(t *testing.T) {
+	t.Parallel()
+
+	bareRepo := createBareRepo(t)
+	localRepo := createRepoWithRemote(t, bareRepo)
+	ctx := context.Background()
+
+	commitFile(t, localRepo, "notes.md", "base\n", "Add notes")
+	if err := Push(ctx, localRepo); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	otherRepo := filepath.Join(t.TempDir(), "other")
+	if output, err := exec.Command("git", "clone", bareRepo, otherRepo).CombinedOutput(); err != nil {
+		t.Fatalf("Failed to clone: %v\nOutput: %s", err, output)
+	}
+	for _, kv := range [][]string{{"user.email", "test@example.com"}, {"user.name", "Test User"}} {
+		if err := exec.Command("git", "-C", otherRepo, "config", kv[0], kv[1]).Run(); err != nil {
+			t.Fatalf("Failed to configure git: %v", err)
+		}
+	}
+	commitFile(t, otherRepo, "notes.md", "", "Empty notes")
+	if err := Push(ctx, otherRepo); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	commitFile(t, localRepo, "notes.md", "local\n", "Local change")
+	if err := PullWithRebase(ctx, localRepo); err == nil {
+		t.Fatal("PullWithRebase() should fail with a conflict")
+	}
+
+	_, ours, _, err := GetConflictVersions(ctx, localRepo, "notes.md")
+	if err != nil {
+		t.Fatalf("GetConflictVersions() error = %v", err)
+	}
+	if ours == nil || len(ours) != 0 {
+		t.Fatalf("GetConflictVersions() ours = %#v, want an empty, non-nil file", ours)
+	}
+
+	// Keeping the empty side keeps the file
+	if err := ResolveConflict(ctx, localRepo, "notes.md", ours); err != nil {
+		t.Fatalf("ResolveConflict() error = %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(localRepo, "notes.md"))
+	if err != nil || len(content) != 0 {
+		t.Errorf("notes.md = %q, %v; want an empty file", content, err)
+	}
+}
+
+func TestCheckIgnored(t *testing.T) {
+	t.Parallel()
+
+	repoPath := createTestRepo(t)
+	ctx := context.Background()
+
+	if err := os.WriteFile(filepath.Join(repoPath, ".gitignore"), []byte("projects/\n*.log\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write .gitignore: %v", err)
+	}
+
+	ignored, err := CheckIgnored(ctx, repoPath, []string{"projects/", "debug.log", "settings.json", "test.txt", "déjà.log"})
+	if err != nil {
+		t.Fatalf("CheckIgnored() error = %v", err)
+	}
+	if strings.Join(ignored, ",") != "projects/,debug.log,déjà.log" {
+		t.Errorf("CheckIgnored() = %v, want [projects/ debug.log déjà.log]", ignored)
+	}
+
+	ignored, err = CheckIgnored(ctx, repoPath, []string{"settings.json"})
+	if err != nil || len(ignored) != 0 {
+		t.Errorf("CheckIgnored() = %v, %v; want nothing ignored", ignored, err)
+	}
+}
@@ -0,0 +1,353 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Profiles are stored in the repository's local git config, so each machine
+// has its own list:
+//
+//	[claude-sync-profile "work"]
+//		remote = origin
+//		branch = work
+//		local = work
+//	[claude-sync]
+//		profile = work
+const (
+	profileSection   = "claude-sync-profile"
+	activeProfileKey = "claude-sync.profile"
+	// DefaultProfile names the setup that existed before the first profile was created
+	DefaultProfile = "default"
+	// stashPrefix marks stashes made when switching away from a profile
+	stashPrefix = "claude-sync profile "
+)
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// ErrProfileNotFound is returned for a profile name that was never created
+var ErrProfileNotFound = errors.New("profile not found")
+
+// Profile maps a name to the branch of a remote that holds its configuration
+type Profile struct {
+	Name string `json:"name"`
+	// Remote is the git remote the profile syncs with
+	Remote string `json:"remote"`
+	// Branch is the branch on the remote
+	Branch string `json:"branch"`
+	// Local is the local branch checked out while the profile is active
+	Local string `json:"local"`
+}
+
+// ListProfiles returns the profiles of a repository, sorted by name, and the
+// name of the active one. Both are empty until a profile is created.
+func ListProfiles(ctx context.Context, repoPath string) ([]Profile, string, error) {
+	output, err := gitConfig(ctx, repoPath, "--get-regexp", `^`+regexp.QuoteMeta(profileSection)+`\.`)
+	if err != nil {
+		return nil, "", err
+	}
+
+	byName := map[string]*Profile{}
+	for _, line := range splitLines(output) {
+		key, value, _ := strings.Cut(line, " ")
+		rest := strings.TrimPrefix(key, profileSection+".")
+		dot := strings.LastIndex(rest, ".")
+		if dot <= 0 {
+			continue
+		}
+		name, field := rest[:dot], rest[dot+1:]
+		p := byName[name]
+		if p == nil {
+			p = &Profile{Name: name}
+			byName[name] = p
+		}
+		switch field {
+		case "remote":
+			p.Remote = value
+		case "branch":
+			p.Branch = value
+		case "local":
+			p.Local = value
+		}
+	}
+
+	profiles := make([]Profile, 0, len(byName))
+	for _, p := range byName {
+		profiles = append(profiles, *p)
+	}
+	slices.SortFunc(profiles, func(a, b Profile) int { return strings.Compare(a.Name, b.Name) })
+
+	active, err := gitConfig(ctx, repoPath, "--get", activeProfileKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return profiles, strings.TrimSpace(active), nil
+}
+
+// GetProfile returns a profile by name
+func GetProfile(ctx context.Context, repoPath, name string) (Profile, error) {
+	profiles, _, err := ListProfiles(ctx, repoPath)
+	if err != nil {
+		return Profile{}, err
+	}
+	for _, p := range profiles {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return Profile{}, fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+}
+
+// CreateProfile creates a profile backed by a branch of a remote. remote is
+// the name of an existing remote or the URL of a new one, which is added
+// under the profile's name; it defaults to the current branch's remote.
+// branch defaults to the profile name on an existing remote and to "main" on
+// a new one. When the branch does not exist on the remote yet it starts from
+// the current commit and is pushed.
+//
+// The first profile created also registers the current setup as the
+// "default" profile, so it can be switched back to.
+func CreateProfile(ctx context.Context, repoPath, name, remote, branch string) (Profile, error) {
+	if !profileNamePattern.MatchString(name) {
+		return Profile{}, fmt.Errorf("invalid profile name %q: use letters, digits, '-' and '_'", name)
+	}
+	profiles, _, err := ListProfiles(ctx, repoPath)
+	if err != nil {
+		return Profile{}, err
+	}
+	if len(profiles) == 0 && name != DefaultProfile {
+		current, err := currentProfile(ctx, repoPath)
+		if err != nil {
+			return Profile{}, err
+		}
+		if err := saveProfile(ctx, repoPath, current); err != nil {
+			return Profile{}, err
+		}
+		if _, err := gitConfig(ctx, repoPath, activeProfileKey, DefaultProfile); err != nil {
+			return Profile{}, err
+		}
+		profiles = append(profiles, current)
+	}
+	for _, p := range profiles {
+		if p.Name == name {
+			return Profile{}, fmt.Errorf("profile %s already exists", name)
+		}
+	}
+	if branchExists(ctx, repoPath, name) {
+		return Profile{}, fmt.Errorf("branch %s already exists; choose another profile name", name)
+	}
+
+	p := Profile{Name: name, Local: name, Branch: branch}
+	addedRemote := false
+	switch {
+	case remote == "":
+		if p.Remote, err = CurrentRemote(ctx, repoPath); err != nil {
+			return Profile{}, err
+		}
+	case remoteExists(ctx, repoPath, remote):
+		p.Remote = remote
+	default:
+		if remoteExists(ctx, repoPath, name) {
+			return Profile{}, fmt.Errorf("a remote named %s already exists; pass its name instead of a URL", name)
+		}
+		if err := AddRemote(ctx, repoPath, name, remote); err != nil {
+			return Profile{}, err
+		}
+		p.Remote, addedRemote = name, true
+	}
+	if p.Branch == "" {
+		p.Branch = name
+		if addedRemote {
+			p.Branch = "main"
+		}
+	}
+
+	if err := createProfileBranch(ctx, repoPath, p); err != nil {
+		if addedRemote {
+			_ = exec.CommandContext(ctx, "git", "-C", repoPath, "remote", "remove", p.Remote).Run()
+		}
+		return Profile{}, err
+	}
+	if err := saveProfile(ctx, repoPath, p); err != nil {
+		return Profile{}, err
+	}
+	return p, nil
+}
+
+// createProfileBranch creates the local branch of a profile, tracking the
+// remote branch, which is pushed first if it does not exist yet
+func createProfileBranch(ctx context.Context, repoPath string, p Profile) error {
+	remoteRef := "refs/remotes/" + p.Remote + "/" + p.Branch
+	fetch := exec.CommandContext(ctx, "git", "-C", repoPath, "fetch", p.Remote,
+		"+refs/heads/"+p.Branch+":"+remoteRef)
+	if fetch.Run() == nil {
+		return runGit(ctx, repoPath, "failed to create branch", "branch", "--track", p.Local, remoteRef)
+	}
+
+	if err := runGit(ctx, repoPath, "failed to create branch", "branch", "--no-track", p.Local, "HEAD"); err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "push", "-u", p.Remote, p.Local+":"+p.Branch)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		_ = exec.CommandContext(ctx, "git", "-C", repoPath, "branch", "-D", p.Local).Run()
+		return enhancePushError(err, string(output))
+	}
+	return nil
+}
+
+// SwitchProfile checks out the branch of another profile. The working tree
+// must be clean unless stash is set, in which case local changes are stashed
+// under the current profile's name. Changes stashed when leaving the target
+// profile earlier are restored.
+func SwitchProfile(ctx context.Context, repoPath, name string, stash bool) error {
+	p, err := GetProfile(ctx, repoPath, name)
+	if err != nil {
+		return err
+	}
+	_, active, err := ListProfiles(ctx, repoPath)
+	if err != nil {
+		return err
+	}
+
+	if stash {
+		dirty, err := HasUncommittedChanges(ctx, repoPath)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			if err := runGit(ctx, repoPath, "failed to stash changes",
+				"stash", "push", "--include-untracked", "-m", stashPrefix+active); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := runGit(ctx, repoPath, "failed to check out profile "+name, "checkout", "--quiet", p.Local); err != nil {
+		return err
+	}
+	if _, err := gitConfig(ctx, repoPath, activeProfileKey, name); err != nil {
+		return err
+	}
+	return restoreProfileStash(ctx, repoPath, name)
+}
+
+// restoreProfileStash pops the changes stashed when leaving a profile
+func restoreProfileStash(ctx context.Context, repoPath, name string) error {
+	output, err := exec.CommandContext(ctx, "git", "-C", repoPath, "stash", "list", "--format=%gd %gs").Output()
+	if err != nil {
+		return fmt.Errorf("failed to list stashes: %w", err)
+	}
+	for _, line := range splitLines(string(output)) {
+		ref, subject, _ := strings.Cut(line, " ")
+		// The subject is "On <branch>: <message>"
+		if _, message, ok := strings.Cut(subject, ": "); ok && message == stashPrefix+name {
+			return runGit(ctx, repoPath, "failed to restore stashed changes (they are kept in "+ref+")",
+				"stash", "pop", "--quiet", ref)
+		}
+	}
+	return nil
+}
+
+// currentProfile describes the checked-out branch as the default profile
+func currentProfile(ctx context.Context, repoPath string) (Profile, error) {
+	local, err := getCurrentBranch(ctx, repoPath)
+	if err != nil {
+		return Profile{}, err
+	}
+	if local == "" {
+		return Profile{}, fmt.Errorf("no branch is checked out")
+	}
+	remote, err := CurrentRemote(ctx, repoPath)
+	if err != nil {
+		return Profile{}, err
+	}
+	branch, err := upstreamBranch(ctx, repoPath)
+	if err != nil {
+		return Profile{}, err
+	}
+	return Profile{Name: DefaultProfile, Remote: remote, Branch: branch, Local: local}, nil
+}
+
+// upstreamBranch returns the name of the remote branch the current branch
+// tracks, or the current branch's own name when it tracks none
+func upstreamBranch(ctx context.Context, repoPath string) (string, error) {
+	local, err := getCurrentBranch(ctx, repoPath)
+	if err != nil {
+		return "", err
+	}
+	if local == "" {
+		return "", fmt.Errorf("no branch is checked out")
+	}
+	merge, err := gitConfig(ctx, repoPath, "--get", "branch."+local+".merge")
+	if err != nil {
+		return "", err
+	}
+	if merge = strings.TrimSpace(merge); merge != "" {
+		return strings.TrimPrefix(merge, "refs/heads/"), nil
+	}
+	return local, nil
+}
+
+// CurrentRemote returns the remote the current branch tracks, or origin
+// when it tracks none
+func CurrentRemote(ctx context.Context, repoPath string) (string, error) {
+	branch, err := getCurrentBranch(ctx, repoPath)
+	if err != nil {
+		return "", err
+	}
+	if branch != "" {
+		remote, err := gitConfig(ctx, repoPath, "--get", "branch."+branch+".remote")
+		if err != nil {
+			return "", err
+		}
+		if remote = strings.TrimSpace(remote); remote != "" && remote != "." {
+			return remote, nil
+		}
+	}
+	return "origin", nil
+}
+
+func saveProfile(ctx context.Context, repoPath string, p Profile) error {
+	for field, value := range map[string]string{"remote": p.Remote, "branch": p.Branch, "local": p.Local} {
+		if _, err := gitConfig(ctx, repoPath, profileSection+"."+p.Name+"."+field, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func remoteExists(ctx context.Context, repoPath, name string) bool {
+	return exec.CommandContext(ctx, "git", "-C", repoPath, "remote", "get-url", name).Run() == nil
+}
+
+func branchExists(ctx context.Context, repoPath, name string) bool {
+	return exec.CommandContext(ctx, "git", "-C", repoPath, "rev-parse", "--verify", "--quiet", "refs/heads/"+name).Run() == nil
+}
+
+// gitConfig runs git config on the repository. A missing key is not an
+// error and yields empty output.
+func gitConfig(ctx context.Context, repoPath string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", repoPath, "config", "--local"}, args...)...)
+	output, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read git config: %w", err)
+	}
+	return string(output), nil
+}
+
+func runGit(ctx context.Context, repoPath, message string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", repoPath}, args...)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w\nOutput: %s", message, err, string(output))
+	}
+	return nil
+}
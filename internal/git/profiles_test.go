@@ -0,0 +1,115 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateProfile_OnCurrentRemote(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	bare := createBareRepo(t)
+	repoPath := createRepoWithRemote(t, bare)
+
+	p, err := CreateProfile(ctx, repoPath, "work", "", "")
+	if err != nil {
+		t.Fatalf("CreateProfile() error = %v", err)
+	}
+	if p != (Profile{Name: "work", Remote: "origin", Branch: "work", Local: "work"}) {
+		t.Errorf("CreateProfile() = %+v", p)
+	}
+	if err := exec.Command("git", "-C", bare, "rev-parse", "--verify", "refs/heads/work").Run(); err != nil {
+		t.Errorf("profile branch was not pushed: %v", err)
+	}
+
+	profiles, active, err := ListProfiles(ctx, repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active != DefaultProfile || len(profiles) != 2 {
+		t.Fatalf("ListProfiles() = %+v, %q", profiles, active)
+	}
+	if profiles[0] != (Profile{Name: DefaultProfile, Remote: "origin", Branch: "main", Local: "main"}) {
+		t.Errorf("default profile = %+v", profiles[0])
+	}
+
+	for _, name := range []string{"work", "default", "-bad", "a/b"} {
+		if _, err := CreateProfile(ctx, repoPath, name, "", ""); err == nil {
+			t.Errorf("CreateProfile(%q) succeeded", name)
+		}
+	}
+	if _, err := GetProfile(ctx, repoPath, "missing"); err == nil {
+		t.Error("GetProfile() of a missing profile succeeded")
+	}
+}
+
+func TestCreateProfile_NewRemote(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repoPath := createRepoWithRemote(t, createBareRepo(t))
+	personal := createBareRepo(t)
+
+	p, err := CreateProfile(ctx, repoPath, "personal", personal, "")
+	if err != nil {
+		t.Fatalf("CreateProfile() error = %v", err)
+	}
+	if p.Remote != "personal" || p.Branch != "main" {
+		t.Errorf("CreateProfile() = %+v", p)
+	}
+	url, err := exec.Command("git", "-C", repoPath, "remote", "get-url", "personal").Output()
+	if err != nil || strings.TrimSpace(string(url)) != personal {
+		t.Errorf("remote personal = %q, %v", url, err)
+	}
+
+	// Once the profile is active, sync uses its remote
+	if err := SwitchProfile(ctx, repoPath, "personal", false); err != nil {
+		t.Fatal(err)
+	}
+	if remote, err := CurrentRemote(ctx, repoPath); err != nil || remote != "personal" {
+		t.Errorf("CurrentRemote() = %q, %v", remote, err)
+	}
+}
+
+func TestSwitchProfile_StashesChanges(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repoPath := createRepoWithRemote(t, createBareRepo(t))
+	if _, err := CreateProfile(ctx, repoPath, "work", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := SwitchProfile(ctx, repoPath, "work", false); err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, repoPath, "CLAUDE.md", "work notes", "work")
+
+	draft := filepath.Join(repoPath, "draft.md")
+	if err := os.WriteFile(draft, []byte("unfinished"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SwitchProfile(ctx, repoPath, DefaultProfile, true); err != nil {
+		t.Fatalf("SwitchProfile() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repoPath, "CLAUDE.md")); !os.IsNotExist(err) {
+		t.Errorf("work file present in the default profile: %v", err)
+	}
+	if _, err := os.Stat(draft); !os.IsNotExist(err) {
+		t.Errorf("stashed file present in the default profile: %v", err)
+	}
+	if _, active, _ := ListProfiles(ctx, repoPath); active != DefaultProfile {
+		t.Errorf("active profile = %q", active)
+	}
+
+	if err := SwitchProfile(ctx, repoPath, "work", true); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(draft); err != nil || string(data) != "unfinished" {
+		t.Errorf("stashed changes not restored: %q, %v", data, err)
+	}
+}
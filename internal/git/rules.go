@@ -0,0 +1,277 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/mfenderov/claude-sync/internal/rules"
+)
+
+// DefaultRules is written to .claude-sync.yaml when sync is set up
+const DefaultRules = `# claude-sync rules: which files in ~/.claude are synced
+#
+# Patterns are gitignore-style globs relative to ~/.claude:
+#   "*.md"       any .md file at any depth
+#   agents/**    everything under agents/
+#   /CLAUDE.md   only CLAUDE.md at the top level
+#
+# A file is synced when it matches no exclude pattern and either the
+# include list is empty or one of its patterns matches. Excludes are also
+# written to .git/info/exclude so plain git commands agree.
+
+include: []
+
+exclude:
+  # Per-machine session state and caches
+  - projects/**
+  - todos/**
+  - shell-snapshots/**
+  - session-env/**
+  - file-history/**
+  - statsig/**
+  - debug/**
+  - ide/**
+  - history.jsonl
+`
+
+const (
+	excludeBegin = "# BEGIN claude-sync rules (generated from " + rules.FileName + ", do not edit)"
+	excludeEnd   = "# END claude-sync rules"
+
+	secretsBegin = "# BEGIN claude-sync secrets (plaintext of encrypted files, do not edit)"
+	secretsEnd   = "# END claude-sync secrets"
+)
+
+// WriteDefaultRules creates .claude-sync.yaml with DefaultRules unless the
+// repository already has one
+func WriteDefaultRules(repoPath string) error {
+	rulesPath := filepath.Join(repoPath, rules.FileName)
+	if _, err := os.Stat(rulesPath); err == nil {
+		return nil
+	}
+	if err := os.WriteFile(rulesPath, []byte(DefaultRules), 0o644); err != nil {
+		return fmt.Errorf("failed to create %s: %w", rules.FileName, err)
+	}
+	return nil
+}
+
+// LoadRules reads the repository's sync rules without changing anything,
+// for callers that only inspect the repository
+func LoadRules(repoPath string) (*rules.Set, error) {
+	return rules.Load(repoPath)
+}
+
+// ApplyRules reads the repository's sync rules and regenerates the exclude
+// block in .git/info/exclude so git itself ignores excluded paths and the
+// files built from overlays. It runs when sync is set up and before
+// committing.
+func ApplyRules(repoPath string) (*rules.Set, error) {
+	set, err := rules.Load(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	if IsGitRepo(repoPath) {
+		patterns := append([]string{}, set.Exclude...)
+		for _, target := range set.Overlays {
+			patterns = append(patterns, "/"+escapeGlob(target))
+		}
+		excludePath := filepath.Join(repoPath, ".git", "info", "exclude")
+		if err := writeManagedBlock(excludePath, excludeBegin, excludeEnd, patterns); err != nil {
+			return nil, err
+		}
+	}
+	return set, nil
+}
+
+// ExcludeSecretFiles keeps the plaintext of encrypted secret files out of
+// git by listing them in .git/info/exclude. Files that are already tracked
+// are removed from the index; the working tree copy is kept.
+func ExcludeSecretFiles(ctx context.Context, repoPath string, files []string) error {
+	if err := WriteSecretExcludes(repoPath, files); err != nil {
+		return err
+	}
+	return UntrackFiles(ctx, repoPath, files)
+}
+
+// WriteSecretExcludes is ExcludeSecretFiles without untracking, for callers
+// that untrack through another git implementation
+func WriteSecretExcludes(repoPath string, files []string) error {
+	patterns := make([]string, 0, len(files))
+	for _, file := range files {
+		patterns = append(patterns, "/"+escapeGlob(file))
+	}
+	excludePath := filepath.Join(repoPath, ".git", "info", "exclude")
+	return writeManagedBlock(excludePath, secretsBegin, secretsEnd, patterns)
+}
+
+// UntrackFiles removes files from the index so the next commit deletes them
+// from the repository; the working tree copies are kept. Files that are not
+// tracked are skipped.
+func UntrackFiles(ctx context.Context, repoPath string, files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	args := append([]string{"-C", repoPath, "--literal-pathspecs", "rm", "--cached", "--quiet", "--ignore-unmatch", "--"}, files...)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to untrack files: %w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
+
+// escapeGlob escapes gitignore wildcards so a path matches only itself
+func escapeGlob(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if strings.ContainsRune(`*?[\!# `, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// writeManagedBlock replaces the lines between begin and end markers in a
+// file, appending the block if it is missing and removing it when lines is
+// empty. Content outside the block is left untouched.
+func writeManagedBlock(path, begin, end string, lines []string) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	kept, _, _ := splitManagedBlock(string(existing), begin, end)
+	kept = trimBlankLines(kept)
+
+	var b strings.Builder
+	for _, line := range kept {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	if len(lines) > 0 {
+		if len(kept) > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(begin + "\n")
+		for _, line := range lines {
+			b.WriteString(line + "\n")
+		}
+		b.WriteString(end + "\n")
+	}
+
+	if b.String() == string(existing) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// splitManagedBlock separates the lines of content outside the begin/end
+// markers from the lines inside them, reporting whether the block exists
+func splitManagedBlock(content, begin, end string) (outside, inside []string, found bool) {
+	if content == "" {
+		return nil, nil, false
+	}
+	inBlock := false
+	for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		switch {
+		case line == begin:
+			inBlock = true
+			found = true
+		case line == end && inBlock:
+			inBlock = false
+		case inBlock:
+			inside = append(inside, line)
+		default:
+			outside = append(outside, line)
+		}
+	}
+	return outside, inside, found
+}
+
+// stageChanges stages every change the rules allow. Without rules this is
+// plain "git add -A"; with rules only the synced changed files are staged.
+func stageChanges(ctx context.Context, repoPath string) error {
+	set, err := ApplyRules(repoPath)
+	if err != nil {
+		return err
+	}
+	if set.Empty() {
+		cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "add", "-A")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to stage changes: %w\nOutput: %s", err, string(output))
+		}
+		return nil
+	}
+
+	files, err := changedFiles(ctx, repoPath)
+	if err != nil {
+		return err
+	}
+	files = set.Filter(files)
+	if len(files) == 0 {
+		return nil
+	}
+
+	var pathspecs bytes.Buffer
+	for _, file := range files {
+		pathspecs.WriteString(file)
+		pathspecs.WriteByte(0)
+	}
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "--literal-pathspecs",
+		"add", "-A", "--pathspec-from-file=-", "--pathspec-file-nul")
+	cmd.Stdin = &pathspecs
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to stage changes: %w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
+
+// UntrackedPath is an untracked path and the rule that decides whether it is synced
+type UntrackedPath struct {
+	Path     string
+	Decision rules.Decision
+}
+
+// GetUntrackedPaths lists untracked files and directories that .gitignore
+// does not ignore, with the sync rule that applies to each. Paths excluded
+// by the rules are listed too, so callers can show why they are not synced.
+// Directories have a trailing slash and are listed instead of their contents.
+func GetUntrackedPaths(ctx context.Context, repoPath string) ([]UntrackedPath, error) {
+	set, err := LoadRules(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// --exclude-per-directory honours .gitignore but not .git/info/exclude,
+	// where the rule excludes are written
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "ls-files", "-z", "--others",
+		"--directory", "--no-empty-directory", "--exclude-per-directory=.gitignore")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list untracked files: %w", err)
+	}
+
+	var paths []UntrackedPath
+	for _, p := range strings.Split(string(output), "\x00") {
+		if p == "" {
+			continue
+		}
+		paths = append(paths, UntrackedPath{
+			Path:     p,
+			Decision: set.Match(p, strings.HasSuffix(p, "/")),
+		})
+	}
+	return paths, nil
+}
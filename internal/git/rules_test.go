@@ -0,0 +1,181 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommitChangesFollowsRules(t *testing.T) {
+	t.Parallel()
+
+	repoPath := createTestRepo(t)
+	ctx := context.Background()
+
+	rulesFile := "include:\n  - \"*.md\"\n  - settings.json\nexclude:\n  - drafts/**\n"
+	files := map[string]string{
+		".claude-sync.yaml":  rulesFile,
+		"CLAUDE.md":          "# notes",
+		"settings.json":      "{}",
+		"drafts/idea.md":     "wip",
+		"projects/chat.json": "{}",
+		"test.txt":           "modified",
+	}
+	for name, content := range files {
+		path := filepath.Join(repoPath, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	changed, err := GetChangedFiles(ctx, repoPath)
+	if err != nil {
+		t.Fatalf("GetChangedFiles() error = %v", err)
+	}
+	if strings.Join(changed, ",") != ".claude-sync.yaml,CLAUDE.md,settings.json" {
+		t.Errorf("GetChangedFiles() = %v", changed)
+	}
+	if hasChanges, err := HasUncommittedChanges(ctx, repoPath); err != nil || !hasChanges {
+		t.Errorf("HasUncommittedChanges() = %v, %v; want true", hasChanges, err)
+	}
+	// Inspecting the repository leaves it untouched
+	if exclude, _ := os.ReadFile(filepath.Join(repoPath, ".git", "info", "exclude")); strings.Contains(string(exclude), excludeBegin) {
+		t.Errorf(".git/info/exclude = %q before committing, want no rules block", exclude)
+	}
+
+	if err := CommitChanges(ctx, repoPath, "rules"); err != nil {
+		t.Fatalf("CommitChanges() error = %v", err)
+	}
+
+	output, err := exec.Command("git", "-C", repoPath, "show", "--name-only", "--pretty=format:", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(splitLines(string(output)), ","); got != ".claude-sync.yaml,CLAUDE.md,settings.json" {
+		t.Errorf("committed files = %s", got)
+	}
+
+	hasChanges, err := HasUncommittedChanges(ctx, repoPath)
+	if err != nil || hasChanges {
+		t.Errorf("HasUncommittedChanges() = %v, %v; want false once synced files are committed", hasChanges, err)
+	}
+
+	exclude, err := os.ReadFile(filepath.Join(repoPath, ".git", "info", "exclude"))
+	if err != nil || !strings.Contains(string(exclude), excludeBegin+"\ndrafts/**\n"+excludeEnd) {
+		t.Errorf(".git/info/exclude = %q, want generated rules block", exclude)
+	}
+}
+
+func TestGetUntrackedPaths(t *testing.T) {
+	t.Parallel()
+
+	repoPath := createTestRepo(t)
+	ctx := context.Background()
+
+	if err := os.WriteFile(filepath.Join(repoPath, ".claude-sync.yaml"), []byte("exclude:\n  - todos/**\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, ".gitignore"), []byte("*.log\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"todos/a.json", "agents/reviewer.md", "debug.log"} {
+		path := filepath.Join(repoPath, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, err := GetUntrackedPaths(ctx, repoPath)
+	if err != nil {
+		t.Fatalf("GetUntrackedPaths() error = %v", err)
+	}
+
+	got := map[string]bool{}
+	for _, p := range paths {
+		got[p.Path] = p.Decision.Synced
+	}
+	want := map[string]bool{".claude-sync.yaml": true, ".gitignore": true, "agents/": true, "todos/": false}
+	if len(got) != len(want) {
+		t.Fatalf("GetUntrackedPaths() = %+v, want %v", paths, want)
+	}
+	for path, synced := range want {
+		if s, ok := got[path]; !ok || s != synced {
+			t.Errorf("GetUntrackedPaths()[%s] synced = %v (listed %v), want %v", path, s, ok, synced)
+		}
+	}
+}
+
+func TestExcludeSecretFiles(t *testing.T) {
+	t.Parallel()
+
+	repoPath := createTestRepo(t)
+	ctx := context.Background()
+
+	// Both the tracked file and the one with glob characters stay local only
+	for _, name := range []string{"mcp.json", "a [b].json"} {
+		if err := os.WriteFile(filepath.Join(repoPath, name), []byte("secret"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := CommitChanges(ctx, repoPath, "plaintext"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, "ab.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ExcludeSecretFiles(ctx, repoPath, []string{"mcp.json", "a [b].json"}); err != nil {
+		t.Fatalf("ExcludeSecretFiles() error = %v", err)
+	}
+	if err := CommitChanges(ctx, repoPath, "encrypt"); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := exec.Command("git", "-C", repoPath, "ls-files").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(splitLines(string(output)), ","); got != "ab.json,test.txt" {
+		t.Errorf("tracked files = %s, want ab.json,test.txt", got)
+	}
+	if _, err := os.Stat(filepath.Join(repoPath, "mcp.json")); err != nil {
+		t.Errorf("working tree copy removed: %v", err)
+	}
+}
+
+func TestWriteManagedBlock(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "exclude")
+	if err := os.WriteFile(path, []byte("# user entry\n*.tmp\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeManagedBlock(path, "# BEGIN", "# END", []string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeManagedBlock(path, "# BEGIN", "# END", []string{"c"}); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := os.ReadFile(path)
+	if string(content) != "# user entry\n*.tmp\n\n# BEGIN\nc\n# END\n" {
+		t.Errorf("content = %q", content)
+	}
+
+	if err := writeManagedBlock(path, "# BEGIN", "# END", nil); err != nil {
+		t.Fatal(err)
+	}
+	content, _ = os.ReadFile(path)
+	if string(content) != "# user entry\n*.tmp\n" {
+		t.Errorf("content after removing block = %q", content)
+	}
+}
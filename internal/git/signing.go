@@ -0,0 +1,137 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Signature is the signature check of one commit
+type Signature struct {
+	SHA     string
+	Subject string
+	// Status is git's %G? code: G good, U good but of unknown validity,
+	// N unsigned, B bad, X or Y expired, R revoked, E cannot be checked
+	Status string
+	// Fingerprint is the fingerprint of the signing key; SSH fingerprints
+	// start with "SHA256:"
+	Fingerprint string
+	// PrimaryFingerprint is the primary key of an OpenPGP subkey
+	PrimaryFingerprint string
+	// Signer is the allowed signer (SSH) or user ID (OpenPGP) that signed
+	Signer string
+}
+
+// SigningConfig is how commits made in a repository are signed, including
+// the global git configuration
+type SigningConfig struct {
+	Sign bool
+	// Format is ssh, openpgp, or x509
+	Format string
+	Key    string
+}
+
+// CommitSignatures checks the signatures of the commits in revRange, newest
+// first. SSH signatures are checked against allowedSigners, the content of
+// an allowed signers file; OpenPGP signatures against the keyring.
+func CommitSignatures(ctx context.Context, repoPath, revRange string, allowedSigners []byte) ([]Signature, error) {
+	file, err := os.CreateTemp("", "claude-sync-allowed-signers-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write allowed signers: %w", err)
+	}
+	defer func() { _ = os.Remove(file.Name()) }()
+	_, err = file.Write(allowedSigners)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write allowed signers: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "-c", "gpg.ssh.allowedSignersFile="+file.Name(),
+		"log", "--format=%H%x1f%G?%x1f%GF%x1f%GP%x1f%GS%x1f%s%x1e", revRange, "--")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check signatures in %s: %w%s", revRange, err, stderrOf(err))
+	}
+
+	var signatures []Signature
+	for _, record := range strings.Split(string(output), "\x1e") {
+		fields := strings.Split(strings.TrimLeft(record, "\n"), "\x1f")
+		if len(fields) != 6 {
+			continue
+		}
+		signatures = append(signatures, Signature{
+			SHA:                fields[0],
+			Status:             fields[1],
+			Fingerprint:        fields[2],
+			PrimaryFingerprint: fields[3],
+			Signer:             fields[4],
+			Subject:            fields[5],
+		})
+	}
+	return signatures, nil
+}
+
+// GetSigningConfig returns how commits made in a repository are signed
+func GetSigningConfig(ctx context.Context, repoPath string) (SigningConfig, error) {
+	var cfg SigningConfig
+	sign, err := effectiveConfig(ctx, repoPath, "--type=bool", "commit.gpgsign")
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Sign = sign == "true"
+	if cfg.Format, err = effectiveConfig(ctx, repoPath, "gpg.format"); err != nil {
+		return cfg, err
+	}
+	if cfg.Format == "" {
+		cfg.Format = "openpgp"
+	}
+	cfg.Key, err = effectiveConfig(ctx, repoPath, "user.signingkey")
+	return cfg, err
+}
+
+// ConfigureSigning makes the repository sign every commit with key, in the
+// given format (ssh or openpgp). allowedSignersFile lets plain git commands
+// such as git log --show-signature check SSH signatures.
+func ConfigureSigning(ctx context.Context, repoPath, format, key, allowedSignersFile string) error {
+	settings := [][2]string{
+		{"gpg.format", format},
+		{"user.signingkey", key},
+		{"commit.gpgsign", "true"},
+		{"gpg.ssh.allowedSignersFile", allowedSignersFile},
+	}
+	for _, kv := range settings {
+		if err := runGit(ctx, repoPath, "failed to configure commit signing", "config", "--local", kv[0], kv[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// signArgs returns the flag that signs commits made with plumbing commands,
+// which ignore commit.gpgsign
+func signArgs(ctx context.Context, repoPath string) []string {
+	if cfg, err := GetSigningConfig(ctx, repoPath); err == nil && cfg.Sign {
+		return []string{"-S"}
+	}
+	return nil
+}
+
+// effectiveConfig reads a git config value from every scope; a missing key
+// yields ""
+func effectiveConfig(ctx context.Context, repoPath string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", repoPath, "config", "--get"}, args...)...)
+	output, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read git config: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,85 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newSSHKey creates an SSH key pair and returns the private key path and
+// the public key
+func newSSHKey(t *testing.T, name string) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not available")
+	}
+	key := filepath.Join(t.TempDir(), name)
+	if output, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", name, "-f", key).CombinedOutput(); err != nil {
+		t.Fatalf("ssh-keygen: %v\n%s", err, output)
+	}
+	public, err := os.ReadFile(key + ".pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, strings.TrimSpace(string(public))
+}
+
+func TestCommitSignatures(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repoPath := createTestRepo(t)
+	start, err := RevParse(ctx, repoPath, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, public := newSSHKey(t, "laptop")
+	allowed := filepath.Join(repoPath, "allowed_signers")
+
+	if err := ConfigureSigning(ctx, repoPath, "ssh", key, allowed); err != nil {
+		t.Fatalf("ConfigureSigning() error = %v", err)
+	}
+	cfg, err := GetSigningConfig(ctx, repoPath)
+	if err != nil || !cfg.Sign || cfg.Format != "ssh" || cfg.Key != key {
+		t.Fatalf("GetSigningConfig() = %+v, %v", cfg, err)
+	}
+
+	commitFile(t, repoPath, "signed.txt", "signed", "signed")
+	if err := RevertTo(ctx, repoPath, start, "Undo"); err != nil {
+		t.Fatalf("RevertTo() error = %v", err)
+	}
+	if output, err := exec.Command("git", "-C", repoPath, "-c", "commit.gpgsign=false",
+		"commit", "--allow-empty", "-m", "unsigned").CombinedOutput(); err != nil {
+		t.Fatalf("git commit: %v\n%s", err, output)
+	}
+
+	signatures, err := CommitSignatures(ctx, repoPath, start+"..HEAD", []byte("laptop "+public+"\n"))
+	if err != nil {
+		t.Fatalf("CommitSignatures() error = %v", err)
+	}
+	if len(signatures) != 3 {
+		t.Fatalf("CommitSignatures() = %+v, want 3 commits", signatures)
+	}
+	if s := signatures[0]; s.Subject != "unsigned" || s.Status != "N" {
+		t.Errorf("unsigned commit = %+v, want status N", s)
+	}
+	for _, s := range signatures[1:] {
+		if s.Status != "G" || s.Signer != "laptop" || !strings.HasPrefix(s.Fingerprint, "SHA256:") {
+			t.Errorf("%s = %+v, want a good signature by laptop", s.Subject, s)
+		}
+	}
+
+	_, other := newSSHKey(t, "desktop")
+	signatures, err = CommitSignatures(ctx, repoPath, start+"..HEAD~1", []byte("desktop "+other+"\n"))
+	if err != nil {
+		t.Fatalf("CommitSignatures() error = %v", err)
+	}
+	for _, s := range signatures {
+		if s.Status != "U" {
+			t.Errorf("%s = %+v, want status U for a key that is not allowed", s.Subject, s)
+		}
+	}
+}
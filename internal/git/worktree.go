@@ -0,0 +1,50 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+)
+
+// ListFiles returns the tracked files matching the pathspecs, and with
+// untracked also the untracked files that are not ignored, sorted
+func ListFiles(ctx context.Context, repoPath string, untracked bool, pathspecs ...string) ([]string, error) {
+	args := []string{"-C", repoPath, "ls-files", "-z", "--cached"}
+	if untracked {
+		args = append(args, "--others", "--exclude-standard")
+	}
+	args = append(append(args, "--"), pathspecs...)
+	output, err := exec.CommandContext(ctx, "git", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w%s", err, stderrOf(err))
+	}
+	files := splitNul(string(output))
+	slices.Sort(files)
+	return files, nil
+}
+
+// SetSkipWorktree marks tracked files so git ignores their working tree
+// content and never stages it, or clears the mark. A pull that changes a
+// marked file fails, so the mark is cleared before pulling.
+func SetSkipWorktree(ctx context.Context, repoPath string, files []string, skip bool) error {
+	if len(files) == 0 {
+		return nil
+	}
+	flag := "--no-skip-worktree"
+	if skip {
+		flag = "--skip-worktree"
+	}
+	args := append([]string{"update-index", flag, "--"}, files...)
+	return runGit(ctx, repoPath, "failed to update the index", args...)
+}
+
+// CheckoutFiles overwrites files in the working tree with their content in
+// the index
+func CheckoutFiles(ctx context.Context, repoPath string, files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	args := append([]string{"checkout", "--quiet", "--"}, files...)
+	return runGit(ctx, repoPath, "failed to check out files", args...)
+}
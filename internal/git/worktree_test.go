@@ -0,0 +1,50 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSkipWorktreeAndCheckoutFiles(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repoPath := createTestRepo(t)
+	commitFile(t, repoPath, "hooks/check.sh", "echo ok", "add hook")
+	if err := os.WriteFile(filepath.Join(repoPath, "hooks/new.sh"), []byte("echo new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tracked, err := ListFiles(ctx, repoPath, false, "hooks")
+	if err != nil || !slices.Equal(tracked, []string{"hooks/check.sh"}) {
+		t.Errorf("ListFiles(tracked) = %v, %v", tracked, err)
+	}
+	all, err := ListFiles(ctx, repoPath, true, "hooks")
+	if err != nil || !slices.Equal(all, []string{"hooks/check.sh", "hooks/new.sh"}) {
+		t.Errorf("ListFiles(untracked) = %v, %v", all, err)
+	}
+
+	hook := filepath.Join(repoPath, "hooks/check.sh")
+	if err := SetSkipWorktree(ctx, repoPath, []string{"hooks/check.sh"}, true); err != nil {
+		t.Fatalf("SetSkipWorktree() error = %v", err)
+	}
+	if err := os.WriteFile(hook, []byte("exit 0"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if files, err := changedFiles(ctx, repoPath); err != nil || slices.Contains(files, "hooks/check.sh") {
+		t.Errorf("changedFiles() = %v, %v; want the skipped file left out", files, err)
+	}
+
+	if err := SetSkipWorktree(ctx, repoPath, []string{"hooks/check.sh"}, false); err != nil {
+		t.Fatalf("SetSkipWorktree() error = %v", err)
+	}
+	if err := CheckoutFiles(ctx, repoPath, []string{"hooks/check.sh"}); err != nil {
+		t.Fatalf("CheckoutFiles() error = %v", err)
+	}
+	if data, _ := os.ReadFile(hook); string(data) != "echo ok" {
+		t.Errorf("hooks/check.sh = %q, want the committed content", data)
+	}
+}
@@ -0,0 +1,24 @@
+package gogit
+
+import "context"
+
+// The bundle directory is kept in the local config as claude-sync.bundledir,
+// as in the git package
+const (
+	bundleSection = "claude-sync"
+	bundleDirKey  = "bundledir"
+)
+
+// BundleDir returns the directory bundles are exchanged through, or "" when
+// the repository syncs with its remote
+func BundleDir(ctx context.Context, repoPath string) (string, error) {
+	repo, err := open(repoPath)
+	if err != nil {
+		return "", err
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		return "", err
+	}
+	return cfg.Raw.Section(bundleSection).Option(bundleDirKey), nil
+}
@@ -0,0 +1,320 @@
+// Package gogit implements the repository operations of a sync with
+// go-git, a git implementation in pure Go, so claude-sync works on machines
+// without the git binary.
+//
+// It covers what a sync needs: init, clone, status, commit, fetch, pull,
+// push, and ahead/behind. Pulls merge instead of rebasing, since go-git
+// cannot rebase; a merge that conflicts leaves the conflicts in the index
+// and MERGE_HEAD behind, as git merge does, so git can finish it too.
+//
+// Thread Safety: Functions in this package are NOT thread-safe, like those
+// of the git package.
+package gogit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage/memory"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+)
+
+// TokenEnv holds a token for HTTPS remotes, which go-git cannot get from a
+// git credential helper
+const TokenEnv = "CLAUDE_SYNC_GIT_TOKEN"
+
+// defaultBranch is the branch new repositories start on
+const defaultBranch = "main"
+
+// open opens the repository at repoPath
+func open(repoPath string) (*gogit.Repository, error) {
+	repo, err := gogit.PlainOpen(repoPath)
+	if err != nil {
+		return nil, &git.OperationError{Op: "open", Path: repoPath, Err: err}
+	}
+	return repo, nil
+}
+
+// InitRepo initializes a new repository on the main branch
+func InitRepo(ctx context.Context, repoPath string) error {
+	_, err := gogit.PlainInitWithOptions(repoPath, &gogit.PlainInitOptions{
+		InitOptions: gogit.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName(defaultBranch)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize git repo: %w", err)
+	}
+	return nil
+}
+
+// CloneRepo clones a remote repository to destPath
+func CloneRepo(ctx context.Context, remoteURL, destPath string) error {
+	auth, err := authFor(remoteURL)
+	if err != nil {
+		return err
+	}
+	_, err = gogit.PlainCloneContext(ctx, destPath, false, &gogit.CloneOptions{URL: remoteURL, Auth: auth})
+	if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return fmt.Errorf("repository is empty: %w\n\n"+
+			"The repository exists but has no commits.\n"+
+			"If this is a new repo, use 'Start fresh' instead.", err)
+	}
+	if err != nil {
+		return enhanceRemoteError(fmt.Errorf("failed to clone repository: %w", err))
+	}
+	return nil
+}
+
+// ValidateRemote checks that a remote repository exists and is accessible
+func ValidateRemote(ctx context.Context, remoteURL string) error {
+	if _, err := listRemote(ctx, remoteURL); err != nil {
+		return &git.RemoteError{URL: remoteURL, Op: "validate", Err: err}
+	}
+	return nil
+}
+
+// RemoteHasCommits checks whether a remote repository has any branches
+func RemoteHasCommits(ctx context.Context, remoteURL string) (bool, error) {
+	refs, err := listRemote(ctx, remoteURL)
+	if err != nil {
+		return false, fmt.Errorf("failed to check remote: %w", err)
+	}
+	for _, ref := range refs {
+		if ref.Name().IsBranch() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// listRemote lists the references of a remote; an empty repository has none
+func listRemote(ctx context.Context, remoteURL string) ([]*plumbing.Reference, error) {
+	auth, err := authFor(remoteURL)
+	if err != nil {
+		return nil, err
+	}
+	remote := gogit.NewRemote(memory.NewStorage(), &config.RemoteConfig{Name: "origin", URLs: []string{remoteURL}})
+	refs, err := remote.ListContext(ctx, &gogit.ListOptions{Auth: auth})
+	if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return nil, nil
+	}
+	return refs, err
+}
+
+// AddRemote adds a remote repository
+func AddRemote(ctx context.Context, repoPath, name, url string) error {
+	repo, err := open(repoPath)
+	if err != nil {
+		return err
+	}
+	if _, err := repo.CreateRemote(&config.RemoteConfig{Name: name, URLs: []string{url}}); err != nil {
+		return fmt.Errorf("failed to add remote: %w", err)
+	}
+	return nil
+}
+
+// Fetch fetches from the current branch's remote without merging
+func Fetch(ctx context.Context, repoPath string) error {
+	repo, err := open(repoPath)
+	if err != nil {
+		return err
+	}
+	return fetch(ctx, repo, currentRemote(repo))
+}
+
+func fetch(ctx context.Context, repo *gogit.Repository, remoteName string) error {
+	remote, err := repo.Remote(remoteName)
+	if err != nil {
+		return fmt.Errorf("failed to fetch: %w", err)
+	}
+	auth, err := authFor(remote.Config().URLs[0])
+	if err != nil {
+		return err
+	}
+	err = remote.FetchContext(ctx, &gogit.FetchOptions{Auth: auth})
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) && !errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return enhanceRemoteError(fmt.Errorf("failed to fetch: %w", err))
+	}
+	return nil
+}
+
+// Push pushes the current branch to its upstream
+func Push(ctx context.Context, repoPath string) error {
+	repo, err := open(repoPath)
+	if err != nil {
+		return err
+	}
+	branch, target, err := pushBranches(repo)
+	if err != nil {
+		return err
+	}
+	return push(ctx, repo, currentRemote(repo), branch, target)
+}
+
+// pushBranches returns the current branch and the branch it pushes to:
+// the one it tracks, or the one of the same name
+func pushBranches(repo *gogit.Repository) (branch, target string, err error) {
+	if branch, err = currentBranch(repo); err != nil {
+		return "", "", err
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		return "", "", err
+	}
+	if b, ok := cfg.Branches[branch]; ok && b.Merge.IsBranch() {
+		return branch, b.Merge.Short(), nil
+	}
+	return branch, branch, nil
+}
+
+// PushWithUpstream pushes the current branch to the current remote (origin
+// unless the active profile uses another) and sets upstream tracking
+func PushWithUpstream(ctx context.Context, repoPath string) error {
+	repo, err := open(repoPath)
+	if err != nil {
+		return err
+	}
+	branch, err := currentBranch(repo)
+	if err != nil {
+		return err
+	}
+	remote := currentRemote(repo)
+	if err := push(ctx, repo, remote, branch, branch); err != nil {
+		return err
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	cfg.Branches[branch] = &config.Branch{Name: branch, Remote: remote, Merge: plumbing.NewBranchReferenceName(branch)}
+	if err := repo.SetConfig(cfg); err != nil {
+		return fmt.Errorf("failed to set upstream: %w", err)
+	}
+	return nil
+}
+
+func push(ctx context.Context, repo *gogit.Repository, remoteName, branch, target string) error {
+	remote, err := repo.Remote(remoteName)
+	if err != nil {
+		return fmt.Errorf("failed to push: %w", err)
+	}
+	auth, err := authFor(remote.Config().URLs[0])
+	if err != nil {
+		return err
+	}
+	spec := config.RefSpec(plumbing.NewBranchReferenceName(branch).String() + ":" + plumbing.NewBranchReferenceName(target).String())
+	err = remote.PushContext(ctx, &gogit.PushOptions{RefSpecs: []config.RefSpec{spec}, Auth: auth})
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return enhanceRemoteError(fmt.Errorf("failed to push: %w", err))
+	}
+
+	// Keep the remote-tracking branch current, as git push does
+	hash, err := repo.ResolveRevision(plumbing.Revision(plumbing.NewBranchReferenceName(branch)))
+	if err != nil {
+		return err
+	}
+	tracking := plumbing.NewRemoteReferenceName(remoteName, target)
+	return repo.Storer.SetReference(plumbing.NewHashReference(tracking, *hash))
+}
+
+// currentBranch returns the branch HEAD points to, which may have no
+// commits yet
+func currentBranch(repo *gogit.Repository) (string, error) {
+	head, err := repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return "", fmt.Errorf("failed to get current branch: %w", err)
+	}
+	if head.Type() != plumbing.SymbolicReference || !head.Target().IsBranch() {
+		return "", errors.New("failed to get current branch: HEAD is detached")
+	}
+	return head.Target().Short(), nil
+}
+
+// currentRemote returns the remote of the current branch, or origin
+func currentRemote(repo *gogit.Repository) string {
+	branch, err := currentBranch(repo)
+	if err != nil {
+		return "origin"
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		return "origin"
+	}
+	if b, ok := cfg.Branches[branch]; ok && b.Remote != "" {
+		return b.Remote
+	}
+	return "origin"
+}
+
+// authFor returns the credentials for a remote: the SSH agent or a default
+// key for SSH, and the token in CLAUDE_SYNC_GIT_TOKEN or the URL's own
+// credentials for HTTPS. nil lets go-git use its defaults.
+func authFor(remoteURL string) (transport.AuthMethod, error) {
+	ep, err := transport.NewEndpoint(remoteURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote URL %s: %w", remoteURL, err)
+	}
+	switch ep.Protocol {
+	case "ssh":
+		user := ep.User
+		if user == "" {
+			user = "git"
+		}
+		if os.Getenv("SSH_AUTH_SOCK") != "" {
+			return ssh.NewSSHAgentAuth(user)
+		}
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil
+		}
+		for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+			key := filepath.Join(home, ".ssh", name)
+			if _, err := os.Stat(key); err == nil {
+				return ssh.NewPublicKeysFromFile(user, key, "")
+			}
+		}
+	case "http", "https":
+		if token := os.Getenv(TokenEnv); token != "" {
+			user := ep.User
+			if user == "" {
+				user = "git"
+			}
+			return &http.BasicAuth{Username: user, Password: token}, nil
+		}
+		if ep.User != "" {
+			return &http.BasicAuth{Username: ep.User, Password: ep.Password}, nil
+		}
+	}
+	return nil, nil
+}
+
+// enhanceRemoteError adds the usual fixes to authentication failures
+func enhanceRemoteError(err error) error {
+	switch {
+	case errors.Is(err, transport.ErrAuthenticationRequired), errors.Is(err, transport.ErrAuthorizationFailed):
+		return fmt.Errorf("authentication failed: %w\n\n"+
+			"Common fixes:\n"+
+			"  1. For HTTPS: set %s to an access token\n"+
+			"  2. For SSH: add your key to ssh-agent (ssh-add ~/.ssh/id_ed25519)\n"+
+			"  3. Check repository access permissions", err, TokenEnv)
+	case errors.Is(err, transport.ErrRepositoryNotFound):
+		return fmt.Errorf("repository not found: %w\n\n"+
+			"Common fixes:\n"+
+			"  1. Verify the repository exists on the remote\n"+
+			"  2. Ensure you have access to the repository", err)
+	case strings.Contains(err.Error(), "non-fast-forward"):
+		return fmt.Errorf("%w\n\nThe remote has commits this machine does not have yet - pull first", err)
+	}
+	return err
+}
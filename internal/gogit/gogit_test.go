@@ -0,0 +1,330 @@
+package gogit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+)
+
+const notes = "one\ntwo\nthree\nfour\nfive\n"
+
+// createBareRepo creates an empty bare repository on main
+func createBareRepo(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "remote.git")
+	_, err := gogit.PlainInitWithOptions(path, &gogit.PlainInitOptions{
+		InitOptions: gogit.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName(defaultBranch)},
+		Bare:        true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to init bare repo: %v", err)
+	}
+	return path
+}
+
+// createMachines sets up a remote with notes.md and two clones of it
+func createMachines(t *testing.T) (first, second string) {
+	t.Helper()
+	ctx := context.Background()
+	remote := createBareRepo(t)
+	first = filepath.Join(t.TempDir(), "first")
+	if err := InitRepo(ctx, first); err != nil {
+		t.Fatalf("InitRepo() error = %v", err)
+	}
+	writeTestFile(t, first, "notes.md", notes)
+	if err := InitialCommit(ctx, first, "Initial commit"); err != nil {
+		t.Fatalf("InitialCommit() error = %v", err)
+	}
+	if err := AddRemote(ctx, first, "origin", remote); err != nil {
+		t.Fatalf("AddRemote() error = %v", err)
+	}
+	if err := PushWithUpstream(ctx, first); err != nil {
+		t.Fatalf("PushWithUpstream() error = %v", err)
+	}
+	second = filepath.Join(t.TempDir(), "second")
+	if err := CloneRepo(ctx, remote, second); err != nil {
+		t.Fatalf("CloneRepo() error = %v", err)
+	}
+	return first, second
+}
+
+func writeTestFile(t *testing.T, repoPath, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(repoPath, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readTestFile(t *testing.T, repoPath, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(repoPath, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+// commitNotes commits new notes and pushes them when push is set
+func commitNotes(t *testing.T, repoPath, content string, push bool) {
+	t.Helper()
+	ctx := context.Background()
+	writeTestFile(t, repoPath, "notes.md", content)
+	if err := CommitChanges(ctx, repoPath, "Update notes"); err != nil {
+		t.Fatalf("CommitChanges() error = %v", err)
+	}
+	if push {
+		if err := Push(ctx, repoPath); err != nil {
+			t.Fatalf("Push() error = %v", err)
+		}
+	}
+}
+
+// gitStatus returns what the git binary reports as changed, to check that
+// git reads the repository the same way
+func gitStatus(t *testing.T, repoPath string) string {
+	t.Helper()
+	output, err := exec.Command("git", "-C", repoPath, "status", "--porcelain").Output()
+	if err != nil {
+		t.Fatalf("git status error = %v", err)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func TestPull_MergesSeparateEdits(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	first, second := createMachines(t)
+	commitNotes(t, second, "ONE\ntwo\nthree\nfour\nfive\n", true)
+	commitNotes(t, first, "one\ntwo\nthree\nfour\nFIVE\n", false)
+
+	if _, ahead, behind, err := GetBranchInfo(ctx, first); err != nil || ahead != 1 || behind != 0 {
+		t.Errorf("GetBranchInfo() before fetch = %d, %d, %v, want 1, 0", ahead, behind, err)
+	}
+	if err := Fetch(ctx, first); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if branch, ahead, behind, err := GetBranchInfo(ctx, first); err != nil || branch != "main" || ahead != 1 || behind != 1 {
+		t.Errorf("GetBranchInfo() after fetch = %s, %d, %d, %v, want main, 1, 1", branch, ahead, behind, err)
+	}
+	if commits, err := GetCommitsInRange(ctx, first, "HEAD..@{upstream}"); err != nil || len(commits) != 1 || !strings.HasSuffix(commits[0], " Update notes") {
+		t.Errorf("GetCommitsInRange() = %v, %v", commits, err)
+	}
+
+	if err := Pull(ctx, first); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if got := readTestFile(t, first, "notes.md"); got != "ONE\ntwo\nthree\nfour\nFIVE\n" {
+		t.Errorf("merged notes = %q", got)
+	}
+	if _, ahead, behind, err := GetBranchInfo(ctx, first); err != nil || ahead != 2 || behind != 0 {
+		t.Errorf("GetBranchInfo() after pull = %d, %d, %v, want 2, 0", ahead, behind, err)
+	}
+	if status := gitStatus(t, first); status != "" {
+		t.Errorf("git status after pull = %q, want clean", status)
+	}
+
+	if err := Push(ctx, first); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if err := Pull(ctx, second); err != nil {
+		t.Fatalf("Pull() fast-forward error = %v", err)
+	}
+	if got := readTestFile(t, second, "notes.md"); got != "ONE\ntwo\nthree\nfour\nFIVE\n" {
+		t.Errorf("fast-forwarded notes = %q", got)
+	}
+	if status := gitStatus(t, second); status != "" {
+		t.Errorf("git status after fast-forward = %q, want clean", status)
+	}
+}
+
+func TestPull_Conflict(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	first, second := createMachines(t)
+	commitNotes(t, second, "one\ntwo\nremote\nfour\nfive\n", true)
+	local := "one\ntwo\nlocal\nfour\nfive\n"
+	commitNotes(t, first, local, false)
+
+	err := Pull(ctx, first)
+	var conflictErr *git.ConflictError
+	if !errors.As(err, &conflictErr) || !slices.Equal(conflictErr.Files, []string{"notes.md"}) {
+		t.Fatalf("Pull() error = %v, want a conflict in notes.md", err)
+	}
+	if conflicted, err := HasConflicts(ctx, first); err != nil || !conflicted {
+		t.Errorf("HasConflicts() = %v, %v, want true", conflicted, err)
+	}
+	if files, err := GetConflictedFiles(ctx, first); err != nil || !slices.Equal(files, []string{"notes.md"}) {
+		t.Errorf("GetConflictedFiles() = %v, %v", files, err)
+	}
+	base, remote, mine, err := GetConflictVersions(ctx, first, "notes.md")
+	if err != nil || string(base) != notes || string(remote) != "one\ntwo\nremote\nfour\nfive\n" || string(mine) != local {
+		t.Errorf("GetConflictVersions() = %q, %q, %q, %v", base, remote, mine, err)
+	}
+	if got := readTestFile(t, first, "notes.md"); !strings.Contains(got, "<<<<<<< HEAD\nlocal\n=======\nremote\n>>>>>>> origin/main\n") {
+		t.Errorf("conflicted notes = %q, want conflict markers", got)
+	}
+
+	// Aborting puts the local commit back
+	if err := AbortMerge(ctx, first); err != nil {
+		t.Fatalf("AbortMerge() error = %v", err)
+	}
+	if got := readTestFile(t, first, "notes.md"); got != local {
+		t.Errorf("notes after abort = %q, want %q", got, local)
+	}
+	if conflicted, err := HasConflicts(ctx, first); err != nil || conflicted {
+		t.Errorf("HasConflicts() after abort = %v, %v, want false", conflicted, err)
+	}
+	if status := gitStatus(t, first); status != "" {
+		t.Errorf("git status after abort = %q, want clean", status)
+	}
+
+	// Resolving and continuing records a merge commit
+	if err := Pull(ctx, first); err == nil {
+		t.Fatal("Pull() again succeeded, want a conflict")
+	}
+	resolved := "one\ntwo\nlocal and remote\nfour\nfive\n"
+	if err := ResolveConflict(ctx, first, "notes.md", []byte(resolved)); err != nil {
+		t.Fatalf("ResolveConflict() error = %v", err)
+	}
+	if err := ContinueMerge(ctx, first); err != nil {
+		t.Fatalf("ContinueMerge() error = %v", err)
+	}
+	if got := readTestFile(t, first, "notes.md"); got != resolved {
+		t.Errorf("notes after merge = %q", got)
+	}
+	parents, err := exec.Command("git", "-C", first, "rev-list", "--parents", "-n", "1", "HEAD").Output()
+	if err != nil || len(strings.Fields(string(parents))) != 3 {
+		t.Errorf("HEAD parents = %q, %v, want a merge commit", parents, err)
+	}
+	if status := gitStatus(t, first); status != "" {
+		t.Errorf("git status after merge = %q, want clean", status)
+	}
+}
+
+func TestPull_RefusesToOverwriteLocalChanges(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	first, second := createMachines(t)
+	commitNotes(t, second, "ONE\ntwo\nthree\nfour\nfive\n", true)
+	writeTestFile(t, first, "notes.md", "uncommitted\n")
+
+	if err := Pull(ctx, first); err == nil {
+		t.Fatal("Pull() over an uncommitted change succeeded")
+	}
+	if got := readTestFile(t, first, "notes.md"); got != "uncommitted\n" {
+		t.Errorf("notes = %q, want the uncommitted change kept", got)
+	}
+}
+
+func TestGetChangedFiles(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	first, _ := createMachines(t)
+	if files, err := GetChangedFiles(ctx, first); err != nil || len(files) != 0 {
+		t.Fatalf("GetChangedFiles() on a clean repo = %v, %v", files, err)
+	}
+
+	writeTestFile(t, first, ".gitignore", "*.key\n")
+	writeTestFile(t, first, "secret.key", "ignored")
+	writeTestFile(t, first, "notes.md", "changed\n")
+	if err := os.MkdirAll(filepath.Join(first, "skills"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, first, "skills/new.md", "new")
+
+	files, err := GetChangedFiles(ctx, first)
+	if want := []string{".gitignore", "notes.md", "skills/new.md"}; err != nil || !slices.Equal(files, want) {
+		t.Errorf("GetChangedFiles() = %v, %v, want %v", files, err, want)
+	}
+
+	if err := CommitChanges(ctx, first, "Update"); err != nil {
+		t.Fatalf("CommitChanges() error = %v", err)
+	}
+	if err := os.Remove(filepath.Join(first, "skills", "new.md")); err != nil {
+		t.Fatal(err)
+	}
+	if files, err := GetChangedFiles(ctx, first); err != nil || !slices.Equal(files, []string{"skills/new.md"}) {
+		t.Errorf("GetChangedFiles() after a removal = %v, %v", files, err)
+	}
+	if err := CommitChanges(ctx, first, "Remove"); err != nil {
+		t.Fatalf("CommitChanges() removal error = %v", err)
+	}
+	if status := gitStatus(t, first); status != "" {
+		t.Errorf("git status after commits = %q, want clean", status)
+	}
+}
+
+func TestBundleDir(t *testing.T) {
+	ctx := context.Background()
+	_, repo := createMachines(t)
+
+	if dir, err := BundleDir(ctx, repo); err != nil || dir != "" {
+		t.Fatalf("BundleDir() = %q, %v, want none", dir, err)
+	}
+	bundles := t.TempDir()
+	if err := git.SetBundleDir(ctx, repo, bundles); err != nil {
+		t.Fatalf("SetBundleDir() error = %v", err)
+	}
+	if dir, err := BundleDir(ctx, repo); err != nil || dir != bundles {
+		t.Errorf("BundleDir() = %q, %v, want %q", dir, err, bundles)
+	}
+}
+
+func TestUntrackFiles(t *testing.T) {
+	ctx := context.Background()
+	repo, _ := createMachines(t)
+
+	if err := UntrackFiles(ctx, repo, []string{"notes.md", "missing.md"}); err != nil {
+		t.Fatalf("UntrackFiles() error = %v", err)
+	}
+	if got := readTestFile(t, repo, "notes.md"); got != notes {
+		t.Errorf("notes.md = %q, want the working tree copy kept", got)
+	}
+	if got := gitStatus(t, repo); got != "D  notes.md\n?? notes.md" {
+		t.Errorf("git status = %q, want notes.md staged for deletion and untracked", got)
+	}
+}
+
+func TestCommitChanges_LeavesOutUntrackedIgnoredFiles(t *testing.T) {
+	ctx := context.Background()
+	repo, _ := createMachines(t)
+
+	exclude := filepath.Join(repo, ".git", "info", "exclude")
+	if err := os.MkdirAll(filepath.Dir(exclude), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(exclude, []byte("/notes.md\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := UntrackFiles(ctx, repo, []string{"notes.md"}); err != nil {
+		t.Fatalf("UntrackFiles() error = %v", err)
+	}
+	if err := CommitChanges(ctx, repo, "Untrack notes"); err != nil {
+		t.Fatalf("CommitChanges() error = %v", err)
+	}
+
+	output, err := exec.Command("git", "-C", repo, "ls-tree", "--name-only", "HEAD").Output()
+	if err != nil {
+		t.Fatalf("git ls-tree error = %v", err)
+	}
+	if strings.Contains(string(output), "notes.md") {
+		t.Errorf("notes.md is still committed: %s", output)
+	}
+	if got := gitStatus(t, repo); got != "" {
+		t.Errorf("git status = %q, want clean", got)
+	}
+}
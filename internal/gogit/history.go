@@ -0,0 +1,264 @@
+package gogit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// GetBranchInfo returns the current branch and how many commits it is ahead
+// of and behind its upstream. Without an upstream both counts are 0.
+func GetBranchInfo(ctx context.Context, repoPath string) (branch string, ahead, behind int, err error) {
+	repo, err := open(repoPath)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	branch, err = currentBranch(repo)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("failed to get branch: %w", err)
+	}
+	head, err := resolve(repo, "HEAD")
+	if err != nil {
+		return branch, 0, 0, nil
+	}
+	upstream, err := resolve(repo, "@{upstream}")
+	if err != nil {
+		return branch, 0, 0, nil
+	}
+
+	outgoing, err := commitsBetween(repo, upstream, head)
+	if err != nil {
+		return branch, 0, 0, err
+	}
+	incoming, err := commitsBetween(repo, head, upstream)
+	if err != nil {
+		return branch, 0, 0, err
+	}
+	return branch, len(outgoing), len(incoming), nil
+}
+
+// GetRecentCommits returns the last count commits as "<sha> <subject>"
+func GetRecentCommits(ctx context.Context, repoPath string, count int) ([]string, error) {
+	repo, err := open(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	head, err := resolve(repo, "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commits: %w", err)
+	}
+	commits, err := commitsBetween(repo, plumbing.ZeroHash, head)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commits: %w", err)
+	}
+	return oneLines(commits[:min(count, len(commits))]), nil
+}
+
+// GetCommitsInRange returns one-line commits ("<sha> <subject>") in a
+// revision range such as "HEAD..@{upstream}", newest first
+func GetCommitsInRange(ctx context.Context, repoPath, revRange string) ([]string, error) {
+	repo, err := open(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	from, to, _, err := resolveRange(repo, revRange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits in %s: %w", revRange, err)
+	}
+	commits, err := commitsBetween(repo, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits in %s: %w", revRange, err)
+	}
+	return oneLines(commits), nil
+}
+
+// GetFilesInRange returns the files changed in a revision range. "A..B"
+// compares A and B; "A...B" compares B with where it forked from A.
+func GetFilesInRange(ctx context.Context, repoPath, revRange string) ([]string, error) {
+	repo, err := open(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	from, to, symmetric, err := resolveRange(repo, revRange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files in %s: %w", revRange, err)
+	}
+	if symmetric {
+		if from, err = mergeBase(repo, from, to); err != nil {
+			return nil, fmt.Errorf("failed to list files in %s: %w", revRange, err)
+		}
+	}
+
+	files := []string{}
+	old, err := commitFiles(repo, from)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := commitFiles(repo, to)
+	if err != nil {
+		return nil, err
+	}
+	for p, f := range old {
+		if u, ok := updated[p]; !ok || u != f {
+			files = append(files, p)
+		}
+	}
+	for p := range updated {
+		if _, ok := old[p]; !ok {
+			files = append(files, p)
+		}
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// RevParse resolves a revision such as "HEAD" or "@{upstream}" to a full commit SHA
+func RevParse(ctx context.Context, repoPath, rev string) (string, error) {
+	repo, err := open(repoPath)
+	if err != nil {
+		return "", err
+	}
+	hash, err := resolve(repo, rev)
+	if err != nil {
+		return "", err
+	}
+	return hash.String(), nil
+}
+
+// resolve resolves a revision to a commit. Besides what go-git resolves it
+// understands "@{upstream}" and "@{u}", alone or after a branch name.
+func resolve(repo *gogit.Repository, rev string) (plumbing.Hash, error) {
+	for _, suffix := range []string{"@{upstream}", "@{u}"} {
+		branch, ok := strings.CutSuffix(rev, suffix)
+		if !ok {
+			continue
+		}
+		if branch == "" || branch == "HEAD" {
+			current, err := currentBranch(repo)
+			if err != nil {
+				return plumbing.ZeroHash, fmt.Errorf("failed to resolve %s: %w", rev, err)
+			}
+			branch = current
+		}
+		cfg, err := repo.Config()
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		b, ok := cfg.Branches[branch]
+		if !ok || b.Remote == "" || !b.Merge.IsBranch() {
+			return plumbing.ZeroHash, fmt.Errorf("failed to resolve %s: no upstream configured for %s", rev, branch)
+		}
+		rev = plumbing.NewRemoteReferenceName(b.Remote, b.Merge.Short()).String()
+		break
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to resolve %s: %w", rev, err)
+	}
+	if _, err := repo.CommitObject(*hash); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to resolve %s: not a commit", rev)
+	}
+	return *hash, nil
+}
+
+// resolveRange resolves "A..B" or "A...B"; an empty side means HEAD
+func resolveRange(repo *gogit.Repository, revRange string) (from, to plumbing.Hash, symmetric bool, err error) {
+	left, right, ok := strings.Cut(revRange, "...")
+	symmetric = ok
+	if !ok {
+		if left, right, ok = strings.Cut(revRange, ".."); !ok {
+			return from, to, false, errors.New("not a revision range")
+		}
+	}
+	if left == "" {
+		left = "HEAD"
+	}
+	if right == "" {
+		right = "HEAD"
+	}
+	if from, err = resolve(repo, left); err != nil {
+		return from, to, false, err
+	}
+	to, err = resolve(repo, right)
+	return from, to, symmetric, err
+}
+
+// mergeBase returns the best common ancestor of two commits
+func mergeBase(repo *gogit.Repository, a, b plumbing.Hash) (plumbing.Hash, error) {
+	ca, err := repo.CommitObject(a)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	cb, err := repo.CommitObject(b)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	bases, err := ca.MergeBase(cb)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if len(bases) == 0 {
+		return plumbing.ZeroHash, errors.New("no common history")
+	}
+	return bases[0].Hash, nil
+}
+
+// commitsBetween returns the commits reachable from to but not from from,
+// newest first like git log. A zero from means all of to's history.
+func commitsBetween(repo *gogit.Repository, from, to plumbing.Hash) ([]*object.Commit, error) {
+	exclude := map[plumbing.Hash]bool{}
+	if !from.IsZero() {
+		start, err := repo.CommitObject(from)
+		if err != nil {
+			return nil, err
+		}
+		err = object.NewCommitPreorderIter(start, nil, nil).ForEach(func(c *object.Commit) error {
+			exclude[c.Hash] = true
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	start, err := repo.CommitObject(to)
+	if err != nil {
+		return nil, err
+	}
+	var commits []*object.Commit
+	iter := object.NewCommitIterCTime(start, exclude, nil)
+	defer iter.Close()
+	for {
+		c, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			return commits, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, c)
+	}
+}
+
+// oneLines formats commits as "<sha> <subject>", like --pretty=format:"%h %s"
+func oneLines(commits []*object.Commit) []string {
+	lines := make([]string, 0, len(commits))
+	for _, c := range commits {
+		paragraph, _, _ := strings.Cut(strings.TrimSpace(c.Message), "\n\n")
+		lines = append(lines, shortHash(c.Hash)+" "+strings.Join(strings.Fields(paragraph), " "))
+	}
+	return lines
+}
+
+// shortHash abbreviates a commit SHA the way git does for small repositories
+func shortHash(hash plumbing.Hash) string {
+	return hash.String()[:7]
+}
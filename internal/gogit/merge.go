@@ -0,0 +1,690 @@
+package gogit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+	"github.com/mfenderov/claude-sync/internal/textdiff"
+)
+
+// Files under .git that record a merge in progress, named as git names them
+const (
+	mergeHeadFile = "MERGE_HEAD"
+	mergeMsgFile  = "MERGE_MSG"
+	origHeadFile  = "ORIG_HEAD"
+)
+
+// stageMerged is the stage of index entries without conflicts. go-git's
+// index.Merged is 1, the stage of the merge base, so it cannot be used.
+const stageMerged index.Stage = 0
+
+// Pull fetches the current branch's upstream and merges it, fast-forwarding
+// when there are no local commits. When files conflict the merge is left in
+// progress and a *git.ConflictError is returned.
+func Pull(ctx context.Context, repoPath string) error {
+	repo, err := open(repoPath)
+	if err != nil {
+		return err
+	}
+	branch, err := currentBranch(repo)
+	if err != nil {
+		return err
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	b, ok := cfg.Branches[branch]
+	if !ok || b.Remote == "" || !b.Merge.IsBranch() {
+		return fmt.Errorf("no upstream branch configured for %s\n\n"+
+			"This usually happens after initial setup.\n"+
+			"The sync will set upstream automatically.", branch)
+	}
+	if err := fetch(ctx, repo, b.Remote); err != nil {
+		return err
+	}
+
+	upstream := plumbing.NewRemoteReferenceName(b.Remote, b.Merge.Short())
+	ref, err := repo.Reference(upstream, true)
+	if err != nil {
+		return fmt.Errorf("failed to pull: %s not found on the remote: %w", b.Merge.Short(), err)
+	}
+	name := upstream.Short()
+	return merge(repo, repoPath, ref.Hash(), name, "Merge remote-tracking branch '"+name+"'", false)
+}
+
+// MergeOnto merges a commit that was already fetched, such as a reviewed
+// one, without fetching
+func MergeOnto(ctx context.Context, repoPath, onto string) error {
+	repo, err := open(repoPath)
+	if err != nil {
+		return err
+	}
+	hash, err := resolve(repo, onto)
+	if err != nil {
+		return err
+	}
+	name := shortHash(hash)
+	if err := merge(repo, repoPath, hash, name, "Merge commit '"+name+"'", false); err != nil {
+		return fmt.Errorf("failed to merge %s: %w", onto, err)
+	}
+	return nil
+}
+
+// PullAllowUnrelatedHistories fetches origin and merges its main branch,
+// even when it shares no history with the current branch
+func PullAllowUnrelatedHistories(ctx context.Context, repoPath string) error {
+	repo, err := open(repoPath)
+	if err != nil {
+		return err
+	}
+	if err := fetch(ctx, repo, "origin"); err != nil {
+		return err
+	}
+	ref, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", defaultBranch), true)
+	if err != nil {
+		return fmt.Errorf("failed to merge histories: origin has no %s branch: %w", defaultBranch, err)
+	}
+	if err := merge(repo, repoPath, ref.Hash(), "origin/"+defaultBranch, "Merge branch '"+defaultBranch+"' of origin", true); err != nil {
+		return fmt.Errorf("failed to merge histories: %w", err)
+	}
+	return nil
+}
+
+// mergeConflict is a path both sides changed in ways that do not merge
+type mergeConflict struct {
+	// base, ours and theirs are nil when that side does not have the file
+	base, ours, theirs *treeFile
+	// content is what the working tree gets: the file with conflict
+	// markers, or the side that still has the file
+	content []byte
+	mode    filemode.FileMode
+}
+
+// merge merges theirs into HEAD. name labels theirs in conflict markers.
+func merge(repo *gogit.Repository, repoPath string, theirs plumbing.Hash, name, message string, allowUnrelated bool) error {
+	if mergeInProgress(repoPath) {
+		return errors.New("a merge is already in progress - finish or abort it first")
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return err
+	}
+	theirsCommit, err := repo.CommitObject(theirs)
+	if err != nil {
+		return err
+	}
+	if upToDate, err := theirsCommit.IsAncestor(headCommit); err != nil || upToDate {
+		return err
+	}
+
+	bases, err := headCommit.MergeBase(theirsCommit)
+	if err != nil {
+		return fmt.Errorf("failed to find the merge base: %w", err)
+	}
+	base := map[string]treeFile{}
+	fastForward := false
+	switch {
+	case len(bases) == 0 && !allowUnrelated:
+		return errors.New("refusing to merge unrelated histories")
+	case len(bases) > 0:
+		if base, err = commitFiles(repo, bases[0].Hash); err != nil {
+			return err
+		}
+		fastForward = bases[0].Hash == head.Hash()
+	}
+	ours, err := commitFiles(repo, head.Hash())
+	if err != nil {
+		return err
+	}
+	theirsFiles, err := commitFiles(repo, theirs)
+	if err != nil {
+		return err
+	}
+
+	result, conflicts, err := mergeTrees(repo, base, ours, theirsFiles, name)
+	if err != nil {
+		return err
+	}
+	if err := checkOverwrites(repoPath, ours, result, conflicts); err != nil {
+		return err
+	}
+	if err := checkout(repo, repoPath, ours, result, conflicts); err != nil {
+		return err
+	}
+
+	if fastForward {
+		branch, err := repo.Storer.Reference(plumbing.HEAD)
+		if err != nil {
+			return err
+		}
+		return repo.Storer.SetReference(plumbing.NewHashReference(branch.Target(), theirs))
+	}
+	if len(conflicts) == 0 {
+		return commit(repo, message, head.Hash(), theirs)
+	}
+
+	state := map[string]string{
+		mergeHeadFile: theirs.String() + "\n",
+		origHeadFile:  head.Hash().String() + "\n",
+		mergeMsgFile:  message + "\n",
+	}
+	for file, content := range state {
+		if err := os.WriteFile(filepath.Join(repoPath, ".git", file), []byte(content), 0o644); err != nil {
+			return fmt.Errorf("failed to record the merge: %w", err)
+		}
+	}
+	files := make([]string, 0, len(conflicts))
+	for file := range conflicts {
+		files = append(files, file)
+	}
+	slices.Sort(files)
+	return &git.ConflictError{Path: repoPath, Files: files}
+}
+
+// mergeTrees merges the files of ours and theirs against base. Paths only
+// one side changed take that side; text files both sides changed are merged
+// line by line.
+func mergeTrees(repo *gogit.Repository, base, ours, theirs map[string]treeFile, name string) (map[string]treeFile, map[string]*mergeConflict, error) {
+	paths := map[string]bool{}
+	for _, files := range []map[string]treeFile{base, ours, theirs} {
+		for p := range files {
+			paths[p] = true
+		}
+	}
+
+	result := map[string]treeFile{}
+	conflicts := map[string]*mergeConflict{}
+	for p := range paths {
+		b, o, t := lookup(base, p), lookup(ours, p), lookup(theirs, p)
+		switch {
+		case sameFile(o, t), sameFile(b, t):
+			if o != nil {
+				result[p] = *o
+			}
+			continue
+		case sameFile(b, o):
+			if t != nil {
+				result[p] = *t
+			}
+			continue
+		}
+
+		c := &mergeConflict{base: b, ours: o, theirs: t}
+		if o == nil || t == nil || !isText(o.mode) || !isText(t.mode) {
+			// Deleted on one side, or not a text file: keep what exists
+			side := o
+			if side == nil {
+				side = t
+			}
+			content, err := readBlob(repo, side.hash)
+			if err != nil {
+				return nil, nil, err
+			}
+			c.content, c.mode = content, side.mode
+			conflicts[p] = c
+			continue
+		}
+
+		var contents [3][]byte
+		for i, f := range []*treeFile{b, o, t} {
+			if f == nil {
+				continue
+			}
+			content, err := readBlob(repo, f.hash)
+			if err != nil {
+				return nil, nil, err
+			}
+			contents[i] = content
+		}
+		mode := o.mode
+		if b != nil && o.mode == b.mode {
+			mode = t.mode
+		}
+		if isBinary(contents[0]) || isBinary(contents[1]) || isBinary(contents[2]) {
+			c.content, c.mode = contents[1], o.mode
+			conflicts[p] = c
+			continue
+		}
+		merged, clean := textdiff.Merge3(contents[0], contents[1], contents[2], "HEAD", name)
+		if !clean {
+			c.content, c.mode = merged, mode
+			conflicts[p] = c
+			continue
+		}
+		hash, err := storeBlob(repo, merged)
+		if err != nil {
+			return nil, nil, err
+		}
+		result[p] = treeFile{hash: hash, mode: mode}
+	}
+	return result, conflicts, nil
+}
+
+func lookup(files map[string]treeFile, path string) *treeFile {
+	if f, ok := files[path]; ok {
+		return &f
+	}
+	return nil
+}
+
+// sameFile reports whether two versions are equal; nil means no file
+func sameFile(a, b *treeFile) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func isText(mode filemode.FileMode) bool {
+	return mode == filemode.Regular || mode == filemode.Executable
+}
+
+// isBinary reports whether content looks binary, as git decides: a NUL
+// byte in the first 8000 bytes
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0
+}
+
+func readBlob(repo *gogit.Repository, hash plumbing.Hash) ([]byte, error) {
+	blob, err := repo.BlobObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", hash, err)
+	}
+	r, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+	return io.ReadAll(r)
+}
+
+// checkOverwrites refuses a merge that would overwrite working tree files
+// which differ from HEAD, such as uncommitted edits or untracked files,
+// before anything is changed
+func checkOverwrites(repoPath string, ours, result map[string]treeFile, conflicts map[string]*mergeConflict) error {
+	var blocked []string
+	for _, p := range changedPaths(ours, result, conflicts) {
+		o := lookup(ours, p)
+		info, err := os.Lstat(filepath.Join(repoPath, filepath.FromSlash(p)))
+		if errors.Is(err, fs.ErrNotExist) {
+			if o != nil {
+				blocked = append(blocked, p)
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			blocked = append(blocked, p)
+			continue
+		}
+		hash, err := hashFile(repoPath, p, info)
+		if err != nil {
+			return err
+		}
+		if o != nil && hash == o.hash {
+			continue
+		}
+		if r := lookup(result, p); r != nil && hash == r.hash {
+			continue
+		}
+		blocked = append(blocked, p)
+	}
+	if len(blocked) > 0 {
+		slices.Sort(blocked)
+		return fmt.Errorf("the merge would overwrite local changes to:\n  %s\nCommit or remove them, then sync again",
+			strings.Join(blocked, "\n  "))
+	}
+	return nil
+}
+
+// changedPaths returns the paths whose working tree file a checkout from
+// ours to result changes, deletions first so a file can replace a directory
+func changedPaths(ours, result map[string]treeFile, conflicts map[string]*mergeConflict) []string {
+	var removed, written []string
+	for p := range conflicts {
+		written = append(written, p)
+	}
+	for p, o := range ours {
+		if _, ok := conflicts[p]; ok {
+			continue
+		}
+		if r, ok := result[p]; !ok {
+			removed = append(removed, p)
+		} else if r != o {
+			written = append(written, p)
+		}
+	}
+	for p := range result {
+		if _, ok := ours[p]; !ok {
+			written = append(written, p)
+		}
+	}
+	slices.Sort(removed)
+	slices.Sort(written)
+	return append(removed, written...)
+}
+
+// checkout updates the working tree and the index from ours to the merge
+// result. Conflicted paths get their content in the working tree and their
+// base, ours and theirs versions as index stages 1 to 3, as git does.
+func checkout(repo *gogit.Repository, repoPath string, ours, result map[string]treeFile, conflicts map[string]*mergeConflict) error {
+	for _, p := range changedPaths(ours, result, conflicts) {
+		if c, ok := conflicts[p]; ok {
+			if err := writeFile(repoPath, p, c.content, c.mode); err != nil {
+				return err
+			}
+			continue
+		}
+		r, ok := result[p]
+		if !ok {
+			if err := removeFile(repoPath, p); err != nil {
+				return err
+			}
+			continue
+		}
+		content, err := readBlob(repo, r.hash)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(repoPath, p, content, r.mode); err != nil {
+			return err
+		}
+	}
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return err
+	}
+	resetIndex(idx, repoPath, result)
+	for p, c := range conflicts {
+		for stage, f := range map[index.Stage]*treeFile{index.AncestorMode: c.base, index.OurMode: c.ours, index.TheirMode: c.theirs} {
+			if f != nil {
+				idx.Entries = append(idx.Entries, &index.Entry{Name: p, Hash: f.hash, Mode: f.mode, Stage: stage})
+			}
+		}
+	}
+	sortEntries(idx)
+	return repo.Storer.SetIndex(idx)
+}
+
+// resetIndex makes the index hold exactly files. Entries that already have
+// the right content are kept with their file stats and flags; the others
+// take the stats of the working tree file.
+func resetIndex(idx *index.Index, repoPath string, files map[string]treeFile) {
+	existing := map[string]*index.Entry{}
+	for _, e := range idx.Entries {
+		if e.Stage == stageMerged {
+			existing[e.Name] = e
+		}
+	}
+	idx.Entries = idx.Entries[:0]
+	for p, f := range files {
+		if e, ok := existing[p]; ok && e.Hash == f.hash && e.Mode == f.mode {
+			idx.Entries = append(idx.Entries, e)
+			continue
+		}
+		e := &index.Entry{Name: p, Hash: f.hash, Mode: f.mode}
+		if info, err := os.Lstat(filepath.Join(repoPath, filepath.FromSlash(p))); err == nil {
+			e.Size, e.ModifiedAt = uint32(info.Size()), info.ModTime()
+		}
+		idx.Entries = append(idx.Entries, e)
+	}
+}
+
+// writeFile writes a working tree file with the given git mode
+func writeFile(repoPath, name string, content []byte, mode filemode.FileMode) error {
+	path := filepath.Join(repoPath, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", name, err)
+	}
+	if info, err := os.Lstat(path); err == nil && (mode == filemode.Symlink || info.Mode()&fs.ModeSymlink != 0) {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", name, err)
+		}
+	}
+	if mode == filemode.Symlink {
+		if err := os.Symlink(filepath.FromSlash(string(content)), path); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		return nil
+	}
+
+	perm := os.FileMode(0o644)
+	if mode == filemode.Executable {
+		perm = 0o755
+	}
+	if err := os.WriteFile(path, content, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := os.Chmod(path, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// removeFile deletes a working tree file and the directories it leaves empty
+func removeFile(repoPath, name string) error {
+	path := filepath.Join(repoPath, filepath.FromSlash(name))
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete %s: %w", name, err)
+	}
+	for dir := filepath.Dir(path); dir != repoPath && strings.HasPrefix(dir, repoPath); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// mergeInProgress reports whether a merge stopped at conflicts
+func mergeInProgress(repoPath string) bool {
+	_, err := os.Stat(filepath.Join(repoPath, ".git", mergeHeadFile))
+	return err == nil
+}
+
+// clearMergeState removes the record of a merge in progress
+func clearMergeState(repoPath string) error {
+	for _, file := range []string{mergeHeadFile, mergeMsgFile} {
+		if err := os.Remove(filepath.Join(repoPath, ".git", file)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasConflicts checks if the index has unresolved conflicts
+func HasConflicts(ctx context.Context, repoPath string) (bool, error) {
+	files, err := GetConflictedFiles(ctx, repoPath)
+	return len(files) > 0, err
+}
+
+// GetConflictedFiles returns the paths with unresolved conflicts, sorted
+func GetConflictedFiles(ctx context.Context, repoPath string) ([]string, error) {
+	repo, err := open(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, &git.OperationError{Op: "list conflicts", Path: repoPath, Err: err}
+	}
+	files := []string{}
+	for _, e := range idx.Entries {
+		if e.Stage != stageMerged && !slices.Contains(files, e.Name) {
+			files = append(files, e.Name)
+		}
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// GetConflictVersions returns the base, remote and local versions of a
+// conflicted file, in the order a pull rebase has them: the merge's
+// "theirs" is the remote and "ours" the local branch. A version is nil when
+// that side does not have the file.
+func GetConflictVersions(ctx context.Context, repoPath, file string) (base, remote, local []byte, err error) {
+	repo, err := open(repoPath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, nil, nil, &git.OperationError{Op: "list conflict stages", Path: repoPath, Err: err}
+	}
+	for _, e := range idx.Entries {
+		if e.Name != file || e.Stage == stageMerged {
+			continue
+		}
+		content, err := readBlob(repo, e.Hash)
+		if err != nil {
+			return nil, nil, nil, &git.OperationError{Op: "read conflict stage", Path: repoPath, Err: err}
+		}
+		switch e.Stage {
+		case index.AncestorMode:
+			base = content
+		case index.OurMode:
+			local = content
+		case index.TheirMode:
+			remote = content
+		}
+	}
+	return base, remote, local, nil
+}
+
+// ResolveConflict writes the resolved content of a conflicted file and
+// marks it as resolved. A nil content resolves the conflict by deleting the file.
+func ResolveConflict(ctx context.Context, repoPath, file string, content []byte) error {
+	repo, err := open(repoPath)
+	if err != nil {
+		return err
+	}
+	fullPath := filepath.Join(repoPath, filepath.FromSlash(file))
+	if content == nil {
+		if err := removeFile(repoPath, file); err != nil {
+			return err
+		}
+	} else {
+		perm := os.FileMode(0o644)
+		if info, err := os.Stat(fullPath); err == nil {
+			perm = info.Mode().Perm()
+		}
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", file, err)
+		}
+		if err := os.WriteFile(fullPath, content, perm); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file, err)
+		}
+	}
+	if err := stageFiles(repo, repoPath, []string{file}); err != nil {
+		return fmt.Errorf("failed to mark %s as resolved: %w", file, err)
+	}
+	return nil
+}
+
+// ContinueMerge commits a merge whose conflicts have all been resolved
+func ContinueMerge(ctx context.Context, repoPath string) error {
+	data, err := os.ReadFile(filepath.Join(repoPath, ".git", mergeHeadFile))
+	if err != nil {
+		return errors.New("failed to continue merge: no merge in progress")
+	}
+	if files, err := GetConflictedFiles(ctx, repoPath); err != nil || len(files) > 0 {
+		return fmt.Errorf("failed to continue merge: unresolved conflicts in %s", strings.Join(files, ", "))
+	}
+	message, err := os.ReadFile(filepath.Join(repoPath, ".git", mergeMsgFile))
+	if err != nil {
+		message = []byte("Merge")
+	}
+
+	repo, err := open(repoPath)
+	if err != nil {
+		return err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return err
+	}
+	theirs := plumbing.NewHash(strings.TrimSpace(string(data)))
+	if err := commit(repo, strings.TrimSpace(string(message)), head.Hash(), theirs); err != nil {
+		return fmt.Errorf("failed to continue merge: %w", err)
+	}
+	return clearMergeState(repoPath)
+}
+
+// AbortMerge gives up a merge in progress and puts the working tree and
+// the index back as they were at HEAD
+func AbortMerge(ctx context.Context, repoPath string) error {
+	if !mergeInProgress(repoPath) {
+		return errors.New("failed to abort merge: no merge in progress")
+	}
+	repo, err := open(repoPath)
+	if err != nil {
+		return err
+	}
+	head, err := headFiles(repo)
+	if err != nil {
+		return err
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return err
+	}
+
+	// Whatever the merge changed differs from HEAD in the index
+	changed := map[string]bool{}
+	inIndex := map[string]bool{}
+	for _, e := range idx.Entries {
+		inIndex[e.Name] = true
+		if h, ok := head[e.Name]; e.Stage != stageMerged || !ok || h.hash != e.Hash || h.mode != e.Mode {
+			changed[e.Name] = true
+		}
+	}
+	for p := range head {
+		if !inIndex[p] {
+			changed[p] = true
+		}
+	}
+	for p := range changed {
+		h, ok := head[p]
+		if !ok {
+			if err := removeFile(repoPath, p); err != nil {
+				return err
+			}
+			continue
+		}
+		content, err := readBlob(repo, h.hash)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(repoPath, p, content, h.mode); err != nil {
+			return err
+		}
+	}
+
+	resetIndex(idx, repoPath, head)
+	sortEntries(idx)
+	if err := repo.Storer.SetIndex(idx); err != nil {
+		return err
+	}
+	return clearMergeState(repoPath)
+}
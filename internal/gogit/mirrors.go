@@ -0,0 +1,45 @@
+package gogit
+
+import (
+	"context"
+	"slices"
+)
+
+// mirrorKey marks a remote as a mirror, as in the git package
+const mirrorKey = "claude-sync-mirror"
+
+// ListMirrors returns the names of the mirror remotes, sorted, leaving out
+// the primary remote when it is also marked as a mirror
+func ListMirrors(ctx context.Context, repoPath string) ([]string, error) {
+	repo, err := open(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		return nil, err
+	}
+	primary := currentRemote(repo)
+	var mirrors []string
+	for _, sub := range cfg.Raw.Section("remote").Subsections {
+		if sub.Name != primary && sub.Option(mirrorKey) == "true" {
+			mirrors = append(mirrors, sub.Name)
+		}
+	}
+	slices.Sort(mirrors)
+	return mirrors, nil
+}
+
+// PushMirror pushes the current branch to a mirror, under the name it has on
+// the primary remote
+func PushMirror(ctx context.Context, repoPath, name string) error {
+	repo, err := open(repoPath)
+	if err != nil {
+		return err
+	}
+	branch, target, err := pushBranches(repo)
+	if err != nil {
+		return err
+	}
+	return push(ctx, repo, name, branch, target)
+}
@@ -0,0 +1,527 @@
+package gogit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+)
+
+// HasUncommittedChanges checks if there are changes, tracked or untracked,
+// that the sync rules in .claude-sync.yaml allow to be committed
+func HasUncommittedChanges(ctx context.Context, repoPath string) (bool, error) {
+	files, err := GetChangedFiles(ctx, repoPath)
+	return len(files) > 0, err
+}
+
+// GetChangedFiles returns the modified and untracked files that the sync
+// rules in .claude-sync.yaml allow to be committed
+func GetChangedFiles(ctx context.Context, repoPath string) ([]string, error) {
+	set, err := git.LoadRules(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := open(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	files, err := changedFiles(repo, repoPath)
+	if err != nil {
+		return nil, err
+	}
+	return set.Filter(files), nil
+}
+
+// changedFiles returns the files that differ from HEAD in the index or the
+// working tree, and the untracked files that are not ignored, sorted.
+// Files marked skip-worktree are left out, as git does.
+func changedFiles(repo *gogit.Repository, repoPath string) ([]string, error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the index: %w", err)
+	}
+	head, err := headFiles(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := map[string]bool{}
+	tracked := make(map[string]bool, len(idx.Entries))
+	for _, e := range idx.Entries {
+		tracked[e.Name] = true
+		if e.Stage != stageMerged {
+			changed[e.Name] = true
+			continue
+		}
+		if h, ok := head[e.Name]; !ok || h.hash != e.Hash || h.mode != e.Mode {
+			changed[e.Name] = true
+			continue
+		}
+		if e.SkipWorktree {
+			continue
+		}
+		modified, err := worktreeDiffers(repoPath, e)
+		if err != nil {
+			return nil, err
+		}
+		if modified {
+			changed[e.Name] = true
+		}
+	}
+	for name := range head {
+		if !tracked[name] {
+			changed[name] = true
+		}
+	}
+
+	untracked, err := untrackedFiles(repoPath, tracked)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range untracked {
+		changed[name] = true
+	}
+
+	files := make([]string, 0, len(changed))
+	for name := range changed {
+		files = append(files, name)
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// treeFile is a file in a tree
+type treeFile struct {
+	hash plumbing.Hash
+	mode filemode.FileMode
+}
+
+// headFiles returns the files of the HEAD commit, none before the first commit
+func headFiles(repo *gogit.Repository) (map[string]treeFile, error) {
+	head, err := repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return map[string]treeFile{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	return commitFiles(repo, head.Hash())
+}
+
+// commitFiles returns the files of a commit by path
+func commitFiles(repo *gogit.Repository, hash plumbing.Hash) (map[string]treeFile, error) {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit %s: %w", hash, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the tree of %s: %w", hash, err)
+	}
+	return treeFiles(tree)
+}
+
+func treeFiles(tree *object.Tree) (map[string]treeFile, error) {
+	files := map[string]treeFile{}
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tree: %w", err)
+		}
+		if entry.Mode == filemode.Dir {
+			continue
+		}
+		files[name] = treeFile{hash: entry.Hash, mode: entry.Mode}
+	}
+}
+
+// worktreeDiffers reports whether the working tree file of an index entry
+// has other content or mode. Files whose size and modification time match
+// the index are not read.
+func worktreeDiffers(repoPath string, e *index.Entry) (bool, error) {
+	info, err := os.Lstat(filepath.Join(repoPath, filepath.FromSlash(e.Name)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	mode, err := filemode.NewFromOSFileMode(info.Mode())
+	if err != nil || mode != e.Mode {
+		return true, nil
+	}
+	if uint32(info.Size()) == e.Size && info.ModTime().Equal(e.ModifiedAt) {
+		return false, nil
+	}
+	hash, err := hashFile(repoPath, e.Name, info)
+	if err != nil {
+		return false, err
+	}
+	return hash != e.Hash, nil
+}
+
+// readWorktreeFile returns the content git stores for a working tree file:
+// its bytes, or the target of a symlink
+func readWorktreeFile(repoPath, name string, info fs.FileInfo) ([]byte, error) {
+	path := filepath.Join(repoPath, filepath.FromSlash(name))
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		return []byte(filepath.ToSlash(target)), err
+	}
+	return os.ReadFile(path)
+}
+
+func hashFile(repoPath, name string, info fs.FileInfo) (plumbing.Hash, error) {
+	content, err := readWorktreeFile(repoPath, name, info)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return plumbing.ComputeHash(plumbing.BlobObject, content), nil
+}
+
+// untrackedFiles walks the working tree for files that are not tracked and
+// not ignored by .gitignore, .git/info/exclude, or the global excludes file.
+// Ignored directories and nested repositories are not entered.
+func untrackedFiles(repoPath string, tracked map[string]bool) ([]string, error) {
+	matcher, err := ignoreMatcher(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	err = filepath.WalkDir(repoPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == repoPath {
+			return nil
+		}
+		rel, err := filepath.Rel(repoPath, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if d.IsDir() {
+			if d.Name() == ".git" || matcher.Match(strings.Split(name, "/"), true) {
+				return filepath.SkipDir
+			}
+			if _, err := os.Lstat(filepath.Join(path, ".git")); err == nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !tracked[name] && !matcher.Match(strings.Split(name, "/"), false) {
+			files = append(files, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list untracked files: %w", err)
+	}
+	return files, nil
+}
+
+// ignoreMatcher matches the paths ignored by .gitignore, .git/info/exclude,
+// and the global excludes file
+func ignoreMatcher(repoPath string) (gitignore.Matcher, error) {
+	patterns, err := gitignore.ReadPatterns(osfs.New(repoPath), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ignore files: %w", err)
+	}
+	if global, err := gitignore.LoadGlobalPatterns(osfs.New("/")); err == nil {
+		patterns = append(global, patterns...)
+	}
+	return gitignore.NewMatcher(patterns), nil
+}
+
+// ignored reports whether a file or one of its directories is ignored
+func ignored(matcher gitignore.Matcher, name string) bool {
+	parts := strings.Split(name, "/")
+	for i := 1; i < len(parts); i++ {
+		if matcher.Match(parts[:i], true) {
+			return true
+		}
+	}
+	return matcher.Match(parts, false)
+}
+
+// CommitChanges commits all changes, tracked and untracked, that the sync
+// rules in .claude-sync.yaml allow
+func CommitChanges(ctx context.Context, repoPath, message string) error {
+	repo, err := open(repoPath)
+	if err != nil {
+		return err
+	}
+	if err := stageChanges(repo, repoPath); err != nil {
+		return err
+	}
+	if err := commit(repo, message); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// InitialCommit creates the first commit with all files the sync rules
+// allow, on the main branch
+func InitialCommit(ctx context.Context, repoPath, message string) error {
+	repo, err := open(repoPath)
+	if err != nil {
+		return err
+	}
+	if err := stageChanges(repo, repoPath); err != nil {
+		return err
+	}
+	if err := commit(repo, message); err != nil {
+		return fmt.Errorf("failed to create initial commit: %w", err)
+	}
+	return ensureDefaultBranch(repo)
+}
+
+// ensureDefaultBranch renames the current branch to main, for repositories
+// that git initialized with another default branch
+func ensureDefaultBranch(repo *gogit.Repository) error {
+	branch, err := currentBranch(repo)
+	if err != nil || branch == defaultBranch {
+		return nil
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil
+	}
+	main := plumbing.NewBranchReferenceName(defaultBranch)
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(main, head.Hash())); err != nil {
+		return fmt.Errorf("failed to rename branch to main: %w", err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, main)); err != nil {
+		return fmt.Errorf("failed to rename branch to main: %w", err)
+	}
+	return repo.Storer.RemoveReference(plumbing.NewBranchReferenceName(branch))
+}
+
+// stageChanges stages every changed file the rules allow, like the git
+// package does with git add
+func stageChanges(repo *gogit.Repository, repoPath string) error {
+	set, err := git.ApplyRules(repoPath)
+	if err != nil {
+		return err
+	}
+	files, err := changedFiles(repo, repoPath)
+	if err != nil {
+		return err
+	}
+	// Like git add, leave out ignored files that are not in the index, so a
+	// file untracked on purpose, such as a secret's plaintext, stays out
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return fmt.Errorf("failed to read the index: %w", err)
+	}
+	matcher, err := ignoreMatcher(repoPath)
+	if err != nil {
+		return err
+	}
+	files = slices.DeleteFunc(files, func(name string) bool {
+		_, err := idx.Entry(name)
+		return err != nil && ignored(matcher, name)
+	})
+	if err := stageFiles(repo, repoPath, set.Filter(files)); err != nil {
+		return fmt.Errorf("failed to stage changes: %w", err)
+	}
+	return nil
+}
+
+// stageFiles updates the index entries of files from the working tree,
+// removing the entries of files that no longer exist
+func stageFiles(repo *gogit.Repository, repoPath string, files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return err
+	}
+	for _, name := range files {
+		removeEntries(idx, name)
+		info, err := os.Lstat(filepath.Join(repoPath, filepath.FromSlash(name)))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		entry, err := worktreeEntry(repo, repoPath, name, info)
+		if err != nil {
+			return err
+		}
+		idx.Entries = append(idx.Entries, entry)
+	}
+	sortEntries(idx)
+	return repo.Storer.SetIndex(idx)
+}
+
+// UntrackFiles removes files from the index so the next commit deletes them
+// from the repository; the working tree copies are kept. Files that are not
+// tracked are skipped.
+func UntrackFiles(ctx context.Context, repoPath string, files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	repo, err := open(repoPath)
+	if err != nil {
+		return err
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return err
+	}
+	tracked := len(idx.Entries)
+	for _, name := range files {
+		removeEntries(idx, name)
+	}
+	if len(idx.Entries) == tracked {
+		return nil
+	}
+	if err := repo.Storer.SetIndex(idx); err != nil {
+		return fmt.Errorf("failed to untrack files: %w", err)
+	}
+	return nil
+}
+
+// worktreeEntry stores a working tree file as a blob and returns its index entry
+func worktreeEntry(repo *gogit.Repository, repoPath, name string, info fs.FileInfo) (*index.Entry, error) {
+	content, err := readWorktreeFile(repoPath, name, info)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := filemode.NewFromOSFileMode(info.Mode())
+	if err != nil {
+		return nil, fmt.Errorf("cannot stage %s: %w", name, err)
+	}
+	hash, err := storeBlob(repo, content)
+	if err != nil {
+		return nil, err
+	}
+	return &index.Entry{
+		Name:       name,
+		Hash:       hash,
+		Mode:       mode,
+		Size:       uint32(info.Size()),
+		ModifiedAt: info.ModTime(),
+	}, nil
+}
+
+// storeBlob writes content to the object database
+func storeBlob(repo *gogit.Repository, content []byte) (plumbing.Hash, error) {
+	obj := repo.Storer.NewEncodedObject()
+	obj.SetType(plumbing.BlobObject)
+	w, err := obj.Writer()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if _, err := w.Write(content); err != nil {
+		_ = w.Close()
+		return plumbing.ZeroHash, err
+	}
+	if err := w.Close(); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return repo.Storer.SetEncodedObject(obj)
+}
+
+// removeEntries removes every stage of name from the index
+func removeEntries(idx *index.Index, name string) {
+	idx.Entries = slices.DeleteFunc(idx.Entries, func(e *index.Entry) bool { return e.Name == name })
+}
+
+// sortEntries orders index entries by path and stage, as git requires
+func sortEntries(idx *index.Index) {
+	slices.SortFunc(idx.Entries, func(a, b *index.Entry) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return int(a.Stage) - int(b.Stage)
+	})
+}
+
+// commit commits the index on top of HEAD, and of parents when given
+func commit(repo *gogit.Repository, message string, parents ...plumbing.Hash) error {
+	cfg, err := repo.ConfigScoped(config.GlobalScope)
+	if err != nil {
+		return err
+	}
+	if signingConfigured(cfg) {
+		return errors.New("commit signing is configured, which the go-git backend cannot do - use --git-backend git")
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	author, committer := signatures(cfg)
+	_, err = wt.Commit(message, &gogit.CommitOptions{
+		Author:            author,
+		Committer:         committer,
+		Parents:           parents,
+		AllowEmptyCommits: len(parents) > 1,
+	})
+	return err
+}
+
+// signingConfigured reports whether commit.gpgsign is set in the repository
+// or the global configuration. The merged configuration only has the
+// repository's raw sections, so the global file is read as well.
+func signingConfigured(local *config.Config) bool {
+	sign := local.Raw.Section("commit").Option("gpgsign")
+	if !local.Raw.Section("commit").HasOption("gpgsign") {
+		if global, err := config.LoadConfig(config.GlobalScope); err == nil {
+			sign = global.Raw.Section("commit").Option("gpgsign")
+		}
+	}
+	return strings.EqualFold(sign, "true") || sign == "1" || strings.EqualFold(sign, "yes") || strings.EqualFold(sign, "on")
+}
+
+// signatures returns the author and committer of new commits from the
+// GIT_AUTHOR_* and GIT_COMMITTER_* variables, then user.name and
+// user.email, as git does. Without either it falls back to the login and
+// hostname, which git refuses but a sync should not.
+func signatures(cfg *config.Config) (author, committer *object.Signature) {
+	name, email := cfg.User.Name, cfg.User.Email
+	if name == "" {
+		name = envOr("USER", "claude-sync")
+	}
+	if email == "" {
+		host, _ := os.Hostname()
+		email = name + "@" + host
+	}
+	now := time.Now()
+	author = &object.Signature{Name: envOr("GIT_AUTHOR_NAME", name), Email: envOr("GIT_AUTHOR_EMAIL", email), When: now}
+	committer = &object.Signature{Name: envOr("GIT_COMMITTER_NAME", name), Email: envOr("GIT_COMMITTER_EMAIL", email), When: now}
+	return author, committer
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
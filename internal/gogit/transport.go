@@ -0,0 +1,53 @@
+package gogit
+
+import (
+	"context"
+
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+)
+
+func init() {
+	// go-git serves file remotes with git-upload-pack unless told otherwise;
+	// serve them in-process so local remotes work without git too
+	client.InstallProtocol("file", fileTransport{server.NewClient(server.DefaultLoader)})
+}
+
+// fileTransport serves file remotes in-process. go-git's server fails a
+// fetch when the client has commits the remote does not, which git ignores,
+// so those are left out of the request.
+type fileTransport struct {
+	transport.Transport
+}
+
+func (t fileTransport) NewUploadPackSession(ep *transport.Endpoint, auth transport.AuthMethod) (transport.UploadPackSession, error) {
+	session, err := t.Transport.NewUploadPackSession(ep, auth)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := server.DefaultLoader.Load(ep)
+	if err != nil {
+		_ = session.Close()
+		return nil, err
+	}
+	return &uploadPackSession{UploadPackSession: session, remote: remote}, nil
+}
+
+type uploadPackSession struct {
+	transport.UploadPackSession
+	remote storer.Storer
+}
+
+func (s *uploadPackSession) UploadPack(ctx context.Context, req *packp.UploadPackRequest) (*packp.UploadPackResponse, error) {
+	haves := req.Haves[:0]
+	for _, h := range req.Haves {
+		if s.remote.HasEncodedObject(h) == nil {
+			haves = append(haves, h)
+		}
+	}
+	req.Haves = haves
+	return s.UploadPackSession.UploadPack(ctx, req)
+}
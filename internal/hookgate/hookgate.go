@@ -0,0 +1,553 @@
+// Package hookgate keeps hooks that arrive from other machines disabled
+// until they are approved on this one.
+//
+// Claude Code runs the commands in the hooks section of settings.json,
+// which usually call scripts under hooks/. A bad push to the config
+// repository would otherwise run on every machine after its next sync, so
+// each machine keeps a record of the sha256 of the scripts and commands it
+// approved. After a pull, a script that is not approved is replaced by a
+// stub that does nothing, and so is a command in a settings file, until
+// 'claude-sync hooks approve' is run. Quarantined files are marked
+// skip-worktree so the stubs are never committed.
+//
+// Hooks edited on this machine are approved when they are committed, and
+// the hooks present when the gate is first used are approved as they are,
+// unless the repository was just cloned.
+// The record lives inside the repository's .git directory, so it is never
+// synced.
+package hookgate
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+	"github.com/mfenderov/claude-sync/internal/jsondoc"
+)
+
+// FileName is the record file name inside the repository's .git directory
+const FileName = "claude-sync-hooks.json"
+
+// ScriptDir holds the hook scripts
+const ScriptDir = "hooks"
+
+// maxHistory caps the commits searched for the approved version of a script
+const maxHistory = 50
+
+// stubScript replaces a quarantined script
+const stubScript = `#!/bin/sh
+# Quarantined by claude-sync: this hook changed on another machine.
+# Review and enable it with 'claude-sync hooks approve'.
+exit 0
+`
+
+// stubPrefix starts the command that replaces a quarantined command; the ID
+// of the original follows it
+const stubPrefix = "true # quarantined by claude-sync, see 'claude-sync hooks approve': "
+
+// Hook is a hook script, or a hook command in a settings file
+type Hook struct {
+	// File is the script, or the settings file holding the command
+	File string `json:"file"`
+	// Event and Matcher say when Claude Code runs a command
+	Event   string `json:"event,omitempty"`
+	Matcher string `json:"matcher,omitempty"`
+	Command string `json:"command,omitempty"`
+	// Hash is the sha256 of the script or command
+	Hash string `json:"hash"`
+}
+
+// IsCommand reports whether the hook is a command in a settings file
+func (h Hook) IsCommand() bool {
+	return h.Event != ""
+}
+
+// String describes the hook on one line
+func (h Hook) String() string {
+	if !h.IsCommand() {
+		return h.File
+	}
+	when := h.Event
+	if h.Matcher != "" {
+		when += " [" + h.Matcher + "]"
+	}
+	return fmt.Sprintf("%s %s: %s", h.File, when, h.Command)
+}
+
+// quarantine is a file whose hooks are disabled
+type quarantine struct {
+	// Stub is the hash of the content written in place of the file
+	Stub string `json:"stub"`
+	// Commands maps the ID in each stub command to the command it replaced
+	Commands map[string]string `json:"commands,omitempty"`
+	// Hooks are the disabled script or commands
+	Hooks []Hook `json:"hooks"`
+}
+
+// record is the gate's state for one repository
+type record struct {
+	Approved    []string               `json:"approved"`
+	Quarantined map[string]*quarantine `json:"quarantined,omitempty"`
+
+	approved map[string]bool
+}
+
+// Path returns the record path for a repository
+func Path(repoPath string) string {
+	return filepath.Join(repoPath, ".git", FileName)
+}
+
+// Quarantine disables the hooks in tracked files that are not approved,
+// typically right after a pull, and returns every hook waiting for approval
+func Quarantine(ctx context.Context, repoPath string) ([]Hook, error) {
+	r, err := load(ctx, repoPath)
+	if err != nil {
+		return nil, err
+	}
+	files, err := hookFiles(ctx, repoPath, false)
+	if err != nil {
+		return nil, err
+	}
+
+	stubs := map[string][]byte{}
+	for _, file := range files {
+		if r.Quarantined[file] != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(repoPath, filepath.FromSlash(file)))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		q, stub, err := r.quarantine(file, data)
+		if err != nil {
+			return nil, err
+		}
+		if q != nil {
+			r.Quarantined[file] = q
+			stubs[file] = stub
+		}
+	}
+	if len(stubs) == 0 {
+		return r.pending(), nil
+	}
+
+	// Recorded and hidden from git before the stubs are written, so they
+	// cannot be committed even if writing is interrupted
+	if err := r.save(repoPath); err != nil {
+		return nil, err
+	}
+	if err := git.SetSkipWorktree(ctx, repoPath, slices.Sorted(maps.Keys(stubs)), true); err != nil {
+		return nil, err
+	}
+	for file, stub := range stubs {
+		// Writing in place keeps the file mode
+		if err := os.WriteFile(filepath.Join(repoPath, filepath.FromSlash(file)), stub, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to quarantine %s: %w", file, err)
+		}
+	}
+	return r.pending(), nil
+}
+
+// Init starts a record with no hooks approved for a repository that has
+// none, so every hook in it waits for approval, as in a fresh clone
+func Init(repoPath string) error {
+	r, err := read(repoPath)
+	if err != nil || r != nil {
+		return err
+	}
+	r = &record{Quarantined: map[string]*quarantine{}, approved: map[string]bool{}}
+	return r.save(repoPath)
+}
+
+// Capture approves the hooks edited on this machine before a commit. A
+// quarantined file that was edited here gets its pulled hooks back and is
+// committed with the edits; it is quarantined again after the commit. It
+// returns the quarantined files that were edited.
+func Capture(ctx context.Context, repoPath string) ([]string, error) {
+	r, err := load(ctx, repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var released []string
+	for _, file := range slices.Sorted(maps.Keys(r.Quarantined)) {
+		data, err := os.ReadFile(filepath.Join(repoPath, filepath.FromSlash(file)))
+		if err == nil && digest(data) == r.Quarantined[file].Stub {
+			continue
+		}
+		if err := r.release(ctx, repoPath, file); err != nil {
+			return nil, err
+		}
+		released = append(released, file)
+	}
+
+	files, err := hookFiles(ctx, repoPath, true)
+	if err != nil {
+		return nil, err
+	}
+	_, headErr := git.RevParse(ctx, repoPath, "HEAD")
+	for _, file := range files {
+		if r.Quarantined[file] != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(repoPath, filepath.FromSlash(file)))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var committed []byte
+		if headErr == nil {
+			if committed, err = git.ReadFileAt(ctx, repoPath, "HEAD", file); err != nil {
+				return nil, err
+			}
+		}
+		if committed == nil || !bytes.Equal(data, committed) {
+			r.approveEdits(file, data, committed)
+		}
+	}
+	return released, r.save(repoPath)
+}
+
+// Release puts the committed content back into every quarantined file, so
+// a pull or reset can update them. Quarantine disables them again after.
+func Release(ctx context.Context, repoPath string) error {
+	r, err := load(ctx, repoPath)
+	if err != nil || len(r.Quarantined) == 0 {
+		return err
+	}
+	for _, file := range slices.Sorted(maps.Keys(r.Quarantined)) {
+		if err := r.release(ctx, repoPath, file); err != nil {
+			return err
+		}
+	}
+	return r.save(repoPath)
+}
+
+// Pending returns the hooks waiting for approval, by file
+func Pending(repoPath string) ([]Hook, error) {
+	r, err := read(repoPath)
+	if err != nil || r == nil {
+		return nil, err
+	}
+	return r.pending(), nil
+}
+
+// Approve approves every hook waiting for approval, puts it back in place,
+// and returns the hooks approved
+func Approve(ctx context.Context, repoPath string) ([]Hook, error) {
+	r, err := load(ctx, repoPath)
+	if err != nil {
+		return nil, err
+	}
+	hooks := r.pending()
+	for _, h := range hooks {
+		r.approve(h.Hash)
+	}
+	for _, file := range slices.Sorted(maps.Keys(r.Quarantined)) {
+		if err := r.release(ctx, repoPath, file); err != nil {
+			return nil, err
+		}
+	}
+	return hooks, r.save(repoPath)
+}
+
+// ApprovedVersion returns the newest committed version of a script that is
+// approved on this machine, or nil when the recent history has none
+func ApprovedVersion(ctx context.Context, repoPath, file string) ([]byte, error) {
+	r, err := read(repoPath)
+	if err != nil || r == nil {
+		return nil, err
+	}
+	commits, err := git.Log(ctx, repoPath, git.LogOptions{Path: file, Limit: maxHistory})
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range commits {
+		data, err := git.ReadFileAt(ctx, repoPath, c.SHA, file)
+		if err != nil {
+			return nil, err
+		}
+		if data != nil && r.approved[digest(data)] {
+			return data, nil
+		}
+	}
+	return nil, nil
+}
+
+// Commands returns the hook commands in a settings document
+func Commands(file string, data []byte) ([]Hook, error) {
+	doc, err := jsondoc.ParseObject(data)
+	if err != nil {
+		return nil, err
+	}
+	var hooks []Hook
+	eachCommand(doc, func(event, matcher, command string) (string, bool) {
+		hooks = append(hooks, Hook{File: file, Event: event, Matcher: matcher, Command: command, Hash: digest([]byte(command))})
+		return "", false
+	})
+	return hooks, nil
+}
+
+// quarantine returns the quarantine of a file and the stub to write in its
+// place, or nil when all its hooks are approved. Settings files that are not
+// valid JSON are left alone; Claude Code cannot load them either.
+func (r *record) quarantine(file string, data []byte) (*quarantine, []byte, error) {
+	if !isSettings(file) {
+		hash := digest(data)
+		if r.approved[hash] {
+			return nil, nil, nil
+		}
+		q := &quarantine{Stub: digest([]byte(stubScript)), Hooks: []Hook{{File: file, Hash: hash}}}
+		return q, []byte(stubScript), nil
+	}
+
+	doc, err := jsondoc.ParseObject(data)
+	if err != nil {
+		return nil, nil, nil
+	}
+	q := &quarantine{Commands: map[string]string{}}
+	eachCommand(doc, func(event, matcher, command string) (string, bool) {
+		hash := digest([]byte(command))
+		if _, stub := stubID(command); stub || r.approved[hash] {
+			return "", false
+		}
+		q.Commands[hash[:12]] = command
+		q.Hooks = append(q.Hooks, Hook{File: file, Event: event, Matcher: matcher, Command: command, Hash: hash})
+		return stubPrefix + hash[:12], true
+	})
+	if len(q.Hooks) == 0 {
+		return nil, nil, nil
+	}
+	stub, err := jsondoc.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	q.Stub = digest(stub)
+	return q, stub, nil
+}
+
+// release lifts the quarantine of a file. An untouched stub is replaced by
+// the committed content; in a settings file edited since, only the stub
+// commands are replaced, keeping the edits. An edited script or a deleted
+// file is kept as it is.
+func (r *record) release(ctx context.Context, repoPath, file string) error {
+	q := r.Quarantined[file]
+	path := filepath.Join(repoPath, filepath.FromSlash(file))
+	data, readErr := os.ReadFile(path)
+	if readErr != nil && !errors.Is(readErr, os.ErrNotExist) {
+		return readErr
+	}
+	if err := git.SetSkipWorktree(ctx, repoPath, []string{file}, false); err != nil {
+		return err
+	}
+	delete(r.Quarantined, file)
+
+	switch {
+	case readErr != nil:
+		return nil
+	case digest(data) == q.Stub:
+		return git.CheckoutFiles(ctx, repoPath, []string{file})
+	case len(q.Commands) > 0:
+		doc, err := jsondoc.ParseObject(data)
+		if err != nil {
+			return nil
+		}
+		eachCommand(doc, func(_, _, command string) (string, bool) {
+			id, ok := stubID(command)
+			original, found := q.Commands[id]
+			return original, ok && found
+		})
+		out, err := jsondoc.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, out, 0o644)
+	}
+	return nil
+}
+
+// approveEdits approves a script edited on this machine, or the commands of
+// a settings file that the committed version lacks
+func (r *record) approveEdits(file string, data, committed []byte) {
+	if !isSettings(file) {
+		r.approve(digest(data))
+		return
+	}
+	hooks, err := Commands(file, data)
+	if err != nil {
+		return
+	}
+	known := map[string]bool{}
+	if before, err := Commands(file, committed); err == nil {
+		for _, h := range before {
+			known[h.Hash] = true
+		}
+	}
+	for _, h := range hooks {
+		if _, stub := stubID(h.Command); !stub && !known[h.Hash] {
+			r.approve(h.Hash)
+		}
+	}
+}
+
+func (r *record) approve(hash string) {
+	if !r.approved[hash] {
+		r.approved[hash] = true
+		r.Approved = append(r.Approved, hash)
+	}
+}
+
+// pending returns the quarantined hooks by file
+func (r *record) pending() []Hook {
+	var hooks []Hook
+	for _, file := range slices.Sorted(maps.Keys(r.Quarantined)) {
+		hooks = append(hooks, r.Quarantined[file].Hooks...)
+	}
+	return hooks
+}
+
+// load reads the record of a repository. Without one, the hooks in it are
+// approved as they are, and the new record is saved.
+func load(ctx context.Context, repoPath string) (*record, error) {
+	r, err := read(repoPath)
+	if err != nil || r != nil {
+		return r, err
+	}
+
+	r = &record{Quarantined: map[string]*quarantine{}, approved: map[string]bool{}}
+	files, err := hookFiles(ctx, repoPath, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(repoPath, filepath.FromSlash(file)))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		r.approveEdits(file, data, nil)
+	}
+	return r, r.save(repoPath)
+}
+
+// read reads the record of a repository, or returns nil when there is none
+func read(repoPath string) (*record, error) {
+	data, err := os.ReadFile(Path(repoPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read approved hooks: %w", err)
+	}
+	var r record
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to read approved hooks from %s: %w", Path(repoPath), err)
+	}
+	if r.Quarantined == nil {
+		r.Quarantined = map[string]*quarantine{}
+	}
+	r.approved = make(map[string]bool, len(r.Approved))
+	for _, hash := range r.Approved {
+		r.approved[hash] = true
+	}
+	return &r, nil
+}
+
+func (r *record) save(repoPath string) error {
+	if r.Approved == nil {
+		r.Approved = []string{}
+	}
+	slices.Sort(r.Approved)
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(Path(repoPath), append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to save approved hooks: %w", err)
+	}
+	return nil
+}
+
+// hookFiles lists the scripts and the top-level settings files, tracked
+// only or with untracked ones
+func hookFiles(ctx context.Context, repoPath string, untracked bool) ([]string, error) {
+	files, err := git.ListFiles(ctx, repoPath, untracked, ScriptDir, ":(glob)settings*.json")
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(files, func(file string) bool {
+		return !strings.HasPrefix(file, ScriptDir+"/") && !isSettings(file)
+	}), nil
+}
+
+// isSettings reports whether a file is settings.json, one of its overlay
+// layers, or settings.local.json
+func isSettings(file string) bool {
+	return file == "settings.json" ||
+		!strings.Contains(file, "/") && strings.HasPrefix(file, "settings.") && strings.HasSuffix(file, ".json")
+}
+
+// eachCommand calls fn with each hook command in a settings document, and
+// replaces the command when fn returns true
+func eachCommand(doc *jsondoc.Object, fn func(event, matcher, command string) (string, bool)) {
+	value, _ := doc.Get("hooks")
+	events, ok := value.(*jsondoc.Object)
+	if !ok {
+		return
+	}
+	for _, event := range events.Keys() {
+		value, _ := events.Get(event)
+		groups, _ := value.([]any)
+		for _, g := range groups {
+			group, ok := g.(*jsondoc.Object)
+			if !ok {
+				continue
+			}
+			value, _ := group.Get("matcher")
+			matcher, _ := value.(string)
+			value, _ = group.Get("hooks")
+			entries, _ := value.([]any)
+			for _, e := range entries {
+				entry, ok := e.(*jsondoc.Object)
+				if !ok {
+					continue
+				}
+				value, _ := entry.Get("command")
+				command, ok := value.(string)
+				if !ok {
+					continue
+				}
+				if replacement, replace := fn(event, matcher, command); replace {
+					entry.Set("command", replacement)
+				}
+			}
+		}
+	}
+}
+
+// stubID returns the ID in a stub command
+func stubID(command string) (string, bool) {
+	return strings.CutPrefix(command, stubPrefix)
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
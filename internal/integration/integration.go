@@ -0,0 +1,294 @@
+// Package integration adds Claude Code hooks that run claude-sync, so the
+// configuration is pulled when a session starts and committed and pushed
+// when it ends.
+//
+// The hooks are entries in the hooks section of settings.json:
+//
+//	"SessionStart": [{"matcher": "startup|resume", "hooks": [{"type": "command", "command": "claude-sync pull --quiet"}]}]
+//	"SessionEnd":   [{"hooks": [{"type": "command", "command": "claude-sync sync --quiet"}]}]
+//
+// With review the commands get --review, so incoming changes are declined
+// instead of applied unreviewed. Installing adds the entries that are
+// missing, switches ours to or from review, and leaves everything else,
+// including key order, as it is. Uninstalling removes only entries whose
+// command is one of ours.
+package integration
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+
+	"github.com/mfenderov/claude-sync/internal/jsondoc"
+)
+
+// SettingsFile holds the hooks, relative to the Claude directory
+const SettingsFile = "settings.json"
+
+// Commands run by the hooks
+const (
+	PullCommand = "claude-sync pull --quiet"
+	SyncCommand = "claude-sync sync --quiet"
+)
+
+// ReviewFlag is added to the commands when incoming changes need review
+const ReviewFlag = " --review"
+
+// Hook is a hook entry that runs claude-sync
+type Hook struct {
+	Event   string `json:"event"`
+	Matcher string `json:"matcher,omitempty"`
+	Command string `json:"command"`
+}
+
+// String describes the hook on one line
+func (h Hook) String() string {
+	when := h.Event
+	if h.Matcher != "" {
+		when += " [" + h.Matcher + "]"
+	}
+	return when + ": " + h.Command
+}
+
+// Hooks returns the hooks to install. With pushOnStop changes are also
+// committed and pushed after every response, not only when a session ends.
+// With review nothing incoming is applied without a review.
+func Hooks(pushOnStop, review bool) []Hook {
+	pull, sync := PullCommand, SyncCommand
+	if review {
+		pull, sync = pull+ReviewFlag, sync+ReviewFlag
+	}
+	hooks := []Hook{
+		{Event: "SessionStart", Matcher: "startup|resume", Command: pull},
+		{Event: "SessionEnd", Command: sync},
+	}
+	if pushOnStop {
+		hooks = append(hooks, Hook{Event: "Stop", Command: sync})
+	}
+	return hooks
+}
+
+// Install adds the hooks missing from a settings file, or switches ours to
+// them, and returns them
+func Install(path string, hooks []Hook) ([]Hook, error) {
+	doc, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+	present := installed(doc)
+
+	var added []Hook
+	for _, h := range hooks {
+		if slices.ContainsFunc(present, func(p Hook) bool { return p.Event == h.Event && p.Command == h.Command }) {
+			continue
+		}
+		section, ok := object(doc, "hooks")
+		if !ok {
+			return nil, fmt.Errorf("%s: hooks is not an object", path)
+		}
+		groups, _ := section.Get(h.Event)
+		list, ok := groups.([]any)
+		if groups != nil && !ok {
+			return nil, fmt.Errorf("%s: hooks.%s is not an array", path, h.Event)
+		}
+		if switchCommand(list, h.Command) {
+			added = append(added, h)
+			continue
+		}
+
+		group := jsondoc.NewObject()
+		if h.Matcher != "" {
+			group.Set("matcher", h.Matcher)
+		}
+		entry := jsondoc.NewObject()
+		entry.Set("type", "command")
+		entry.Set("command", h.Command)
+		group.Set("hooks", []any{entry})
+		section.Set(h.Event, append(list, group))
+		added = append(added, h)
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+	return added, save(path, doc)
+}
+
+// Uninstall removes the hooks that run claude-sync from a settings file and
+// returns them. Groups and events left empty by the removal are removed too.
+func Uninstall(path string) ([]Hook, error) {
+	doc, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []Hook
+	section, _ := doc.Get("hooks")
+	hooks, ok := section.(*jsondoc.Object)
+	if !ok {
+		return nil, nil
+	}
+	for _, event := range hooks.Keys() {
+		value, _ := hooks.Get(event)
+		groups, ok := value.([]any)
+		if !ok {
+			continue
+		}
+		kept := make([]any, 0, len(groups))
+		for _, g := range groups {
+			group, matcher, entries := parseGroup(g)
+			if group == nil {
+				kept = append(kept, g)
+				continue
+			}
+			rest := make([]any, 0, len(entries))
+			for _, e := range entries {
+				if command, ok := ours(e); ok {
+					removed = append(removed, Hook{Event: event, Matcher: matcher, Command: command})
+					continue
+				}
+				rest = append(rest, e)
+			}
+			if len(rest) == len(entries) {
+				kept = append(kept, g)
+			} else if len(rest) > 0 {
+				group.Set("hooks", rest)
+				kept = append(kept, group)
+			}
+		}
+		switch {
+		case len(kept) == len(groups):
+		case len(kept) == 0:
+			hooks.Delete(event)
+		default:
+			hooks.Set(event, kept)
+		}
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	if hooks.Len() == 0 {
+		doc.Delete("hooks")
+	}
+	return removed, save(path, doc)
+}
+
+// Installed returns the hooks in a settings file that run claude-sync
+func Installed(path string) ([]Hook, error) {
+	doc, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+	return installed(doc), nil
+}
+
+func installed(doc *jsondoc.Object) []Hook {
+	section, _ := doc.Get("hooks")
+	hooks, ok := section.(*jsondoc.Object)
+	if !ok {
+		return nil
+	}
+
+	var found []Hook
+	for _, event := range hooks.Keys() {
+		value, _ := hooks.Get(event)
+		groups, _ := value.([]any)
+		for _, g := range groups {
+			_, matcher, entries := parseGroup(g)
+			for _, e := range entries {
+				if command, ok := ours(e); ok {
+					found = append(found, Hook{Event: event, Matcher: matcher, Command: command})
+				}
+			}
+		}
+	}
+	return found
+}
+
+// parseGroup returns a matcher group, its matcher, and its hook entries, or
+// a nil group when the value is not one
+func parseGroup(v any) (*jsondoc.Object, string, []any) {
+	group, ok := v.(*jsondoc.Object)
+	if !ok {
+		return nil, "", nil
+	}
+	matcher, _ := group.Get("matcher")
+	entries, _ := group.Get("hooks")
+	list, ok := entries.([]any)
+	if !ok {
+		return nil, "", nil
+	}
+	m, _ := matcher.(string)
+	return group, m, list
+}
+
+// switchCommand sets the command of the first entry of ours in an event's
+// groups, which runs claude-sync with or without review, and reports
+// whether there was one
+func switchCommand(groups []any, command string) bool {
+	for _, g := range groups {
+		_, _, entries := parseGroup(g)
+		for _, e := range entries {
+			if _, ok := ours(e); ok {
+				e.(*jsondoc.Object).Set("command", command)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ours returns the command of a hook entry that runs claude-sync
+func ours(v any) (string, bool) {
+	entry, ok := v.(*jsondoc.Object)
+	if !ok {
+		return "", false
+	}
+	value, _ := entry.Get("command")
+	command, _ := value.(string)
+	switch command {
+	case PullCommand, SyncCommand, PullCommand + ReviewFlag, SyncCommand + ReviewFlag:
+		return command, true
+	}
+	return "", false
+}
+
+// object returns the object under key, creating it when it is missing
+func object(doc *jsondoc.Object, key string) (*jsondoc.Object, bool) {
+	value, ok := doc.Get(key)
+	if !ok || value == nil {
+		obj := jsondoc.NewObject()
+		doc.Set(key, obj)
+		return obj, true
+	}
+	obj, ok := value.(*jsondoc.Object)
+	return obj, ok
+}
+
+func load(path string) (*jsondoc.Object, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	doc, err := jsondoc.ParseObject(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return doc, nil
+}
+
+func save(path string, doc *jsondoc.Object) error {
+	data, err := jsondoc.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	mode := fs.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.WriteFile(path, data, mode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mfenderov/claude-sync/internal/jsondoc"
+)
+
+// reformat renders a document the way Install and Uninstall write it
+func reformat(t *testing.T, doc string) string {
+	t.Helper()
+	obj, err := jsondoc.ParseObject([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := jsondoc.Marshal(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+const existing = `{
+  "permissions": {
+    "allow": ["Bash(ls)"]
+  },
+  "hooks": {
+    "SessionStart": [
+      {
+        "matcher": "startup",
+        "hooks": [
+          {
+            "type": "command",
+            "command": "echo hello"
+          }
+        ]
+      }
+    ]
+  },
+  "model": "opus"
+}
+`
+
+func TestInstallAndUninstall(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), SettingsFile)
+	if err := os.WriteFile(path, []byte(existing), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	added, err := Install(path, Hooks(false, false))
+	if err != nil || len(added) != 2 {
+		t.Fatalf("Install() = %v, %v, want both hooks", added, err)
+	}
+	want := `{
+  "permissions": {
+    "allow": [
+      "Bash(ls)"
+    ]
+  },
+  "hooks": {
+    "SessionStart": [
+      {
+        "matcher": "startup",
+        "hooks": [
+          {
+            "type": "command",
+            "command": "echo hello"
+          }
+        ]
+      },
+      {
+        "matcher": "startup|resume",
+        "hooks": [
+          {
+            "type": "command",
+            "command": "claude-sync pull --quiet"
+          }
+        ]
+      }
+    ],
+    "SessionEnd": [
+      {
+        "hooks": [
+          {
+            "type": "command",
+            "command": "claude-sync sync --quiet"
+          }
+        ]
+      }
+    ]
+  },
+  "model": "opus"
+}
+`
+	data, _ := os.ReadFile(path)
+	if string(data) != want {
+		t.Errorf("settings.json after Install() =\n%s\nwant\n%s", data, want)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, want it kept", info.Mode().Perm())
+	}
+
+	if again, err := Install(path, Hooks(false, false)); err != nil || len(again) != 0 {
+		t.Errorf("Install() again = %v, %v, want nothing added", again, err)
+	}
+	if added, err := Install(path, Hooks(true, false)); err != nil || len(added) != 1 || added[0].Event != "Stop" {
+		t.Errorf("Install(pushOnStop) = %v, %v, want only the Stop hook", added, err)
+	}
+	if hooks, err := Installed(path); err != nil || len(hooks) != 3 {
+		t.Errorf("Installed() = %v, %v", hooks, err)
+	}
+
+	// Installing with review switches the hooks already there
+	if switched, err := Install(path, Hooks(true, true)); err != nil || len(switched) != 3 {
+		t.Errorf("Install(review) = %v, %v, want the three hooks switched", switched, err)
+	}
+	hooks, err := Installed(path)
+	if err != nil || len(hooks) != 3 {
+		t.Fatalf("Installed() with review = %v, %v", hooks, err)
+	}
+	for _, h := range hooks {
+		if !strings.HasSuffix(h.Command, ReviewFlag) {
+			t.Errorf("Installed() hook %v, want it to review", h)
+		}
+	}
+
+	removed, err := Uninstall(path)
+	if err != nil || len(removed) != 3 {
+		t.Fatalf("Uninstall() = %v, %v, want the three hooks", removed, err)
+	}
+	data, _ = os.ReadFile(path)
+	if doc := string(data); doc != reformat(t, existing) {
+		t.Errorf("settings.json after Uninstall() =\n%s\nwant the original settings", doc)
+	}
+	if hooks, err := Installed(path); err != nil || len(hooks) != 0 {
+		t.Errorf("Installed() after Uninstall() = %v, %v", hooks, err)
+	}
+}
+
+func TestInstallCreatesSettings(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), SettingsFile)
+	if _, err := Install(path, Hooks(false, false)); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if _, err := Uninstall(path); err != nil {
+		t.Fatalf("Uninstall() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "{}\n" {
+		t.Errorf("settings.json = %q, want the hooks section removed", data)
+	}
+}
+
+func TestInstallRejectsUnexpectedShape(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), SettingsFile)
+	if err := os.WriteFile(path, []byte(`{"hooks": {"SessionEnd": "oops"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Install(path, Hooks(false, false)); err == nil {
+		t.Error("Install() error = nil, want an error for a malformed hooks section")
+	}
+	if data, _ := os.ReadFile(path); string(data) != `{"hooks": {"SessionEnd": "oops"}}` {
+		t.Errorf("settings.json = %s, want it untouched", data)
+	}
+}
@@ -0,0 +1,130 @@
+// Package journal keeps a local record of what each sync changed: the commit
+// the repository was at before the sync and the ranges it pulled and pushed.
+// The record is what 'claude-sync undo' restores from.
+//
+// The journal is a file of JSON lines inside the repository's .git
+// directory, so it is never synced and each machine has its own.
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileName is the journal file name inside the repository's .git directory
+const FileName = "claude-sync-journal"
+
+// MaxEntries bounds the journal; older entries are dropped
+const MaxEntries = 100
+
+// Path returns the journal path for a repository
+func Path(repoPath string) string {
+	return filepath.Join(repoPath, ".git", FileName)
+}
+
+// Range is a range of commits From..To; it is empty when both are equal
+type Range struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Empty reports whether the range holds no commits
+func (r Range) Empty() bool {
+	return r.From == "" || r.To == "" || r.From == r.To
+}
+
+func (r Range) String() string {
+	return r.From + ".." + r.To
+}
+
+// Entry records one sync, or the undo of one
+type Entry struct {
+	Time time.Time `json:"time"`
+	// Before is HEAD when the sync started
+	Before string `json:"before"`
+	// Local is HEAD after local changes were committed, before the pull
+	Local string `json:"local"`
+	// Pulled is the range the upstream branch moved by in the pull
+	Pulled Range `json:"pulled"`
+	// Pushed is the range the upstream branch moved by in the push
+	Pushed Range `json:"pushed"`
+	// After is HEAD when the sync finished
+	After string `json:"after"`
+	// Undo marks an entry written by undo; it cannot be undone itself
+	Undo bool `json:"undo,omitempty"`
+}
+
+// Changed reports whether the sync changed the repository or the remote
+func (e Entry) Changed() bool {
+	return e.Before != e.After || !e.Pulled.Empty() || !e.Pushed.Empty()
+}
+
+// Append adds an entry to the journal of a repository
+func Append(repoPath string, entry Entry) error {
+	entries, err := read(repoPath)
+	if err != nil {
+		return err
+	}
+	entries = append(entries, entry)
+	if len(entries) > MaxEntries {
+		entries = entries[len(entries)-MaxEntries:]
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	// Written to a temporary file first so a crash cannot truncate the journal
+	path := Path(repoPath)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+// Last returns the most recent entry, or nil when nothing was recorded yet
+func Last(repoPath string) (*Entry, error) {
+	entries, err := read(repoPath)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[len(entries)-1], nil
+}
+
+func read(repoPath string) ([]Entry, error) {
+	data, err := os.ReadFile(Path(repoPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var e Entry
+		// A damaged line only loses that entry
+		if json.Unmarshal(line, &e) == nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries, scanner.Err()
+}
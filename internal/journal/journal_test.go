@@ -0,0 +1,78 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newRepo(t *testing.T) string {
+	t.Helper()
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func TestAppendAndLast(t *testing.T) {
+	t.Parallel()
+
+	repo := newRepo(t)
+	if e, err := Last(repo); e != nil || err != nil {
+		t.Fatalf("Last() of an empty journal = %v, %v", e, err)
+	}
+
+	first := Entry{Time: time.Unix(100, 0).UTC(), Before: "a", Local: "a", After: "b", Pulled: Range{From: "x", To: "b"}}
+	second := Entry{Time: time.Unix(200, 0).UTC(), Before: "b", Local: "c", After: "c", Pushed: Range{From: "b", To: "c"}}
+	for _, e := range []Entry{first, second} {
+		if err := Append(repo, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	last, err := Last(repo)
+	if err != nil || last == nil || *last != second {
+		t.Errorf("Last() = %+v, %v, want %+v", last, err, second)
+	}
+}
+
+func TestAppendKeepsMaxEntries(t *testing.T) {
+	t.Parallel()
+
+	repo := newRepo(t)
+	for i := range MaxEntries + 5 {
+		if err := Append(repo, Entry{Before: "a", After: string(rune('a' + i%26))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := read(repo)
+	if err != nil || len(entries) != MaxEntries {
+		t.Errorf("read() = %d entries, %v, want %d", len(entries), err, MaxEntries)
+	}
+}
+
+func TestReadSkipsDamagedLines(t *testing.T) {
+	t.Parallel()
+
+	repo := newRepo(t)
+	content := `{"before":"a","after":"b"}` + "\n{\"before\":\n" + `{"before":"b","after":"c"}` + "\n"
+	if err := os.WriteFile(Path(repo), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	last, err := Last(repo)
+	if err != nil || last == nil || last.After != "c" {
+		t.Errorf("Last() = %+v, %v", last, err)
+	}
+}
+
+func TestEntryChanged(t *testing.T) {
+	t.Parallel()
+
+	if (Entry{Before: "a", After: "a", Pulled: Range{From: "x", To: "x"}}).Changed() {
+		t.Error("a sync that moved nothing reports a change")
+	}
+	if !(Entry{Before: "a", After: "a", Pushed: Range{From: "x", To: "y"}}).Changed() {
+		t.Error("a sync that pushed reports no change")
+	}
+}
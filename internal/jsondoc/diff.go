@@ -0,0 +1,112 @@
+package jsondoc
+
+import "strconv"
+
+// Kinds of Change
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Change is a difference between two JSON documents at one path
+type Change struct {
+	// Path is the dotted key path, e.g. "permissions.allow"; array entries
+	// that were added or removed end in "[]", and the root is ""
+	Path string `json:"path"`
+	// Kind is Added, Removed, or Changed
+	Kind string `json:"kind"`
+	// Old and New are compact JSON renderings, empty on the side without a value
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+// Diff lists the differences between two parsed values, in document order.
+// Objects are compared key by key. Arrays whose entries were only added or
+// removed are reported per entry, so a new permission shows up on its own;
+// any other array change is reported as a change of the whole array.
+func Diff(a, b any) []Change {
+	return diff("", a, b, nil)
+}
+
+func diff(path string, a, b any, changes []Change) []Change {
+	if Equal(a, b) {
+		return changes
+	}
+	switch at := a.(type) {
+	case *Object:
+		bt, ok := b.(*Object)
+		if !ok {
+			break
+		}
+		for _, key := range at.keys {
+			bv, ok := bt.values[key]
+			if !ok {
+				changes = append(changes, Change{Path: join(path, key), Kind: Removed, Old: Compact(at.values[key])})
+				continue
+			}
+			changes = diff(join(path, key), at.values[key], bv, changes)
+		}
+		for _, key := range bt.keys {
+			if _, ok := at.values[key]; !ok {
+				changes = append(changes, Change{Path: join(path, key), Kind: Added, New: Compact(bt.values[key])})
+			}
+		}
+		return changes
+	case []any:
+		bt, ok := b.([]any)
+		if !ok {
+			break
+		}
+		if entries, ok := diffEntries(path, at, bt); ok {
+			return append(changes, entries...)
+		}
+	}
+	return append(changes, Change{Path: path, Kind: Changed, Old: Compact(a), New: Compact(b)})
+}
+
+// diffEntries reports the entries removed from a and added to b, provided
+// the entries both keep are in the same order
+func diffEntries(path string, a, b []any) ([]Change, bool) {
+	var kept []any
+	var changes []Change
+	for _, v := range a {
+		if containsValue(b, v) {
+			kept = append(kept, v)
+		} else {
+			changes = append(changes, Change{Path: path + "[]", Kind: Removed, Old: Compact(v)})
+		}
+	}
+	var order []any
+	for _, v := range b {
+		if containsValue(a, v) {
+			order = append(order, v)
+		} else {
+			changes = append(changes, Change{Path: path + "[]", Kind: Added, New: Compact(v)})
+		}
+	}
+	return changes, Equal(kept, order)
+}
+
+func containsValue(items []any, v any) bool {
+	for _, item := range items {
+		if Equal(item, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func join(path, key string) string {
+	// Keys that would make the path ambiguous are quoted
+	for _, r := range key {
+		if r == '.' || r == '[' || r == ' ' || r == '"' {
+			key = strconv.Quote(key)
+			break
+		}
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
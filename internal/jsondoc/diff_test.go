@@ -0,0 +1,50 @@
+package jsondoc
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	parse := func(s string) any {
+		v, err := Parse([]byte(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	old := parse(`{
+  "model": "sonnet",
+  "env": {"EDITOR": "vim", "DEBUG": "1"},
+  "permissions": {"allow": ["Bash(ls)", "Bash(git status)"]},
+  "hooks": ["a", "b"],
+  "enabledPlugins": {"a.b@market": true}
+}`)
+	updated := parse(`{
+  "model": "opus",
+  "env": {"EDITOR": "vim"},
+  "permissions": {"allow": ["Bash(ls)", "Bash(npm test)"]},
+  "hooks": ["b", "a"],
+  "enabledPlugins": {"a.b@market": true, "c.d@market": false}
+}`)
+
+	want := []Change{
+		{Path: "model", Kind: Changed, Old: `"sonnet"`, New: `"opus"`},
+		{Path: "env.DEBUG", Kind: Removed, Old: `"1"`},
+		{Path: "permissions.allow[]", Kind: Removed, Old: `"Bash(git status)"`},
+		{Path: "permissions.allow[]", Kind: Added, New: `"Bash(npm test)"`},
+		{Path: "hooks", Kind: Changed, Old: `["a","b"]`, New: `["b","a"]`},
+		{Path: `enabledPlugins."c.d@market"`, Kind: Added, New: "false"},
+	}
+	if got := Diff(old, updated); !slices.Equal(got, want) {
+		t.Errorf("Diff() =\n%v\nwant\n%v", got, want)
+	}
+	if got := Diff(old, old); len(got) != 0 {
+		t.Errorf("Diff() of equal documents = %v", got)
+	}
+	if got := Diff(parse(`[1]`), parse(`{"a": 1}`)); !slices.Equal(got, []Change{{Kind: Changed, Old: "[1]", New: `{"a":1}`}}) {
+		t.Errorf("Diff() of different types = %v", got)
+	}
+}
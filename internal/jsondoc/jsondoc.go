@@ -0,0 +1,291 @@
+// Package jsondoc parses and writes JSON documents while preserving object
+// key order and number formatting.
+//
+// Claude Code settings files are edited by hand and by tools; round-tripping
+// them through map[string]any would reorder every key. Values produced by
+// Parse are *Object, []any, string, json.Number, bool, or nil.
+package jsondoc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Object is a JSON object that remembers the order of its keys
+type Object struct {
+	values map[string]any
+	keys   []string
+}
+
+// NewObject creates an empty Object
+func NewObject() *Object {
+	return &Object{values: map[string]any{}}
+}
+
+// Keys returns the keys in document order
+func (o *Object) Keys() []string {
+	return append([]string(nil), o.keys...)
+}
+
+// Len returns the number of keys
+func (o *Object) Len() int {
+	return len(o.keys)
+}
+
+// Get returns the value for key and whether it exists
+func (o *Object) Get(key string) (any, bool) {
+	v, ok := o.values[key]
+	return v, ok
+}
+
+// Set stores a value, appending the key if it is new
+func (o *Object) Set(key string, value any) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// Delete removes a key if present
+func (o *Object) Delete(key string) {
+	if _, ok := o.values[key]; !ok {
+		return
+	}
+	delete(o.values, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Parse decodes a JSON document, keeping object key order
+func Parse(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	v, err := parseValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return v, nil
+}
+
+// ParseObject decodes a JSON document that must be an object. Empty input
+// yields an empty object.
+func ParseObject(data []byte) (*Object, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return NewObject(), nil
+	}
+	v, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := v.(*Object)
+	if !ok {
+		return nil, fmt.Errorf("expected a JSON object, got %s", TypeName(v))
+	}
+	return obj, nil
+}
+
+func parseValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			obj := NewObject()
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, ok := keyTok.(string)
+				if !ok {
+					return nil, fmt.Errorf("expected object key, got %v", keyTok)
+				}
+				value, err := parseValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				obj.Set(key, value)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return obj, nil
+		case '[':
+			arr := []any{}
+			for dec.More() {
+				value, err := parseValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, value)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return arr, nil
+		}
+		return nil, fmt.Errorf("unexpected delimiter %v", t)
+	default:
+		return t, nil
+	}
+}
+
+// Marshal encodes a value with two-space indentation and a trailing newline,
+// the layout Claude Code uses for settings.json
+func Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeValue(&buf, v, ""); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func writeValue(buf *bytes.Buffer, v any, indent string) error {
+	switch t := v.(type) {
+	case *Object:
+		if t.Len() == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteString("{\n")
+		for i, key := range t.keys {
+			buf.WriteString(indent + "  ")
+			if err := writeScalar(buf, key); err != nil {
+				return err
+			}
+			buf.WriteString(": ")
+			if err := writeValue(buf, t.values[key], indent+"  "); err != nil {
+				return err
+			}
+			if i < len(t.keys)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "}")
+	case []any:
+		if len(t) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteString("[\n")
+		for i, item := range t {
+			buf.WriteString(indent + "  ")
+			if err := writeValue(buf, item, indent+"  "); err != nil {
+				return err
+			}
+			if i < len(t)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "]")
+	default:
+		return writeScalar(buf, t)
+	}
+	return nil
+}
+
+func writeScalar(buf *bytes.Buffer, v any) error {
+	var scalar bytes.Buffer
+	enc := json.NewEncoder(&scalar)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	buf.Write(bytes.TrimRight(scalar.Bytes(), "\n"))
+	return nil
+}
+
+// Equal reports whether two parsed values are deeply equal. Object key order
+// is ignored; array order is not.
+func Equal(a, b any) bool {
+	switch at := a.(type) {
+	case *Object:
+		bt, ok := b.(*Object)
+		if !ok || at.Len() != bt.Len() {
+			return false
+		}
+		for _, key := range at.keys {
+			bv, ok := bt.values[key]
+			if !ok || !Equal(at.values[key], bv) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		bt, ok := b.([]any)
+		if !ok || len(at) != len(bt) {
+			return false
+		}
+		for i := range at {
+			if !Equal(at[i], bt[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		bt, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if at == bt {
+			return true
+		}
+		af, aErr := at.Float64()
+		bf, bErr := bt.Float64()
+		return aErr == nil && bErr == nil && af == bf
+	default:
+		return a == b
+	}
+}
+
+// Compact renders a value as single-line JSON, for reports and diffs
+func Compact(v any) string {
+	data, err := Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return strings.TrimSpace(string(data))
+	}
+	return buf.String()
+}
+
+// TypeName returns the JSON type name of a parsed value
+func TypeName(v any) string {
+	switch v.(type) {
+	case *Object:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
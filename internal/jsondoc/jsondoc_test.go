@@ -0,0 +1,106 @@
+package jsondoc
+
+import (
+	"testing"
+)
+
+func TestRoundTripPreservesOrder(t *testing.T) {
+	t.Parallel()
+
+	input := `{
+  "model": "opus",
+  "env": {
+    "ZED": "1",
+    "ALPHA": "<b>&</b>"
+  },
+  "permissions": {
+    "allow": [
+      "Bash(ls:*)",
+      "Read"
+    ],
+    "deny": []
+  },
+  "cleanupPeriodDays": 30,
+  "ratio": 1.50,
+  "enabledPlugins": {},
+  "statusLine": null,
+  "includeCoAuthoredBy": false
+}
+`
+	v, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	out, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if string(out) != input {
+		t.Errorf("round trip changed the document:\n%s", out)
+	}
+}
+
+func TestObjectSetDelete(t *testing.T) {
+	t.Parallel()
+
+	obj, err := ParseObject([]byte(`{"b": 1, "a": 2}`))
+	if err != nil {
+		t.Fatalf("ParseObject() error = %v", err)
+	}
+
+	obj.Set("c", "new")
+	obj.Set("b", "updated")
+	obj.Delete("a")
+	obj.Delete("missing")
+
+	if got := Compact(obj); got != `{"b":"updated","c":"new"}` {
+		t.Errorf("Compact() = %s", got)
+	}
+}
+
+func TestParseObject(t *testing.T) {
+	t.Parallel()
+
+	if obj, err := ParseObject([]byte("  ")); err != nil || obj.Len() != 0 {
+		t.Errorf("ParseObject(empty) = %v, %v; want empty object", obj, err)
+	}
+	if _, err := ParseObject([]byte(`[1, 2]`)); err == nil {
+		t.Error("ParseObject() should reject arrays")
+	}
+	if _, err := Parse([]byte(`{"a": 1} {"b": 2}`)); err == nil {
+		t.Error("Parse() should reject trailing data")
+	}
+	if _, err := Parse([]byte(`{"a": `)); err == nil {
+		t.Error("Parse() should reject truncated input")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	t.Parallel()
+
+	parse := func(s string) any {
+		v, err := Parse([]byte(s))
+		if err != nil {
+			t.Fatalf("Parse(%s) error = %v", s, err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{`{"a": 1, "b": [1, 2]}`, `{"b": [1, 2], "a": 1}`, true},
+		{`{"a": 1}`, `{"a": 1.0}`, true},
+		{`[1, 2]`, `[2, 1]`, false},
+		{`{"a": null}`, `{}`, false},
+		{`"x"`, `"x"`, true},
+	}
+
+	for _, tt := range tests {
+		if got := Equal(parse(tt.a), parse(tt.b)); got != tt.want {
+			t.Errorf("Equal(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Package jsonmerge performs three-way merges of JSON documents such as
+// Claude Code's settings.json.
+//
+// Keys changed on only one side are taken from that side, keys changed
+// identically on both sides are kept, and collections that are naturally
+// additive (enabled plugins and permission lists) are unioned. Only a key
+// that really changed differently on both sides is reported as a conflict.
+package jsonmerge
+
+import (
+	"fmt"
+
+	"github.com/mfenderov/claude-sync/internal/jsondoc"
+)
+
+// unionArrays lists array paths whose entries are merged as sets
+var unionArrays = map[string]bool{
+	"permissions.allow":                 true,
+	"permissions.deny":                  true,
+	"permissions.ask":                   true,
+	"permissions.additionalDirectories": true,
+}
+
+// unionObjects lists object paths whose boolean members are merged by
+// keeping a member enabled if either side enabled it
+var unionObjects = map[string]bool{
+	"enabledPlugins": true,
+}
+
+// Conflict describes a key that changed differently on both sides
+type Conflict struct {
+	// Path is the dotted key path, e.g. "permissions.defaultMode"
+	Path string
+	// Ours and Theirs are compact JSON renderings; "(deleted)" when removed
+	Ours   string
+	Theirs string
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s (ours: %s, theirs: %s)", c.Path, c.Ours, c.Theirs)
+}
+
+const deleted = "(deleted)"
+
+// Merge merges ours and theirs against their common ancestor base. A nil or
+// empty base means the file was added on both sides. The merged document is
+// written with the key order of ours, followed by keys only theirs added.
+// When conflicts are returned the merged document must not be used.
+func Merge(base, ours, theirs []byte) ([]byte, []Conflict, error) {
+	baseDoc, err := jsondoc.ParseObject(base)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse base version: %w", err)
+	}
+	oursDoc, err := jsondoc.ParseObject(ours)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse our version: %w", err)
+	}
+	theirsDoc, err := jsondoc.ParseObject(theirs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse their version: %w", err)
+	}
+
+	m := &merger{}
+	merged := m.object("", baseDoc, oursDoc, theirsDoc)
+	if len(m.conflicts) > 0 {
+		return nil, m.conflicts, nil
+	}
+
+	out, err := jsondoc.Marshal(merged)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to write merged document: %w", err)
+	}
+	return out, nil, nil
+}
+
+type merger struct {
+	conflicts []Conflict
+}
+
+func (m *merger) conflict(path string, ours, theirs any, hasOurs, hasTheirs bool) {
+	c := Conflict{Path: path, Ours: deleted, Theirs: deleted}
+	if hasOurs {
+		c.Ours = jsondoc.Compact(ours)
+	}
+	if hasTheirs {
+		c.Theirs = jsondoc.Compact(theirs)
+	}
+	m.conflicts = append(m.conflicts, c)
+}
+
+func (m *merger) object(path string, base, ours, theirs *jsondoc.Object) *jsondoc.Object {
+	if base == nil {
+		base = jsondoc.NewObject()
+	}
+
+	keys := ours.Keys()
+	for _, key := range theirs.Keys() {
+		if _, ok := ours.Get(key); !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	result := jsondoc.NewObject()
+	for _, key := range keys {
+		b, inBase := base.Get(key)
+		o, inOurs := ours.Get(key)
+		t, inTheirs := theirs.Get(key)
+		childPath := join(path, key)
+
+		switch {
+		case inOurs && inTheirs:
+			result.Set(key, m.value(childPath, unionObjects[path], b, o, t, inBase))
+		case inOurs:
+			if !inBase {
+				result.Set(key, o)
+			} else if !jsondoc.Equal(b, o) {
+				m.conflict(childPath, o, nil, true, false)
+			}
+		case inTheirs:
+			if !inBase {
+				result.Set(key, t)
+			} else if !jsondoc.Equal(b, t) {
+				m.conflict(childPath, nil, t, false, true)
+			}
+		}
+	}
+	return result
+}
+
+func (m *merger) value(path string, inUnionObject bool, base, ours, theirs any, inBase bool) any {
+	switch {
+	case jsondoc.Equal(ours, theirs):
+		return ours
+	case inBase && jsondoc.Equal(base, ours):
+		return theirs
+	case inBase && jsondoc.Equal(base, theirs):
+		return ours
+	}
+
+	if o, ok := ours.(*jsondoc.Object); ok {
+		if t, ok := theirs.(*jsondoc.Object); ok {
+			b, _ := base.(*jsondoc.Object)
+			return m.object(path, b, o, t)
+		}
+	}
+
+	if o, ok := ours.([]any); ok && unionArrays[path] {
+		if t, ok := theirs.([]any); ok {
+			b, _ := base.([]any)
+			return union(b, o, t)
+		}
+	}
+
+	if o, ok := ours.(bool); ok && inUnionObject {
+		if t, ok := theirs.(bool); ok {
+			return o || t
+		}
+	}
+
+	m.conflict(path, ours, theirs, true, true)
+	return ours
+}
+
+// union merges arrays as sets: entries either side added are kept, entries
+// either side removed are dropped, and the order of ours is preserved
+func union(base, ours, theirs []any) []any {
+	result := []any{}
+	for _, item := range ours {
+		if contains(base, item) && !contains(theirs, item) {
+			continue
+		}
+		result = append(result, item)
+	}
+	for _, item := range theirs {
+		if contains(ours, item) || contains(base, item) || contains(result, item) {
+			continue
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+func contains(items []any, v any) bool {
+	for _, item := range items {
+		if jsondoc.Equal(item, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,166 @@
+package jsonmerge
+
+import (
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		base   string
+		ours   string
+		theirs string
+		want   string
+	}{
+		{
+			name:   "each side enables a different plugin",
+			base:   `{"enabledPlugins": {"a@market": true}}`,
+			ours:   `{"enabledPlugins": {"a@market": true, "b@market": true}}`,
+			theirs: `{"enabledPlugins": {"a@market": true, "c@market": true}}`,
+			want: `{
+  "enabledPlugins": {
+    "a@market": true,
+    "b@market": true,
+    "c@market": true
+  }
+}
+`,
+		},
+		{
+			name:   "plugin enabled on one side and disabled on the other stays enabled",
+			base:   `{"enabledPlugins": {"a@market": false}}`,
+			ours:   `{"enabledPlugins": {"a@market": true}}`,
+			theirs: `{"enabledPlugins": {"a@market": false, "b@market": true}}`,
+			want: `{
+  "enabledPlugins": {
+    "a@market": true,
+    "b@market": true
+  }
+}
+`,
+		},
+		{
+			name:   "permissions are unioned and removals respected",
+			base:   `{"permissions": {"allow": ["Read", "Bash(ls:*)"], "deny": []}}`,
+			ours:   `{"permissions": {"allow": ["Read", "Bash(ls:*)", "Edit"], "deny": ["WebFetch"]}}`,
+			theirs: `{"permissions": {"allow": ["Read", "Grep"], "deny": ["Bash(rm:*)"]}}`,
+			want: `{
+  "permissions": {
+    "allow": [
+      "Read",
+      "Edit",
+      "Grep"
+    ],
+    "deny": [
+      "WebFetch",
+      "Bash(rm:*)"
+    ]
+  }
+}
+`,
+		},
+		{
+			name:   "non-overlapping keys are merged",
+			base:   `{"model": "sonnet", "env": {"A": "1"}}`,
+			ours:   `{"model": "opus", "env": {"A": "1"}}`,
+			theirs: `{"model": "sonnet", "env": {"A": "1", "B": "2"}, "cleanupPeriodDays": 7}`,
+			want: `{
+  "model": "opus",
+  "env": {
+    "A": "1",
+    "B": "2"
+  },
+  "cleanupPeriodDays": 7
+}
+`,
+		},
+		{
+			name:   "deletion on one side is kept",
+			base:   `{"model": "opus", "statusLine": {"type": "command"}}`,
+			ours:   `{"model": "opus"}`,
+			theirs: `{"model": "opus", "statusLine": {"type": "command"}, "theme": "dark"}`,
+			want: `{
+  "model": "opus",
+  "theme": "dark"
+}
+`,
+		},
+		{
+			name:   "file added on both sides",
+			base:   ``,
+			ours:   `{"permissions": {"allow": ["Read"]}}`,
+			theirs: `{"permissions": {"allow": ["Edit"]}, "model": "opus"}`,
+			want: `{
+  "permissions": {
+    "allow": [
+      "Read",
+      "Edit"
+    ]
+  },
+  "model": "opus"
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, conflicts, err := Merge([]byte(tt.base), []byte(tt.ours), []byte(tt.theirs))
+			if err != nil {
+				t.Fatalf("Merge() error = %v", err)
+			}
+			if len(conflicts) > 0 {
+				t.Fatalf("Merge() unexpected conflicts: %v", conflicts)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Merge() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerge_Conflicts(t *testing.T) {
+	t.Parallel()
+
+	base := `{"model": "sonnet", "env": {"A": "1"}, "hooks": {"Stop": []}, "enabledPlugins": {"a@m": true}}`
+	ours := `{"model": "opus", "env": {"A": "2"}, "hooks": {"Stop": [{"matcher": "x"}]}, "enabledPlugins": {}}`
+	theirs := `{"model": "haiku", "hooks": {"Stop": [{"matcher": "y"}]}, "enabledPlugins": {"a@m": false}}`
+
+	got, conflicts, err := Merge([]byte(base), []byte(ours), []byte(theirs))
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if got != nil {
+		t.Errorf("Merge() should not return a document on conflict, got %s", got)
+	}
+
+	want := []Conflict{
+		{Path: "model", Ours: `"opus"`, Theirs: `"haiku"`},
+		{Path: "env", Ours: `{"A":"2"}`, Theirs: deleted},
+		{Path: "hooks.Stop", Ours: `[{"matcher":"x"}]`, Theirs: `[{"matcher":"y"}]`},
+		{Path: "enabledPlugins.a@m", Ours: deleted, Theirs: "false"},
+	}
+	if len(conflicts) != len(want) {
+		t.Fatalf("Merge() conflicts = %v, want %v", conflicts, want)
+	}
+	for i := range want {
+		if conflicts[i] != want[i] {
+			t.Errorf("conflict %d = %+v, want %+v", i, conflicts[i], want[i])
+		}
+	}
+}
+
+func TestMerge_InvalidJSON(t *testing.T) {
+	t.Parallel()
+
+	if _, _, err := Merge([]byte(`{}`), []byte(`{"a": `), []byte(`{}`)); err == nil {
+		t.Error("Merge() should fail on invalid JSON")
+	}
+	if _, _, err := Merge([]byte(`{}`), []byte(`{}`), []byte(`[]`)); err == nil {
+		t.Error("Merge() should fail when a side is not an object")
+	}
+}
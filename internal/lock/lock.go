@@ -0,0 +1,249 @@
+// Package lock provides a cross-process lock file that keeps concurrent
+// claude-sync runs (a session hook, a cron job, a manual run) from operating
+// on the same repository at once.
+//
+// The lock file records who holds it. A lock whose process has died on this
+// host, or that is older than StaleAfter on another host, is reclaimed.
+package lock
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// FileName is the lock file name inside the repository's .git directory
+const FileName = "claude-sync.lock"
+
+// Defaults for Options
+const (
+	DefaultStaleAfter   = time.Hour
+	DefaultPollInterval = 250 * time.Millisecond
+)
+
+// Path returns the lock file path for a repository
+func Path(repoPath string) string {
+	return filepath.Join(repoPath, ".git", FileName)
+}
+
+// Info describes the process holding a lock
+type Info struct {
+	Started time.Time `json:"started"`
+	Host    string    `json:"host"`
+	Command string    `json:"command,omitempty"`
+	PID     int       `json:"pid"`
+}
+
+func (i Info) String() string {
+	s := fmt.Sprintf("pid %d on %s since %s", i.PID, i.Host, i.Started.Local().Format("2006-01-02 15:04:05"))
+	if i.Command != "" {
+		s += " (" + i.Command + ")"
+	}
+	return s
+}
+
+// HeldError is returned when the lock is held by another process
+type HeldError struct {
+	Path   string
+	Holder Info
+}
+
+var _ error = &HeldError{}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("another sync is running: locked by %s (lock file: %s)", e.Holder, e.Path)
+}
+
+// Options configures Acquire
+type Options struct {
+	// OnWait is called once, with the current holder, when Acquire starts waiting
+	OnWait func(holder Info)
+	// Wait makes Acquire wait for the lock instead of failing immediately
+	Wait bool
+	// Timeout bounds the wait; zero waits until the context is done
+	Timeout time.Duration
+	// StaleAfter is the age after which a lock held from another host is
+	// considered abandoned; defaults to DefaultStaleAfter
+	StaleAfter time.Duration
+	// PollInterval defaults to DefaultPollInterval
+	PollInterval time.Duration
+}
+
+// Lock is a held lock file
+type Lock struct {
+	path string
+	info Info
+}
+
+// Acquire takes the lock at path, reclaiming it if it is stale
+func Acquire(ctx context.Context, path string, opts Options) (*Lock, error) {
+	if opts.StaleAfter <= 0 {
+		opts.StaleAfter = DefaultStaleAfter
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	if opts.Wait && opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	info := currentProcess()
+	waiting := false
+	for {
+		held, err := tryCreate(path, info)
+		if err == nil {
+			return &Lock{path: path, info: info}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		data, holder, readErr := read(path)
+		if errors.Is(readErr, os.ErrNotExist) {
+			// Released between our create and read
+			continue
+		}
+		if isStale(holder, readErr, held, opts.StaleAfter) {
+			if err := reclaim(path, data); err != nil {
+				return nil, fmt.Errorf("failed to remove stale lock: %w", err)
+			}
+			continue
+		}
+
+		heldErr := &HeldError{Path: path, Holder: holder}
+		if !opts.Wait {
+			return nil, heldErr
+		}
+		if !waiting && opts.OnWait != nil {
+			opts.OnWait(holder)
+		}
+		waiting = true
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("gave up waiting: %w", heldErr)
+		case <-time.After(opts.PollInterval):
+		}
+	}
+}
+
+// Release removes the lock file if this process still owns it
+func (l *Lock) Release() error {
+	holder, err := Read(l.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if holder.PID != l.info.PID || holder.Host != l.info.Host || !holder.Started.Equal(l.info.Started) {
+		// Reclaimed as stale by someone else; not ours to remove
+		return nil
+	}
+	return os.Remove(l.path)
+}
+
+// Read returns the holder recorded in a lock file
+func Read(path string) (Info, error) {
+	_, info, err := read(path)
+	return info, err
+}
+
+// read returns a lock file's contents and the holder recorded in them
+func read(path string) ([]byte, Info, error) {
+	var info Info
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, info, err
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return data, info, fmt.Errorf("corrupt lock file %s: %w", path, err)
+	}
+	return data, info, nil
+}
+
+// graves numbers the names reclaim moves lock files to, so reclaims in one
+// process never collide
+var graves atomic.Int64
+
+// reclaim removes a lock file judged stale from its contents. Another
+// process may have reclaimed it first and taken the lock since, so the file
+// is moved aside atomically and removed only if it is still the stale one;
+// otherwise the new holder's lock is linked back in place. Linking never
+// replaces a file, unlike renaming.
+func reclaim(path string, stale []byte) error {
+	grave := fmt.Sprintf("%s.stale-%d-%d", path, os.Getpid(), graves.Add(1))
+	if err := os.Rename(path, grave); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// Already reclaimed or released
+			return nil
+		}
+		return err
+	}
+	data, err := os.ReadFile(grave)
+	if err == nil && !bytes.Equal(data, stale) {
+		if err := os.Link(grave, path); err != nil && !errors.Is(err, os.ErrExist) {
+			return err
+		}
+	}
+	return os.Remove(grave)
+}
+
+// tryCreate creates the lock file exclusively. On failure it returns the
+// modification time of the existing file, if any.
+func tryCreate(path string, info Info) (time.Time, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		var modTime time.Time
+		if stat, statErr := os.Stat(path); statErr == nil {
+			modTime = stat.ModTime()
+		}
+		return modTime, err
+	}
+
+	data, err := json.Marshal(info)
+	if err == nil {
+		_, err = f.Write(append(data, '\n'))
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return time.Time{}, err
+	}
+	return time.Time{}, nil
+}
+
+// isStale decides whether an existing lock can be reclaimed
+func isStale(holder Info, readErr error, modTime time.Time, staleAfter time.Duration) bool {
+	if readErr != nil {
+		// Unreadable: may be mid-write, so only reclaim once it has aged
+		return !modTime.IsZero() && time.Since(modTime) > 10*time.Second
+	}
+
+	host, _ := os.Hostname()
+	if holder.Host == host {
+		return !processAlive(holder.PID)
+	}
+	return time.Since(holder.Started) > staleAfter
+}
+
+func currentProcess() Info {
+	host, _ := os.Hostname()
+	return Info{
+		PID:     os.Getpid(),
+		Host:    host,
+		Started: time.Now().UTC().Truncate(time.Millisecond),
+		Command: strings.Join(append([]string{filepath.Base(os.Args[0])}, os.Args[1:]...), " "),
+	}
+}
@@ -0,0 +1,249 @@
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func writeLock(t *testing.T, path string, info Info) {
+	t.Helper()
+	data, err := json.Marshal(info)
+	if err != nil {
+		t.Fatalf("Failed to marshal lock: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("Failed to write lock: %v", err)
+	}
+}
+
+// deadPID returns the PID of a process that has already exited
+func deadPID(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("cannot run helper process: %v", err)
+	}
+	return cmd.Process.Pid
+}
+
+func TestAcquireAndRelease(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), FileName)
+	ctx := context.Background()
+
+	l, err := Acquire(ctx, path, Options{})
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	holder, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if holder.PID != os.Getpid() || holder.Host == "" || holder.Started.IsZero() {
+		t.Errorf("Read() = %+v, want this process", holder)
+	}
+
+	_, err = Acquire(ctx, path, Options{})
+	var heldErr *HeldError
+	if !errors.As(err, &heldErr) {
+		t.Fatalf("second Acquire() error = %v, want HeldError", err)
+	}
+	if !strings.Contains(err.Error(), "another sync is running") || heldErr.Holder.PID != os.Getpid() {
+		t.Errorf("HeldError = %v", err)
+	}
+
+	if err := l.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Release() should remove the lock file")
+	}
+}
+
+func TestAcquire_ReclaimsStaleLocks(t *testing.T) {
+	t.Parallel()
+
+	host, _ := os.Hostname()
+	tests := []struct {
+		name    string
+		holder  Info
+		reclaim bool
+	}{
+		{"dead process on this host", Info{PID: deadPID(t), Host: host, Started: time.Now()}, true},
+		{"old lock from another host", Info{PID: 1, Host: "other-host", Started: time.Now().Add(-2 * time.Hour)}, true},
+		{"recent lock from another host", Info{PID: 1, Host: "other-host", Started: time.Now()}, false},
+		{"live process on this host", Info{PID: os.Getppid(), Host: host, Started: time.Now().Add(-48 * time.Hour)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), FileName)
+			writeLock(t, path, tt.holder)
+
+			l, err := Acquire(context.Background(), path, Options{})
+			if tt.reclaim {
+				if err != nil {
+					t.Fatalf("Acquire() error = %v, want stale lock reclaimed", err)
+				}
+				_ = l.Release()
+				return
+			}
+			var heldErr *HeldError
+			if !errors.As(err, &heldErr) || heldErr.Holder.Host != tt.holder.Host {
+				t.Errorf("Acquire() error = %v, want HeldError for %s", err, tt.holder.Host)
+			}
+		})
+	}
+}
+
+func TestAcquire_ConcurrentReclaim(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), FileName)
+	stale := Info{PID: 1, Host: "other-host", Started: time.Now().Add(-2 * time.Hour)}
+	ctx := context.Background()
+
+	// Two reclaimers judge the same lock stale. The first takes the lock
+	// before the second acts on its judgement, which must leave it alone.
+	writeLock(t, path, stale)
+	staleData, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := Acquire(ctx, path, Options{})
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if err := reclaim(path, staleData); err != nil {
+		t.Fatalf("reclaim() error = %v", err)
+	}
+	if holder, err := Read(path); err != nil || !holder.Started.Equal(first.info.Started) {
+		t.Fatalf("lock after a late reclaim = %+v, %v, want the first reclaimer's", holder, err)
+	}
+	if _, err := Acquire(ctx, path, Options{}); err == nil {
+		t.Fatal("Acquire() succeeded while the first reclaimer holds the lock")
+	}
+	if err := first.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+
+	// Racing for real, only one reclaimer at a time holds the lock
+	for range 50 {
+		writeLock(t, path, stale)
+		var holders, overlaps atomic.Int32
+		var wg sync.WaitGroup
+		for range 2 {
+			wg.Go(func() {
+				l, err := Acquire(ctx, path, Options{Wait: true, PollInterval: time.Millisecond})
+				if err != nil {
+					t.Errorf("Acquire() error = %v", err)
+					return
+				}
+				if holders.Add(1) > 1 {
+					overlaps.Add(1)
+				}
+				time.Sleep(time.Millisecond)
+				holders.Add(-1)
+				if err := l.Release(); err != nil {
+					t.Errorf("Release() error = %v", err)
+				}
+			})
+		}
+		wg.Wait()
+		if n := overlaps.Load(); n > 0 {
+			t.Fatalf("%d reclaimers held the lock at the same time", n)
+		}
+	}
+
+	leftovers, err := filepath.Glob(path + ".*")
+	if err != nil || len(leftovers) != 0 {
+		t.Errorf("reclaiming left %v behind, %v", leftovers, err)
+	}
+}
+
+func TestAcquire_Wait(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), FileName)
+	ctx := context.Background()
+
+	first, err := Acquire(ctx, path, Options{})
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	var waitedFor Info
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = first.Release()
+	}()
+
+	second, err := Acquire(ctx, path, Options{
+		Wait:         true,
+		Timeout:      5 * time.Second,
+		PollInterval: 10 * time.Millisecond,
+		OnWait:       func(holder Info) { waitedFor = holder },
+	})
+	if err != nil {
+		t.Fatalf("Acquire() with wait error = %v", err)
+	}
+	defer second.Release()
+
+	if waitedFor.PID != os.Getpid() {
+		t.Errorf("OnWait holder = %+v, want this process", waitedFor)
+	}
+}
+
+func TestAcquire_WaitTimeout(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), FileName)
+	writeLock(t, path, Info{PID: 1, Host: "other-host", Started: time.Now()})
+
+	start := time.Now()
+	_, err := Acquire(context.Background(), path, Options{
+		Wait:         true,
+		Timeout:      50 * time.Millisecond,
+		PollInterval: 10 * time.Millisecond,
+	})
+	var heldErr *HeldError
+	if !errors.As(err, &heldErr) {
+		t.Fatalf("Acquire() error = %v, want HeldError after timeout", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Error("Acquire() did not honour the timeout")
+	}
+}
+
+func TestRelease_LeavesReclaimedLock(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), FileName)
+	l, err := Acquire(context.Background(), path, Options{})
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	// Another process reclaimed the lock in the meantime
+	writeLock(t, path, Info{PID: 1, Host: "other-host", Started: time.Now()})
+
+	if err := l.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Error("Release() removed a lock it no longer owns")
+	}
+}
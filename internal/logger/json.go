@@ -0,0 +1,116 @@
+package logger
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event is a single NDJSON record written by JSONLogger
+type Event struct {
+	Time     time.Time      `json:"time"`
+	Fields   map[string]any `json:"fields,omitempty"`
+	Phase    string         `json:"phase"`
+	Severity string         `json:"severity"`
+	Type     string         `json:"type"`
+	Icon     string         `json:"icon,omitempty"`
+	Message  string         `json:"message,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// JSONLogger writes every log call as one JSON object per line (NDJSON)
+// for dashboards, shell prompts, and other machine consumers
+//
+//nolint:govet // fieldalignment: struct field order optimized for readability
+type JSONLogger struct {
+	mu    sync.Mutex
+	enc   *json.Encoder
+	phase string
+	now   func() time.Time
+}
+
+// NewJSON creates a JSONLogger writing to w
+func NewJSON(w io.Writer) *JSONLogger {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &JSONLogger{
+		enc:   enc,
+		phase: "start",
+		now:   time.Now,
+	}
+}
+
+// Phase sets the phase attached to subsequent events
+func (l *JSONLogger) Phase(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.phase = name
+}
+
+// Data writes structured fields for the current phase
+func (l *JSONLogger) Data(fields map[string]any) {
+	l.write(Event{Type: "data", Severity: "info", Fields: fields})
+}
+
+// Title writes a title event
+func (l *JSONLogger) Title(title string) {
+	l.write(Event{Type: "title", Severity: "info", Message: strings.TrimSpace(title)})
+}
+
+// Success writes a success event
+func (l *JSONLogger) Success(icon, message string) {
+	l.write(Event{Type: "message", Severity: "success", Icon: icon, Message: message})
+}
+
+// Error writes an error event including the underlying error
+func (l *JSONLogger) Error(icon, message string, err error) {
+	e := Event{Type: "message", Severity: "error", Icon: icon, Message: message}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	l.write(e)
+}
+
+// Warning writes a warning event
+func (l *JSONLogger) Warning(icon, message string) {
+	l.write(Event{Type: "message", Severity: "warning", Icon: icon, Message: message})
+}
+
+// Info writes an info event
+func (l *JSONLogger) Info(icon, message string) {
+	l.write(Event{Type: "message", Severity: "info", Icon: icon, Message: message})
+}
+
+// Muted writes a low-priority detail event
+func (l *JSONLogger) Muted(message string) {
+	l.write(Event{Type: "detail", Severity: "debug", Message: strings.TrimSpace(message)})
+}
+
+// ListItem writes a list item event
+func (l *JSONLogger) ListItem(message string) {
+	l.write(Event{Type: "list_item", Severity: "info", Message: message})
+}
+
+// Box writes a box event with its content split into lines
+func (l *JSONLogger) Box(title, content string) {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	l.write(Event{Type: "box", Severity: "info", Message: title, Fields: map[string]any{"lines": lines}})
+}
+
+// Newline is a no-op: blank lines carry no information for machines
+func (l *JSONLogger) Newline() {}
+
+func (l *JSONLogger) write(e Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e.Time = l.now()
+	e.Phase = l.phase
+	_ = l.enc.Encode(e)
+}
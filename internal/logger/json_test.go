@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONLogger(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	l := NewJSON(&buf)
+	l.now = func() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	l.Title("🎭 Claude Config Sync")
+	l.Phase("commit")
+	l.Data(map[string]any{"changed_files": []string{"settings.json"}})
+	l.Success("✓", "Changes committed")
+	l.Newline()
+	l.Phase("push")
+	l.Error("✗", "Failed to push", errors.New("network error"))
+	l.Box("Recent Activity", "  abc123 First\n\n  def456 Second\n")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected 5 NDJSON lines (newline is dropped), got %d:\n%s", len(lines), buf.String())
+	}
+
+	var events []Event
+	for _, line := range lines {
+		var e Event
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("line is not valid JSON: %q: %v", line, err)
+		}
+		events = append(events, e)
+	}
+
+	if events[0].Phase != "start" || events[0].Type != "title" || events[0].Message != "🎭 Claude Config Sync" {
+		t.Errorf("unexpected title event: %+v", events[0])
+	}
+	if events[1].Phase != "commit" || events[1].Type != "data" || events[1].Fields["changed_files"] == nil {
+		t.Errorf("unexpected data event: %+v", events[1])
+	}
+	if events[2].Severity != "success" || events[2].Phase != "commit" {
+		t.Errorf("unexpected success event: %+v", events[2])
+	}
+	if events[3].Severity != "error" || events[3].Error != "network error" || events[3].Phase != "push" {
+		t.Errorf("unexpected error event: %+v", events[3])
+	}
+	if lines := events[4].Fields["lines"].([]any); len(lines) != 2 || lines[0] != "abc123 First" {
+		t.Errorf("unexpected box lines: %v", events[4].Fields["lines"])
+	}
+}
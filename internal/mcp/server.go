@@ -0,0 +1,280 @@
+// Package mcp implements a Model Context Protocol server over stdio, so
+// Claude can call claude-sync tools from inside a session.
+//
+// Messages are JSON-RPC 2.0 objects, one per line. The server answers
+// initialize, ping, tools/list, and tools/call, and ignores notifications.
+// Tools return structured results, sent both as structuredContent and as
+// JSON text for clients that only read text content.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+)
+
+// ProtocolVersion is the newest protocol revision the server speaks
+const ProtocolVersion = "2025-06-18"
+
+// supportedVersions are the protocol revisions the server accepts, newest first
+var supportedVersions = []string{ProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// maxMessageSize bounds a single incoming message
+const maxMessageSize = 16 << 20
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Handler runs a tool with its JSON arguments and returns a JSON-encodable
+// object as the result
+type Handler func(ctx context.Context, args json.RawMessage) (any, error)
+
+// Tool is a tool the server offers
+type Tool struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// InputSchema is the JSON Schema of the arguments
+	InputSchema map[string]any `json:"inputSchema"`
+	Handler     Handler        `json:"-"`
+}
+
+// Server serves tools to one client
+//
+//nolint:govet // fieldalignment: struct field order optimized for readability
+type Server struct {
+	name         string
+	version      string
+	instructions string
+	tools        []Tool
+	mu           sync.Mutex
+}
+
+// NewServer creates a server that introduces itself with name and version.
+// Instructions tell the client when to use the tools.
+func NewServer(name, version, instructions string) *Server {
+	return &Server{name: name, version: version, instructions: instructions}
+}
+
+// AddTool registers a tool
+func (s *Server) AddTool(t Tool) {
+	s.tools = append(s.tools, t)
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// content is a text content block of a tool result
+type content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// callResult is the result of tools/call
+type callResult struct {
+	Content           []content `json:"content"`
+	StructuredContent any       `json:"structuredContent,omitempty"`
+	IsError           bool      `json:"isError,omitempty"`
+}
+
+// Serve reads requests from r and writes responses to w until r ends or ctx
+// is cancelled. Requests are handled one at a time, in order.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxMessageSize)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		resp, ok := s.handle(ctx, line)
+		if !ok {
+			continue
+		}
+		if err := enc.Encode(resp); err != nil {
+			return fmt.Errorf("failed to write response: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read request: %w", err)
+	}
+	return nil
+}
+
+// handle answers one message. It reports false for notifications, which get
+// no response.
+func (s *Server) handle(ctx context.Context, line []byte) (response, bool) {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return failure(nil, &rpcError{Code: codeParseError, Message: "parse error: " + err.Error()}), true
+	}
+	if req.ID == nil {
+		return response{}, false
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return failure(req.ID, &rpcError{Code: codeInvalidRequest, Message: "invalid request"}), true
+	}
+
+	result, err := s.dispatch(ctx, req)
+	if err != nil {
+		var rpcErr *rpcError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		return failure(req.ID, rpcErr), true
+	}
+	return response{JSONRPC: "2.0", ID: req.ID, Result: result}, true
+}
+
+func (s *Server) dispatch(ctx context.Context, req request) (any, error) {
+	switch req.Method {
+	case "initialize":
+		return s.initialize(req.Params)
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return map[string]any{"tools": s.tools}, nil
+	case "tools/call":
+		return s.call(ctx, req.Params)
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+func (s *Server) initialize(params json.RawMessage) (any, error) {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid initialize params: %w", err)
+		}
+	}
+	// Answer with the client's revision when we speak it, else our newest
+	version := ProtocolVersion
+	if slices.Contains(supportedVersions, p.ProtocolVersion) {
+		version = p.ProtocolVersion
+	}
+
+	result := map[string]any{
+		"protocolVersion": version,
+		"capabilities":    map[string]any{"tools": map[string]any{}},
+		"serverInfo":      map[string]any{"name": s.name, "version": s.version},
+	}
+	if s.instructions != "" {
+		result["instructions"] = s.instructions
+	}
+	return result, nil
+}
+
+// call runs a tool. Failures of the tool itself are reported in the result,
+// so the model sees them; unknown tools and bad arguments are protocol errors.
+func (s *Server) call(ctx context.Context, params json.RawMessage) (any, error) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid tools/call params: %w", err)
+	}
+	i := slices.IndexFunc(s.tools, func(t Tool) bool { return t.Name == p.Name })
+	if i < 0 {
+		return nil, fmt.Errorf("unknown tool: %s", p.Name)
+	}
+	args := p.Arguments
+	if len(args) == 0 || string(args) == "null" {
+		args = json.RawMessage("{}")
+	}
+
+	// Tools change the same repository, so one runs at a time
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, err := s.tools[i].Handler(ctx, args)
+
+	var invalid *InvalidArgumentsError
+	if errors.As(err, &invalid) {
+		return nil, invalid
+	}
+	result := callResult{StructuredContent: value, IsError: err != nil}
+	if value != nil {
+		text, merr := json.MarshalIndent(value, "", "  ")
+		if merr != nil {
+			return nil, fmt.Errorf("failed to encode the result of %s: %w", p.Name, merr)
+		}
+		result.Content = append(result.Content, content{Type: "text", Text: string(text)})
+	}
+	if err != nil {
+		result.Content = append(result.Content, content{Type: "text", Text: "Error: " + err.Error()})
+	}
+	if result.Content == nil {
+		result.Content = []content{}
+	}
+	return result, nil
+}
+
+func failure(id json.RawMessage, err *rpcError) response {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return response{JSONRPC: "2.0", ID: id, Error: err}
+}
+
+// InvalidArgumentsError reports tool arguments that do not match the tool's
+// input schema
+type InvalidArgumentsError struct {
+	Err error
+}
+
+func (e *InvalidArgumentsError) Error() string {
+	return "invalid arguments: " + e.Err.Error()
+}
+
+func (e *InvalidArgumentsError) Unwrap() error {
+	return e.Err
+}
+
+// Decode unmarshals tool arguments into v, rejecting unknown fields
+func Decode(args json.RawMessage, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(args))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return &InvalidArgumentsError{Err: err}
+	}
+	return nil
+}
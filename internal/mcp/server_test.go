@@ -0,0 +1,145 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newTestServer() *Server {
+	s := NewServer("claude-sync", "1.2.3", "Use these tools to sync")
+	s.AddTool(Tool{
+		Name:        "echo",
+		Description: "Echoes its text",
+		InputSchema: map[string]any{"type": "object"},
+		Handler: func(_ context.Context, args json.RawMessage) (any, error) {
+			var a struct {
+				Text string `json:"text"`
+			}
+			if err := Decode(args, &a); err != nil {
+				return nil, err
+			}
+			if a.Text == "fail" {
+				return map[string]any{"partial": true}, errors.New("it failed")
+			}
+			return map[string]any{"text": a.Text}, nil
+		},
+	})
+	return s
+}
+
+// exchange sends requests, one per line, and returns the decoded responses
+func exchange(t *testing.T, s *Server, requests ...string) []map[string]any {
+	t.Helper()
+	var out strings.Builder
+	if err := s.Serve(context.Background(), strings.NewReader(strings.Join(requests, "\n")+"\n"), &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+	var responses []map[string]any
+	scanner := bufio.NewScanner(strings.NewReader(out.String()))
+	for scanner.Scan() {
+		var resp map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			t.Fatalf("response %q is not JSON: %v", scanner.Text(), err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func TestServeHandshakeAndTools(t *testing.T) {
+	t.Parallel()
+
+	responses := exchange(t, newTestServer(),
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"0"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":"three","method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"ping"}`,
+	)
+	if len(responses) != 4 {
+		t.Fatalf("got %d responses, want 4 (none for the notification): %v", len(responses), responses)
+	}
+
+	init := responses[0]["result"].(map[string]any)
+	if init["protocolVersion"] != "2025-03-26" {
+		t.Errorf("protocolVersion = %v, want the client's", init["protocolVersion"])
+	}
+	if info := init["serverInfo"].(map[string]any); info["name"] != "claude-sync" || info["version"] != "1.2.3" {
+		t.Errorf("serverInfo = %v", info)
+	}
+	if init["instructions"] != "Use these tools to sync" {
+		t.Errorf("instructions = %v", init["instructions"])
+	}
+
+	tools := responses[1]["result"].(map[string]any)["tools"].([]any)
+	if len(tools) != 1 || tools[0].(map[string]any)["name"] != "echo" || tools[0].(map[string]any)["inputSchema"] == nil {
+		t.Errorf("tools/list = %v", tools)
+	}
+
+	if responses[2]["id"] != "three" {
+		t.Errorf("id = %v, want it echoed", responses[2]["id"])
+	}
+	call := responses[2]["result"].(map[string]any)
+	if call["structuredContent"].(map[string]any)["text"] != "hi" || call["isError"] != nil {
+		t.Errorf("tools/call = %v", call)
+	}
+	text := call["content"].([]any)[0].(map[string]any)["text"].(string)
+	if !strings.Contains(text, `"text": "hi"`) {
+		t.Errorf("text content = %q, want the result as JSON", text)
+	}
+
+	if _, ok := responses[3]["result"].(map[string]any); !ok {
+		t.Errorf("ping = %v", responses[3])
+	}
+}
+
+func TestServeErrors(t *testing.T) {
+	t.Parallel()
+
+	responses := exchange(t, newTestServer(),
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"1999-01-01"}}`,
+		`not json`,
+		`{"jsonrpc":"2.0","id":2,"method":"resources/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"missing"}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"echo","arguments":{"txt":"typo"}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"echo","arguments":{"text":"fail"}}}`,
+	)
+	if len(responses) != 6 {
+		t.Fatalf("got %d responses, want 6: %v", len(responses), responses)
+	}
+
+	if v := responses[0]["result"].(map[string]any)["protocolVersion"]; v != ProtocolVersion {
+		t.Errorf("protocolVersion = %v, want %s for an unknown revision", v, ProtocolVersion)
+	}
+	code := func(resp map[string]any) float64 {
+		e, _ := resp["error"].(map[string]any)
+		c, _ := e["code"].(float64)
+		return c
+	}
+	if code(responses[1]) != codeParseError || responses[1]["id"] != nil {
+		t.Errorf("parse error response = %v", responses[1])
+	}
+	if code(responses[2]) != codeMethodNotFound {
+		t.Errorf("unknown method response = %v", responses[2])
+	}
+	if code(responses[3]) != codeInvalidParams {
+		t.Errorf("unknown tool response = %v", responses[3])
+	}
+	if code(responses[4]) != codeInvalidParams {
+		t.Errorf("unknown argument response = %v", responses[4])
+	}
+
+	// A failing tool is a result the model can read, not a protocol error
+	call := responses[5]["result"].(map[string]any)
+	if call["isError"] != true || call["structuredContent"].(map[string]any)["partial"] != true {
+		t.Errorf("failed tools/call = %v", call)
+	}
+	blocks := call["content"].([]any)
+	if last := blocks[len(blocks)-1].(map[string]any)["text"]; last != "Error: it failed" {
+		t.Errorf("error content = %v", last)
+	}
+}
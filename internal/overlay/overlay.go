@@ -0,0 +1,446 @@
+// Package overlay builds per-machine files from committed layers.
+//
+// A target such as settings.json is listed under overlays in
+// .claude-sync.yaml. Its content comes from layers next to it, applied in
+// order:
+//
+//	settings.base.json    shared by every machine
+//	settings.linux.json   this operating system (linux, darwin, windows)
+//	settings.laptop.json  this host (short hostname, or $CLAUDE_SYNC_HOST)
+//
+// JSON layers are deep-merged: objects are merged key by key, other values
+// (including arrays) replace the value below, and null removes a key. For
+// other files, such as hook scripts, the most specific layer is used as is.
+// Only the layers are committed; the target is built on each machine.
+//
+// Local edits of a target are written back before committing: a changed
+// JSON value goes to the most specific layer that sets it, or to the base,
+// and an edited file goes to the layer it was built from.
+package overlay
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/mfenderov/claude-sync/internal/jsondoc"
+)
+
+// HostEnv overrides the host name used to select host layers
+const HostEnv = "CLAUDE_SYNC_HOST"
+
+// BaseName selects the layer shared by every machine
+const BaseName = "base"
+
+// stateDir keeps the last built content of each target, to tell local edits
+// from changes pulled into the layers
+var stateDir = filepath.Join(".git", "claude-sync-overlays")
+
+// Selectors name the layers that apply to this machine
+type Selectors struct {
+	OS   string
+	Host string
+}
+
+// CurrentSelectors returns the selectors of this machine
+func CurrentSelectors() (Selectors, error) {
+	host := os.Getenv(HostEnv)
+	if host == "" {
+		name, err := os.Hostname()
+		if err != nil {
+			return Selectors{}, fmt.Errorf("failed to get host name: %w", err)
+		}
+		host, _, _ = strings.Cut(name, ".")
+	}
+	return Selectors{OS: runtime.GOOS, Host: strings.ToLower(host)}, nil
+}
+
+// LayerName returns the name of a target's layer for a selector, for
+// example settings.linux.json for settings.json and "linux"
+func LayerName(target, selector string) string {
+	dir, file := path.Split(target)
+	ext := path.Ext(file)
+	if ext == file {
+		// Dotfiles such as .mcp have no extension to keep at the end
+		ext = ""
+	}
+	return dir + strings.TrimSuffix(file, ext) + "." + selector + ext
+}
+
+// Layers returns the layers of a target that apply to this machine, from
+// least to most specific
+func (s Selectors) Layers(target string) []string {
+	layers := []string{LayerName(target, BaseName)}
+	for _, selector := range []string{s.OS, s.Host} {
+		if selector != "" && selector != BaseName {
+			layers = append(layers, LayerName(target, selector))
+		}
+	}
+	return layers
+}
+
+// Builder builds the targets of one repository
+type Builder struct {
+	repoPath string
+	targets  []string
+	sel      Selectors
+}
+
+// New creates a Builder for targets relative to repoPath
+func New(repoPath string, targets []string, sel Selectors) *Builder {
+	return &Builder{repoPath: repoPath, targets: targets, sel: sel}
+}
+
+// layer is one existing layer file of a target
+type layer struct {
+	name string
+	data []byte
+	mode fs.FileMode
+}
+
+// Apply builds every target from its layers and returns the targets whose
+// file changed. Targets without layers are left alone.
+func (b *Builder) Apply() ([]string, error) {
+	var written []string
+	for _, target := range b.targets {
+		layers, err := b.readLayers(target)
+		if err != nil {
+			return written, err
+		}
+		if len(layers) == 0 {
+			continue
+		}
+		built, mode, err := build(target, layers)
+		if err != nil {
+			return written, err
+		}
+		changed, err := b.writeTarget(target, built, mode)
+		if err != nil {
+			return written, err
+		}
+		if err := b.saveState(target, built); err != nil {
+			return written, err
+		}
+		if changed {
+			written = append(written, target)
+		}
+	}
+	return written, nil
+}
+
+// Capture writes local edits of targets back into their layers and returns
+// the layers written. A target without layers becomes the base layer, which
+// is how an existing file is turned into a target. Targets are then rebuilt,
+// so edits made to the layers directly show up too.
+func (b *Builder) Capture() ([]string, error) {
+	var written []string
+	for _, target := range b.targets {
+		layers, err := b.captureTarget(target)
+		written = append(written, layers...)
+		if err != nil {
+			return written, err
+		}
+	}
+	_, err := b.Apply()
+	return written, err
+}
+
+func (b *Builder) captureTarget(target string) ([]string, error) {
+	live, err := os.ReadFile(b.path(target))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	layers, err := b.readLayers(target)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(layers) == 0 {
+		base := LayerName(target, BaseName)
+		info, err := os.Stat(b.path(target))
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(b.path(base), live, info.Mode().Perm()); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", base, err)
+		}
+		return []string{base}, b.saveState(target, live)
+	}
+
+	built, _, err := build(target, layers)
+	if err != nil {
+		return nil, err
+	}
+	// Without a record of the last build (e.g. the first sync after the
+	// layers were pulled), differences from the layers count as local edits
+	// so nothing on this machine is lost
+	previous, err := b.loadState(target)
+	if err != nil {
+		previous = built
+	}
+	if bytes.Equal(live, previous) {
+		return nil, nil
+	}
+
+	var changed []string
+	if isJSON(target) {
+		changed, err = b.captureJSON(target, layers, previous, live)
+	} else {
+		top := layers[len(layers)-1]
+		if !bytes.Equal(top.data, live) {
+			err = os.WriteFile(b.path(top.name), live, top.mode)
+			changed = []string{top.name}
+		}
+	}
+	if err != nil {
+		return changed, err
+	}
+	return changed, b.saveState(target, live)
+}
+
+// captureJSON applies the differences between the previous build and the
+// live file to the layers
+func (b *Builder) captureJSON(target string, layers []layer, previous, live []byte) ([]string, error) {
+	before, err := jsondoc.ParseObject(previous)
+	if err != nil {
+		// An unreadable previous build: compare with the layers instead
+		built, _, buildErr := build(target, layers)
+		if buildErr != nil {
+			return nil, buildErr
+		}
+		if before, err = jsondoc.ParseObject(built); err != nil {
+			return nil, err
+		}
+	}
+	after, err := jsondoc.ParseObject(live)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", target, err)
+	}
+
+	docs := make([]*jsondoc.Object, len(layers))
+	for i, l := range layers {
+		if docs[i], err = jsondoc.ParseObject(l.data); err != nil {
+			return nil, fmt.Errorf("%s: %w", l.name, err)
+		}
+	}
+	touched := make([]bool, len(layers))
+	for _, c := range diff(nil, before, after) {
+		if c.deleted {
+			for i, doc := range docs {
+				if deletePath(doc, c.path) {
+					touched[i] = true
+				}
+			}
+			continue
+		}
+		i := 0
+		for j := len(docs) - 1; j > 0; j-- {
+			if _, ok := lookup(docs[j], c.path); ok {
+				i = j
+				break
+			}
+		}
+		setPath(docs[i], c.path, c.value)
+		touched[i] = true
+	}
+
+	var written []string
+	for i, doc := range docs {
+		if !touched[i] {
+			continue
+		}
+		data, err := jsondoc.Marshal(doc)
+		if err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(b.path(layers[i].name), data, layers[i].mode); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", layers[i].name, err)
+		}
+		written = append(written, layers[i].name)
+	}
+	return written, nil
+}
+
+func (b *Builder) readLayers(target string) ([]layer, error) {
+	var layers []layer
+	for _, name := range b.sel.Layers(target) {
+		info, err := os.Stat(b.path(name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(b.path(name))
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer{name: name, data: data, mode: info.Mode().Perm()})
+	}
+	return layers, nil
+}
+
+// build merges JSON layers, or picks the most specific layer of other files
+func build(target string, layers []layer) ([]byte, fs.FileMode, error) {
+	top := layers[len(layers)-1]
+	if !isJSON(target) {
+		return top.data, top.mode, nil
+	}
+
+	merged := jsondoc.NewObject()
+	for _, l := range layers {
+		doc, err := jsondoc.ParseObject(l.data)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", l.name, err)
+		}
+		mergeObject(merged, doc)
+	}
+	data, err := jsondoc.Marshal(merged)
+	return data, layers[0].mode, err
+}
+
+// mergeObject deep-merges src into dst; null in src removes the key
+func mergeObject(dst, src *jsondoc.Object) {
+	for _, key := range src.Keys() {
+		value, _ := src.Get(key)
+		if value == nil {
+			dst.Delete(key)
+			continue
+		}
+		srcObj, srcIsObj := value.(*jsondoc.Object)
+		current, _ := dst.Get(key)
+		if dstObj, ok := current.(*jsondoc.Object); ok && srcIsObj {
+			mergeObject(dstObj, srcObj)
+			continue
+		}
+		if srcIsObj {
+			// Copy so later layers never modify a parsed layer
+			copied := jsondoc.NewObject()
+			mergeObject(copied, srcObj)
+			value = copied
+		}
+		dst.Set(key, value)
+	}
+}
+
+// change is one value that differs between two documents
+type change struct {
+	value   any
+	path    []string
+	deleted bool
+}
+
+// diff lists the leaf values that differ from before to after; nested
+// objects are compared key by key, everything else as a whole
+func diff(prefix []string, before, after *jsondoc.Object) []change {
+	var changes []change
+	for _, key := range after.Keys() {
+		p := append(append([]string{}, prefix...), key)
+		a, _ := after.Get(key)
+		old, existed := before.Get(key)
+		if existed && jsondoc.Equal(old, a) {
+			continue
+		}
+		oldObj, oldIsObj := old.(*jsondoc.Object)
+		newObj, newIsObj := a.(*jsondoc.Object)
+		if existed && oldIsObj && newIsObj {
+			changes = append(changes, diff(p, oldObj, newObj)...)
+			continue
+		}
+		changes = append(changes, change{path: p, value: a})
+	}
+	for _, key := range before.Keys() {
+		if _, ok := after.Get(key); !ok {
+			changes = append(changes, change{path: append(append([]string{}, prefix...), key), deleted: true})
+		}
+	}
+	return changes
+}
+
+func lookup(doc *jsondoc.Object, p []string) (any, bool) {
+	var current any = doc
+	for _, key := range p {
+		obj, ok := current.(*jsondoc.Object)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = obj.Get(key); !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func setPath(doc *jsondoc.Object, p []string, value any) {
+	obj := doc
+	for _, key := range p[:len(p)-1] {
+		next, ok := obj.Get(key)
+		child, isObj := next.(*jsondoc.Object)
+		if !ok || !isObj {
+			child = jsondoc.NewObject()
+			obj.Set(key, child)
+		}
+		obj = child
+	}
+	obj.Set(p[len(p)-1], value)
+}
+
+func deletePath(doc *jsondoc.Object, p []string) bool {
+	parent, ok := lookup(doc, p[:len(p)-1])
+	obj, isObj := parent.(*jsondoc.Object)
+	if !ok || !isObj {
+		return false
+	}
+	if _, ok := obj.Get(p[len(p)-1]); !ok {
+		return false
+	}
+	obj.Delete(p[len(p)-1])
+	return true
+}
+
+// writeTarget writes a built target unless it already has that content
+func (b *Builder) writeTarget(target string, data []byte, mode fs.FileMode) (bool, error) {
+	if current, err := os.ReadFile(b.path(target)); err == nil && bytes.Equal(current, data) {
+		if info, err := os.Stat(b.path(target)); err == nil && info.Mode().Perm() != mode {
+			return false, os.Chmod(b.path(target), mode)
+		}
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(b.path(target)), 0o755); err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(b.path(target), data, mode); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	// WriteFile keeps the mode of an existing file
+	return true, os.Chmod(b.path(target), mode)
+}
+
+func (b *Builder) loadState(target string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(b.repoPath, stateDir, filepath.FromSlash(target)))
+}
+
+func (b *Builder) saveState(target string, data []byte) error {
+	statePath := filepath.Join(b.repoPath, stateDir, filepath.FromSlash(target))
+	if err := os.MkdirAll(filepath.Dir(statePath), 0o755); err != nil {
+		return fmt.Errorf("failed to save overlay state: %w", err)
+	}
+	return os.WriteFile(statePath, data, 0o600)
+}
+
+func (b *Builder) path(name string) string {
+	return filepath.Join(b.repoPath, filepath.FromSlash(name))
+}
+
+func isJSON(target string) bool {
+	return strings.EqualFold(path.Ext(target), ".json")
+}
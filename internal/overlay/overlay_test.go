@@ -0,0 +1,215 @@
+package overlay
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var linuxLaptop = Selectors{OS: "linux", Host: "laptop"}
+
+func writeFile(t *testing.T, repo, name, content string) {
+	t.Helper()
+	path := filepath.Join(repo, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, repo, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(repo, filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestLayerName(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"settings.json":        "settings.linux.json",
+		"hooks/notify.sh":      "hooks/notify.linux.sh",
+		"hooks/run":            "hooks/run.linux",
+		".mcp":                 ".mcp.linux",
+		"agents/a.b/CLAUDE.md": "agents/a.b/CLAUDE.linux.md",
+	}
+	for target, want := range tests {
+		if got := LayerName(target, "linux"); got != want {
+			t.Errorf("LayerName(%q) = %q, want %q", target, got, want)
+		}
+	}
+
+	layers := linuxLaptop.Layers("settings.json")
+	if !slices.Equal(layers, []string{"settings.base.json", "settings.linux.json", "settings.laptop.json"}) {
+		t.Errorf("Layers() = %v", layers)
+	}
+}
+
+func TestApply_MergesJSONLayers(t *testing.T) {
+	t.Parallel()
+
+	repo := t.TempDir()
+	writeFile(t, repo, "settings.base.json", `{
+  "model": "sonnet",
+  "env": {"EDITOR": "vim", "PROXY": "http://proxy"},
+  "permissions": {"allow": ["Bash(ls)"]}
+}`)
+	writeFile(t, repo, "settings.linux.json", `{"env": {"SHELL": "/bin/bash"}, "permissions": {"allow": ["Bash(apt)"]}}`)
+	writeFile(t, repo, "settings.laptop.json", `{"model": "opus", "env": {"PROXY": null}}`)
+	writeFile(t, repo, "settings.darwin.json", `{"model": "haiku"}`)
+
+	b := New(repo, []string{"settings.json"}, linuxLaptop)
+	written, err := b.Apply()
+	if err != nil || !slices.Equal(written, []string{"settings.json"}) {
+		t.Fatalf("Apply() = %v, %v", written, err)
+	}
+	want := `{
+  "model": "opus",
+  "env": {
+    "EDITOR": "vim",
+    "SHELL": "/bin/bash"
+  },
+  "permissions": {
+    "allow": [
+      "Bash(apt)"
+    ]
+  }
+}
+`
+	if got := readFile(t, repo, "settings.json"); got != want {
+		t.Errorf("settings.json =\n%s\nwant\n%s", got, want)
+	}
+	if got := readFile(t, repo, "settings.base.json"); !containsAll(got, "PROXY", "sonnet") {
+		t.Errorf("Apply() modified the base layer: %s", got)
+	}
+
+	if written, err := b.Apply(); err != nil || len(written) != 0 {
+		t.Errorf("second Apply() = %v, %v, want nothing written", written, err)
+	}
+}
+
+func TestApply_SelectsMostSpecificFile(t *testing.T) {
+	t.Parallel()
+
+	repo := t.TempDir()
+	writeFile(t, repo, "hooks/notify.base.sh", "notify-send done\n")
+	writeFile(t, repo, "hooks/notify.darwin.sh", "osascript -e done\n")
+	if err := os.Chmod(filepath.Join(repo, "hooks/notify.base.sh"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := New(repo, []string{"hooks/notify.sh"}, linuxLaptop).Apply(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, repo, "hooks/notify.sh"); got != "notify-send done\n" {
+		t.Errorf("linux hook = %q", got)
+	}
+	info, err := os.Stat(filepath.Join(repo, "hooks/notify.sh"))
+	if err != nil || info.Mode().Perm() != 0o755 {
+		t.Errorf("hook mode = %v, %v, want 0755", info, err)
+	}
+
+	if _, err := New(repo, []string{"hooks/notify.sh"}, Selectors{OS: "darwin", Host: "mac"}).Apply(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, repo, "hooks/notify.sh"); got != "osascript -e done\n" {
+		t.Errorf("darwin hook = %q", got)
+	}
+}
+
+func TestCapture_TurnsFileIntoBaseLayer(t *testing.T) {
+	t.Parallel()
+
+	repo := t.TempDir()
+	writeFile(t, repo, "settings.json", "{\n  \"model\": \"sonnet\"\n}\n")
+
+	written, err := New(repo, []string{"settings.json", "missing.json"}, linuxLaptop).Capture()
+	if err != nil || !slices.Equal(written, []string{"settings.base.json"}) {
+		t.Fatalf("Capture() = %v, %v", written, err)
+	}
+	if got := readFile(t, repo, "settings.base.json"); got != "{\n  \"model\": \"sonnet\"\n}\n" {
+		t.Errorf("settings.base.json = %q", got)
+	}
+}
+
+func TestCapture_WritesEditsToLayers(t *testing.T) {
+	t.Parallel()
+
+	repo := t.TempDir()
+	writeFile(t, repo, "settings.base.json", `{"model": "sonnet", "env": {"EDITOR": "vim"}, "theme": "dark"}`)
+	writeFile(t, repo, "settings.laptop.json", `{"env": {"PROXY": "http://proxy"}}`)
+	b := New(repo, []string{"settings.json"}, linuxLaptop)
+	if _, err := b.Apply(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Claude Code edits the built file: a host value, a new value, and a removal
+	writeFile(t, repo, "settings.json", `{"model": "sonnet", "env": {"EDITOR": "vim", "PROXY": "http://other", "DEBUG": "1"}}`)
+	written, err := b.Capture()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(written, []string{"settings.base.json", "settings.laptop.json"}) {
+		t.Errorf("Capture() = %v", written)
+	}
+
+	base := readFile(t, repo, "settings.base.json")
+	if !containsAll(base, `"DEBUG": "1"`, `"EDITOR": "vim"`) || strings.Contains(base, "theme") || strings.Contains(base, "PROXY") {
+		t.Errorf("settings.base.json =\n%s", base)
+	}
+	if host := readFile(t, repo, "settings.laptop.json"); !containsAll(host, `"PROXY": "http://other"`) || strings.Contains(host, "DEBUG") {
+		t.Errorf("settings.laptop.json =\n%s", host)
+	}
+
+	// Nothing changed since, so nothing is captured
+	if written, err := b.Capture(); err != nil || len(written) != 0 {
+		t.Errorf("second Capture() = %v, %v", written, err)
+	}
+}
+
+func TestCapture_KeepsEditsWithoutState(t *testing.T) {
+	t.Parallel()
+
+	// The layers were pulled while the file was edited, and nothing was built yet
+	repo := t.TempDir()
+	writeFile(t, repo, "hooks/notify.base.sh", "old\n")
+	writeFile(t, repo, "hooks/notify.linux.sh", "linux\n")
+	writeFile(t, repo, "hooks/notify.sh", "edited\n")
+
+	written, err := New(repo, []string{"hooks/notify.sh"}, linuxLaptop).Capture()
+	if err != nil || !slices.Equal(written, []string{"hooks/notify.linux.sh"}) {
+		t.Fatalf("Capture() = %v, %v", written, err)
+	}
+	if got := readFile(t, repo, "hooks/notify.linux.sh"); got != "edited\n" {
+		t.Errorf("linux layer = %q", got)
+	}
+	if got := readFile(t, repo, "hooks/notify.base.sh"); got != "old\n" {
+		t.Errorf("base layer = %q", got)
+	}
+}
+
+func TestApply_InvalidLayer(t *testing.T) {
+	t.Parallel()
+
+	repo := t.TempDir()
+	writeFile(t, repo, "settings.base.json", `{"model": `)
+	if _, err := New(repo, []string{"settings.json"}, linuxLaptop).Apply(); err == nil {
+		t.Error("Apply() with an invalid layer succeeded")
+	}
+}
+
+func containsAll(s string, parts ...string) bool {
+	for _, part := range parts {
+		if !strings.Contains(s, part) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,234 @@
+package prompts
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/mfenderov/claude-sync/internal/textdiff"
+	"github.com/mfenderov/claude-sync/internal/ui"
+)
+
+// ConflictChoice is the user's decision for a conflicted file
+type ConflictChoice string
+
+const (
+	// KeepRemote keeps the version from the remote
+	KeepRemote ConflictChoice = "remote"
+	// KeepLocal keeps the version from this machine
+	KeepLocal ConflictChoice = "local"
+	// EditFile opens the file with conflict markers in $EDITOR
+	EditFile ConflictChoice = "edit"
+	// AbortResolution stops resolving and restores the previous state
+	AbortResolution ConflictChoice = ""
+)
+
+// ConflictView describes the conflicted file shown by ResolveConflict
+type ConflictView struct {
+	// Notice is an optional message shown above the diff
+	Notice string
+	// Files lists every conflicted file; Current indexes the one shown
+	Files   []string
+	Remote  []byte
+	Local   []byte
+	Current int
+	// RemoteDeleted and LocalDeleted mark a side that removed the file
+	RemoteDeleted bool
+	LocalDeleted  bool
+}
+
+// conflictModel is a model for the side-by-side conflict resolver
+//
+//nolint:govet // fieldalignment: struct field order optimized for readability
+type conflictModel struct {
+	view   ConflictView
+	rows   []textdiff.Row
+	offset int
+	width  int
+	height int
+	choice ConflictChoice
+	done   bool
+}
+
+// Init implements tea.Model
+func (m conflictModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model
+func (m conflictModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.offset = min(m.offset, m.maxOffset())
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			m.offset = max(m.offset-1, 0)
+		case "down", "j":
+			m.offset = min(m.offset+1, m.maxOffset())
+		case "pgup", "b":
+			m.offset = max(m.offset-m.diffHeight(), 0)
+		case "pgdown", " ", "f":
+			m.offset = min(m.offset+m.diffHeight(), m.maxOffset())
+		case "n":
+			m.offset = m.nextChange()
+		case "r":
+			return m.finish(KeepRemote)
+		case "l":
+			return m.finish(KeepLocal)
+		case "e":
+			return m.finish(EditFile)
+		case "q", "ctrl+c", "esc":
+			return m.finish(AbortResolution)
+		}
+	}
+	return m, nil
+}
+
+func (m conflictModel) finish(choice ConflictChoice) (tea.Model, tea.Cmd) {
+	m.choice = choice
+	m.done = true
+	return m, tea.Quit
+}
+
+// diffHeight is the number of diff rows that fit on screen
+func (m conflictModel) diffHeight() int {
+	// Title, file list, column header and help line take the rest
+	return max(m.height-len(m.view.Files)-8, 5)
+}
+
+func (m conflictModel) maxOffset() int {
+	return max(len(m.rows)-m.diffHeight(), 0)
+}
+
+// nextChange returns the offset of the next changed block below the top row
+func (m conflictModel) nextChange() int {
+	for i := m.offset + 1; i < len(m.rows); i++ {
+		if m.rows[i].Changed() && !m.rows[i-1].Changed() {
+			return min(i, m.maxOffset())
+		}
+	}
+	return m.offset
+}
+
+// View implements tea.Model
+func (m conflictModel) View() string {
+	if m.done {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(ui.HeaderStyle.Render(fmt.Sprintf("Resolve merge conflicts (%d/%d)", m.view.Current+1, len(m.view.Files))))
+	b.WriteString("\n")
+
+	for i, file := range m.view.Files {
+		switch {
+		case i < m.view.Current:
+			b.WriteString(ui.SuccessStyle.Render("  ✓ ") + ui.MutedStyle.Render(file))
+		case i == m.view.Current:
+			b.WriteString(ui.PrimaryStyle.Render("  ▸ ") + ui.InfoStyle.Render(file))
+		default:
+			b.WriteString(ui.MutedStyle.Render("  • " + file))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	if m.view.Notice != "" {
+		b.WriteString(ui.RenderWarning("⚠️", m.view.Notice))
+		b.WriteString("\n\n")
+	}
+
+	column := max((m.width-3)/2, 20)
+	remoteTitle, localTitle := "remote", "local (this machine)"
+	if m.view.RemoteDeleted {
+		remoteTitle += " - deleted"
+	}
+	if m.view.LocalDeleted {
+		localTitle += " - deleted"
+	}
+	b.WriteString(ui.PrimaryStyle.Render(fit(remoteTitle, column)))
+	b.WriteString(ui.MutedStyle.Render(" │ "))
+	b.WriteString(ui.PrimaryStyle.Render(fit(localTitle, column)))
+	b.WriteString("\n")
+
+	end := min(m.offset+m.diffHeight(), len(m.rows))
+	for _, row := range m.rows[m.offset:end] {
+		left, right := fit(row.Old, column), fit(row.New, column)
+		if row.Changed() {
+			left, right = ui.DiffRemovedStyle.Render(left), ui.DiffAddedStyle.Render(right)
+		} else {
+			left, right = ui.MutedStyle.Render(left), ui.MutedStyle.Render(right)
+		}
+		b.WriteString(left + ui.MutedStyle.Render(" │ ") + right + "\n")
+	}
+	if len(m.rows) == 0 {
+		b.WriteString(ui.MutedStyle.Render("  (both versions are empty)") + "\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(ui.MutedStyle.Render(
+		"(↑/↓ scroll, n next change, r keep remote, l keep local, e open in $EDITOR, q abort)"))
+
+	return b.String()
+}
+
+// fit truncates or pads text to exactly width columns
+func fit(text string, width int) string {
+	runes := []rune(strings.ReplaceAll(text, "\t", "    "))
+	if len(runes) > width {
+		return string(runes[:width-1]) + "…"
+	}
+	return string(runes) + strings.Repeat(" ", width-len(runes))
+}
+
+// ResolveConflict shows a side-by-side diff of a conflicted file and asks
+// which version to keep. AbortResolution is returned when the user cancels.
+func ResolveConflict(view ConflictView) (ConflictChoice, error) {
+	m := conflictModel{
+		view:   view,
+		rows:   textdiff.SideBySide(textdiff.Lines(textdiff.SplitLines(view.Remote), textdiff.SplitLines(view.Local))),
+		width:  100,
+		height: 30,
+	}
+
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	finalModel, err := p.Run()
+	if err != nil {
+		return AbortResolution, err
+	}
+
+	finalConflict, ok := finalModel.(conflictModel)
+	if !ok {
+		return AbortResolution, fmt.Errorf("unexpected model type: %T", finalModel)
+	}
+	return finalConflict.choice, nil
+}
+
+// OpenEditor opens a file in $VISUAL or $EDITOR (falling back to vi) and
+// waits for the editor to exit
+func OpenEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// Allow editors with arguments, e.g. "code --wait"
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+	return nil
+}
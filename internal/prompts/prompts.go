@@ -5,15 +5,23 @@ package prompts
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/term"
 
 	"github.com/mfenderov/claude-sync/internal/ui"
 )
 
+// IsTerminal reports whether both stdin and stdout are attached to a terminal,
+// which the interactive prompts require
+func IsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
 // confirmModel is a model for yes/no confirmation prompts
 type confirmModel struct {
 	prompt   string
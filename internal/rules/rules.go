@@ -0,0 +1,253 @@
+// Package rules decides which paths in the Claude directory are synced.
+//
+// Rules live in .claude-sync.yaml at the root of the config repository:
+//
+//	include:
+//	  - settings.json
+//	  - agents/**
+//	exclude:
+//	  - projects/**
+//	  - "*.log"
+//
+// Patterns are gitignore-style globs relative to the repository root. A
+// pattern without a slash matches a name at any depth, "**" matches any
+// number of directories, and a pattern that matches a directory also matches
+// everything below it. A path is synced when it matches no exclude pattern
+// and either the include list is empty or one of its patterns matches.
+//
+// Files listed under overlays are built on each machine from committed
+// layers (see package overlay) and are never synced themselves:
+//
+//	overlays:
+//	  - settings.json
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// FileName is the rules file at the root of the config repository
+const FileName = ".claude-sync.yaml"
+
+// alwaysSynced paths describe the sync itself and are never filtered out.
+// Features add the files they keep in the repository with AlwaysSync.
+var alwaysSynced = []string{FileName, ".gitignore"}
+
+// AlwaysSync registers files at the repository root that configure the sync
+// itself, such as the secret scanner's allowlist, so that no rule filters
+// them out. The packages that own the files call it from init.
+func AlwaysSync(files ...string) {
+	alwaysSynced = append(alwaysSynced, files...)
+}
+
+// Set is a parsed rules file
+type Set struct {
+	Include  []string `yaml:"include"`
+	Exclude  []string `yaml:"exclude"`
+	Overlays []string `yaml:"overlays"`
+}
+
+// Decision explains whether a path is synced and which rule decided it
+type Decision struct {
+	// Rule is the deciding pattern, prefixed with "include: " or
+	// "exclude: ", or a short explanation when no pattern matched
+	Rule   string
+	Synced bool
+}
+
+// Load reads the rules file from a repository. A missing file yields an
+// empty set, which syncs everything.
+func Load(repoPath string) (*Set, error) {
+	data, err := os.ReadFile(filepath.Join(repoPath, FileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return &Set{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", FileName, err)
+	}
+	return Parse(data)
+}
+
+// Parse parses and validates rules file content
+func Parse(data []byte) (*Set, error) {
+	var set Set
+	if err := yaml.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", FileName, err)
+	}
+	for _, pattern := range append(append([]string{}, set.Include...), set.Exclude...) {
+		if err := validate(pattern); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", FileName, err)
+		}
+	}
+	for i, target := range set.Overlays {
+		clean := path.Clean(strings.TrimSpace(target))
+		if clean == "." || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") || strings.ContainsAny(clean, `*?[\`) {
+			return nil, fmt.Errorf("invalid %s: overlay %q: must be a file path relative to the repository", FileName, target)
+		}
+		set.Overlays[i] = clean
+	}
+	return &set, nil
+}
+
+func validate(pattern string) error {
+	if strings.TrimSpace(pattern) == "" || strings.Trim(pattern, "/") == "" {
+		return fmt.Errorf("empty pattern")
+	}
+	if strings.HasPrefix(pattern, "!") {
+		return fmt.Errorf("pattern %q: negation is not supported, use include and exclude instead", pattern)
+	}
+	for _, segment := range segments(pattern) {
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Empty reports whether the set has no rules, so everything is synced
+func (s *Set) Empty() bool {
+	return len(s.Include) == 0 && len(s.Exclude) == 0 && len(s.Overlays) == 0
+}
+
+// Match decides whether a slash-separated path relative to the repository
+// is synced. isDir marks a directory, which is included when an include
+// pattern could match something inside it.
+func (s *Set) Match(p string, isDir bool) Decision {
+	p = strings.Trim(p, "/")
+	for _, always := range alwaysSynced {
+		if p == always {
+			return Decision{Synced: true, Rule: "always synced"}
+		}
+	}
+	for _, target := range s.Overlays {
+		if p == target {
+			return Decision{Synced: false, Rule: "overlay: built from layers"}
+		}
+		if isLayer(target, p) {
+			return Decision{Synced: true, Rule: "overlay layer: " + target}
+		}
+	}
+
+	for _, pattern := range s.Exclude {
+		if matches(pattern, p) {
+			return Decision{Synced: false, Rule: "exclude: " + pattern}
+		}
+	}
+	if len(s.Include) == 0 {
+		return Decision{Synced: true, Rule: "no include rules"}
+	}
+	for _, pattern := range s.Include {
+		if matches(pattern, p) || (isDir && couldContain(pattern, p)) {
+			return Decision{Synced: true, Rule: "include: " + pattern}
+		}
+	}
+	return Decision{Synced: false, Rule: "matches no include rule"}
+}
+
+// Synced reports whether a file is synced
+func (s *Set) Synced(p string) bool {
+	return s.Match(p, false).Synced
+}
+
+// Filter returns the synced files from paths, keeping their order
+func (s *Set) Filter(paths []string) []string {
+	if s.Empty() {
+		return paths
+	}
+	var synced []string
+	for _, p := range paths {
+		if s.Synced(p) {
+			synced = append(synced, p)
+		}
+	}
+	return synced
+}
+
+// isLayer reports whether p is named like a layer of an overlay target, such
+// as settings.linux.json for settings.json
+func isLayer(target, p string) bool {
+	dir, file := path.Split(target)
+	ext := path.Ext(file)
+	if ext == file {
+		ext = ""
+	}
+	rest, ok := strings.CutPrefix(p, dir+strings.TrimSuffix(file, ext)+".")
+	if !ok {
+		return false
+	}
+	selector, ok := strings.CutSuffix(rest, ext)
+	return ok && selector != "" && !strings.ContainsAny(selector, "/.")
+}
+
+// MatchGlob reports whether a rules-style glob pattern matches a
+// slash-separated path or one of its parent directories
+func MatchGlob(pattern, p string) bool {
+	return matches(pattern, strings.Trim(p, "/"))
+}
+
+// segments splits a pattern into path segments. Unanchored patterns (no
+// slash except a trailing one) match at any depth.
+func segments(pattern string) []string {
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(strings.Trim(pattern, "/"), "/")
+	trimmed := strings.Trim(pattern, "/")
+	if !anchored {
+		trimmed = "**/" + trimmed
+	}
+	return strings.Split(trimmed, "/")
+}
+
+// matches reports whether pattern matches p or one of its parent directories
+func matches(pattern, p string) bool {
+	ps := segments(pattern)
+	ss := strings.Split(p, "/")
+	for n := 1; n <= len(ss); n++ {
+		if matchSegments(ps, ss[:n]) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(ps, ss []string) bool {
+	if len(ps) == 0 {
+		return len(ss) == 0
+	}
+	if ps[0] == "**" {
+		for i := 0; i <= len(ss); i++ {
+			if matchSegments(ps[1:], ss[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(ss) == 0 {
+		return false
+	}
+	ok, _ := path.Match(ps[0], ss[0])
+	return ok && matchSegments(ps[1:], ss[1:])
+}
+
+// couldContain reports whether pattern could match a path inside dir
+func couldContain(pattern, dir string) bool {
+	ps := segments(pattern)
+	for _, segment := range strings.Split(dir, "/") {
+		if len(ps) == 0 {
+			return false
+		}
+		if ps[0] == "**" {
+			return true
+		}
+		if ok, _ := path.Match(ps[0], segment); !ok {
+			return false
+		}
+		ps = ps[1:]
+	}
+	return true
+}
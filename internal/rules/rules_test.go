@@ -0,0 +1,156 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+
+	set := &Set{
+		Include: []string{"/settings.json", "agents/**", "/CLAUDE.md", "skills/*/SKILL.md"},
+		Exclude: []string{"*.log", "agents/scratch/"},
+	}
+
+	tests := []struct {
+		path   string
+		rule   string
+		isDir  bool
+		synced bool
+	}{
+		{path: "settings.json", synced: true, rule: "include: /settings.json"},
+		{path: "agents/reviewer.md", synced: true, rule: "include: agents/**"},
+		{path: "agents/deep/nested.md", synced: true, rule: "include: agents/**"},
+		{path: "agents/debug.log", synced: false, rule: "exclude: *.log"},
+		{path: "agents/scratch/notes.md", synced: false, rule: "exclude: agents/scratch/"},
+		{path: "agents/scratch/", isDir: true, synced: false, rule: "exclude: agents/scratch/"},
+		{path: "CLAUDE.md", synced: true, rule: "include: /CLAUDE.md"},
+		{path: "agents/CLAUDE.md", synced: true, rule: "include: agents/**"},
+		{path: "commands/CLAUDE.md", synced: false, rule: "matches no include rule"},
+		{path: "skills/go/SKILL.md", synced: true, rule: "include: skills/*/SKILL.md"},
+		{path: "skills/go/notes.md", synced: false, rule: "matches no include rule"},
+		{path: "skills/", isDir: true, synced: true, rule: "include: skills/*/SKILL.md"},
+		{path: "projects/", isDir: true, synced: false, rule: "matches no include rule"},
+		{path: ".claude-sync.yaml", synced: true, rule: "always synced"},
+		{path: ".gitignore", synced: true, rule: "always synced"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+			got := set.Match(tt.path, tt.isDir)
+			if got.Synced != tt.synced || got.Rule != tt.rule {
+				t.Errorf("Match(%q) = %+v, want synced=%v rule=%q", tt.path, got, tt.synced, tt.rule)
+			}
+		})
+	}
+}
+
+func TestMatchWithoutIncludes(t *testing.T) {
+	t.Parallel()
+
+	set := &Set{Exclude: []string{"projects/**", "**/cache"}}
+
+	if got := set.Match("settings.json", false); !got.Synced || got.Rule != "no include rules" {
+		t.Errorf("Match(settings.json) = %+v, want synced by default", got)
+	}
+	if set.Synced("projects/abc/session.jsonl") {
+		t.Error("projects/abc/session.jsonl should be excluded")
+	}
+	if set.Synced("plugins/cache/x/plugin.json") {
+		t.Error("plugins/cache/x/plugin.json should be excluded by **/cache")
+	}
+	if !set.Synced("projectsettings.json") {
+		t.Error("projectsettings.json should not match projects/**")
+	}
+}
+
+func TestMatchOverlays(t *testing.T) {
+	t.Parallel()
+
+	set := &Set{Include: []string{"/CLAUDE.md"}, Overlays: []string{"settings.json", "hooks/notify"}}
+
+	tests := []struct {
+		path   string
+		rule   string
+		synced bool
+	}{
+		{path: "settings.json", synced: false, rule: "overlay: built from layers"},
+		{path: "settings.base.json", synced: true, rule: "overlay layer: settings.json"},
+		{path: "settings.linux.json", synced: true, rule: "overlay layer: settings.json"},
+		{path: "settings.local.backup.json", synced: false, rule: "matches no include rule"},
+		{path: "hooks/notify.laptop", synced: true, rule: "overlay layer: hooks/notify"},
+		{path: "hooks/notify", synced: false, rule: "overlay: built from layers"},
+	}
+	for _, tt := range tests {
+		if got := set.Match(tt.path, false); got.Synced != tt.synced || got.Rule != tt.rule {
+			t.Errorf("Match(%q) = %+v, want synced=%v rule=%q", tt.path, got, tt.synced, tt.rule)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	set := &Set{Exclude: []string{"todos/**"}}
+	got := set.Filter([]string{"settings.json", "todos/a.json", "CLAUDE.md"})
+	if strings.Join(got, ",") != "settings.json,CLAUDE.md" {
+		t.Errorf("Filter() = %v", got)
+	}
+
+	empty := &Set{}
+	if got := empty.Filter([]string{"todos/a.json"}); len(got) != 1 {
+		t.Errorf("empty set Filter() = %v, want everything", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	set, err := Parse([]byte("include:\n  - agents/**\nexclude:\n  - \"*.log\"\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(set.Include) != 1 || len(set.Exclude) != 1 || set.Empty() {
+		t.Errorf("Parse() = %+v", set)
+	}
+
+	invalid := map[string]string{
+		"not yaml":      "include: [",
+		"negation":      "exclude:\n  - \"!keep.md\"\n",
+		"bad glob":      "include:\n  - \"agents/[\"\n",
+		"empty pattern": "exclude:\n  - \"/\"\n",
+		"wrong type":    "include: agents\n",
+		"overlay glob":  "overlays:\n  - \"*.json\"\n",
+		"overlay above": "overlays:\n  - ../settings.json\n",
+	}
+	for name, content := range invalid {
+		if _, err := Parse([]byte(content)); err == nil {
+			t.Errorf("Parse(%s) error = nil, want error", name)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	set, err := Load(dir)
+	if err != nil || !set.Empty() {
+		t.Fatalf("Load() without a rules file = %+v, %v; want empty set", set, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte("exclude:\n  - todos/**\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	set, err = Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if set.Synced("todos/a.json") {
+		t.Error("todos/a.json should be excluded")
+	}
+}
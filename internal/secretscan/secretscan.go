@@ -0,0 +1,315 @@
+// Package secretscan finds credentials in files before they are committed.
+//
+// Filenames alone do not catch a token pasted into settings.json or a hook
+// script, so the scanner looks at content: well-known token formats
+// (Anthropic, AWS, GitHub, GitLab, Slack), private key headers, and long
+// high-entropy strings. Findings can be allowed per file or per secret with
+// an allowlist file at the repository root.
+package secretscan
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mfenderov/claude-sync/internal/rules"
+	"github.com/mfenderov/claude-sync/internal/signing"
+)
+
+// AllowlistFile lists paths and fingerprints the scanner must not report
+const AllowlistFile = ".claude-sync-allowlist"
+
+func init() {
+	rules.AlwaysSync(AllowlistFile)
+}
+
+// publicFiles hold public keys only and are never scanned
+var publicFiles = []string{signing.FileName}
+
+// ScanLimit bounds how much of a file is scanned. Config files are far
+// smaller; only the start of a larger file is scanned.
+const ScanLimit = 1 << 20
+
+// Rule detects one kind of secret
+type Rule struct {
+	// Pattern matches the secret; the first capture group, if any, is the
+	// secret itself and the rest is context
+	Pattern     *regexp.Regexp
+	ID          string
+	Description string
+}
+
+// Rules are the content rules applied to every file, in addition to the
+// high-entropy check
+var Rules = []Rule{
+	{
+		ID:          "anthropic-api-key",
+		Description: "Anthropic API key",
+		Pattern:     regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`),
+	},
+	{
+		ID:          "aws-access-key-id",
+		Description: "AWS access key ID",
+		Pattern:     regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	},
+	{
+		ID:          "aws-secret-access-key",
+		Description: "AWS secret access key",
+		Pattern:     regexp.MustCompile(`(?i)aws.{0,20}secret.{0,20}?[=:]\s*["']?([A-Za-z0-9/+]{40})\b`),
+	},
+	{
+		ID:          "github-token",
+		Description: "GitHub token",
+		Pattern:     regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`),
+	},
+	{
+		ID:          "gitlab-token",
+		Description: "GitLab token",
+		Pattern:     regexp.MustCompile(`\bgl(?:pat|dt|rt|ptt)-[A-Za-z0-9_-]{20,}`),
+	},
+	{
+		ID:          "slack-token",
+		Description: "Slack token",
+		Pattern:     regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`),
+	},
+	{
+		ID:          "slack-webhook",
+		Description: "Slack webhook URL",
+		Pattern:     regexp.MustCompile(`https://hooks\.slack\.com/services/[A-Za-z0-9/]{20,}`),
+	},
+	{
+		ID:          "private-key",
+		Description: "Private key",
+		Pattern:     regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`),
+	},
+}
+
+// High-entropy strings: long runs of token characters that look random
+var (
+	entropyToken     = regexp.MustCompile(`[A-Za-z0-9+/_-]{32,}`)
+	separators       = regexp.MustCompile(`[/_-]+`)
+	hexToken         = regexp.MustCompile(`^[0-9a-fA-F-]+$`)
+	entropyThreshold = 4.3
+)
+
+// Finding is a suspected secret
+type Finding struct {
+	// File is slash-separated and relative to the scanned repository
+	File        string
+	Rule        string
+	Description string
+	// Fingerprint identifies the secret without revealing it, for the allowlist
+	Fingerprint string
+	Line        int
+}
+
+// String formats the finding as "file:line: description (fingerprint ...)"
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d: %s (fingerprint %s)", f.File, f.Line, f.Description, f.Fingerprint)
+}
+
+// Scan returns the secrets found in content. name is only used to fill in
+// Finding.File. Binary content is skipped.
+func Scan(name string, content []byte) []Finding {
+	if bytes.IndexByte(content, 0) >= 0 {
+		return nil
+	}
+
+	var findings []Finding
+	for i, line := range strings.Split(string(content), "\n") {
+		found := map[string]bool{}
+		for _, rule := range Rules {
+			for _, m := range rule.Pattern.FindAllStringSubmatch(line, -1) {
+				secret := m[0]
+				if len(m) > 1 && m[1] != "" {
+					secret = m[1]
+				}
+				found[secret] = true
+				findings = append(findings, Finding{
+					File:        name,
+					Line:        i + 1,
+					Rule:        rule.ID,
+					Description: rule.Description,
+					Fingerprint: fingerprint(secret),
+				})
+			}
+		}
+		for _, token := range entropyToken.FindAllString(line, -1) {
+			if found[token] || !looksRandom(token) || coveredBy(found, token) {
+				continue
+			}
+			findings = append(findings, Finding{
+				File:        name,
+				Line:        i + 1,
+				Rule:        "high-entropy-string",
+				Description: "High-entropy string",
+				Fingerprint: fingerprint(token),
+			})
+		}
+	}
+	return findings
+}
+
+// coveredBy reports whether token overlaps a secret a named rule already found
+func coveredBy(found map[string]bool, token string) bool {
+	for secret := range found {
+		if strings.Contains(token, secret) || strings.Contains(secret, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// looksRandom reports whether a token is likely a generated secret: mixed
+// letters and digits with high Shannon entropy. Hex strings such as commit
+// SHAs and UUIDs, and identifiers or paths made of short words, are not
+// reported.
+func looksRandom(token string) bool {
+	if hexToken.MatchString(token) {
+		return false
+	}
+	if parts := separators.Split(token, -1); len(parts) > 2 && longest(parts) < 16 {
+		return false
+	}
+	hasUpper := strings.ContainsAny(token, "ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+	hasLower := strings.ContainsAny(token, "abcdefghijklmnopqrstuvwxyz")
+	hasDigit := strings.ContainsAny(token, "0123456789")
+	if !hasUpper || !hasLower || !hasDigit {
+		return false
+	}
+	return entropy(token) >= entropyThreshold
+}
+
+func longest(parts []string) int {
+	n := 0
+	for _, p := range parts {
+		n = max(n, len(p))
+	}
+	return n
+}
+
+// entropy returns the Shannon entropy of s in bits per character
+func entropy(s string) float64 {
+	counts := map[rune]int{}
+	for _, r := range s {
+		counts[r]++
+	}
+	var h float64
+	n := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+func fingerprint(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// Allowlist suppresses findings for whole files or individual secrets.
+// Each line of the allowlist file is either a path glob, using the same
+// syntax as .claude-sync.yaml, or "fingerprint:<hex>" as shown in reports.
+type Allowlist struct {
+	fingerprints map[string]bool
+	paths        []string
+}
+
+// LoadAllowlist reads the allowlist file from a repository; a missing file
+// allows nothing
+func LoadAllowlist(repoPath string) (*Allowlist, error) {
+	allow := &Allowlist{fingerprints: map[string]bool{}}
+	content, err := os.ReadFile(filepath.Join(repoPath, AllowlistFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return allow, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", AllowlistFile, err)
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if fp, ok := strings.CutPrefix(line, "fingerprint:"); ok {
+			allow.fingerprints[strings.TrimSpace(fp)] = true
+			continue
+		}
+		allow.paths = append(allow.paths, line)
+	}
+	return allow, nil
+}
+
+// Allowed reports whether a finding is suppressed by the allowlist
+func (a *Allowlist) Allowed(f Finding) bool {
+	if a.fingerprints[f.Fingerprint] {
+		return true
+	}
+	return a.allowsFile(f.File)
+}
+
+func (a *Allowlist) allowsFile(file string) bool {
+	for _, pattern := range a.paths {
+		if rules.MatchGlob(pattern, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// ScanFiles scans files (relative to repoPath) and returns the findings the
+// allowlist does not suppress. Missing files and directories are skipped.
+// Only the first ScanLimit bytes of a larger file are scanned; such files
+// are returned as partial, so callers can say what was not scanned.
+func ScanFiles(repoPath string, files []string) (findings []Finding, partial []string, err error) {
+	allow, err := LoadAllowlist(repoPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, file := range files {
+		if allow.allowsFile(file) || slices.Contains(publicFiles, file) {
+			continue
+		}
+		path := filepath.Join(repoPath, filepath.FromSlash(file))
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		content, err := readStart(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		if info.Size() > ScanLimit {
+			partial = append(partial, file)
+		}
+		for _, f := range Scan(file, content) {
+			if !allow.Allowed(f) {
+				findings = append(findings, f)
+			}
+		}
+	}
+	return findings, partial, nil
+}
+
+// readStart reads up to ScanLimit bytes from the start of a file
+func readStart(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return io.ReadAll(io.LimitReader(f, ScanLimit))
+}
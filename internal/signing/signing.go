@@ -0,0 +1,349 @@
+// Package signing verifies that commits pulled from the config repository
+// were made by a trusted machine.
+//
+// Anyone with push access to the config repository can change how Claude
+// Code behaves on every machine that syncs it. Machines that sign their
+// commits with an SSH or OpenPGP key list that key in an allowed signers
+// file at the root of the repository:
+//
+//	laptop ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAA...
+//	desktop openpgp 0123456789ABCDEF0123456789ABCDEF01234567
+//
+// SSH keys use the allowed_signers format of ssh-keygen; OpenPGP keys are
+// listed by fingerprint and must be in the GnuPG keyring. Once the file is
+// committed, a sync checks every incoming commit against the file as it is
+// in the local HEAD, so a pushed commit cannot vouch for itself, and stops
+// before rebasing when one is unsigned or signed by another key. Commits
+// made before their machine signed can be accepted one by one on each
+// machine.
+package signing
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+	"github.com/mfenderov/claude-sync/internal/rules"
+)
+
+// FileName is the allowed signers file at the root of the config repository
+const FileName = ".claude-sync-allowed-signers"
+
+func init() {
+	rules.AlwaysSync(FileName)
+}
+
+// AcceptedFile lists, inside the repository's .git directory, the incoming
+// commits accepted on this machine although they failed verification
+const AcceptedFile = "claude-sync-accepted-commits"
+
+// openPGP is the key type of OpenPGP keys in the allowed signers file
+const openPGP = "openpgp"
+
+// Signer is a key trusted to sign commits
+type Signer struct {
+	Principal string `json:"principal"`
+	// Format is ssh or openpgp
+	Format string `json:"format"`
+	// Key is the public key of an SSH signer, or the fingerprint of an
+	// OpenPGP one
+	Key string `json:"key"`
+}
+
+// String renders the signer as a line of the allowed signers file
+func (s Signer) String() string {
+	if s.Format == openPGP {
+		return s.Principal + " " + openPGP + " " + s.Key
+	}
+	return s.Principal + " " + s.Key
+}
+
+// Rejection is an incoming commit that failed verification
+type Rejection struct {
+	SHA     string `json:"sha"`
+	Subject string `json:"subject"`
+	Reason  string `json:"reason"`
+	// Key is the fingerprint of the signing key, if any
+	Key string `json:"key,omitempty"`
+}
+
+// Path returns the allowed signers file of a repository
+func Path(repoPath string) string {
+	return filepath.Join(repoPath, FileName)
+}
+
+// ParseSigners reads an allowed signers file. Options between the
+// principals and the key are skipped.
+func ParseSigners(data []byte) []Signer {
+	var signers []Signer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		for i := 1; i < len(fields)-1; i++ {
+			if fields[i] == openPGP {
+				signers = append(signers, Signer{Principal: fields[0], Format: openPGP, Key: strings.ToUpper(fields[i+1])})
+				break
+			}
+			if isSSHKeyType(fields[i]) {
+				signers = append(signers, Signer{Principal: fields[0], Format: "ssh", Key: strings.Join(fields[i:], " ")})
+				break
+			}
+		}
+	}
+	return signers
+}
+
+// Enabled reports whether the allowed signers file is committed, which turns
+// on verification of incoming commits
+func Enabled(ctx context.Context, repoPath string) (bool, error) {
+	if _, err := git.RevParse(ctx, repoPath, "HEAD"); err != nil {
+		// Nothing committed yet
+		return false, nil
+	}
+	data, err := git.ReadFileAt(ctx, repoPath, "HEAD", FileName)
+	return data != nil, err
+}
+
+// Verify checks every commit in revRange against the allowed signers file in
+// HEAD and returns the ones that fail, newest first
+func Verify(ctx context.Context, repoPath, revRange string) ([]Rejection, error) {
+	allowed, err := git.ReadFileAt(ctx, repoPath, "HEAD", FileName)
+	if err != nil {
+		return nil, err
+	}
+	fingerprints := map[string]bool{}
+	for _, s := range ParseSigners(allowed) {
+		if s.Format == openPGP {
+			fingerprints[s.Key] = true
+		}
+	}
+
+	accepted, err := readAccepted(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	signatures, err := git.CommitSignatures(ctx, repoPath, revRange, allowed)
+	if err != nil {
+		return nil, err
+	}
+	var rejections []Rejection
+	for _, sig := range signatures {
+		if accepted[sig.SHA] {
+			continue
+		}
+		reason := check(sig, fingerprints)
+		if reason == "" {
+			continue
+		}
+		rejections = append(rejections, Rejection{
+			SHA:     sig.SHA,
+			Subject: sig.Subject,
+			Reason:  reason,
+			Key:     sig.Fingerprint,
+		})
+	}
+	return rejections, nil
+}
+
+// Accept records commits that failed verification as accepted on this
+// machine, typically ones made before their machine signed its commits
+func Accept(repoPath string, shas []string) error {
+	f, err := os.OpenFile(filepath.Join(repoPath, ".git", AcceptedFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to record accepted commits: %w", err)
+	}
+	for _, sha := range shas {
+		if _, err := f.WriteString(sha + "\n"); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("failed to record accepted commits: %w", err)
+		}
+	}
+	return f.Close()
+}
+
+func readAccepted(repoPath string) (map[string]bool, error) {
+	data, err := os.ReadFile(filepath.Join(repoPath, ".git", AcceptedFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read accepted commits: %w", err)
+	}
+	accepted := map[string]bool{}
+	for _, sha := range strings.Fields(string(data)) {
+		accepted[sha] = true
+	}
+	return accepted, nil
+}
+
+// check returns why a signature is not trusted, or "" when it is
+func check(sig git.Signature, fingerprints map[string]bool) string {
+	switch sig.Status {
+	case "N":
+		return "unsigned"
+	case "B":
+		return "bad signature"
+	case "X":
+		return "signature has expired"
+	case "Y":
+		return "signed by an expired key"
+	case "R":
+		return "signed by a revoked key"
+	case "E":
+		return "signature cannot be checked, the key may be missing from the GnuPG keyring"
+	}
+
+	if strings.HasPrefix(sig.Fingerprint, "SHA256:") {
+		// ssh-keygen only reports G for keys in the allowed signers file
+		if sig.Status == "G" {
+			return ""
+		}
+		return "signed by a key that is not an allowed signer"
+	}
+	if fingerprints[strings.ToUpper(sig.Fingerprint)] || fingerprints[strings.ToUpper(sig.PrimaryFingerprint)] {
+		return ""
+	}
+	return "signed by a key that is not an allowed signer"
+}
+
+// Setup makes the repository sign its commits with key and adds the key to
+// the allowed signers file under principal. A key that is a file is an SSH
+// key, its public half next to it; anything else is an OpenPGP key ID.
+func Setup(ctx context.Context, repoPath, key, principal string) (Signer, error) {
+	signer, err := resolve(ctx, key, principal)
+	if err != nil {
+		return signer, err
+	}
+	signingKey := key
+	if signer.Format == "ssh" {
+		if signingKey, err = filepath.Abs(key); err != nil {
+			return signer, err
+		}
+	}
+	if err := git.ConfigureSigning(ctx, repoPath, signer.Format, signingKey, Path(repoPath)); err != nil {
+		return signer, err
+	}
+	return signer, addSigner(repoPath, signer)
+}
+
+// Allow adds the key of another machine to the allowed signers file under
+// principal, without signing with it. The key is an SSH public key file or
+// an OpenPGP key ID.
+func Allow(ctx context.Context, repoPath, key, principal string) (Signer, error) {
+	signer, err := resolve(ctx, key, principal)
+	if err != nil {
+		return signer, err
+	}
+	return signer, addSigner(repoPath, signer)
+}
+
+// resolve finds the public key of an SSH key file, or the fingerprint of an
+// OpenPGP key
+func resolve(ctx context.Context, key, principal string) (Signer, error) {
+	signer := Signer{Principal: principal}
+	if strings.ContainsAny(principal, " \t,") || principal == "" {
+		return signer, fmt.Errorf("invalid signer name %q: use a single word such as the host name", principal)
+	}
+
+	if _, err := os.Stat(key); err == nil {
+		public := key
+		if !strings.HasSuffix(public, ".pub") {
+			public += ".pub"
+		}
+		data, err := os.ReadFile(public)
+		if err != nil {
+			return signer, fmt.Errorf("failed to read the public key: %w", err)
+		}
+		fields := strings.Fields(string(data))
+		if len(fields) < 2 || !isSSHKeyType(fields[0]) {
+			return signer, fmt.Errorf("%s is not an SSH public key", public)
+		}
+		signer.Format = "ssh"
+		signer.Key = fields[0] + " " + fields[1]
+		return signer, nil
+	}
+
+	output, err := exec.CommandContext(ctx, "gpg", "--batch", "--with-colons", "--fingerprint", key).Output()
+	if err != nil {
+		return signer, fmt.Errorf("%s is neither an SSH key file nor an OpenPGP key in the GnuPG keyring: %w", key, err)
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Split(line, ":")
+		if fields[0] == "fpr" && len(fields) > 9 {
+			signer.Format = openPGP
+			signer.Key = fields[9]
+			return signer, nil
+		}
+	}
+	return signer, fmt.Errorf("no fingerprint found for OpenPGP key %s", key)
+}
+
+// addSigner appends a signer to the allowed signers file, unless its key is
+// already listed
+func addSigner(repoPath string, signer Signer) error {
+	if err := mergeByUnion(repoPath); err != nil {
+		return err
+	}
+	path := Path(repoPath)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", FileName, err)
+	}
+	for _, s := range ParseSigners(data) {
+		if s.Format == signer.Format && sameKey(s.Key, signer.Key) {
+			return nil
+		}
+	}
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	data = append(data, signer.String()+"\n"...)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", FileName, err)
+	}
+	return nil
+}
+
+// mergeByUnion makes git merge the allowed signers file by keeping the lines
+// of both sides, so machines that add their keys at the same time do not
+// conflict. The attribute is local to this machine.
+func mergeByUnion(repoPath string) error {
+	path := filepath.Join(repoPath, ".git", "info", "attributes")
+	line := "/" + FileName + " merge=union"
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read git attributes: %w", err)
+	}
+	if slices.Contains(strings.Split(string(data), "\n"), line) {
+		return nil
+	}
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to write git attributes: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, line+"\n"...), 0o644); err != nil {
+		return fmt.Errorf("failed to write git attributes: %w", err)
+	}
+	return nil
+}
+
+// sameKey compares keys ignoring the comment after an SSH key
+func sameKey(a, b string) bool {
+	fa, fb := strings.Fields(a), strings.Fields(b)
+	n := min(len(fa), len(fb), 2)
+	return n > 0 && strings.Join(fa[:n], " ") == strings.Join(fb[:n], " ")
+}
+
+func isSSHKeyType(field string) bool {
+	return strings.HasPrefix(field, "ssh-") || strings.HasPrefix(field, "ecdsa-") || strings.HasPrefix(field, "sk-")
+}
@@ -0,0 +1,160 @@
+package signing
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mfenderov/claude-sync/internal/rules"
+)
+
+func run(t *testing.T, repo string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func newKey(t *testing.T, name string) string {
+	t.Helper()
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not available")
+	}
+	key := filepath.Join(t.TempDir(), name)
+	if output, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", name, "-f", key).CombinedOutput(); err != nil {
+		t.Fatalf("ssh-keygen: %v\n%s", err, output)
+	}
+	return key
+}
+
+func TestParseSigners(t *testing.T) {
+	t.Parallel()
+
+	signers := ParseSigners([]byte(`# trusted machines
+laptop ssh-ed25519 AAAAC3 laptop@example.com
+desktop namespaces="git" ecdsa-sha2-nistp256 AAAAE2
+work openpgp 0123456789abcdef
+
+broken
+`))
+	want := []Signer{
+		{Principal: "laptop", Format: "ssh", Key: "ssh-ed25519 AAAAC3 laptop@example.com"},
+		{Principal: "desktop", Format: "ssh", Key: "ecdsa-sha2-nistp256 AAAAE2"},
+		{Principal: "work", Format: "openpgp", Key: "0123456789ABCDEF"},
+	}
+	if len(signers) != len(want) {
+		t.Fatalf("ParseSigners() = %+v, want %+v", signers, want)
+	}
+	for i := range want {
+		if signers[i] != want[i] {
+			t.Errorf("signer %d = %+v, want %+v", i, signers[i], want[i])
+		}
+	}
+}
+
+func TestSetupAndVerify(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repo := t.TempDir()
+	run(t, repo, "init", "--quiet")
+	run(t, repo, "commit", "--quiet", "--allow-empty", "-m", "initial")
+	if enabled, err := Enabled(ctx, repo); err != nil || enabled {
+		t.Errorf("Enabled() before setup = %v, %v", enabled, err)
+	}
+
+	laptop := newKey(t, "laptop")
+	signer, err := Setup(ctx, repo, laptop, "laptop")
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	if signer.Format != "ssh" || !strings.HasPrefix(signer.Key, "ssh-ed25519 ") {
+		t.Errorf("Setup() = %+v", signer)
+	}
+	if _, err := Setup(ctx, repo, laptop, "laptop"); err != nil {
+		t.Fatalf("Setup() again error = %v", err)
+	}
+	if signers := ParseSigners(mustRead(t, Path(repo))); len(signers) != 1 {
+		t.Errorf("allowed signers = %+v, want the key listed once", signers)
+	}
+	if merge := run(t, repo, "check-attr", "merge", FileName); !strings.HasSuffix(merge, "merge: union") {
+		t.Errorf("git check-attr = %q, want the file merged by union", merge)
+	}
+
+	run(t, repo, "add", FileName)
+	run(t, repo, "commit", "--quiet", "-m", "trust laptop")
+	base := run(t, repo, "rev-parse", "HEAD")
+	if enabled, err := Enabled(ctx, repo); err != nil || !enabled {
+		t.Errorf("Enabled() after commit = %v, %v", enabled, err)
+	}
+
+	// Incoming commits: a signed one, an unsigned one, and one by a key
+	// that lists itself as a signer
+	run(t, repo, "commit", "--quiet", "--allow-empty", "-m", "signed")
+	run(t, repo, "-c", "commit.gpgsign=false", "commit", "--quiet", "--allow-empty", "-m", "unsigned")
+	intruder := newKey(t, "intruder")
+	if _, err := Setup(ctx, repo, intruder, "intruder"); err != nil {
+		t.Fatal(err)
+	}
+	run(t, repo, "commit", "--quiet", "--all", "-m", "trust intruder")
+	head := run(t, repo, "rev-parse", "HEAD")
+	run(t, repo, "reset", "--quiet", "--hard", base)
+
+	rejections, err := Verify(ctx, repo, "HEAD.."+head)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if len(rejections) != 2 {
+		t.Fatalf("Verify() = %+v, want the unsigned and the intruder's commits", rejections)
+	}
+	if r := rejections[0]; r.Subject != "trust intruder" || r.Reason != "signed by a key that is not an allowed signer" || r.Key == "" {
+		t.Errorf("rejection = %+v", r)
+	}
+	if r := rejections[1]; r.Subject != "unsigned" || r.Reason != "unsigned" {
+		t.Errorf("rejection = %+v", r)
+	}
+
+	// A trusted machine allows the other key
+	if _, err := Allow(ctx, repo, intruder+".pub", "desktop"); err != nil {
+		t.Fatalf("Allow() error = %v", err)
+	}
+	run(t, repo, "-c", "user.signingkey="+laptop, "commit", "--quiet", "--all", "-m", "trust desktop")
+	rejections, err = Verify(ctx, repo, "HEAD.."+head)
+	if err != nil || len(rejections) != 1 || rejections[0].Subject != "unsigned" {
+		t.Fatalf("Verify() after Allow() = %+v, %v, want only the unsigned commit", rejections, err)
+	}
+
+	if err := Accept(repo, []string{rejections[0].SHA}); err != nil {
+		t.Fatalf("Accept() error = %v", err)
+	}
+	if rejections, err := Verify(ctx, repo, "HEAD.."+head); err != nil || len(rejections) != 0 {
+		t.Errorf("Verify() after Accept() = %+v, %v, want nothing", rejections, err)
+	}
+}
+
+func mustRead(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestAllowedSignersAlwaysSynced(t *testing.T) {
+	t.Parallel()
+
+	set := &rules.Set{Include: []string{"settings.json"}, Exclude: []string{".claude-sync*"}}
+	if d := set.Match(FileName, false); !d.Synced {
+		t.Errorf("Match(%s) = %+v, want synced whatever the rules say", FileName, d)
+	}
+}
@@ -1,11 +1,23 @@
 package sync
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"os"
+	"slices"
 
 	"github.com/mfenderov/claude-sync/internal/git"
+	"github.com/mfenderov/claude-sync/internal/gogit"
+	"github.com/mfenderov/claude-sync/internal/hookgate"
+	"github.com/mfenderov/claude-sync/internal/journal"
+	"github.com/mfenderov/claude-sync/internal/lock"
 	"github.com/mfenderov/claude-sync/internal/logger"
+	"github.com/mfenderov/claude-sync/internal/overlay"
 	"github.com/mfenderov/claude-sync/internal/prompts"
+	"github.com/mfenderov/claude-sync/internal/secretscan"
+	"github.com/mfenderov/claude-sync/internal/signing"
+	"github.com/mfenderov/claude-sync/internal/vault"
 )
 
 // LoggerAdapter adapts logger.Logger to the Logger interface.
@@ -60,6 +72,240 @@ func (p *PrompterAdapter) SpinWhile(message string, task func() error) error {
 	return prompts.SpinWhile(message, task)
 }
 
+// ConflictResolverAdapter resolves conflicts with the interactive
+// side-by-side resolver from the prompts package.
+type ConflictResolverAdapter struct{}
+
+// NewConflictResolverAdapter creates a new ConflictResolverAdapter.
+func NewConflictResolverAdapter() *ConflictResolverAdapter {
+	return &ConflictResolverAdapter{}
+}
+
+func (r *ConflictResolverAdapter) Resolve(files []string, conflict FileConflict) ([]byte, error) {
+	view := prompts.ConflictView{
+		Files:         files,
+		Current:       slices.Index(files, conflict.Path),
+		Remote:        conflict.Remote,
+		Local:         conflict.Local,
+		RemoteDeleted: conflict.Remote == nil,
+		LocalDeleted:  conflict.Local == nil,
+	}
+
+	for {
+		choice, err := prompts.ResolveConflict(view)
+		if err != nil {
+			return nil, err
+		}
+
+		switch choice {
+		case prompts.KeepRemote:
+			return conflict.Remote, nil
+		case prompts.KeepLocal:
+			return conflict.Local, nil
+		case prompts.EditFile:
+			if err := prompts.OpenEditor(conflict.FullPath); err != nil {
+				return nil, err
+			}
+			content, err := os.ReadFile(conflict.FullPath)
+			if errors.Is(err, os.ErrNotExist) {
+				// Deleting the file in the editor resolves to a deletion
+				return nil, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if bytes.Contains(content, []byte("<<<<<<<")) || bytes.Contains(content, []byte(">>>>>>>")) {
+				view.Notice = conflict.Path + " still contains conflict markers"
+				continue
+			}
+			return content, nil
+		default:
+			return nil, ErrConflictAborted
+		}
+	}
+}
+
+// LockAdapter adapts the lock package to the Locker interface.
+type LockAdapter struct {
+	opts lock.Options
+}
+
+// NewLockAdapter creates a new LockAdapter.
+func NewLockAdapter(opts lock.Options) *LockAdapter {
+	return &LockAdapter{opts: opts}
+}
+
+func (l *LockAdapter) Lock(ctx context.Context, repoPath string) (func(), error) {
+	held, err := lock.Acquire(ctx, lock.Path(repoPath), l.opts)
+	if err != nil {
+		return nil, err
+	}
+	return func() { _ = held.Release() }, nil
+}
+
+// SecretScannerAdapter adapts the secretscan package to the SecretScanner interface.
+type SecretScannerAdapter struct{}
+
+// NewSecretScannerAdapter creates a new SecretScannerAdapter.
+func NewSecretScannerAdapter() *SecretScannerAdapter {
+	return &SecretScannerAdapter{}
+}
+
+// Scan skips the secrets manifest: its key check and salt look random but are
+// not secrets.
+func (a *SecretScannerAdapter) Scan(repoPath string, files []string) ([]secretscan.Finding, []string, error) {
+	files = slices.DeleteFunc(slices.Clone(files), func(file string) bool {
+		return file == vault.ManifestFile
+	})
+	return secretscan.ScanFiles(repoPath, files)
+}
+
+// SecretStoreAdapter adapts the vault package to the SecretStore interface.
+// It also keeps the plaintext of secret files out of git on every machine,
+// including ones without the key, untracking them through git.
+type SecretStoreAdapter struct {
+	git GitOperator
+}
+
+// NewSecretStoreAdapter creates a new SecretStoreAdapter.
+func NewSecretStoreAdapter(git GitOperator) *SecretStoreAdapter {
+	return &SecretStoreAdapter{git: git}
+}
+
+func (a *SecretStoreAdapter) Seal(ctx context.Context, repoPath string) ([]string, error) {
+	v, err := a.open(ctx, repoPath)
+	if v == nil {
+		return nil, err
+	}
+	return v.Seal()
+}
+
+func (a *SecretStoreAdapter) Unseal(ctx context.Context, repoPath string) ([]string, error) {
+	v, err := a.open(ctx, repoPath)
+	if v == nil {
+		return nil, err
+	}
+	return v.Unseal()
+}
+
+// open refreshes the plaintext excludes and opens the vault; it returns a
+// nil vault when the repository has no secret files or the key is missing
+func (a *SecretStoreAdapter) open(ctx context.Context, repoPath string) (*vault.Vault, error) {
+	manifest, err := vault.LoadManifest(repoPath)
+	if err != nil || manifest == nil {
+		return nil, err
+	}
+	if err := git.WriteSecretExcludes(repoPath, manifest.Files); err != nil {
+		return nil, err
+	}
+	if err := a.git.UntrackFiles(ctx, repoPath, manifest.Files); err != nil {
+		return nil, err
+	}
+	return vault.Open(repoPath)
+}
+
+// OverlayAdapter adapts the overlay package to the OverlayBuilder interface,
+// reading the targets from the repository's rules file and untracking the
+// built files through git.
+type OverlayAdapter struct {
+	git GitOperator
+}
+
+// NewOverlayAdapter creates a new OverlayAdapter.
+func NewOverlayAdapter(git GitOperator) *OverlayAdapter {
+	return &OverlayAdapter{git: git}
+}
+
+func (a *OverlayAdapter) Capture(ctx context.Context, repoPath string) ([]string, error) {
+	builder, err := a.builder(ctx, repoPath)
+	if builder == nil {
+		return nil, err
+	}
+	return builder.Capture()
+}
+
+func (a *OverlayAdapter) Apply(ctx context.Context, repoPath string) ([]string, error) {
+	builder, err := a.builder(ctx, repoPath)
+	if builder == nil {
+		return nil, err
+	}
+	return builder.Apply()
+}
+
+// builder keeps the built files out of git and returns a builder for them;
+// it returns nil when the rules list no overlays
+func (a *OverlayAdapter) builder(ctx context.Context, repoPath string) (*overlay.Builder, error) {
+	set, err := git.ApplyRules(repoPath)
+	if err != nil || len(set.Overlays) == 0 {
+		return nil, err
+	}
+	if err := a.git.UntrackFiles(ctx, repoPath, set.Overlays); err != nil {
+		return nil, err
+	}
+	sel, err := overlay.CurrentSelectors()
+	if err != nil {
+		return nil, err
+	}
+	return overlay.New(repoPath, set.Overlays, sel), nil
+}
+
+// JournalAdapter adapts the journal package to the Journal interface.
+type JournalAdapter struct{}
+
+// NewJournalAdapter creates a new JournalAdapter.
+func NewJournalAdapter() *JournalAdapter {
+	return &JournalAdapter{}
+}
+
+func (a *JournalAdapter) Record(repoPath string, entry journal.Entry) error {
+	return journal.Append(repoPath, entry)
+}
+
+func (a *JournalAdapter) Last(repoPath string) (*journal.Entry, error) {
+	return journal.Last(repoPath)
+}
+
+// HookGateAdapter adapts the hookgate package to the HookGate interface.
+type HookGateAdapter struct{}
+
+// NewHookGateAdapter creates a new HookGateAdapter.
+func NewHookGateAdapter() *HookGateAdapter {
+	return &HookGateAdapter{}
+}
+
+func (a *HookGateAdapter) Init(ctx context.Context, repoPath string) error {
+	return hookgate.Init(repoPath)
+}
+
+func (a *HookGateAdapter) Capture(ctx context.Context, repoPath string) ([]string, error) {
+	return hookgate.Capture(ctx, repoPath)
+}
+
+func (a *HookGateAdapter) Release(ctx context.Context, repoPath string) error {
+	return hookgate.Release(ctx, repoPath)
+}
+
+func (a *HookGateAdapter) Quarantine(ctx context.Context, repoPath string) ([]hookgate.Hook, error) {
+	return hookgate.Quarantine(ctx, repoPath)
+}
+
+// SignatureVerifierAdapter adapts the signing package to the
+// SignatureVerifier interface.
+type SignatureVerifierAdapter struct{}
+
+// NewSignatureVerifierAdapter creates a new SignatureVerifierAdapter.
+func NewSignatureVerifierAdapter() *SignatureVerifierAdapter {
+	return &SignatureVerifierAdapter{}
+}
+
+func (a *SignatureVerifierAdapter) Enabled(ctx context.Context, repoPath string) (bool, error) {
+	return signing.Enabled(ctx, repoPath)
+}
+
+func (a *SignatureVerifierAdapter) Verify(ctx context.Context, repoPath, revRange string) ([]signing.Rejection, error) {
+	return signing.Verify(ctx, repoPath, revRange)
+}
+
 // GitAdapter adapts the git package to the GitOperator interface.
 type GitAdapter struct{}
 
@@ -89,6 +335,22 @@ func (g *GitAdapter) SetupGitignore(path string) error {
 	return git.SetupGitignore(path)
 }
 
+func (g *GitAdapter) SwitchProfile(ctx context.Context, path, name string, stash bool) error {
+	return git.SwitchProfile(ctx, path, name, stash)
+}
+
+func (g *GitAdapter) ProfileBranch(ctx context.Context, path, name string) (string, error) {
+	p, err := git.GetProfile(ctx, path, name)
+	if err != nil {
+		return "", err
+	}
+	return p.Local, nil
+}
+
+func (g *GitAdapter) UpgradeGitignore(path string) (bool, error) {
+	return git.UpgradeGitignore(path)
+}
+
 func (g *GitAdapter) InitialCommit(ctx context.Context, path, message string) error {
 	return git.InitialCommit(ctx, path, message)
 }
@@ -110,6 +372,27 @@ func (g *GitAdapter) Fetch(ctx context.Context, path string) error {
 	return git.Fetch(ctx, path)
 }
 
+func (g *GitAdapter) ListMirrors(ctx context.Context, path string) ([]string, error) {
+	return git.ListMirrors(ctx, path)
+}
+
+func (g *GitAdapter) PushMirror(ctx context.Context, path, name string) error {
+	return git.PushMirror(ctx, path, name)
+}
+
+// Bundle operations
+func (g *GitAdapter) CreateBundle(ctx context.Context, path, file string, full bool) (int, error) {
+	return git.CreateBundle(ctx, path, file, full)
+}
+
+func (g *GitAdapter) FetchBundle(ctx context.Context, path, file, name string) (string, error) {
+	return git.FetchBundle(ctx, path, file, name)
+}
+
+func (g *GitAdapter) BundleDir(ctx context.Context, path string) (string, error) {
+	return git.BundleDir(ctx, path)
+}
+
 // Sync operations
 func (g *GitAdapter) HasUncommittedChanges(ctx context.Context, path string) (bool, error) {
 	return git.HasUncommittedChanges(ctx, path)
@@ -123,10 +406,18 @@ func (g *GitAdapter) CommitChanges(ctx context.Context, path, message string) er
 	return git.CommitChanges(ctx, path, message)
 }
 
+func (g *GitAdapter) UntrackFiles(ctx context.Context, path string, files []string) error {
+	return git.UntrackFiles(ctx, path, files)
+}
+
 func (g *GitAdapter) PullWithRebase(ctx context.Context, path string) error {
 	return git.PullWithRebase(ctx, path)
 }
 
+func (g *GitAdapter) RebaseOnto(ctx context.Context, path, onto string) error {
+	return git.RebaseOnto(ctx, path, onto)
+}
+
 func (g *GitAdapter) PullAllowUnrelatedHistories(ctx context.Context, path string) error {
 	return git.PullAllowUnrelatedHistories(ctx, path)
 }
@@ -148,14 +439,215 @@ func (g *GitAdapter) GetRecentCommits(ctx context.Context, path string, count in
 	return git.GetRecentCommits(ctx, path, count)
 }
 
+func (g *GitAdapter) GetCommitsInRange(ctx context.Context, path, revRange string) ([]string, error) {
+	return git.GetCommitsInRange(ctx, path, revRange)
+}
+
+func (g *GitAdapter) GetFilesInRange(ctx context.Context, path, revRange string) ([]string, error) {
+	return git.GetFilesInRange(ctx, path, revRange)
+}
+
+func (g *GitAdapter) RevParse(ctx context.Context, path, rev string) (string, error) {
+	return git.RevParse(ctx, path, rev)
+}
+
 func (g *GitAdapter) HasConflicts(ctx context.Context, path string) (bool, error) {
 	return git.HasConflicts(ctx, path)
 }
 
+func (g *GitAdapter) GetConflictedFiles(ctx context.Context, path string) ([]string, error) {
+	return git.GetConflictedFiles(ctx, path)
+}
+
+func (g *GitAdapter) GetConflictVersions(ctx context.Context, path, file string) (base, ours, theirs []byte, err error) {
+	return git.GetConflictVersions(ctx, path, file)
+}
+
+func (g *GitAdapter) ResolveConflict(ctx context.Context, path, file string, content []byte) error {
+	return git.ResolveConflict(ctx, path, file, content)
+}
+
+func (g *GitAdapter) ContinueRebase(ctx context.Context, path string) error {
+	return git.ContinueRebase(ctx, path)
+}
+
 func (g *GitAdapter) AbortRebase(ctx context.Context, path string) error {
 	return git.AbortRebase(ctx, path)
 }
 
+// History operations
+
+func (g *GitAdapter) ResetTo(ctx context.Context, path, rev string) error {
+	return git.ResetTo(ctx, path, rev)
+}
+
+func (g *GitAdapter) RevertTo(ctx context.Context, path, rev, message string) error {
+	return git.RevertTo(ctx, path, rev, message)
+}
+
+func (g *GitAdapter) Log(ctx context.Context, path string, opts git.LogOptions) ([]git.Commit, error) {
+	return git.Log(ctx, path, opts)
+}
+
+func (g *GitAdapter) ResolveRevision(ctx context.Context, path, at string) (string, error) {
+	return git.ResolveRevision(ctx, path, at)
+}
+
+func (g *GitAdapter) ListFilesAt(ctx context.Context, path, rev, file string) ([]string, error) {
+	return git.ListFilesAt(ctx, path, rev, file)
+}
+
+func (g *GitAdapter) ReadFileAt(ctx context.Context, path, rev, file string) ([]byte, error) {
+	return git.ReadFileAt(ctx, path, rev, file)
+}
+
+func (g *GitAdapter) RestorePath(ctx context.Context, path, rev, file string) error {
+	return git.RestorePath(ctx, path, rev, file)
+}
+
+func (g *GitAdapter) CommitPath(ctx context.Context, path, file, message string) error {
+	return git.CommitPath(ctx, path, file, message)
+}
+
 func (g *GitAdapter) GenerateAutoCommitMessage() string {
 	return git.GenerateAutoCommitMessage()
 }
+
+// GoGitAdapter adapts the gogit package to the GitOperator interface, for
+// machines without the git binary. It covers what a sync does: setup,
+// commit, fetch, pull, push, and conflicts. Pulls merge instead of
+// rebasing. History, restore, profile, and bundle operations still run git.
+type GoGitAdapter struct {
+	*GitAdapter
+}
+
+// NewGoGitAdapter creates a new GoGitAdapter.
+func NewGoGitAdapter() *GoGitAdapter {
+	return &GoGitAdapter{GitAdapter: NewGitAdapter()}
+}
+
+// Repository operations
+func (g *GoGitAdapter) InitRepo(ctx context.Context, path string) error {
+	return gogit.InitRepo(ctx, path)
+}
+
+func (g *GoGitAdapter) CloneRepo(ctx context.Context, remoteURL, destPath string) error {
+	return gogit.CloneRepo(ctx, remoteURL, destPath)
+}
+
+func (g *GoGitAdapter) InitialCommit(ctx context.Context, path, message string) error {
+	return gogit.InitialCommit(ctx, path, message)
+}
+
+// Remote operations
+func (g *GoGitAdapter) ValidateRemote(ctx context.Context, remoteURL string) error {
+	return gogit.ValidateRemote(ctx, remoteURL)
+}
+
+func (g *GoGitAdapter) RemoteHasCommits(ctx context.Context, remoteURL string) (bool, error) {
+	return gogit.RemoteHasCommits(ctx, remoteURL)
+}
+
+func (g *GoGitAdapter) AddRemote(ctx context.Context, path, name, url string) error {
+	return gogit.AddRemote(ctx, path, name, url)
+}
+
+func (g *GoGitAdapter) Fetch(ctx context.Context, path string) error {
+	return gogit.Fetch(ctx, path)
+}
+
+func (g *GoGitAdapter) ListMirrors(ctx context.Context, path string) ([]string, error) {
+	return gogit.ListMirrors(ctx, path)
+}
+
+func (g *GoGitAdapter) PushMirror(ctx context.Context, path, name string) error {
+	return gogit.PushMirror(ctx, path, name)
+}
+
+// Bundle operations. Reading and writing bundles still runs git.
+func (g *GoGitAdapter) BundleDir(ctx context.Context, path string) (string, error) {
+	return gogit.BundleDir(ctx, path)
+}
+
+// Sync operations
+func (g *GoGitAdapter) HasUncommittedChanges(ctx context.Context, path string) (bool, error) {
+	return gogit.HasUncommittedChanges(ctx, path)
+}
+
+func (g *GoGitAdapter) GetChangedFiles(ctx context.Context, path string) ([]string, error) {
+	return gogit.GetChangedFiles(ctx, path)
+}
+
+func (g *GoGitAdapter) CommitChanges(ctx context.Context, path, message string) error {
+	return gogit.CommitChanges(ctx, path, message)
+}
+
+func (g *GoGitAdapter) UntrackFiles(ctx context.Context, path string, files []string) error {
+	return gogit.UntrackFiles(ctx, path, files)
+}
+
+func (g *GoGitAdapter) PullWithRebase(ctx context.Context, path string) error {
+	return gogit.Pull(ctx, path)
+}
+
+func (g *GoGitAdapter) RebaseOnto(ctx context.Context, path, onto string) error {
+	return gogit.MergeOnto(ctx, path, onto)
+}
+
+func (g *GoGitAdapter) PullAllowUnrelatedHistories(ctx context.Context, path string) error {
+	return gogit.PullAllowUnrelatedHistories(ctx, path)
+}
+
+func (g *GoGitAdapter) Push(ctx context.Context, path string) error {
+	return gogit.Push(ctx, path)
+}
+
+func (g *GoGitAdapter) PushWithUpstream(ctx context.Context, path string) error {
+	return gogit.PushWithUpstream(ctx, path)
+}
+
+// Info operations
+func (g *GoGitAdapter) GetBranchInfo(ctx context.Context, path string) (branch string, ahead, behind int, err error) {
+	return gogit.GetBranchInfo(ctx, path)
+}
+
+func (g *GoGitAdapter) GetRecentCommits(ctx context.Context, path string, count int) ([]string, error) {
+	return gogit.GetRecentCommits(ctx, path, count)
+}
+
+func (g *GoGitAdapter) GetCommitsInRange(ctx context.Context, path, revRange string) ([]string, error) {
+	return gogit.GetCommitsInRange(ctx, path, revRange)
+}
+
+func (g *GoGitAdapter) GetFilesInRange(ctx context.Context, path, revRange string) ([]string, error) {
+	return gogit.GetFilesInRange(ctx, path, revRange)
+}
+
+func (g *GoGitAdapter) RevParse(ctx context.Context, path, rev string) (string, error) {
+	return gogit.RevParse(ctx, path, rev)
+}
+
+// Conflict operations. ours is the remote version, as in a pull rebase.
+func (g *GoGitAdapter) HasConflicts(ctx context.Context, path string) (bool, error) {
+	return gogit.HasConflicts(ctx, path)
+}
+
+func (g *GoGitAdapter) GetConflictedFiles(ctx context.Context, path string) ([]string, error) {
+	return gogit.GetConflictedFiles(ctx, path)
+}
+
+func (g *GoGitAdapter) GetConflictVersions(ctx context.Context, path, file string) (base, ours, theirs []byte, err error) {
+	return gogit.GetConflictVersions(ctx, path, file)
+}
+
+func (g *GoGitAdapter) ResolveConflict(ctx context.Context, path, file string, content []byte) error {
+	return gogit.ResolveConflict(ctx, path, file, content)
+}
+
+func (g *GoGitAdapter) ContinueRebase(ctx context.Context, path string) error {
+	return gogit.ContinueMerge(ctx, path)
+}
+
+func (g *GoGitAdapter) AbortRebase(ctx context.Context, path string) error {
+	return gogit.AbortMerge(ctx, path)
+}
@@ -0,0 +1,260 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+	"github.com/mfenderov/claude-sync/internal/journal"
+	"github.com/mfenderov/claude-sync/internal/overlay"
+)
+
+// bundleSource names the bundles imported with Import, which come from no
+// particular machine.
+const bundleSource = "import"
+
+// Export commits local changes and writes them to a bundle file for a
+// machine without network access. The bundle leaves out the commits
+// already imported from other machines' bundles, unless full is set.
+func (s *Service) Export(ctx context.Context, file string, full bool) error {
+	s.logger.Title("📦 Export Bundle")
+	s.phase("check")
+
+	claudeDir, err := s.bundleRepo("Cannot export")
+	if err != nil {
+		return err
+	}
+	unlock, err := s.lock(ctx, claudeDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	s.upgradeGitignore(claudeDir)
+	if err := s.commitLocalChanges(ctx, claudeDir); err != nil {
+		return err
+	}
+	if err := s.exportBundle(ctx, claudeDir, file, full); err != nil {
+		return err
+	}
+
+	s.logger.Success("✨", "Export complete!")
+	s.logger.Newline()
+	return nil
+}
+
+// Import commits local changes, verifies a bundle exported on another
+// machine, and applies it like a pull: local commits are rebased onto the
+// bundle's, with the same conflict handling, signature checks, and hook
+// approval.
+func (s *Service) Import(ctx context.Context, file string) error {
+	s.logger.Title("📦 Import Bundle")
+	s.phase("check")
+
+	claudeDir, err := s.bundleRepo("Cannot import")
+	if err != nil {
+		return err
+	}
+	unlock, err := s.lock(ctx, claudeDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	s.upgradeGitignore(claudeDir)
+	entry := journal.Entry{Before: s.journalHead(ctx, claudeDir)}
+	if err := s.commitLocalChanges(ctx, claudeDir); err != nil {
+		return err
+	}
+	entry.Local = s.journalHead(ctx, claudeDir)
+	tip, err := s.readBundle(ctx, claudeDir, file, bundleSource)
+	if err != nil {
+		s.logger.Error("✗", "Failed to read the bundle", err)
+		return err
+	}
+	applied, err := s.applyBundle(ctx, claudeDir, file, tip)
+	if err != nil || !applied {
+		return err
+	}
+	if s.journal != nil {
+		entry.After = s.revParse(ctx, claudeDir, "HEAD")
+		entry.Time = time.Now()
+		s.record(claudeDir, entry)
+	}
+
+	s.logger.Success("✨", "Import complete!")
+	s.logger.Newline()
+	return nil
+}
+
+// bundleRepo returns the repository bundles are exported from and
+// imported into, which must already be set up.
+func (s *Service) bundleRepo(action string) (string, error) {
+	claudeDir, err := s.git.GetClaudeDir()
+	if err != nil {
+		s.logger.Error("✗", err.Error(), err)
+		return "", err
+	}
+	if !s.git.IsGitRepo(claudeDir) {
+		err := fmt.Errorf("%s is not a git repository - import a full bundle into a clone of it, or run claude-sync once to set up sync", claudeDir)
+		s.logger.Error("✗", action, err)
+		return "", err
+	}
+	return claudeDir, nil
+}
+
+// exchangeBundles syncs through a bundle directory instead of a remote. It
+// imports the bundles other machines left there and then replaces this
+// machine's own bundle. That bundle is full, since any machine may read it
+// next. Like a sync whose push failed, it is recorded for undo even when
+// writing the bundle fails.
+func (s *Service) exchangeBundles(ctx context.Context, claudeDir, dir, before string) error {
+	entry := journal.Entry{Before: before, Local: s.journalHead(ctx, claudeDir)}
+	if err := s.importBundles(ctx, claudeDir, dir); err != nil {
+		return err
+	}
+
+	s.phase("push")
+	var exportErr error
+	own, err := ownBundle(dir)
+	if err != nil {
+		s.logger.Error("✗", "Failed to name this machine's bundle", err)
+		exportErr = err
+	} else {
+		exportErr = s.exportBundle(ctx, claudeDir, own, true)
+	}
+	if s.journal != nil {
+		entry.After = s.revParse(ctx, claudeDir, "HEAD")
+		entry.Time = time.Now()
+		s.record(claudeDir, entry)
+	}
+	return exportErr
+}
+
+// importBundles applies the bundles other machines left in a bundle
+// directory, in name order. A bundle that cannot be read, such as one
+// written by a newer claude-sync or one that builds on a bundle not
+// imported yet, is skipped with a warning; it is retried on the next sync.
+func (s *Service) importBundles(ctx context.Context, claudeDir, dir string) error {
+	own, err := ownBundle(dir)
+	if err != nil {
+		s.logger.Error("✗", "Failed to name this machine's bundle", err)
+		return err
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*"+git.BundleExt))
+	if err != nil {
+		s.logger.Error("✗", "Failed to list bundles in "+dir, err)
+		return err
+	}
+
+	imported := 0
+	for _, file := range files {
+		if file == own {
+			continue
+		}
+		source := strings.TrimSuffix(filepath.Base(file), git.BundleExt)
+		tip, err := s.readBundle(ctx, claudeDir, file, source)
+		if err != nil {
+			s.logger.Warning("⚠️", "Skipped "+filepath.Base(file))
+			s.logger.Muted("  " + err.Error())
+			s.logger.Newline()
+			continue
+		}
+		if _, err := s.applyBundle(ctx, claudeDir, file, tip); err != nil {
+			return err
+		}
+		imported++
+	}
+	if imported == 0 {
+		s.logger.Info("ℹ️", "No bundles from other machines in "+dir)
+		s.logger.Newline()
+	}
+	return nil
+}
+
+// readBundle verifies a bundle and fetches its commits, returning the
+// commit its copy of the current branch points to.
+func (s *Service) readBundle(ctx context.Context, claudeDir, file, source string) (string, error) {
+	var tip string
+	err := s.prompter.SpinWhile("Reading "+filepath.Base(file)+"...", func() error {
+		var err error
+		tip, err = s.git.FetchBundle(ctx, claudeDir, file, source)
+		return err
+	})
+	return tip, err
+}
+
+// applyBundle rebases onto a commit read from a bundle, unless the branch
+// already has it. In review mode the bundle's commits are shown first, and
+// it reports false when the user declines them.
+func (s *Service) applyBundle(ctx context.Context, claudeDir, file, tip string) (bool, error) {
+	incoming, err := s.git.GetCommitsInRange(ctx, claudeDir, "HEAD.."+tip)
+	if err != nil {
+		s.logger.Error("✗", "Failed to list the bundle's commits", err)
+		return false, err
+	}
+	if len(incoming) == 0 {
+		s.logger.Success("✓", "Already up to date with "+filepath.Base(file))
+		s.logger.Newline()
+		return true, nil
+	}
+	if s.review {
+		s.phase("review")
+		apply, err := s.reviewCommits(ctx, claudeDir, tip)
+		if err != nil {
+			return false, err
+		}
+		if !apply {
+			s.logger.Info("ℹ️", "Declined "+filepath.Base(file)+" - its commits were not applied")
+			s.logger.Muted("  They are shown again on the next sync")
+			s.logger.Newline()
+			return false, nil
+		}
+	}
+	s.logger.Info("📦", fmt.Sprintf("Applying %d commit(s) from %s", len(incoming), filepath.Base(file)))
+	approved := &approval{upstream: s.revParse(ctx, claudeDir, "HEAD"), onto: tip}
+	return true, s.pullWithRebaseAndHandleConflicts(ctx, claudeDir, approved)
+}
+
+// exportBundle writes the current branch to a bundle file.
+func (s *Service) exportBundle(ctx context.Context, claudeDir, file string, full bool) error {
+	s.phase("push")
+	var count int
+	err := s.prompter.SpinWhile("Writing "+filepath.Base(file)+"...", func() error {
+		var err error
+		count, err = s.git.CreateBundle(ctx, claudeDir, file, full)
+		return err
+	})
+	if err != nil {
+		s.logger.Error("✗", "Failed to write the bundle", err)
+		return err
+	}
+	if count == 0 {
+		s.logger.Success("✓", "Nothing to export - every commit came from imported bundles")
+		s.logger.Muted("  Use --full to export the whole history anyway")
+		s.logger.Newline()
+		s.data(map[string]any{"exported_commits": 0})
+		return nil
+	}
+	s.logger.Success("✓", fmt.Sprintf("Exported %d commit(s) to %s", count, file))
+	s.logger.Newline()
+	s.data(map[string]any{
+		"bundle":           file,
+		"exported_commits": count,
+		"head_sha":         s.revParse(ctx, claudeDir, "HEAD"),
+	})
+	return nil
+}
+
+// ownBundle returns this machine's bundle in a bundle directory, named
+// after the host like its overlay layers.
+func ownBundle(dir string) (string, error) {
+	sel, err := overlay.CurrentSelectors()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, sel.Host+git.BundleExt), nil
+}
@@ -0,0 +1,139 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mfenderov/claude-sync/internal/jsonmerge"
+)
+
+// isSettingsFile reports whether a conflicted path can be merged key by key:
+// settings.json and its overlay layers such as settings.base.json.
+func isSettingsFile(file string) bool {
+	name := filepath.Base(file)
+	return name == "settings.json" || (strings.HasPrefix(name, "settings.") && strings.HasSuffix(name, ".json"))
+}
+
+// needsResolver reports whether a conflicted path cannot be merged automatically.
+func needsResolver(file string) bool {
+	return !isSettingsFile(file)
+}
+
+// errManualResolution reports conflicts that need a human when no
+// ConflictResolver is configured.
+var errManualResolution = errors.New("conflicts need manual resolution")
+
+// resolveConflicts settles the conflicts of an in-progress pull rebase and
+// continues it until the rebase completes. settings.json is merged key by
+// key; other files go to the ConflictResolver when one is configured.
+// On failure it returns the files still conflicted, leaving the rebase in
+// progress for the caller to abort.
+func (s *Service) resolveConflicts(ctx context.Context, claudeDir string) ([]string, error) {
+	for {
+		files, err := s.git.GetConflictedFiles(ctx, claudeDir)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, errManualResolution
+		}
+		if s.resolver == nil && slices.ContainsFunc(files, needsResolver) {
+			return files, errManualResolution
+		}
+
+		for i, file := range files {
+			if isSettingsFile(file) && s.mergeSettingsFile(ctx, claudeDir, file) {
+				continue
+			}
+			if s.resolver == nil {
+				return files[i:], errManualResolution
+			}
+			if err := s.resolveFile(ctx, claudeDir, files, file); err != nil {
+				return files[i:], err
+			}
+		}
+
+		if err := s.git.ContinueRebase(ctx, claudeDir); err != nil {
+			// A later local commit may conflict with the remote as well
+			if hasConflicts, _ := s.git.HasConflicts(ctx, claudeDir); hasConflicts {
+				continue
+			}
+			return nil, err
+		}
+		s.logger.Newline()
+		return nil, nil
+	}
+}
+
+// resolveFile asks the ConflictResolver for the content of a conflicted file
+// and marks it resolved.
+func (s *Service) resolveFile(ctx context.Context, claudeDir string, files []string, file string) error {
+	_, remote, local, err := s.git.GetConflictVersions(ctx, claudeDir, file)
+	if err != nil {
+		return err
+	}
+
+	content, err := s.resolver.Resolve(files, FileConflict{
+		Path:     file,
+		FullPath: filepath.Join(claudeDir, file),
+		Remote:   remote,
+		Local:    local,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.git.ResolveConflict(ctx, claudeDir, file, content); err != nil {
+		return err
+	}
+	s.logger.Success("✓", "Resolved "+file)
+	return nil
+}
+
+// mergeSettingsFile merges and stages a single conflicted settings file.
+func (s *Service) mergeSettingsFile(ctx context.Context, claudeDir, file string) bool {
+	// During a rebase "ours" is the remote branch being replayed onto and
+	// "theirs" is the local commit
+	base, remote, local, err := s.git.GetConflictVersions(ctx, claudeDir, file)
+	if err != nil {
+		s.logger.Warning("⚠️", fmt.Sprintf("Could not read conflicting versions of %s: %v", file, err))
+		return false
+	}
+	if remote == nil || local == nil {
+		// Deleted on one side - not something a key merge can decide
+		return false
+	}
+
+	merged, conflicts, err := jsonmerge.Merge(base, remote, local)
+	if err != nil {
+		s.logger.Warning("⚠️", fmt.Sprintf("Could not merge %s: %v", file, err))
+		return false
+	}
+	if len(conflicts) > 0 {
+		s.logger.Warning("⚠️", fmt.Sprintf("%s changed the same keys on both machines:", file))
+		report := make([]map[string]string, 0, len(conflicts))
+		for _, c := range conflicts {
+			s.logger.ListItem(fmt.Sprintf("%s (remote: %s, local: %s)", c.Path, c.Ours, c.Theirs))
+			report = append(report, map[string]string{
+				"file":   file,
+				"key":    c.Path,
+				"remote": c.Ours,
+				"local":  c.Theirs,
+			})
+		}
+		s.logger.Newline()
+		s.data(map[string]any{"conflicts": report})
+		return false
+	}
+
+	if err := s.git.ResolveConflict(ctx, claudeDir, file, merged); err != nil {
+		s.logger.Warning("⚠️", fmt.Sprintf("Could not stage merged %s: %v", file, err))
+		return false
+	}
+	s.logger.Success("✓", fmt.Sprintf("Merged %s automatically", file))
+	return true
+}
@@ -0,0 +1,63 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mfenderov/claude-sync/internal/secretscan"
+	"github.com/mfenderov/claude-sync/internal/signing"
+)
+
+// ErrConflictAborted is returned by a ConflictResolver when the user chooses
+// to abort instead of resolving a conflict.
+var ErrConflictAborted = errors.New("conflict resolution aborted")
+
+// SecretsFoundError is returned when the secret scanner blocks a commit.
+type SecretsFoundError struct {
+	Findings []secretscan.Finding
+}
+
+var _ error = &SecretsFoundError{}
+
+func (e *SecretsFoundError) Error() string {
+	return fmt.Sprintf("found %d possible secret(s) in changed files", len(e.Findings))
+}
+
+// UnverifiedCommitsError is returned when incoming commits are unsigned or
+// signed by a key that is not an allowed signer.
+type UnverifiedCommitsError struct {
+	Rejections []signing.Rejection
+}
+
+var _ error = &UnverifiedCommitsError{}
+
+func (e *UnverifiedCommitsError) Error() string {
+	return fmt.Sprintf("%d incoming commit(s) are not signed by an allowed signer", len(e.Rejections))
+}
+
+// UnansweredError is returned when a question needs an answer but the
+// session is not interactive and no scripted answer was supplied.
+type UnansweredError struct {
+	Question string
+	Key      string
+}
+
+var _ error = &UnansweredError{}
+
+func (e *UnansweredError) Error() string {
+	return fmt.Sprintf("no answer for %q in non-interactive mode (set %s%s or add %q to the answers file)",
+		e.Question, answerEnvPrefix, e.Key, e.Key)
+}
+
+// InvalidAnswerError is returned when a scripted answer is not one of the allowed values.
+type InvalidAnswerError struct {
+	Question string
+	Answer   string
+	Allowed  []string
+}
+
+var _ error = &InvalidAnswerError{}
+
+func (e *InvalidAnswerError) Error() string {
+	return fmt.Sprintf("invalid answer %q for %q (allowed: %v)", e.Answer, e.Question, e.Allowed)
+}
@@ -0,0 +1,56 @@
+package sync
+
+import (
+	"context"
+	"strings"
+)
+
+// structured returns the logger's EventLogger side, or nil when the logger
+// only renders human-readable output.
+func (s *Service) structured() EventLogger {
+	el, _ := s.logger.(EventLogger)
+	return el
+}
+
+// phase marks the start of a sync phase for structured loggers.
+func (s *Service) phase(name string) {
+	if el := s.structured(); el != nil {
+		el.Phase(name)
+	}
+}
+
+// data records structured fields for structured loggers.
+func (s *Service) data(fields map[string]any) {
+	if el := s.structured(); el != nil {
+		el.Data(fields)
+	}
+}
+
+// revParse resolves a revision for structured output, returning "" when it
+// cannot be resolved (e.g. no upstream yet).
+func (s *Service) revParse(ctx context.Context, claudeDir, rev string) string {
+	sha, err := s.git.RevParse(ctx, claudeDir, rev)
+	if err != nil {
+		return ""
+	}
+	return sha
+}
+
+// commitsInRange lists commits for structured output, ignoring errors.
+func (s *Service) commitsInRange(ctx context.Context, claudeDir, revRange string) []map[string]string {
+	commits, err := s.git.GetCommitsInRange(ctx, claudeDir, revRange)
+	if err != nil {
+		return []map[string]string{}
+	}
+	return commitFields(commits)
+}
+
+// commitFields splits one-line "<sha> <subject>" commits into fields.
+func commitFields(commits []string) []map[string]string {
+	fields := make([]map[string]string, 0, len(commits))
+	for _, commit := range commits {
+		sha, subject, _ := strings.Cut(commit, " ")
+		fields = append(fields, map[string]string{"sha": sha, "subject": subject})
+	}
+	return fields
+}
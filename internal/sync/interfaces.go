@@ -3,7 +3,15 @@
 // from TUI interactions and git operations.
 package sync
 
-import "context"
+import (
+	"context"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+	"github.com/mfenderov/claude-sync/internal/hookgate"
+	"github.com/mfenderov/claude-sync/internal/journal"
+	"github.com/mfenderov/claude-sync/internal/secretscan"
+	"github.com/mfenderov/claude-sync/internal/signing"
+)
 
 // Prompter defines the interface for user interaction.
 // This allows the business logic to be tested with mock implementations.
@@ -41,6 +49,108 @@ type Logger interface {
 	Newline()
 }
 
+// EventLogger is optionally implemented by a Logger that also records
+// structured data, such as the NDJSON logger behind --output json.
+// The service only gathers the extra details when the logger asks for them.
+type EventLogger interface {
+	// Phase marks the start of a sync phase; later messages belong to it
+	Phase(name string)
+	// Data records structured fields for the current phase
+	Data(fields map[string]any)
+}
+
+// FileConflict holds both versions of a file that conflicted during a pull.
+// During the rebase the remote version is git's "ours" and the local
+// version is "theirs".
+type FileConflict struct {
+	// Path is relative to the Claude directory
+	Path string
+	// FullPath is the working tree file, which contains git's conflict markers
+	FullPath string
+	// Remote and Local are nil when that side deleted the file
+	Remote []byte
+	Local  []byte
+}
+
+// ConflictResolver lets the user decide how to resolve conflicted files.
+// Implementations are interactive; without one the service aborts the rebase
+// and explains how to resolve the conflicts manually.
+type ConflictResolver interface {
+	// Resolve returns the content to keep for conflict, or nil to delete the
+	// file. files lists every file conflicted in the current step, in order.
+	// It returns ErrConflictAborted when the user aborts.
+	Resolve(files []string, conflict FileConflict) ([]byte, error)
+}
+
+// Locker guards a repository against concurrent syncs from other processes.
+type Locker interface {
+	// Lock acquires the lock for the repository and returns the function
+	// that releases it. It fails when another process holds the lock.
+	Lock(ctx context.Context, repoPath string) (unlock func(), err error)
+}
+
+// SecretScanner checks files for credentials before they are committed.
+type SecretScanner interface {
+	// Scan returns the suspected secrets in files, which are relative to
+	// repoPath, and the files that were too large to scan in full.
+	Scan(repoPath string, files []string) (findings []secretscan.Finding, partial []string, err error)
+}
+
+// SecretStore keeps encrypted copies of secret files in the repository.
+type SecretStore interface {
+	// Seal encrypts changed secret files into their sidecars before a commit
+	// and returns the sidecars written.
+	Seal(ctx context.Context, repoPath string) ([]string, error)
+	// Unseal decrypts changed sidecars after a pull and returns the files written.
+	Unseal(ctx context.Context, repoPath string) ([]string, error)
+}
+
+// OverlayBuilder builds per-machine files such as settings.json from the
+// committed base and overlay layers.
+type OverlayBuilder interface {
+	// Capture writes local edits of built files back into their layers
+	// before a commit and returns the layers written.
+	Capture(ctx context.Context, repoPath string) ([]string, error)
+	// Apply builds files from their layers after a pull and returns the files written.
+	Apply(ctx context.Context, repoPath string) ([]string, error)
+}
+
+// Journal records what each sync changed, so the last one can be undone.
+type Journal interface {
+	// Record appends an entry to the repository's journal.
+	Record(repoPath string, entry journal.Entry) error
+	// Last returns the most recent entry, or nil when there is none.
+	Last(repoPath string) (*journal.Entry, error)
+}
+
+// SignatureVerifier checks that incoming commits are signed by a key the
+// repository trusts.
+type SignatureVerifier interface {
+	// Enabled reports whether the repository lists allowed signers.
+	Enabled(ctx context.Context, repoPath string) (bool, error)
+	// Verify returns the commits in revRange that are unsigned or signed by
+	// a key that is not allowed.
+	Verify(ctx context.Context, repoPath, revRange string) ([]signing.Rejection, error)
+}
+
+// HookGate keeps hook scripts and hook commands pulled from the remote
+// disabled until they are approved on this machine.
+type HookGate interface {
+	// Init starts the record of a repository with no hooks approved, so the
+	// hooks of a fresh clone wait for approval.
+	Init(ctx context.Context, repoPath string) error
+	// Capture approves the hooks edited on this machine before a commit and
+	// returns the quarantined files that were edited, which get their
+	// pulled hooks back so the edits can be committed.
+	Capture(ctx context.Context, repoPath string) ([]string, error)
+	// Release puts the committed content back into quarantined files before
+	// git updates the working tree.
+	Release(ctx context.Context, repoPath string) error
+	// Quarantine disables the hooks that are not approved and returns all
+	// hooks waiting for approval.
+	Quarantine(ctx context.Context, repoPath string) ([]hookgate.Hook, error)
+}
+
 // GitOperator defines the interface for git operations.
 // This allows the business logic to be tested with mock git operations.
 type GitOperator interface {
@@ -56,6 +166,7 @@ type GitOperator interface {
 	InitRepo(ctx context.Context, path string) error
 	CloneRepo(ctx context.Context, remoteURL, destPath string) error
 	SetupGitignore(path string) error
+	UpgradeGitignore(path string) (bool, error)
 	InitialCommit(ctx context.Context, path, message string) error
 
 	// Remote operations
@@ -63,20 +174,52 @@ type GitOperator interface {
 	RemoteHasCommits(ctx context.Context, remoteURL string) (bool, error)
 	AddRemote(ctx context.Context, path, name, url string) error
 	Fetch(ctx context.Context, path string) error
+	ListMirrors(ctx context.Context, path string) ([]string, error)
+	PushMirror(ctx context.Context, path, name string) error
+
+	// Bundle operations
+	CreateBundle(ctx context.Context, path, file string, full bool) (int, error)
+	FetchBundle(ctx context.Context, path, file, name string) (string, error)
+	BundleDir(ctx context.Context, path string) (string, error)
 
 	// Sync operations
 	HasUncommittedChanges(ctx context.Context, path string) (bool, error)
 	GetChangedFiles(ctx context.Context, path string) ([]string, error)
 	CommitChanges(ctx context.Context, path, message string) error
+	UntrackFiles(ctx context.Context, path string, files []string) error
 	PullWithRebase(ctx context.Context, path string) error
+	RebaseOnto(ctx context.Context, path, onto string) error
 	PullAllowUnrelatedHistories(ctx context.Context, path string) error
 	Push(ctx context.Context, path string) error
 	PushWithUpstream(ctx context.Context, path string) error
 
+	// Profile operations
+	SwitchProfile(ctx context.Context, path, name string, stash bool) error
+	ProfileBranch(ctx context.Context, path, name string) (string, error)
+
 	// Info operations
 	GetBranchInfo(ctx context.Context, path string) (branch string, ahead, behind int, err error)
 	GetRecentCommits(ctx context.Context, path string, count int) ([]string, error)
+	GetCommitsInRange(ctx context.Context, path, revRange string) ([]string, error)
+	GetFilesInRange(ctx context.Context, path, revRange string) ([]string, error)
+	RevParse(ctx context.Context, path, rev string) (string, error)
+	GenerateAutoCommitMessage() string
+
+	// History operations
+	ResetTo(ctx context.Context, path, rev string) error
+	RevertTo(ctx context.Context, path, rev, message string) error
+	Log(ctx context.Context, path string, opts git.LogOptions) ([]git.Commit, error)
+	ResolveRevision(ctx context.Context, path, at string) (string, error)
+	ListFilesAt(ctx context.Context, path, rev, file string) ([]string, error)
+	ReadFileAt(ctx context.Context, path, rev, file string) ([]byte, error)
+	RestorePath(ctx context.Context, path, rev, file string) error
+	CommitPath(ctx context.Context, path, file, message string) error
+
+	// Conflict operations
 	HasConflicts(ctx context.Context, path string) (bool, error)
+	GetConflictedFiles(ctx context.Context, path string) ([]string, error)
+	GetConflictVersions(ctx context.Context, path, file string) (base, ours, theirs []byte, err error)
+	ResolveConflict(ctx context.Context, path, file string, content []byte) error
+	ContinueRebase(ctx context.Context, path string) error
 	AbortRebase(ctx context.Context, path string) error
-	GenerateAutoCommitMessage() string
 }
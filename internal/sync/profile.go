@@ -0,0 +1,66 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+)
+
+// SwitchProfile makes another profile active: the profile's commits are
+// verified when the repository lists allowed signers, local changes are
+// committed to the current profile (or stashed when stash is set), the
+// profile's branch is checked out, and its secret files and overlays are
+// built. The next sync pulls from and pushes to the profile's remote branch.
+func (s *Service) SwitchProfile(ctx context.Context, name string, stash bool) error {
+	s.logger.Title("🎭 Switching Profile")
+
+	claudeDir, err := s.git.GetClaudeDir()
+	if err != nil {
+		s.logger.Error("✗", err.Error(), err)
+		return err
+	}
+	if !s.git.IsGitRepo(claudeDir) {
+		err := fmt.Errorf("%s is not a git repository - run claude-sync once to set up sync", claudeDir)
+		s.logger.Error("✗", "Cannot switch profile", err)
+		return err
+	}
+
+	unlock, err := s.lock(ctx, claudeDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := s.verifyProfile(ctx, claudeDir, name); err != nil {
+		return err
+	}
+
+	// Built files and secret plaintexts are ignored by git, so their edits
+	// are saved to the layers and sidecars before the tree changes
+	if stash {
+		if err := s.captureOverlays(ctx, claudeDir); err != nil {
+			return err
+		}
+		if err := s.sealSecrets(ctx, claudeDir); err != nil {
+			return err
+		}
+	} else if err := s.commitLocalChanges(ctx, claudeDir); err != nil {
+		return err
+	}
+
+	err = s.withHooksReleased(ctx, claudeDir, func() error {
+		return s.git.SwitchProfile(ctx, claudeDir, name, stash)
+	})
+	if err != nil {
+		s.logger.Error("✗", "Failed to switch profile", err)
+		return err
+	}
+	s.logger.Success("✓", "Switched to profile "+name)
+	s.logger.Newline()
+
+	s.unsealSecrets(ctx, claudeDir)
+	s.applyOverlays(ctx, claudeDir)
+
+	s.logger.Info("💡", "Run 'claude-sync' to sync with the profile's remote")
+	s.logger.Newline()
+	return nil
+}
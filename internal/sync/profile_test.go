@@ -0,0 +1,100 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mfenderov/claude-sync/internal/signing"
+)
+
+func TestService_SwitchProfile_CommitsFirst(t *testing.T) {
+	t.Parallel()
+
+	git := NewMockGitOperator(t)
+	prompter := NewMockPrompter(t)
+	logger := NewMockLogger(t)
+	overlays := NewMockOverlayBuilder(t)
+
+	claudeDir := "/home/user/.claude"
+
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	overlays.EXPECT().Capture(mock.Anything, claudeDir).Return(nil, nil)
+	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{"CLAUDE.md"}, nil)
+	git.EXPECT().GenerateAutoCommitMessage().Return("Auto-sync")
+	git.EXPECT().CommitChanges(mock.Anything, claudeDir, "Auto-sync").Return(nil)
+	git.EXPECT().SwitchProfile(mock.Anything, claudeDir, "work", false).Return(nil)
+	overlays.EXPECT().Apply(mock.Anything, claudeDir).Return(nil, nil)
+
+	logger.EXPECT().Success("✓", "Switched to profile work").Once()
+	logger.EXPECT().Title(mock.Anything).Maybe()
+	logger.EXPECT().Success(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().ListItem(mock.Anything).Maybe()
+	logger.EXPECT().Info(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Muted(mock.Anything).Maybe()
+	logger.EXPECT().Newline().Maybe()
+
+	service := NewService(git, prompter, logger, WithOverlays(overlays))
+	if err := service.SwitchProfile(context.Background(), "work", false); err != nil {
+		t.Fatalf("SwitchProfile() error = %v", err)
+	}
+}
+
+func TestService_SwitchProfile_StashFailure(t *testing.T) {
+	t.Parallel()
+
+	git := NewMockGitOperator(t)
+	prompter := NewMockPrompter(t)
+	logger := NewMockLogger(t)
+
+	claudeDir := "/home/user/.claude"
+	switchErr := errors.New("checkout failed")
+
+	// Stashing leaves the changes uncommitted, so nothing is listed
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	git.EXPECT().SwitchProfile(mock.Anything, claudeDir, "work", true).Return(switchErr)
+
+	logger.EXPECT().Error("✗", "Failed to switch profile", switchErr).Once()
+	logger.EXPECT().Title(mock.Anything).Maybe()
+
+	service := NewService(git, prompter, logger)
+	if err := service.SwitchProfile(context.Background(), "work", true); !errors.Is(err, switchErr) {
+		t.Fatalf("SwitchProfile() error = %v, want %v", err, switchErr)
+	}
+}
+
+func TestService_SwitchProfile_RejectsUnverifiedCommits(t *testing.T) {
+	t.Parallel()
+
+	git := NewMockGitOperator(t)
+	prompter := NewMockPrompter(t)
+	logger := NewMockLogger(t)
+	verifier := NewMockSignatureVerifier(t)
+
+	claudeDir := "/home/user/.claude"
+	rejections := []signing.Rejection{{SHA: "1234567890", Subject: "Add hook", Reason: "unsigned"}}
+
+	// Nothing is committed or checked out
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	verifier.EXPECT().Enabled(mock.Anything, claudeDir).Return(true, nil)
+	git.EXPECT().ProfileBranch(mock.Anything, claudeDir, "work").Return("work", nil)
+	verifier.EXPECT().Verify(mock.Anything, claudeDir, "HEAD..work").Return(rejections, nil)
+
+	logger.EXPECT().Error("✗", "Profile work has commits that failed signature verification - the profile was not switched",
+		mock.AnythingOfType("*sync.UnverifiedCommitsError")).Once()
+	logger.EXPECT().Title(mock.Anything).Maybe()
+	logger.EXPECT().Box(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Muted(mock.Anything).Maybe()
+	logger.EXPECT().Newline().Maybe()
+
+	service := NewService(git, prompter, logger, WithSignatureVerifier(verifier))
+	var verifyErr *UnverifiedCommitsError
+	if err := service.SwitchProfile(context.Background(), "work", false); !errors.As(err, &verifyErr) {
+		t.Fatalf("SwitchProfile() error = %v, want UnverifiedCommitsError", err)
+	}
+}
@@ -0,0 +1,82 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mfenderov/claude-sync/internal/journal"
+)
+
+// Pull commits local changes and pulls, without pushing. It is the quick
+// sync run when a Claude Code session starts; the commits it makes are
+// pushed by the next full sync. With a bundle directory it imports the
+// bundles there instead. In review mode the incoming changes are reviewed
+// first, as in Run.
+func (s *Service) Pull(ctx context.Context) error {
+	s.logger.Title("⬇️  Pull Latest Config")
+	s.phase("check")
+
+	claudeDir, err := s.git.GetClaudeDir()
+	if err != nil {
+		s.logger.Error("✗", err.Error(), err)
+		return err
+	}
+	if !s.git.IsGitRepo(claudeDir) {
+		err := fmt.Errorf("%s is not a git repository - run claude-sync once to set up sync", claudeDir)
+		s.logger.Error("✗", "Cannot pull", err)
+		return err
+	}
+
+	unlock, err := s.lock(ctx, claudeDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	s.upgradeGitignore(claudeDir)
+	entry := journal.Entry{Before: s.journalHead(ctx, claudeDir)}
+	bundleDir, err := s.git.BundleDir(ctx, claudeDir)
+	if err != nil {
+		s.logger.Error("✗", "Failed to read the bundle directory", err)
+		return err
+	}
+	// Bundles are reviewed one by one as they are imported
+	var approved *approval
+	if s.review && bundleDir == "" {
+		var ok bool
+		if approved, ok, err = s.reviewIncoming(ctx, claudeDir); err != nil || !ok {
+			return err
+		}
+	}
+	if err := s.commitLocalChanges(ctx, claudeDir); err != nil {
+		return err
+	}
+	if s.journal != nil {
+		entry.Local = s.revParse(ctx, claudeDir, "HEAD")
+		if approved != nil {
+			// The review already fetched; the range starts where the remote was before
+			entry.Pulled.From = approved.upstream
+		} else {
+			entry.Pulled.From = s.revParse(ctx, claudeDir, "@{upstream}")
+		}
+	}
+	if bundleDir != "" {
+		err = s.importBundles(ctx, claudeDir, bundleDir)
+	} else {
+		err = s.pullWithRebaseAndHandleConflicts(ctx, claudeDir, approved)
+	}
+	if err != nil {
+		return err
+	}
+	if s.journal != nil {
+		entry.Pulled.To = s.revParse(ctx, claudeDir, "@{upstream}")
+		entry.After = s.revParse(ctx, claudeDir, "HEAD")
+		entry.Time = time.Now()
+		s.record(claudeDir, entry)
+	}
+
+	s.logger.Success("✨", "Pull complete!")
+	s.logger.Newline()
+	return nil
+}
@@ -0,0 +1,86 @@
+package sync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestService_Pull_CommitsAndPullsWithoutPushing(t *testing.T) {
+	t.Parallel()
+
+	gitMock, prompter, logger := previewMocks(t)
+	claudeDir := "/home/user/.claude"
+
+	gitMock.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	gitMock.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	gitMock.EXPECT().IsGitRepo(claudeDir).Return(true)
+	gitMock.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
+	gitMock.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{"CLAUDE.md"}, nil)
+	gitMock.EXPECT().GenerateAutoCommitMessage().Return("Auto-sync")
+	gitMock.EXPECT().CommitChanges(mock.Anything, claudeDir, "Auto-sync").Return(nil)
+	gitMock.EXPECT().PullWithRebase(mock.Anything, claudeDir).Return(nil)
+	logger.EXPECT().Info(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().ListItem(mock.Anything).Maybe()
+	logger.EXPECT().Box(mock.Anything, mock.Anything).Maybe()
+
+	// Nothing is pushed: the strict mock fails on Push
+	service := NewService(gitMock, prompter, logger)
+	if err := service.Pull(context.Background()); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+}
+
+func expectPullReview(gitMock *MockGitOperator, claudeDir string) {
+	gitMock.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	gitMock.EXPECT().IsGitRepo(claudeDir).Return(true)
+	gitMock.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
+	gitMock.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	gitMock.EXPECT().RevParse(mock.Anything, claudeDir, "@{upstream}").Return("remote-old", nil).Once()
+	gitMock.EXPECT().Fetch(mock.Anything, claudeDir).Return(nil)
+	gitMock.EXPECT().RevParse(mock.Anything, claudeDir, "@{upstream}").Return("reviewed", nil).Once()
+	gitMock.EXPECT().GetCommitsInRange(mock.Anything, claudeDir, "HEAD..reviewed").Return([]string{"abc Auto-sync from laptop"}, nil)
+	gitMock.EXPECT().GetFilesInRange(mock.Anything, claudeDir, "HEAD...reviewed").Return([]string{"hooks/notify.sh"}, nil)
+}
+
+func TestService_Pull_ReviewApprovedRebasesOntoReviewedCommit(t *testing.T) {
+	t.Parallel()
+
+	gitMock, prompter, logger := previewMocks(t)
+	claudeDir := "/home/user/.claude"
+
+	expectPullReview(gitMock, claudeDir)
+	logger.EXPECT().Box(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Warning(mock.Anything, mock.Anything).Maybe()
+	prompter.EXPECT().Confirm("Apply these incoming changes?").Return(true, nil)
+	gitMock.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{}, nil)
+	gitMock.EXPECT().RebaseOnto(mock.Anything, claudeDir, "reviewed").Return(nil)
+	logger.EXPECT().Info(mock.Anything, mock.Anything).Maybe()
+
+	service := NewService(gitMock, prompter, logger, WithReview(true))
+	if err := service.Pull(context.Background()); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+}
+
+func TestService_Pull_ReviewUnansweredDeclines(t *testing.T) {
+	t.Parallel()
+
+	gitMock, prompter, logger := previewMocks(t)
+	claudeDir := "/home/user/.claude"
+
+	expectPullReview(gitMock, claudeDir)
+	logger.EXPECT().Box(mock.Anything, mock.Anything).Maybe()
+	prompter.EXPECT().Confirm("Apply these incoming changes?").
+		Return(false, &UnansweredError{Question: "Apply these incoming changes?", Key: QuestionKey("Apply these incoming changes?")})
+	logger.EXPECT().Warning("⚠️", "Nobody to review the incoming changes - declining them").Once()
+	logger.EXPECT().Warning("⚠️", mock.Anything).Maybe()
+	logger.EXPECT().Info("ℹ️", "Incoming changes declined - nothing was committed, pulled, or pushed").Once()
+
+	// Nothing is committed or pulled: the strict mock fails on any such call
+	service := NewService(gitMock, prompter, logger, WithReview(true))
+	if err := service.Pull(context.Background()); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+}
@@ -0,0 +1,314 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+	"github.com/mfenderov/claude-sync/internal/textdiff"
+)
+
+const (
+	// maxVersions caps the versions listed by Restore.
+	maxVersions = 20
+	// maxPreviewLines caps the diff shown when restoring a single file.
+	maxPreviewLines = 40
+)
+
+// Statuses of a file in a restore preview.
+const (
+	restoreMissing = "missing"
+	restoreChanged = "changed"
+)
+
+// version is a commit after which a path existed.
+type version struct {
+	commit git.Commit
+	files  int
+}
+
+// restoreFile is a file a restore writes, with how it differs from the
+// working tree.
+type restoreFile struct {
+	Path    string `json:"path"`
+	Status  string `json:"status"`
+	content []byte
+	current []byte
+}
+
+// Restore brings back a file or directory, relative to the Claude
+// directory, as it was at an earlier commit. It lists the versions in the
+// history and restores the one at, a revision or a date such as
+// "2 days ago", or the one the user picks when at is empty. After a preview
+// and confirmation the files are written to the working tree, and can be
+// committed and synced straight away.
+func (s *Service) Restore(ctx context.Context, path, at string) error {
+	s.logger.Title("🕰️  Restore From History")
+	s.phase("check")
+
+	claudeDir, err := s.git.GetClaudeDir()
+	if err != nil {
+		s.logger.Error("✗", err.Error(), err)
+		return err
+	}
+	if !s.git.IsGitRepo(claudeDir) {
+		err := fmt.Errorf("%s is not a git repository - run claude-sync once to set up sync", claudeDir)
+		s.logger.Error("✗", "Cannot restore", err)
+		return err
+	}
+
+	unlock, err := s.lock(ctx, claudeDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	s.phase("versions")
+	versions, err := s.versions(ctx, claudeDir, path)
+	if err != nil {
+		return err
+	}
+	rev, err := s.chooseVersion(ctx, claudeDir, at, versions)
+	if err != nil || rev == "" {
+		return err
+	}
+
+	s.phase("preview")
+	files, err := s.previewRestore(ctx, claudeDir, path, rev)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		s.logger.Info("ℹ️", fmt.Sprintf("%s already matches %s - nothing to restore", path, shortSHA(rev)))
+		s.logger.Newline()
+		return nil
+	}
+	confirmed, err := s.prompter.Confirm("Restore these files?")
+	if err != nil {
+		s.logger.Error("✗", "Failed to read input", err)
+		return err
+	}
+	if !confirmed {
+		s.logger.Info("ℹ️", "Restore cancelled - nothing was changed")
+		s.logger.Newline()
+		return nil
+	}
+
+	s.phase("restore")
+	before := s.journalHead(ctx, claudeDir)
+	// Restored hooks were previewed, so they are approved like local edits
+	err = s.withHooksReleased(ctx, claudeDir, func() error {
+		if err := s.git.RestorePath(ctx, claudeDir, rev, path); err != nil {
+			s.logger.Error("✗", "Failed to restore "+path, err)
+			return err
+		}
+		_, err := s.captureHooks(ctx, claudeDir)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	s.logger.Success("✓", fmt.Sprintf("Restored %d file(s) from %s", len(files), shortSHA(rev)))
+	for _, f := range files {
+		s.logger.ListItem("→ " + f.Path)
+	}
+	s.logger.Newline()
+	s.unsealSecrets(ctx, claudeDir)
+
+	syncNow, err := s.prompter.Confirm("Commit and sync the restored files now?")
+	if err != nil {
+		s.logger.Error("✗", "Failed to read input", err)
+		return err
+	}
+	if !syncNow {
+		s.logger.Muted("  The restored files are committed on the next sync")
+		s.logger.Newline()
+		s.data(map[string]any{"revision": rev, "synced": false})
+		return nil
+	}
+
+	head := s.revParse(ctx, claudeDir, "HEAD")
+	message := fmt.Sprintf("Restore %s from %s", path, shortSHA(rev))
+	if err := s.git.CommitPath(ctx, claudeDir, path, message); err != nil {
+		s.logger.Error("✗", "Failed to commit the restored files", err)
+		return err
+	}
+	// Restoring uncommitted edits back to the last commit leaves nothing to commit
+	if sha := s.revParse(ctx, claudeDir, "HEAD"); sha != head {
+		s.logger.Success("✓", "Committed "+message)
+		s.logger.Newline()
+		s.data(map[string]any{"revision": rev, "synced": true, "commit_sha": sha})
+	}
+	return s.commitPullAndPush(ctx, claudeDir, before)
+}
+
+// versions lists the recent commits after which path existed, newest first,
+// and shows them.
+func (s *Service) versions(ctx context.Context, claudeDir, path string) ([]version, error) {
+	commits, err := s.git.Log(ctx, claudeDir, git.LogOptions{Path: path, Limit: maxVersions})
+	if err != nil {
+		s.logger.Error("✗", "Failed to read the history of "+path, err)
+		return nil, err
+	}
+
+	var versions []version
+	var content strings.Builder
+	for _, c := range commits {
+		files, err := s.git.ListFilesAt(ctx, claudeDir, c.SHA, path)
+		if err != nil {
+			s.logger.Error("✗", "Failed to read the history of "+path, err)
+			return nil, err
+		}
+		// A commit that deleted the path left no version of it
+		if len(files) == 0 {
+			continue
+		}
+		v := version{commit: c, files: len(files)}
+		versions = append(versions, v)
+		content.WriteString(v.label() + "\n")
+	}
+	if len(versions) == 0 {
+		err := fmt.Errorf("%s has no history in %s", path, claudeDir)
+		s.logger.Error("✗", "Cannot restore", err)
+		return nil, err
+	}
+	s.logger.Box("Versions of "+path, strings.TrimRight(content.String(), "\n"))
+	return versions, nil
+}
+
+// label describes a version on one line.
+func (v version) label() string {
+	origin := v.commit.Subject
+	if v.commit.Host != "" {
+		origin = "from " + v.commit.Host
+	}
+	label := fmt.Sprintf("%s  %s  %s", shortSHA(v.commit.SHA), v.commit.Time.Local().Format("2006-01-02 15:04"), origin)
+	if v.files > 1 {
+		label += fmt.Sprintf(" (%d files)", v.files)
+	}
+	return label
+}
+
+// chooseVersion resolves at, or asks which version to restore when it is
+// empty. It returns "" when the user cancels.
+func (s *Service) chooseVersion(ctx context.Context, claudeDir, at string, versions []version) (string, error) {
+	if at != "" {
+		rev, err := s.git.ResolveRevision(ctx, claudeDir, at)
+		if err != nil {
+			s.logger.Error("✗", "Cannot restore", err)
+			return "", err
+		}
+		return rev, nil
+	}
+
+	options := make([]SelectOption, 0, len(versions)+1)
+	for _, v := range versions {
+		options = append(options, SelectOption{Label: v.label(), Value: shortSHA(v.commit.SHA)})
+	}
+	options = append(options, SelectOption{Label: "❌ Cancel", Value: "cancel"})
+	choice, err := s.prompter.Select("Which version should be restored?", options)
+	if err != nil {
+		s.logger.Error("✗", "Failed to read input", err)
+		return "", err
+	}
+	for _, v := range versions {
+		if shortSHA(v.commit.SHA) == choice {
+			return v.commit.SHA, nil
+		}
+	}
+	s.logger.Info("ℹ️", "Restore cancelled - nothing was changed")
+	s.logger.Newline()
+	return "", nil
+}
+
+// previewRestore shows how the files of path at rev differ from the working
+// tree and returns the ones a restore changes. A single file is previewed
+// as a diff.
+func (s *Service) previewRestore(ctx context.Context, claudeDir, path, rev string) ([]restoreFile, error) {
+	paths, err := s.git.ListFilesAt(ctx, claudeDir, rev, path)
+	if err != nil {
+		s.logger.Error("✗", "Failed to list the files to restore", err)
+		return nil, err
+	}
+	if len(paths) == 0 {
+		err := fmt.Errorf("%s does not exist at %s", path, shortSHA(rev))
+		s.logger.Error("✗", "Cannot restore", err)
+		return nil, err
+	}
+
+	var files []restoreFile
+	unchanged := 0
+	for _, p := range paths {
+		f := restoreFile{Path: p, Status: restoreMissing}
+		if f.content, err = s.git.ReadFileAt(ctx, claudeDir, rev, p); err != nil {
+			s.logger.Error("✗", "Failed to read "+p, err)
+			return nil, err
+		}
+		current, err := os.ReadFile(filepath.Join(claudeDir, filepath.FromSlash(p)))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			s.logger.Error("✗", "Failed to read "+p, err)
+			return nil, err
+		case bytes.Equal(current, f.content):
+			unchanged++
+			continue
+		default:
+			f.Status, f.current = restoreChanged, current
+		}
+		files = append(files, f)
+	}
+
+	var content strings.Builder
+	if len(paths) == 1 && len(files) == 1 {
+		content.WriteString(previewDiff(files[0]))
+	} else {
+		for _, f := range files {
+			if f.Status == restoreMissing {
+				content.WriteString("+ " + f.Path + " (missing, restored)\n")
+			} else {
+				content.WriteString("~ " + f.Path + " (changed, overwritten)\n")
+			}
+		}
+		if unchanged > 0 {
+			content.WriteString(fmt.Sprintf("  %d file(s) already match\n", unchanged))
+		}
+	}
+	if len(files) > 0 {
+		s.logger.Box(path+" at "+shortSHA(rev), strings.TrimRight(content.String(), "\n"))
+	}
+	s.data(map[string]any{"revision": rev, "files": files, "unchanged": unchanged})
+	return files, nil
+}
+
+// previewDiff renders the changes a restore makes to one file, cut short
+// after maxPreviewLines lines.
+func previewDiff(f restoreFile) string {
+	if bytes.IndexByte(f.content, 0) >= 0 || bytes.IndexByte(f.current, 0) >= 0 {
+		return "Binary file " + f.Status
+	}
+	var lines []string
+	for _, h := range textdiff.Hunks(textdiff.Lines(textdiff.SplitLines(f.current), textdiff.SplitLines(f.content)), 3) {
+		lines = append(lines, fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines))
+		for _, l := range h.Lines {
+			switch l.Kind {
+			case textdiff.Delete:
+				lines = append(lines, "-"+l.Text)
+			case textdiff.Insert:
+				lines = append(lines, "+"+l.Text)
+			default:
+				lines = append(lines, " "+l.Text)
+			}
+		}
+	}
+	if len(lines) > maxPreviewLines {
+		more := len(lines) - maxPreviewLines
+		lines = append(lines[:maxPreviewLines], fmt.Sprintf("... %d more line(s)", more))
+	}
+	return strings.Join(lines, "\n")
+}
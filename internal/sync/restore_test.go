@@ -0,0 +1,139 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+)
+
+// previewMocks is undoMocks without the Box expectation, so tests can check
+// previews.
+func previewMocks(t *testing.T) (*MockGitOperator, *MockPrompter, *MockLogger) {
+	t.Helper()
+
+	logger := NewMockLogger(t)
+	logger.EXPECT().Title(mock.Anything).Maybe()
+	logger.EXPECT().Success(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Muted(mock.Anything).Maybe()
+	logger.EXPECT().Newline().Maybe()
+
+	prompter := NewMockPrompter(t)
+	prompter.EXPECT().SpinWhile(mock.Anything, mock.Anything).RunAndReturn(func(msg string, task func() error) error {
+		return task()
+	}).Maybe()
+
+	return NewMockGitOperator(t), prompter, logger
+}
+
+var (
+	deletedSHA = strings.Repeat("c", 40)
+	skillSHA   = strings.Repeat("b", 40)
+	firstSHA   = strings.Repeat("a", 40)
+)
+
+func TestService_Restore_DeletedDirectory(t *testing.T) {
+	t.Parallel()
+
+	gitMock, prompter, logger := previewMocks(t)
+	claudeDir := t.TempDir()
+	skill := []string{"skills/review/SKILL.md", "skills/review/notes.md"}
+
+	gitMock.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	gitMock.EXPECT().IsGitRepo(claudeDir).Return(true)
+	gitMock.EXPECT().Log(mock.Anything, claudeDir, git.LogOptions{Path: "skills/review", Limit: maxVersions}).Return([]git.Commit{
+		{SHA: deletedSHA, Host: "laptop", Time: time.Date(2024, 1, 3, 0, 0, 0, 0, time.Local)},
+		{SHA: skillSHA, Subject: "Add notes", Time: time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local)},
+	}, nil)
+	gitMock.EXPECT().ListFilesAt(mock.Anything, claudeDir, deletedSHA, "skills/review").Return([]string{}, nil)
+	gitMock.EXPECT().ListFilesAt(mock.Anything, claudeDir, skillSHA, "skills/review").Return(skill, nil)
+	logger.EXPECT().Box("Versions of skills/review", mock.Anything).Once()
+	prompter.EXPECT().Select("Which version should be restored?", mock.Anything).RunAndReturn(func(_ string, options []SelectOption) (string, error) {
+		// The deleting commit left nothing to restore
+		if len(options) != 2 || options[0].Value != "bbbbbbb" || !strings.Contains(options[0].Label, "(2 files)") {
+			t.Errorf("options = %v, want the version before the deletion and cancel", options)
+		}
+		return "bbbbbbb", nil
+	})
+	gitMock.EXPECT().ReadFileAt(mock.Anything, claudeDir, skillSHA, skill[0]).Return([]byte("review"), nil)
+	gitMock.EXPECT().ReadFileAt(mock.Anything, claudeDir, skillSHA, skill[1]).Return([]byte("notes"), nil)
+	logger.EXPECT().Box("skills/review at bbbbbbb", "+ skills/review/SKILL.md (missing, restored)\n+ skills/review/notes.md (missing, restored)").Once()
+	prompter.EXPECT().Confirm("Restore these files?").Return(true, nil)
+	gitMock.EXPECT().RestorePath(mock.Anything, claudeDir, skillSHA, "skills/review").Return(nil)
+	logger.EXPECT().ListItem(mock.Anything).Maybe()
+	prompter.EXPECT().Confirm("Commit and sync the restored files now?").Return(false, nil)
+
+	service := NewService(gitMock, prompter, logger)
+	if err := service.Restore(context.Background(), "skills/review", ""); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+}
+
+func TestService_Restore_AtDateCommitsAndSyncs(t *testing.T) {
+	t.Parallel()
+
+	gitMock, prompter, logger := previewMocks(t)
+	claudeDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(claudeDir, "CLAUDE.md"), []byte("broken\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	gitMock.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	gitMock.EXPECT().IsGitRepo(claudeDir).Return(true)
+	gitMock.EXPECT().Log(mock.Anything, claudeDir, mock.Anything).Return([]git.Commit{{SHA: firstSHA, Subject: "Initial"}}, nil)
+	gitMock.EXPECT().ListFilesAt(mock.Anything, claudeDir, firstSHA, "CLAUDE.md").Return([]string{"CLAUDE.md"}, nil)
+	logger.EXPECT().Box("Versions of CLAUDE.md", mock.Anything).Once()
+	gitMock.EXPECT().ResolveRevision(mock.Anything, claudeDir, "2 days ago").Return(firstSHA, nil)
+	gitMock.EXPECT().ReadFileAt(mock.Anything, claudeDir, firstSHA, "CLAUDE.md").Return([]byte("rules\n"), nil)
+	logger.EXPECT().Box("CLAUDE.md at aaaaaaa", "@@ -1,1 +1,1 @@\n-broken\n+rules").Once()
+	prompter.EXPECT().Confirm("Restore these files?").Return(true, nil)
+	gitMock.EXPECT().RestorePath(mock.Anything, claudeDir, firstSHA, "CLAUDE.md").Return(nil)
+	logger.EXPECT().ListItem(mock.Anything).Maybe()
+	prompter.EXPECT().Confirm("Commit and sync the restored files now?").Return(true, nil)
+	gitMock.EXPECT().RevParse(mock.Anything, claudeDir, "HEAD").Return("head", nil).Once()
+	gitMock.EXPECT().CommitPath(mock.Anything, claudeDir, "CLAUDE.md", "Restore CLAUDE.md from aaaaaaa").Return(nil)
+	gitMock.EXPECT().RevParse(mock.Anything, claudeDir, "HEAD").Return("restored", nil).Once()
+	gitMock.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{}, nil)
+	gitMock.EXPECT().HasUncommittedChanges(mock.Anything, claudeDir).Return(false, nil)
+	gitMock.EXPECT().PullWithRebase(mock.Anything, claudeDir).Return(nil)
+	gitMock.EXPECT().Push(mock.Anything, claudeDir).Return(nil)
+	gitMock.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	gitMock.EXPECT().ListMirrors(mock.Anything, claudeDir).Return(nil, nil)
+	gitMock.EXPECT().GetRecentCommits(mock.Anything, claudeDir, 5).Return([]string{}, nil)
+	logger.EXPECT().Info(mock.Anything, mock.Anything).Maybe()
+
+	service := NewService(gitMock, prompter, logger)
+	if err := service.Restore(context.Background(), "CLAUDE.md", "2 days ago"); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+}
+
+func TestService_Restore_NothingToRestore(t *testing.T) {
+	t.Parallel()
+
+	gitMock, prompter, logger := previewMocks(t)
+	claudeDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(claudeDir, "CLAUDE.md"), []byte("rules\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	gitMock.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	gitMock.EXPECT().IsGitRepo(claudeDir).Return(true)
+	gitMock.EXPECT().Log(mock.Anything, claudeDir, mock.Anything).Return([]git.Commit{{SHA: firstSHA, Subject: "Initial"}}, nil)
+	gitMock.EXPECT().ListFilesAt(mock.Anything, claudeDir, firstSHA, "CLAUDE.md").Return([]string{"CLAUDE.md"}, nil)
+	logger.EXPECT().Box("Versions of CLAUDE.md", mock.Anything).Once()
+	prompter.EXPECT().Select(mock.Anything, mock.Anything).Return("aaaaaaa", nil)
+	gitMock.EXPECT().ReadFileAt(mock.Anything, claudeDir, firstSHA, "CLAUDE.md").Return([]byte("rules\n"), nil)
+	logger.EXPECT().Info("ℹ️", "CLAUDE.md already matches aaaaaaa - nothing to restore").Once()
+
+	service := NewService(gitMock, prompter, logger)
+	if err := service.Restore(context.Background(), "CLAUDE.md", ""); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+}
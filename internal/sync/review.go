@@ -0,0 +1,146 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// reviewCategories group incoming files in a review, in display order.
+// Files outside them are listed as other files.
+var reviewCategories = []struct {
+	name  string
+	title string
+}{
+	{"settings", "Settings"},
+	{"hooks", "Hooks"},
+	{"skills", "Skills"},
+	{"agents", "Agents"},
+	{"commands", "Commands"},
+	{"other", "Other files"},
+}
+
+// approval is the state of the remote the user approved in review mode.
+type approval struct {
+	// upstream is the upstream commit before the review fetched.
+	upstream string
+	// onto is the fetched upstream commit that was reviewed; the sync
+	// rebases onto it instead of pulling whatever the remote has by then.
+	onto string
+}
+
+// reviewIncoming fetches, shows the incoming commits and the files they
+// change by category, and asks whether to apply them. It reports false when
+// the user declines. The approval is nil when the branch has no upstream
+// yet, so there is nothing to review.
+func (s *Service) reviewIncoming(ctx context.Context, claudeDir string) (*approval, bool, error) {
+	s.phase("review")
+	upstream := s.revParse(ctx, claudeDir, "@{upstream}")
+	err := s.prompter.SpinWhile("Fetching incoming changes...", func() error {
+		return s.git.Fetch(ctx, claudeDir)
+	})
+	if err != nil {
+		s.logger.Error("✗", "Failed to fetch incoming changes", err)
+		return nil, false, err
+	}
+	onto := s.revParse(ctx, claudeDir, "@{upstream}")
+	if onto == "" {
+		return nil, true, nil
+	}
+	approved := &approval{upstream: upstream, onto: onto}
+
+	apply, err := s.reviewCommits(ctx, claudeDir, onto)
+	if err != nil {
+		return nil, false, err
+	}
+	if !apply {
+		s.logger.Info("ℹ️", "Incoming changes declined - nothing was committed, pulled, or pushed")
+		s.logger.Muted("  They are shown again on the next sync")
+		s.logger.Newline()
+		return nil, false, nil
+	}
+	return approved, true, nil
+}
+
+// reviewCommits shows the commits in HEAD..onto and the files they change by
+// category, and asks whether to apply them. It reports true without asking
+// when there is nothing to apply, and false when nobody can answer.
+func (s *Service) reviewCommits(ctx context.Context, claudeDir, onto string) (bool, error) {
+	commits, err := s.git.GetCommitsInRange(ctx, claudeDir, "HEAD.."+onto)
+	if err != nil {
+		s.logger.Error("✗", "Failed to list incoming commits", err)
+		return false, err
+	}
+	if len(commits) == 0 {
+		s.logger.Success("✓", "No incoming changes to review")
+		s.logger.Newline()
+		return true, nil
+	}
+	files, err := s.git.GetFilesInRange(ctx, claudeDir, "HEAD..."+onto)
+	if err != nil {
+		s.logger.Error("✗", "Failed to list incoming files", err)
+		return false, err
+	}
+
+	groups := map[string][]string{}
+	for _, file := range files {
+		groups[reviewCategory(file)] = append(groups[reviewCategory(file)], file)
+	}
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf("Commits (%d):\n", len(commits)))
+	for _, c := range commits {
+		content.WriteString("  " + c + "\n")
+	}
+	for _, category := range reviewCategories {
+		if len(groups[category.name]) == 0 {
+			continue
+		}
+		content.WriteString(fmt.Sprintf("\n%s (%d):\n", category.title, len(groups[category.name])))
+		for _, file := range groups[category.name] {
+			content.WriteString("  " + file + "\n")
+		}
+	}
+	s.logger.Box("Incoming Changes", strings.TrimRight(content.String(), "\n"))
+	if len(groups["hooks"]) > 0 || len(groups["settings"]) > 0 {
+		s.logger.Warning("⚠️", "Hooks and settings decide which commands run on this machine - check them before applying")
+		s.logger.Muted("  'claude-sync diff --incoming' shows the changes in detail")
+		s.logger.Newline()
+	}
+	s.data(map[string]any{
+		"incoming_commits": commitFields(commits),
+		"incoming_files":   groups,
+	})
+
+	apply, err := s.prompter.Confirm("Apply these incoming changes?")
+	var unanswered *UnansweredError
+	if errors.As(err, &unanswered) {
+		// Nobody can review, e.g. in a Claude Code hook: the changes wait
+		// for a sync that can
+		s.logger.Warning("⚠️", "Nobody to review the incoming changes - declining them")
+		apply, err = false, nil
+	}
+	if err != nil {
+		s.logger.Error("✗", "Failed to read input", err)
+		return false, err
+	}
+	s.data(map[string]any{"approved": apply})
+	return apply, nil
+}
+
+// reviewCategory returns the name of the review category of a file.
+func reviewCategory(file string) string {
+	top, _, nested := strings.Cut(file, "/")
+	if !nested {
+		// settings.json, its overlay layers, and settings.local.json
+		if strings.HasPrefix(file, "settings.") && strings.HasSuffix(file, ".json") {
+			return "settings"
+		}
+		return "other"
+	}
+	switch top {
+	case "hooks", "skills", "agents", "commands":
+		return top
+	}
+	return "other"
+}
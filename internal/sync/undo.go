@@ -0,0 +1,275 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mfenderov/claude-sync/internal/journal"
+)
+
+// journalHead returns HEAD for the journal entry of a sync that is starting,
+// or "" when no journal is configured.
+func (s *Service) journalHead(ctx context.Context, claudeDir string) string {
+	if s.journal == nil {
+		return ""
+	}
+	return s.revParse(ctx, claudeDir, "HEAD")
+}
+
+// pullAndPush pulls and pushes, applying only the reviewed commits when
+// approved is set. With a journal it records what the sync changed, starting
+// from before, HEAD before local changes were committed. A sync whose push
+// failed is recorded too, since its pull changed the tree.
+func (s *Service) pullAndPush(ctx context.Context, claudeDir, before string, approved *approval) error {
+	if s.journal == nil {
+		if err := s.pullWithRebaseAndHandleConflicts(ctx, claudeDir, approved); err != nil {
+			return err
+		}
+		return s.pushToRemote(ctx, claudeDir)
+	}
+
+	entry := journal.Entry{Before: before, Local: s.revParse(ctx, claudeDir, "HEAD")}
+	if approved != nil {
+		// The review already fetched; the range starts where the remote was before
+		entry.Pulled.From = approved.upstream
+	} else {
+		entry.Pulled.From = s.revParse(ctx, claudeDir, "@{upstream}")
+	}
+	if err := s.pullWithRebaseAndHandleConflicts(ctx, claudeDir, approved); err != nil {
+		return err
+	}
+	entry.Pulled.To = s.revParse(ctx, claudeDir, "@{upstream}")
+	pushErr := s.pushToRemote(ctx, claudeDir)
+	entry.Pushed = journal.Range{From: entry.Pulled.To, To: s.revParse(ctx, claudeDir, "@{upstream}")}
+	entry.After = s.revParse(ctx, claudeDir, "HEAD")
+	entry.Time = time.Now()
+	s.record(claudeDir, entry)
+	return pushErr
+}
+
+// record appends a journal entry for a sync that changed something. Failing
+// to record does not fail the sync, it only means it cannot be undone.
+func (s *Service) record(claudeDir string, entry journal.Entry) {
+	if !entry.Changed() || entry.Local == "" || entry.After == "" {
+		return
+	}
+	if err := s.journal.Record(claudeDir, entry); err != nil {
+		s.logger.Warning("⚠️", fmt.Sprintf("Could not record the sync for undo: %v", err))
+	}
+}
+
+// Undo restores the state from before the last sync. It previews the
+// commits the sync pulled and pushed, then either resets this machine to
+// the state before the pull or, when the sync reached the remote, adds a
+// commit that restores that state and pushes it.
+func (s *Service) Undo(ctx context.Context) error {
+	s.logger.Title("⏪ Undo Last Sync")
+	s.phase("check")
+
+	claudeDir, err := s.git.GetClaudeDir()
+	if err != nil {
+		s.logger.Error("✗", err.Error(), err)
+		return err
+	}
+	if !s.git.IsGitRepo(claudeDir) {
+		err := fmt.Errorf("%s is not a git repository - run claude-sync once to set up sync", claudeDir)
+		s.logger.Error("✗", "Cannot undo", err)
+		return err
+	}
+
+	unlock, err := s.lock(ctx, claudeDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entry, err := s.lastUndoable(ctx, claudeDir)
+	if err != nil || entry == nil {
+		return err
+	}
+
+	s.phase("preview")
+	onRemote := !entry.Pulled.Empty() || !entry.Pushed.Empty()
+	if err := s.previewUndo(ctx, claudeDir, entry); err != nil {
+		return err
+	}
+
+	options := []SelectOption{
+		{Label: "💻 Restore this machine only (the next sync brings the changes back)", Value: "local"},
+		{Label: "❌ Cancel", Value: "cancel"},
+	}
+	if onRemote {
+		revert := SelectOption{Label: "↩️  Restore and push a revert commit, so other machines follow", Value: "revert"}
+		options = append([]SelectOption{revert}, options...)
+	}
+	choice, err := s.prompter.Select("How should the sync be undone?", options)
+	if err != nil {
+		s.logger.Error("✗", "Failed to read input", err)
+		return err
+	}
+
+	s.phase("undo")
+	switch choice {
+	case "revert":
+		message := "Undo sync of " + entry.Time.Local().Format("2006-01-02 15:04:05")
+		err := s.withHooksReleased(ctx, claudeDir, func() error {
+			return s.git.RevertTo(ctx, claudeDir, entry.Local, message)
+		})
+		if err != nil {
+			s.logger.Error("✗", "Failed to create revert commit", err)
+			return err
+		}
+		s.logger.Success("✓", "Committed "+message)
+	case "local":
+		err := s.withHooksReleased(ctx, claudeDir, func() error {
+			return s.git.ResetTo(ctx, claudeDir, entry.Local)
+		})
+		if err != nil {
+			s.logger.Error("✗", "Failed to restore the previous state", err)
+			return err
+		}
+		s.logger.Success("✓", "Restored the state before the last sync")
+	default:
+		s.logger.Info("ℹ️", "Undo cancelled - nothing was changed")
+		s.logger.Newline()
+		return nil
+	}
+	s.logger.Newline()
+	s.unsealSecrets(ctx, claudeDir)
+	s.applyOverlays(ctx, claudeDir)
+
+	undo := journal.Entry{Time: time.Now(), Before: entry.After, Local: entry.After, Undo: true}
+	var pushErr error
+	if choice == "revert" {
+		undo.Pushed.From = s.revParse(ctx, claudeDir, "@{upstream}")
+		pushErr = s.pushToRemote(ctx, claudeDir)
+		undo.Pushed.To = s.revParse(ctx, claudeDir, "@{upstream}")
+	}
+	undo.After = s.revParse(ctx, claudeDir, "HEAD")
+	s.record(claudeDir, undo)
+	if pushErr != nil {
+		s.logger.Muted("  The revert commit is kept and pushed on the next sync")
+		s.logger.Newline()
+		return pushErr
+	}
+
+	if choice == "local" && onRemote {
+		s.logger.Info("💡", "The remote still has the changes; the next sync pulls them again")
+		s.logger.Newline()
+	}
+	s.data(map[string]any{"mode": choice, "head_sha": undo.After})
+	return nil
+}
+
+// lastUndoable returns the journal entry of the last sync, or nil after
+// explaining why there is nothing to undo. It fails when the repository
+// changed since that sync.
+func (s *Service) lastUndoable(ctx context.Context, claudeDir string) (*journal.Entry, error) {
+	if s.journal == nil {
+		err := errors.New("no sync journal is configured")
+		s.logger.Error("✗", "Cannot undo", err)
+		return nil, err
+	}
+	entry, err := s.journal.Last(claudeDir)
+	if err != nil {
+		s.logger.Error("✗", "Failed to read the sync journal", err)
+		return nil, err
+	}
+	if entry == nil {
+		s.logger.Info("ℹ️", "No sync recorded yet - nothing to undo")
+		s.logger.Newline()
+		return nil, nil
+	}
+	if entry.Undo {
+		s.logger.Info("ℹ️", "The last sync was already undone")
+		s.logger.Newline()
+		return nil, nil
+	}
+
+	head, err := s.git.RevParse(ctx, claudeDir, "HEAD")
+	if err != nil {
+		s.logger.Error("✗", "Failed to read HEAD", err)
+		return nil, err
+	}
+	if head != entry.After {
+		err := fmt.Errorf("the repository changed since the last sync (it ended at %s, HEAD is %s)",
+			shortSHA(entry.After), shortSHA(head))
+		s.logger.Error("✗", "Cannot undo", err)
+		s.logger.Muted("  Undo only restores the state before the most recent sync")
+		s.logger.Newline()
+		return nil, err
+	}
+	dirty, err := s.git.HasUncommittedChanges(ctx, claudeDir)
+	if err != nil {
+		s.logger.Error("✗", "Failed to check for uncommitted changes", err)
+		return nil, err
+	}
+	if dirty {
+		err := errors.New("there are uncommitted changes")
+		s.logger.Error("✗", "Cannot undo", err)
+		s.logger.Muted("  Run claude-sync to sync them, or discard them, and try again")
+		s.logger.Newline()
+		return nil, err
+	}
+	return entry, nil
+}
+
+// previewUndo shows what the last sync pulled and pushed and which files
+// undoing it changes.
+func (s *Service) previewUndo(ctx context.Context, claudeDir string, entry *journal.Entry) error {
+	var pulled, pushed []string
+	var err error
+	if !entry.Pulled.Empty() {
+		if pulled, err = s.git.GetCommitsInRange(ctx, claudeDir, entry.Pulled.String()); err != nil {
+			s.logger.Error("✗", "Failed to list pulled commits", err)
+			return err
+		}
+	}
+	if !entry.Pushed.Empty() {
+		if pushed, err = s.git.GetCommitsInRange(ctx, claudeDir, entry.Pushed.String()); err != nil {
+			s.logger.Error("✗", "Failed to list pushed commits", err)
+			return err
+		}
+	}
+	files, err := s.git.GetFilesInRange(ctx, claudeDir, entry.Local+".."+entry.After)
+	if err != nil {
+		s.logger.Error("✗", "Failed to list changed files", err)
+		return err
+	}
+
+	var content strings.Builder
+	writeSection := func(title string, lines []string) {
+		if content.Len() > 0 {
+			content.WriteString("\n")
+		}
+		content.WriteString(fmt.Sprintf("%s (%d):\n", title, len(lines)))
+		for _, line := range lines {
+			content.WriteString("  " + line + "\n")
+		}
+	}
+	writeSection("Pulled commits", pulled)
+	writeSection("Pushed commits", pushed)
+	writeSection("Files restored", files)
+	if files == nil {
+		files = []string{}
+	}
+	s.logger.Box("Sync of "+entry.Time.Local().Format("2006-01-02 15:04:05"), content.String())
+	s.data(map[string]any{
+		"sync_time":      entry.Time,
+		"pulled_commits": commitFields(pulled),
+		"pushed_commits": commitFields(pushed),
+		"files":          files,
+	})
+	return nil
+}
+
+// shortSHA abbreviates a commit SHA for messages.
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
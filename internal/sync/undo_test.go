@@ -0,0 +1,185 @@
+package sync
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mfenderov/claude-sync/internal/journal"
+)
+
+func undoMocks(t *testing.T) (*MockGitOperator, *MockPrompter, *MockLogger, *MockJournal) {
+	t.Helper()
+
+	logger := NewMockLogger(t)
+	logger.EXPECT().Title(mock.Anything).Maybe()
+	logger.EXPECT().Success(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Muted(mock.Anything).Maybe()
+	logger.EXPECT().Box(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Newline().Maybe()
+
+	prompter := NewMockPrompter(t)
+	prompter.EXPECT().SpinWhile(mock.Anything, mock.Anything).RunAndReturn(func(msg string, task func() error) error {
+		return task()
+	}).Maybe()
+
+	return NewMockGitOperator(t), prompter, logger, NewMockJournal(t)
+}
+
+func TestService_Run_RecordsJournal(t *testing.T) {
+	t.Parallel()
+
+	git, prompter, logger, journalMock := undoMocks(t)
+	claudeDir := "/home/user/.claude"
+
+	git.EXPECT().ClaudeDirExists().Return(true, nil)
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	git.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
+	git.EXPECT().RevParse(mock.Anything, claudeDir, "HEAD").Return("before", nil).Once()
+	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{"CLAUDE.md"}, nil)
+	git.EXPECT().GenerateAutoCommitMessage().Return("Auto-sync")
+	git.EXPECT().CommitChanges(mock.Anything, claudeDir, "Auto-sync").Return(nil)
+	git.EXPECT().HasUncommittedChanges(mock.Anything, claudeDir).Return(false, nil)
+	git.EXPECT().RevParse(mock.Anything, claudeDir, "HEAD").Return("local", nil).Once()
+	git.EXPECT().RevParse(mock.Anything, claudeDir, "@{upstream}").Return("remote-old", nil).Once()
+	git.EXPECT().PullWithRebase(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().RevParse(mock.Anything, claudeDir, "@{upstream}").Return("remote-new", nil).Once()
+	git.EXPECT().Push(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	git.EXPECT().ListMirrors(mock.Anything, claudeDir).Return(nil, nil)
+	git.EXPECT().RevParse(mock.Anything, claudeDir, "@{upstream}").Return("after", nil).Once()
+	git.EXPECT().RevParse(mock.Anything, claudeDir, "HEAD").Return("after", nil).Once()
+	git.EXPECT().GetRecentCommits(mock.Anything, claudeDir, 5).Return([]string{}, nil)
+	logger.EXPECT().ListItem(mock.Anything).Maybe()
+	logger.EXPECT().Info(mock.Anything, mock.Anything).Maybe()
+
+	journalMock.EXPECT().Record(claudeDir, mock.Anything).RunAndReturn(func(_ string, e journal.Entry) error {
+		want := journal.Entry{
+			Time:   e.Time,
+			Before: "before",
+			Local:  "local",
+			Pulled: journal.Range{From: "remote-old", To: "remote-new"},
+			Pushed: journal.Range{From: "remote-new", To: "after"},
+			After:  "after",
+		}
+		if e != want || e.Time.IsZero() {
+			t.Errorf("Record() entry = %+v, want %+v", e, want)
+		}
+		return nil
+	}).Once()
+
+	service := NewService(git, prompter, logger, WithJournal(journalMock))
+	if err := service.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
+
+var lastSync = &journal.Entry{
+	Time:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local),
+	Before: "before",
+	Local:  "local",
+	Pulled: journal.Range{From: "remote-old", To: "remote-new"},
+	Pushed: journal.Range{From: "remote-new", To: "after"},
+	After:  "after",
+}
+
+func expectUndoPreview(git *MockGitOperator, journalMock *MockJournal, claudeDir string) {
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	journalMock.EXPECT().Last(claudeDir).Return(lastSync, nil)
+	git.EXPECT().RevParse(mock.Anything, claudeDir, "HEAD").Return("after", nil).Once()
+	git.EXPECT().HasUncommittedChanges(mock.Anything, claudeDir).Return(false, nil)
+	git.EXPECT().GetCommitsInRange(mock.Anything, claudeDir, "remote-old..remote-new").Return([]string{"abc Broken hooks"}, nil)
+	git.EXPECT().GetCommitsInRange(mock.Anything, claudeDir, "remote-new..after").Return([]string{"def Auto-sync"}, nil)
+	git.EXPECT().GetFilesInRange(mock.Anything, claudeDir, "local..after").Return([]string{"hooks/notify.sh"}, nil)
+}
+
+func TestService_Undo_PushesRevertCommit(t *testing.T) {
+	t.Parallel()
+
+	git, prompter, logger, journalMock := undoMocks(t)
+	claudeDir := "/home/user/.claude"
+
+	expectUndoPreview(git, journalMock, claudeDir)
+	prompter.EXPECT().Select("How should the sync be undone?", mock.Anything).RunAndReturn(func(_ string, options []SelectOption) (string, error) {
+		if len(options) != 3 || options[0].Value != "revert" {
+			t.Errorf("options = %v, want revert first", options)
+		}
+		return "revert", nil
+	})
+	git.EXPECT().RevertTo(mock.Anything, claudeDir, "local", "Undo sync of 2024-01-02 03:04:05").Return(nil)
+	git.EXPECT().RevParse(mock.Anything, claudeDir, "@{upstream}").Return("after", nil).Once()
+	git.EXPECT().Push(mock.Anything, claudeDir).Return(nil)
+	git.EXPECT().ListMirrors(mock.Anything, claudeDir).Return(nil, nil)
+	git.EXPECT().RevParse(mock.Anything, claudeDir, "@{upstream}").Return("reverted", nil).Once()
+	git.EXPECT().RevParse(mock.Anything, claudeDir, "HEAD").Return("reverted", nil).Once()
+	journalMock.EXPECT().Record(claudeDir, mock.MatchedBy(func(e journal.Entry) bool {
+		return e.Undo && e.Before == "after" && e.After == "reverted" && e.Pushed == journal.Range{From: "after", To: "reverted"}
+	})).Return(nil).Once()
+
+	service := NewService(git, prompter, logger, WithJournal(journalMock))
+	if err := service.Undo(context.Background()); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+}
+
+func TestService_Undo_RestoresThisMachine(t *testing.T) {
+	t.Parallel()
+
+	git, prompter, logger, journalMock := undoMocks(t)
+	claudeDir := "/home/user/.claude"
+
+	expectUndoPreview(git, journalMock, claudeDir)
+	prompter.EXPECT().Select(mock.Anything, mock.Anything).Return("local", nil)
+	git.EXPECT().ResetTo(mock.Anything, claudeDir, "local").Return(nil)
+	git.EXPECT().RevParse(mock.Anything, claudeDir, "HEAD").Return("local", nil).Once()
+	journalMock.EXPECT().Record(claudeDir, mock.MatchedBy(func(e journal.Entry) bool {
+		return e.Undo && e.After == "local" && e.Pushed.Empty()
+	})).Return(nil).Once()
+	logger.EXPECT().Info("💡", "The remote still has the changes; the next sync pulls them again").Once()
+
+	service := NewService(git, prompter, logger, WithJournal(journalMock))
+	if err := service.Undo(context.Background()); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+}
+
+func TestService_Undo_RefusesAfterLaterChanges(t *testing.T) {
+	t.Parallel()
+
+	git, prompter, logger, journalMock := undoMocks(t)
+	claudeDir := "/home/user/.claude"
+
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	journalMock.EXPECT().Last(claudeDir).Return(lastSync, nil)
+	git.EXPECT().RevParse(mock.Anything, claudeDir, "HEAD").Return("newer", nil)
+	logger.EXPECT().Error("✗", "Cannot undo", mock.Anything).Once()
+
+	service := NewService(git, prompter, logger, WithJournal(journalMock))
+	err := service.Undo(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "changed since the last sync") {
+		t.Fatalf("Undo() error = %v", err)
+	}
+}
+
+func TestService_Undo_NothingRecorded(t *testing.T) {
+	t.Parallel()
+
+	git, prompter, logger, journalMock := undoMocks(t)
+	claudeDir := "/home/user/.claude"
+
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	journalMock.EXPECT().Last(claudeDir).Return(&journal.Entry{Undo: true}, nil)
+	logger.EXPECT().Info("ℹ️", "The last sync was already undone").Once()
+
+	service := NewService(git, prompter, logger, WithJournal(journalMock))
+	if err := service.Undo(context.Background()); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+}
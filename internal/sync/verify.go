@@ -0,0 +1,101 @@
+package sync
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mfenderov/claude-sync/internal/signing"
+)
+
+// verifyIncoming checks that the incoming commits are signed by an allowed
+// signer before they are rebased onto. Without a review it fetches first and
+// returns an approval for the verified commit, so the sync rebases onto
+// exactly what was checked. Rejected commits stop the sync before anything
+// is pulled or pushed.
+func (s *Service) verifyIncoming(ctx context.Context, claudeDir string, approved *approval) (*approval, error) {
+	if s.verifier == nil {
+		return approved, nil
+	}
+	enabled, err := s.verifier.Enabled(ctx, claudeDir)
+	if err != nil {
+		s.logger.Error("✗", "Failed to read the allowed signers", err)
+		return nil, err
+	}
+	if !enabled {
+		return approved, nil
+	}
+
+	if approved == nil {
+		upstream := s.revParse(ctx, claudeDir, "@{upstream}")
+		err := s.prompter.SpinWhile("Fetching incoming changes...", func() error {
+			return s.git.Fetch(ctx, claudeDir)
+		})
+		if err != nil {
+			s.logger.Error("✗", "Failed to fetch incoming changes", err)
+			return nil, err
+		}
+		onto := s.revParse(ctx, claudeDir, "@{upstream}")
+		if onto == "" {
+			return nil, nil
+		}
+		approved = &approval{upstream: upstream, onto: onto}
+	}
+
+	if err := s.verifyCommits(ctx, claudeDir, approved.onto,
+		"Incoming commits failed signature verification - nothing was pulled or pushed", "sync"); err != nil {
+		return nil, err
+	}
+	return approved, nil
+}
+
+// verifyProfile checks that the commits a profile switch would check out
+// are signed by an allowed signer. A profile branch fetched from its remote
+// has not been verified by a sync yet.
+func (s *Service) verifyProfile(ctx context.Context, claudeDir, name string) error {
+	if s.verifier == nil {
+		return nil
+	}
+	enabled, err := s.verifier.Enabled(ctx, claudeDir)
+	if err != nil {
+		s.logger.Error("✗", "Failed to read the allowed signers", err)
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+	branch, err := s.git.ProfileBranch(ctx, claudeDir, name)
+	if err != nil {
+		s.logger.Error("✗", "Failed to switch profile", err)
+		return err
+	}
+	return s.verifyCommits(ctx, claudeDir, branch,
+		"Profile "+name+" has commits that failed signature verification - the profile was not switched", "switch")
+}
+
+// verifyCommits verifies the commits in HEAD..onto and reports the rejected
+// ones with how to trust their signers before running the command again.
+func (s *Service) verifyCommits(ctx context.Context, claudeDir, onto, failure, retry string) error {
+	rejections, err := s.verifier.Verify(ctx, claudeDir, "HEAD.."+onto)
+	if err != nil {
+		s.logger.Error("✗", "Failed to verify incoming commits", err)
+		return err
+	}
+	if len(rejections) == 0 {
+		return nil
+	}
+
+	verifyErr := &UnverifiedCommitsError{Rejections: rejections}
+	var content strings.Builder
+	for _, r := range rejections {
+		content.WriteString(shortSHA(r.SHA) + " " + r.Subject + " — " + r.Reason + "\n")
+	}
+	s.logger.Box("Unverified Incoming Commits", strings.TrimRight(content.String(), "\n"))
+	s.logger.Error("✗", failure, verifyErr)
+	s.logger.Muted("  Only commits signed by a key in " + signing.FileName + " are applied")
+	s.logger.Muted("  To trust another machine: claude-sync signing allow <its public key> --name <machine>")
+	s.logger.Muted("  then " + retry + " again")
+	s.logger.Muted("  To accept commits made before their machine signed: claude-sync signing accept")
+	s.logger.Newline()
+	s.data(map[string]any{"unverified_commits": rejections})
+	return verifyErr
+}
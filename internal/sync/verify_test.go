@@ -0,0 +1,74 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mfenderov/claude-sync/internal/signing"
+)
+
+func expectVerification(gitMock *MockGitOperator, verifier *MockSignatureVerifier, claudeDir string, rejections []signing.Rejection) {
+	gitMock.EXPECT().ClaudeDirExists().Return(true, nil)
+	gitMock.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	gitMock.EXPECT().IsGitRepo(claudeDir).Return(true)
+	gitMock.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
+	gitMock.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil)
+	gitMock.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{}, nil)
+	gitMock.EXPECT().HasUncommittedChanges(mock.Anything, claudeDir).Return(false, nil)
+	verifier.EXPECT().Enabled(mock.Anything, claudeDir).Return(true, nil)
+	gitMock.EXPECT().RevParse(mock.Anything, claudeDir, "@{upstream}").Return("remote-old", nil).Once()
+	gitMock.EXPECT().Fetch(mock.Anything, claudeDir).Return(nil)
+	gitMock.EXPECT().RevParse(mock.Anything, claudeDir, "@{upstream}").Return("fetched", nil).Once()
+	verifier.EXPECT().Verify(mock.Anything, claudeDir, "HEAD..fetched").Return(rejections, nil)
+}
+
+func TestService_Run_RejectsUnverifiedIncomingCommits(t *testing.T) {
+	t.Parallel()
+
+	gitMock, prompter, logger := previewMocks(t)
+	verifier := NewMockSignatureVerifier(t)
+	claudeDir := "/home/user/.claude"
+
+	rejections := []signing.Rejection{
+		{SHA: "1234567890", Subject: "Add hook", Reason: "unsigned"},
+		{SHA: "abcdef1234", Subject: "Auto-sync", Reason: "signed by a key that is not an allowed signer", Key: "SHA256:x"},
+	}
+	expectVerification(gitMock, verifier, claudeDir, rejections)
+	logger.EXPECT().Box("Unverified Incoming Commits",
+		"1234567 Add hook — unsigned\nabcdef1 Auto-sync — signed by a key that is not an allowed signer").Once()
+	logger.EXPECT().Error("✗", mock.Anything, mock.Anything).Once()
+
+	// Nothing is pulled or pushed: the strict mocks fail on any such call
+	service := NewService(gitMock, prompter, logger, WithSignatureVerifier(verifier))
+	err := service.Run(context.Background())
+	var verifyErr *UnverifiedCommitsError
+	if !errors.As(err, &verifyErr) || len(verifyErr.Rejections) != 2 {
+		t.Fatalf("Run() error = %v, want an UnverifiedCommitsError", err)
+	}
+}
+
+func TestService_Run_RebasesOntoVerifiedCommit(t *testing.T) {
+	t.Parallel()
+
+	gitMock, prompter, logger := previewMocks(t)
+	verifier := NewMockSignatureVerifier(t)
+	claudeDir := "/home/user/.claude"
+
+	// The sync applies exactly the commits that were verified, even if the
+	// remote moved on since the fetch
+	expectVerification(gitMock, verifier, claudeDir, nil)
+	gitMock.EXPECT().RebaseOnto(mock.Anything, claudeDir, "fetched").Return(nil)
+	gitMock.EXPECT().Push(mock.Anything, claudeDir).Return(nil)
+	gitMock.EXPECT().ListMirrors(mock.Anything, claudeDir).Return(nil, nil)
+	gitMock.EXPECT().GetRecentCommits(mock.Anything, claudeDir, 5).Return([]string{}, nil)
+	logger.EXPECT().Info(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Box(mock.Anything, mock.Anything).Maybe()
+
+	service := NewService(gitMock, prompter, logger, WithSignatureVerifier(verifier))
+	if err := service.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Default watch intervals.
+const (
+	DefaultWatchInterval = 5 * time.Minute
+	DefaultMaxBackoff    = 30 * time.Minute
+)
+
+// WatchOptions configures Service.Watch.
+type WatchOptions struct {
+	// Interval between pull/push rounds; defaults to DefaultWatchInterval
+	Interval time.Duration
+	// MaxBackoff caps the retry delay after failed rounds; defaults to DefaultMaxBackoff
+	MaxBackoff time.Duration
+}
+
+// Watch keeps the Claude directory in sync until ctx is cancelled. Each batch
+// received from changes (settled file changes) is committed right away, and
+// every Interval local commits are pushed after pulling remote ones. Failed
+// rounds, typically network errors, are retried with exponential backoff.
+//
+// Cancelling ctx stops watching after the git operation in flight finishes,
+// so a shutdown never interrupts a rebase halfway.
+func (s *Service) Watch(ctx context.Context, changes <-chan []string, opts WatchOptions) error {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultWatchInterval
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultMaxBackoff
+	}
+
+	claudeDir, err := s.git.GetClaudeDir()
+	if err != nil {
+		s.logger.Error("✗", err.Error(), err)
+		return err
+	}
+	if !s.git.IsGitRepo(claudeDir) {
+		err := fmt.Errorf("%s is not a git repository - run claude-sync once to set up sync", claudeDir)
+		s.logger.Error("✗", "Cannot watch", err)
+		return err
+	}
+
+	s.logger.Title("👀 Watching Claude Config")
+	s.logger.Info("ℹ️", "Watching "+claudeDir)
+	s.logger.Muted(fmt.Sprintf("  Syncing with remote every %s - press Ctrl+C to stop", opts.Interval))
+	s.logger.Newline()
+
+	// Git operations must not be killed mid-way by the shutdown signal
+	opCtx := context.WithoutCancel(ctx)
+
+	failures := 0
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("👋", "Stopped watching")
+			return nil
+
+		case files, ok := <-changes:
+			if !ok {
+				s.logger.Info("👋", "Stopped watching")
+				return nil
+			}
+			s.phase("watch")
+			s.data(map[string]any{"changed_paths": files})
+			// Failures are reported as they happen; the next round retries
+			if unlock, err := s.lock(opCtx, claudeDir); err == nil {
+				_ = s.commitLocalChanges(opCtx, claudeDir)
+				unlock()
+			}
+
+		case <-timer.C:
+			if err := s.syncRound(opCtx, claudeDir); err != nil {
+				failures++
+				delay := backoff(opts.Interval, opts.MaxBackoff, failures)
+				s.logger.Warning("⚠️", fmt.Sprintf("Sync failed - retrying in %s", delay))
+				s.logger.Newline()
+				timer.Reset(delay)
+				continue
+			}
+			failures = 0
+			timer.Reset(opts.Interval)
+		}
+	}
+}
+
+// syncRound commits anything left over, then pulls and pushes.
+func (s *Service) syncRound(ctx context.Context, claudeDir string) error {
+	unlock, err := s.lock(ctx, claudeDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	s.upgradeGitignore(claudeDir)
+	before := s.journalHead(ctx, claudeDir)
+	if err := s.commitLocalChanges(ctx, claudeDir); err != nil {
+		return err
+	}
+	return s.pullAndPush(ctx, claudeDir, before, nil)
+}
+
+// backoff returns the delay before the next round after the given number of
+// consecutive failures: the interval doubled per failure, capped at limit.
+func backoff(interval, limit time.Duration, failures int) time.Duration {
+	delay := interval
+	for i := 0; i < failures && delay < limit; i++ {
+		delay *= 2
+	}
+	return min(delay, limit)
+}
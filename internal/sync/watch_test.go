@@ -0,0 +1,148 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func watchMocks(t *testing.T) (*MockGitOperator, *MockPrompter, *MockLogger) {
+	t.Helper()
+
+	git := NewMockGitOperator(t)
+	prompter := NewMockPrompter(t)
+	logger := NewMockLogger(t)
+
+	logger.EXPECT().Title(mock.Anything).Maybe()
+	logger.EXPECT().Success(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Error(mock.Anything, mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Info(mock.Anything, mock.Anything).Maybe()
+	logger.EXPECT().Muted(mock.Anything).Maybe()
+	logger.EXPECT().ListItem(mock.Anything).Maybe()
+	logger.EXPECT().Newline().Maybe()
+
+	prompter.EXPECT().SpinWhile(mock.Anything, mock.Anything).RunAndReturn(func(msg string, task func() error) error {
+		return task()
+	}).Maybe()
+
+	return git, prompter, logger
+}
+
+func TestService_Watch_CommitsSettledChanges(t *testing.T) {
+	t.Parallel()
+
+	git, prompter, logger := watchMocks(t)
+	claudeDir := "/home/user/.claude"
+	synced := make(chan struct{})
+	committed := make(chan struct{})
+
+	logger.EXPECT().Warning(mock.Anything, mock.Anything).Maybe()
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	// Initial round: nothing to commit, then pull and push
+	git.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
+	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{}, nil).Once()
+	git.EXPECT().PullWithRebase(mock.Anything, claudeDir).Return(nil).Once()
+	git.EXPECT().Push(mock.Anything, claudeDir).RunAndReturn(func(context.Context, string) error {
+		close(synced)
+		return nil
+	}).Once()
+	git.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil).Maybe()
+	git.EXPECT().ListMirrors(mock.Anything, claudeDir).Return(nil, nil).Maybe()
+	// Settled change: committed without waiting for the next round
+	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{"settings.json"}, nil).Once()
+	git.EXPECT().GenerateAutoCommitMessage().Return("Auto-sync: 2024-01-01")
+	git.EXPECT().CommitChanges(mock.Anything, claudeDir, "Auto-sync: 2024-01-01").RunAndReturn(func(context.Context, string, string) error {
+		close(committed)
+		return nil
+	}).Once()
+
+	service := NewService(git, prompter, logger)
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan []string)
+	done := make(chan error)
+	go func() {
+		done <- service.Watch(ctx, changes, WatchOptions{Interval: time.Hour})
+	}()
+
+	<-synced
+	changes <- []string{"settings.json"}
+	<-committed
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+}
+
+func TestService_Watch_RetriesFailedRounds(t *testing.T) {
+	t.Parallel()
+
+	git, prompter, logger := watchMocks(t)
+	claudeDir := "/home/user/.claude"
+	recovered := make(chan struct{})
+
+	git.EXPECT().GetClaudeDir().Return(claudeDir, nil)
+	git.EXPECT().IsGitRepo(claudeDir).Return(true)
+	git.EXPECT().UpgradeGitignore(claudeDir).Return(false, nil)
+	git.EXPECT().GetChangedFiles(mock.Anything, claudeDir).Return([]string{}, nil)
+	git.EXPECT().PullWithRebase(mock.Anything, claudeDir).Return(errors.New("could not resolve host")).Once()
+	git.EXPECT().HasConflicts(mock.Anything, claudeDir).Return(false, nil).Once()
+	git.EXPECT().PullWithRebase(mock.Anything, claudeDir).Return(nil).Once()
+	git.EXPECT().Push(mock.Anything, claudeDir).RunAndReturn(func(context.Context, string) error {
+		close(recovered)
+		return nil
+	}).Once()
+	git.EXPECT().BundleDir(mock.Anything, claudeDir).Return("", nil).Maybe()
+	git.EXPECT().ListMirrors(mock.Anything, claudeDir).Return(nil, nil).Maybe()
+	logger.EXPECT().Warning(mock.Anything, "Sync failed - retrying in 20ms").Once()
+
+	service := NewService(git, prompter, logger)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- service.Watch(ctx, nil, WatchOptions{Interval: 10 * time.Millisecond, MaxBackoff: time.Hour})
+	}()
+
+	<-recovered
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+}
+
+func TestService_Watch_NotGitRepo(t *testing.T) {
+	t.Parallel()
+
+	git, prompter, logger := watchMocks(t)
+	git.EXPECT().GetClaudeDir().Return("/home/user/.claude", nil)
+	git.EXPECT().IsGitRepo("/home/user/.claude").Return(false)
+
+	service := NewService(git, prompter, logger)
+	if err := service.Watch(context.Background(), nil, WatchOptions{}); err == nil {
+		t.Fatal("Watch() should fail outside a git repository")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, 10 * time.Minute},
+		{2, 20 * time.Minute},
+		{3, 30 * time.Minute},
+		{10, 30 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := backoff(5*time.Minute, 30*time.Minute, tt.failures); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package textdiff
+
+import (
+	"bytes"
+	"math"
+	"slices"
+	"strings"
+)
+
+// change replaces the base lines [start, end) with lines
+type change struct {
+	start, end int
+	lines      []string
+}
+
+// Merge3 merges the edits from base to ours and from base to theirs line by
+// line. Edits to separate lines are combined; edits to the same or adjacent
+// lines conflict unless both sides made the same edit. Conflicts are written
+// with git's markers, labelled oursLabel and theirsLabel, and clean is false.
+func Merge3(base, ours, theirs []byte, oursLabel, theirsLabel string) (merged []byte, clean bool) {
+	baseLines := splitKeepEnds(base)
+	oursChanges := changes(baseLines, splitKeepEnds(ours))
+	theirsChanges := changes(baseLines, splitKeepEnds(theirs))
+
+	var out bytes.Buffer
+	clean = true
+	pos := 0
+	for len(oursChanges) > 0 || len(theirsChanges) > 0 {
+		// A hunk is the earliest change and every change touching it
+		start := math.MaxInt
+		if len(oursChanges) > 0 {
+			start = oursChanges[0].start
+		}
+		if len(theirsChanges) > 0 && theirsChanges[0].start < start {
+			start = theirsChanges[0].start
+		}
+		end := start
+		var oursHunk, theirsHunk []change
+		for grown := true; grown; {
+			switch {
+			case len(oursChanges) > 0 && oursChanges[0].start <= end:
+				end = max(end, oursChanges[0].end)
+				oursHunk = append(oursHunk, oursChanges[0])
+				oursChanges = oursChanges[1:]
+			case len(theirsChanges) > 0 && theirsChanges[0].start <= end:
+				end = max(end, theirsChanges[0].end)
+				theirsHunk = append(theirsHunk, theirsChanges[0])
+				theirsChanges = theirsChanges[1:]
+			default:
+				grown = false
+			}
+		}
+		writeLines(&out, baseLines[pos:start])
+		pos = end
+
+		oursText := apply(baseLines, start, end, oursHunk)
+		theirsText := apply(baseLines, start, end, theirsHunk)
+		switch {
+		case len(theirsHunk) == 0:
+			writeLines(&out, oursText)
+		case len(oursHunk) == 0 || slices.Equal(oursText, theirsText):
+			writeLines(&out, theirsText)
+		default:
+			clean = false
+			out.WriteString("<<<<<<< " + oursLabel + "\n")
+			writeSide(&out, oursText)
+			out.WriteString("=======\n")
+			writeSide(&out, theirsText)
+			out.WriteString(">>>>>>> " + theirsLabel + "\n")
+		}
+	}
+	writeLines(&out, baseLines[pos:])
+	return out.Bytes(), clean
+}
+
+// splitKeepEnds splits content into lines that keep their line endings, so
+// joining them gives back the content exactly
+func splitKeepEnds(content []byte) []string {
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// changes lists the runs of base lines that other replaces, in order
+func changes(base, other []string) []change {
+	var result []change
+	var current *change
+	i := 0
+	for _, line := range Lines(base, other) {
+		switch line.Kind {
+		case Equal:
+			if current != nil {
+				current.end = i
+				result = append(result, *current)
+				current = nil
+			}
+			i++
+		case Delete:
+			if current == nil {
+				current = &change{start: i}
+			}
+			i++
+		case Insert:
+			if current == nil {
+				current = &change{start: i}
+			}
+			current.lines = append(current.lines, line.Text)
+		}
+	}
+	if current != nil {
+		current.end = i
+		result = append(result, *current)
+	}
+	return result
+}
+
+// apply returns the base lines [start, end) with the changes applied
+func apply(base []string, start, end int, changes []change) []string {
+	var result []string
+	pos := start
+	for _, c := range changes {
+		result = append(result, base[pos:c.start]...)
+		result = append(result, c.lines...)
+		pos = c.end
+	}
+	return append(result, base[pos:end]...)
+}
+
+func writeLines(out *bytes.Buffer, lines []string) {
+	for _, line := range lines {
+		out.WriteString(line)
+	}
+}
+
+// writeSide writes one side of a conflict, ending it with a newline so the
+// next marker starts its own line
+func writeSide(out *bytes.Buffer, lines []string) {
+	writeLines(out, lines)
+	if len(lines) > 0 && !strings.HasSuffix(lines[len(lines)-1], "\n") {
+		out.WriteByte('\n')
+	}
+}
@@ -0,0 +1,237 @@
+// Package textdiff computes line-based diffs for displaying file changes.
+//
+// It is intended for configuration files of modest size: the diff is an
+// exact longest-common-subsequence alignment, falling back to a plain
+// replacement when the inputs are too large to align cheaply.
+package textdiff
+
+import (
+	"strings"
+)
+
+// maxCells bounds the LCS table; larger inputs are shown as a replacement
+const maxCells = 4_000_000
+
+// Kind classifies a diff line
+type Kind int
+
+const (
+	// Equal lines appear on both sides
+	Equal Kind = iota
+	// Delete lines appear only on the old side
+	Delete
+	// Insert lines appear only on the new side
+	Insert
+)
+
+// Line is one line of a unified diff
+type Line struct {
+	Text string
+	Kind Kind
+}
+
+// Row pairs old and new lines for side-by-side display. Old or New is empty
+// when HasOld or HasNew is false.
+type Row struct {
+	Old    string
+	New    string
+	HasOld bool
+	HasNew bool
+}
+
+// Changed reports whether the row differs between the two sides
+func (r Row) Changed() bool {
+	return r.HasOld != r.HasNew || r.Old != r.New
+}
+
+// SplitLines splits content into lines without their line endings
+func SplitLines(content []byte) []string {
+	text := strings.TrimSuffix(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// Lines diffs two sequences of lines
+func Lines(oldLines, newLines []string) []Line {
+	// Trim the common prefix and suffix to keep the table small
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	var result []Line
+	for _, text := range oldLines[:prefix] {
+		result = append(result, Line{Kind: Equal, Text: text})
+	}
+	result = append(result, middle(oldLines[prefix:len(oldLines)-suffix], newLines[prefix:len(newLines)-suffix])...)
+	for _, text := range oldLines[len(oldLines)-suffix:] {
+		result = append(result, Line{Kind: Equal, Text: text})
+	}
+	return result
+}
+
+func middle(a, b []string) []Line {
+	if len(a)*len(b) > maxCells {
+		result := make([]Line, 0, len(a)+len(b))
+		for _, text := range a {
+			result = append(result, Line{Kind: Delete, Text: text})
+		}
+		for _, text := range b {
+			result = append(result, Line{Kind: Insert, Text: text})
+		}
+		return result
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var result []Line
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, Line{Kind: Equal, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, Line{Kind: Delete, Text: a[i]})
+			i++
+		default:
+			result = append(result, Line{Kind: Insert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		result = append(result, Line{Kind: Delete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		result = append(result, Line{Kind: Insert, Text: b[j]})
+	}
+	return result
+}
+
+// SideBySide arranges a diff into rows, pairing each run of deleted lines
+// with the run of inserted lines that replaces it
+func SideBySide(lines []Line) []Row {
+	var rows []Row
+	for i := 0; i < len(lines); {
+		if lines[i].Kind == Equal {
+			rows = append(rows, Row{Old: lines[i].Text, New: lines[i].Text, HasOld: true, HasNew: true})
+			i++
+			continue
+		}
+
+		var deleted, inserted []string
+		for ; i < len(lines) && lines[i].Kind != Equal; i++ {
+			if lines[i].Kind == Delete {
+				deleted = append(deleted, lines[i].Text)
+			} else {
+				inserted = append(inserted, lines[i].Text)
+			}
+		}
+		for k := 0; k < max(len(deleted), len(inserted)); k++ {
+			var row Row
+			if k < len(deleted) {
+				row.Old, row.HasOld = deleted[k], true
+			}
+			if k < len(inserted) {
+				row.New, row.HasNew = inserted[k], true
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// Hunk is a run of changed lines with surrounding context, as in a unified
+// diff. Starts are 1-based line numbers; an empty side starts at the line
+// before it.
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []Line
+}
+
+// Hunks groups the changed lines of a diff into hunks with up to context
+// equal lines around each change. Changes closer than twice the context
+// share a hunk.
+func Hunks(lines []Line, context int) []Hunk {
+	var hunks []Hunk
+	// oldNo and newNo are the line numbers before lines[i]
+	oldNo, newNo := 0, 0
+	for i := 0; i < len(lines); {
+		if lines[i].Kind == Equal {
+			oldNo++
+			newNo++
+			i++
+			continue
+		}
+
+		start := max(i-context, 0)
+		hunk := Hunk{OldStart: oldNo - (i - start) + 1, NewStart: newNo - (i - start) + 1}
+		end := i
+		for end < len(lines) {
+			if lines[end].Kind != Equal {
+				end++
+				continue
+			}
+			run := end
+			for run < len(lines) && lines[run].Kind == Equal {
+				run++
+			}
+			if run == len(lines) || run-end > 2*context {
+				end = min(end+context, len(lines))
+				break
+			}
+			end = run
+		}
+
+		hunk.Lines = lines[start:end]
+		for _, l := range hunk.Lines {
+			if l.Kind != Insert {
+				hunk.OldLines++
+			}
+			if l.Kind != Delete {
+				hunk.NewLines++
+			}
+		}
+		for _, l := range lines[i:end] {
+			if l.Kind != Insert {
+				oldNo++
+			}
+			if l.Kind != Delete {
+				newNo++
+			}
+		}
+		if hunk.OldLines == 0 {
+			hunk.OldStart--
+		}
+		if hunk.NewLines == 0 {
+			hunk.NewStart--
+		}
+		hunks = append(hunks, hunk)
+		i = end
+	}
+	return hunks
+}
@@ -0,0 +1,140 @@
+package textdiff
+
+import (
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	t.Parallel()
+
+	oldLines := []string{"{", `  "model": "opus",`, `  "theme": "dark"`, "}"}
+	newLines := []string{"{", `  "model": "sonnet",`, `  "theme": "dark",`, `  "verbose": true`, "}"}
+
+	got := Lines(oldLines, newLines)
+	want := []Line{
+		{Kind: Equal, Text: "{"},
+		{Kind: Delete, Text: `  "model": "opus",`},
+		{Kind: Delete, Text: `  "theme": "dark"`},
+		{Kind: Insert, Text: `  "model": "sonnet",`},
+		{Kind: Insert, Text: `  "theme": "dark",`},
+		{Kind: Insert, Text: `  "verbose": true`},
+		{Kind: Equal, Text: "}"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Lines() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Lines()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLines_KeepsCommonLinesInside(t *testing.T) {
+	t.Parallel()
+
+	got := Lines([]string{"a", "b", "c", "d"}, []string{"x", "b", "c", "y"})
+	kinds := ""
+	for _, line := range got {
+		kinds += map[Kind]string{Equal: "=", Delete: "-", Insert: "+"}[line.Kind]
+	}
+	if kinds != "-+==-+" {
+		t.Errorf("Lines() kinds = %s, want -+==-+", kinds)
+	}
+}
+
+func TestSideBySide(t *testing.T) {
+	t.Parallel()
+
+	rows := SideBySide(Lines([]string{"a", "b", "c"}, []string{"a", "B", "B2", "c"}))
+	want := []Row{
+		{Old: "a", New: "a", HasOld: true, HasNew: true},
+		{Old: "b", New: "B", HasOld: true, HasNew: true},
+		{New: "B2", HasNew: true},
+		{Old: "c", New: "c", HasOld: true, HasNew: true},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("SideBySide() = %+v, want %+v", rows, want)
+	}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Errorf("SideBySide()[%d] = %+v, want %+v", i, rows[i], want[i])
+		}
+	}
+	if rows[0].Changed() || !rows[1].Changed() || !rows[2].Changed() {
+		t.Error("Changed() did not flag the right rows")
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	t.Parallel()
+
+	if got := SplitLines([]byte("a\r\nb\n")); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("SplitLines() = %q", got)
+	}
+	if got := SplitLines(nil); got != nil {
+		t.Errorf("SplitLines(nil) = %q, want nil", got)
+	}
+}
+
+func TestHunks(t *testing.T) {
+	t.Parallel()
+
+	oldLines := []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12"}
+	newLines := []string{"1", "2", "3", "four", "5", "6", "7", "8", "9", "10", "11", "12", "13"}
+
+	hunks := Hunks(Lines(oldLines, newLines), 2)
+	if len(hunks) != 2 {
+		t.Fatalf("Hunks() returned %d hunks, want 2: %+v", len(hunks), hunks)
+	}
+	first := hunks[0]
+	if first.OldStart != 2 || first.OldLines != 5 || first.NewStart != 2 || first.NewLines != 5 {
+		t.Errorf("first hunk = -%d,%d +%d,%d, want -2,5 +2,5", first.OldStart, first.OldLines, first.NewStart, first.NewLines)
+	}
+	if len(first.Lines) != 6 || first.Lines[2] != (Line{Kind: Delete, Text: "4"}) || first.Lines[3] != (Line{Kind: Insert, Text: "four"}) {
+		t.Errorf("first hunk lines = %+v", first.Lines)
+	}
+	second := hunks[1]
+	if second.OldStart != 11 || second.OldLines != 2 || second.NewStart != 11 || second.NewLines != 3 {
+		t.Errorf("second hunk = -%d,%d +%d,%d, want -11,2 +11,3", second.OldStart, second.OldLines, second.NewStart, second.NewLines)
+	}
+
+	// Changes closer than twice the context share a hunk
+	if got := Hunks(Lines([]string{"a", "b", "c", "d"}, []string{"A", "b", "c", "D"}), 2); len(got) != 1 {
+		t.Errorf("Hunks() returned %d hunks for nearby changes, want 1", len(got))
+	}
+
+	// A new file starts at line 0 on the old side
+	added := Hunks(Lines(nil, []string{"x"}), 3)
+	if len(added) != 1 || added[0].OldStart != 0 || added[0].OldLines != 0 || added[0].NewStart != 1 || added[0].NewLines != 1 {
+		t.Errorf("Hunks() for a new file = %+v", added)
+	}
+}
+
+func TestMerge3(t *testing.T) {
+	t.Parallel()
+
+	base := "a\nb\nc\nd\ne\n"
+	tests := []struct {
+		name         string
+		ours, theirs string
+		want         string
+		clean        bool
+	}{
+		{"separate edits", "A\nb\nc\nd\ne\n", "a\nb\nc\nd\nE\n", "A\nb\nc\nd\nE\n", true},
+		{"same edit", "a\nB\nc\nd\ne\n", "a\nB\nc\nd\ne\n", "a\nB\nc\nd\ne\n", true},
+		{"one side", base, "a\nb\nC\nd\ne\nf\n", "a\nb\nC\nd\ne\nf\n", true},
+		{"conflict", "a\nb\nX\nd\ne\n", "a\nb\nY\nd\ne\n", "a\nb\n<<<<<<< ours\nX\n=======\nY\n>>>>>>> theirs\nd\ne\n", false},
+		{"adjacent edits", "a\nB\nc\nd\ne\n", "a\nb\nC\nd\ne\n", "a\n<<<<<<< ours\nB\nc\n=======\nb\nC\n>>>>>>> theirs\nd\ne\n", false},
+		{"no final newline", "a\nb\nc\nd\nx", "a\nb\nc\nd\ny", "a\nb\nc\nd\n<<<<<<< ours\nx\n=======\ny\n>>>>>>> theirs\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, clean := Merge3([]byte(base), []byte(tt.ours), []byte(tt.theirs), "ours", "theirs")
+			if string(got) != tt.want || clean != tt.clean {
+				t.Errorf("Merge3() = %q, %v, want %q, %v", got, clean, tt.want, tt.clean)
+			}
+		})
+	}
+}
@@ -67,6 +67,12 @@ var (
 	SectionStyle = lipgloss.NewStyle().
 			MarginTop(1).
 			MarginBottom(1)
+
+	DiffRemovedStyle = lipgloss.NewStyle().
+				Foreground(Error)
+
+	DiffAddedStyle = lipgloss.NewStyle().
+			Foreground(Success)
 )
 
 // RenderBox renders content inside a styled box with a title
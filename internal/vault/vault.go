@@ -0,0 +1,563 @@
+// Package vault keeps selected files of the config repository encrypted.
+//
+// A secret file such as mcp.json stays in plaintext on each machine but is
+// never committed; instead an encrypted sidecar (mcp.json.enc) is. The list of
+// secret files lives in the manifest, .claude-sync-secrets.json, which is
+// committed too. Sidecars use AES-256-GCM with the file path as additional
+// data, so a sidecar cannot be swapped for another file's.
+//
+// The key is either a random key file that stays on each machine (by default
+// in the user config directory) or derived from a passphrase given in
+// CLAUDE_SYNC_PASSPHRASE. Machines without the key skip secret files.
+package vault
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mfenderov/claude-sync/internal/rules"
+)
+
+const (
+	// ManifestFile lists the secret files; it is committed with the sidecars
+	ManifestFile = ".claude-sync-secrets.json"
+	// SidecarSuffix is appended to a secret file's path to name its sidecar
+	SidecarSuffix = ".enc"
+
+	// PassphraseEnv holds the passphrase for repositories that use one
+	PassphraseEnv = "CLAUDE_SYNC_PASSPHRASE"
+	// NewPassphraseEnv holds the new passphrase for Rotate
+	NewPassphraseEnv = "CLAUDE_SYNC_NEW_PASSPHRASE"
+	// KeyFileEnv overrides the location of the key file
+	KeyFileEnv = "CLAUDE_SYNC_KEY_FILE"
+
+	keySize       = 32
+	kdfIterations = 600_000
+	// sidecarMagic starts every sidecar. The NUL byte also makes git and the
+	// secret scanner treat sidecars as binary.
+	sidecarMagic = "CSENC\x00\x01"
+	keyCheckData = "claude-sync key check"
+)
+
+func init() {
+	rules.AlwaysSync(ManifestFile)
+}
+
+// ErrNoKey is returned when the key for a repository's secrets is not available
+var ErrNoKey = errors.New("no secrets key on this machine")
+
+// ErrWrongKey is returned when the available key does not match the manifest
+var ErrWrongKey = errors.New("secrets key does not match this repository")
+
+// Manifest is the committed list of secret files and key parameters
+type Manifest struct {
+	// KDF is set when the key is derived from a passphrase
+	KDF *KDF `json:"kdf,omitempty"`
+	// KeyCheck is a value encrypted with the key, to detect a wrong key
+	KeyCheck string   `json:"key_check"`
+	Files    []string `json:"files"`
+	Version  int      `json:"version"`
+}
+
+// KDF holds the PBKDF2 parameters for passphrase-derived keys
+type KDF struct {
+	Salt       string `json:"salt"`
+	Iterations int    `json:"iterations"`
+}
+
+// LoadManifest reads the manifest; it returns nil without error when the
+// repository has no secret files
+func LoadManifest(repoPath string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(repoPath, ManifestFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ManifestFile, err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ManifestFile, err)
+	}
+	// The manifest arrives with pulls, so an entry must not reach outside
+	// the repository when its sidecar is decrypted
+	for _, file := range m.Files {
+		if file != filepath.ToSlash(filepath.Clean(file)) {
+			return nil, fmt.Errorf("invalid %s: %q is not a clean relative path", ManifestFile, file)
+		}
+		if err := checkFile(file); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", ManifestFile, err)
+		}
+	}
+	return &m, nil
+}
+
+// checkFile rejects secret file paths, cleaned and slash-separated, that
+// leave the repository or name the vault's own files
+func checkFile(file string) error {
+	if file == ".." || strings.HasPrefix(file, "../") || path.IsAbs(file) || filepath.IsAbs(file) || filepath.VolumeName(file) != "" {
+		return fmt.Errorf("%s is outside the repository", file)
+	}
+	if file == "." || file == ManifestFile || strings.HasSuffix(file, SidecarSuffix) {
+		return fmt.Errorf("%s cannot be encrypted", file)
+	}
+	return nil
+}
+
+func (m *Manifest) save(repoPath string) error {
+	slices.Sort(m.Files)
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, ManifestFile), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", ManifestFile, err)
+	}
+	return nil
+}
+
+// DefaultKeyFile returns the key file location: $CLAUDE_SYNC_KEY_FILE, or
+// claude-sync/secrets.key in the user config directory
+func DefaultKeyFile() (string, error) {
+	if path := os.Getenv(KeyFileEnv); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "claude-sync", "secrets.key"), nil
+}
+
+// Vault encrypts and decrypts the secret files of one repository
+type Vault struct {
+	manifest *Manifest
+	repoPath string
+	key      []byte
+}
+
+// Open loads the manifest and the key for a repository. It returns nil
+// without error when the repository has no secret files, and ErrNoKey when
+// the key is not available on this machine.
+func Open(repoPath string) (*Vault, error) {
+	m, err := LoadManifest(repoPath)
+	if err != nil || m == nil {
+		return nil, err
+	}
+	key, err := loadKey(m)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkKey(m, key); err != nil {
+		return nil, err
+	}
+	return &Vault{manifest: m, repoPath: repoPath, key: key}, nil
+}
+
+// Init opens the repository's vault, creating the manifest and a key when
+// there is none yet. A new vault uses the passphrase from
+// CLAUDE_SYNC_PASSPHRASE if set, and otherwise a generated key file.
+// created reports whether a key file was generated.
+func Init(repoPath string) (v *Vault, created bool, err error) {
+	m, err := LoadManifest(repoPath)
+	if err != nil {
+		return nil, false, err
+	}
+	if m != nil {
+		v, err := Open(repoPath)
+		return v, false, err
+	}
+
+	m = &Manifest{Version: 1}
+	key, created, err := newKey(m, os.Getenv(PassphraseEnv))
+	if err != nil {
+		return nil, false, err
+	}
+	if m.KeyCheck, err = keyCheck(key); err != nil {
+		return nil, false, err
+	}
+	if err := m.save(repoPath); err != nil {
+		return nil, false, err
+	}
+	return &Vault{manifest: m, repoPath: repoPath, key: key}, created, nil
+}
+
+// newKey sets up the key parameters of a manifest: derived from passphrase
+// when given, otherwise the existing or a newly generated key file
+func newKey(m *Manifest, passphrase string) (key []byte, created bool, err error) {
+	if passphrase != "" {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, false, err
+		}
+		m.KDF = &KDF{Salt: base64.StdEncoding.EncodeToString(salt), Iterations: kdfIterations}
+		key, err := deriveKey(m.KDF, passphrase)
+		return key, false, err
+	}
+
+	m.KDF = nil
+	path, err := DefaultKeyFile()
+	if err != nil {
+		return nil, false, err
+	}
+	if key, err := readKeyFile(path); err == nil {
+		return key, false, nil
+	}
+	key = make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, false, err
+	}
+	if err := writeKeyFile(path, key); err != nil {
+		return nil, false, err
+	}
+	return key, true, nil
+}
+
+func loadKey(m *Manifest) ([]byte, error) {
+	if m.KDF != nil {
+		passphrase := os.Getenv(PassphraseEnv)
+		if passphrase == "" {
+			return nil, fmt.Errorf("%w: set %s", ErrNoKey, PassphraseEnv)
+		}
+		return deriveKey(m.KDF, passphrase)
+	}
+
+	path, err := DefaultKeyFile()
+	if err != nil {
+		return nil, err
+	}
+	key, err := readKeyFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: copy the key file to %s", ErrNoKey, path)
+	}
+	return key, err
+}
+
+func deriveKey(kdf *KDF, passphrase string) ([]byte, error) {
+	salt, err := base64.StdEncoding.DecodeString(kdf.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt in %s: %w", ManifestFile, err)
+	}
+	return pbkdf2.Key(sha256.New, passphrase, salt, kdf.Iterations, keySize)
+}
+
+func readKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != keySize {
+		return nil, fmt.Errorf("invalid key file %s", path)
+	}
+	return key, nil
+}
+
+func writeKeyFile(path string, key []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+	data := base64.StdEncoding.EncodeToString(key) + "\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	return nil
+}
+
+func keyCheck(key []byte) (string, error) {
+	sealed, err := seal(key, "", []byte(keyCheckData))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func checkKey(m *Manifest, key []byte) error {
+	sealed, err := base64.StdEncoding.DecodeString(m.KeyCheck)
+	if err != nil {
+		return fmt.Errorf("invalid key check in %s: %w", ManifestFile, err)
+	}
+	if _, err := unseal(key, "", sealed); err != nil {
+		return ErrWrongKey
+	}
+	return nil
+}
+
+// seal encrypts plaintext for the file at path (used as additional data)
+func seal(key []byte, path string, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append([]byte(sidecarMagic), nonce...)
+	return gcm.Seal(out, nonce, plaintext, []byte(path)), nil
+}
+
+// unseal decrypts and authenticates a sidecar for the file at path
+func unseal(key []byte, path string, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	body, ok := bytes.CutPrefix(sealed, []byte(sidecarMagic))
+	if !ok || len(body) < gcm.NonceSize() {
+		return nil, errors.New("not an encrypted sidecar")
+	}
+	nonce, ciphertext := body[:gcm.NonceSize()], body[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, []byte(path))
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Files returns the secret files, relative to the repository
+func (v *Vault) Files() []string {
+	return slices.Clone(v.manifest.Files)
+}
+
+// Add registers a secret file and writes its sidecar
+func (v *Vault) Add(file string) error {
+	file = filepath.ToSlash(filepath.Clean(file))
+	if err := checkFile(file); err != nil {
+		return err
+	}
+	info, err := os.Stat(filepath.Join(v.repoPath, filepath.FromSlash(file)))
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", file)
+	}
+
+	if !slices.Contains(v.manifest.Files, file) {
+		v.manifest.Files = append(v.manifest.Files, file)
+		if err := v.manifest.save(v.repoPath); err != nil {
+			return err
+		}
+	}
+	_, err = v.sealFile(file)
+	return err
+}
+
+// Seal writes the sidecar of every secret file whose plaintext changed and
+// returns the sidecars written. Missing plaintext files are skipped.
+func (v *Vault) Seal() ([]string, error) {
+	var written []string
+	for _, file := range v.manifest.Files {
+		changed, err := v.sealFile(file)
+		if err != nil {
+			return written, err
+		}
+		if changed {
+			written = append(written, file+SidecarSuffix)
+		}
+	}
+	return written, nil
+}
+
+// sealFile encrypts one file unless its sidecar already holds the same content
+func (v *Vault) sealFile(file string) (bool, error) {
+	plaintext, err := os.ReadFile(v.path(file))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if current, err := v.decrypt(file); err == nil && bytes.Equal(current, plaintext) {
+		return false, nil
+	}
+
+	sealed, err := seal(v.key, file, plaintext)
+	if err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(v.path(file)+SidecarSuffix, sealed, 0o644); err != nil {
+		return false, fmt.Errorf("failed to write sidecar for %s: %w", file, err)
+	}
+	return true, nil
+}
+
+// Unseal writes the plaintext of every secret file whose sidecar differs
+// from it, e.g. after a pull, and returns the files written
+func (v *Vault) Unseal() ([]string, error) {
+	var written []string
+	for _, file := range v.manifest.Files {
+		plaintext, err := v.decrypt(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return written, fmt.Errorf("failed to decrypt %s: %w", file, err)
+		}
+		if current, err := os.ReadFile(v.path(file)); err == nil && bytes.Equal(current, plaintext) {
+			continue
+		}
+
+		perm := os.FileMode(0o600)
+		if info, err := os.Stat(v.path(file)); err == nil {
+			perm = info.Mode().Perm()
+		}
+		if err := os.MkdirAll(filepath.Dir(v.path(file)), 0o755); err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(v.path(file), plaintext, perm); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", file, err)
+		}
+		written = append(written, file)
+	}
+	return written, nil
+}
+
+// Rotate re-encrypts every sidecar with a new key: derived from newPassphrase
+// when given, otherwise a newly generated key file. The previous key file, if
+// any, is kept next to the new one with a ".old" suffix.
+func (v *Vault) Rotate(newPassphrase string) error {
+	if v.manifest.KDF != nil && newPassphrase == "" {
+		return fmt.Errorf("this repository uses a passphrase: set %s to the new passphrase", NewPassphraseEnv)
+	}
+
+	plaintexts := map[string][]byte{}
+	for _, file := range v.manifest.Files {
+		plaintext, err := v.decrypt(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s: %w", file, err)
+		}
+		plaintexts[file] = plaintext
+	}
+
+	if newPassphrase == "" {
+		path, err := DefaultKeyFile()
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); err == nil {
+			if err := os.Rename(path, path+".old"); err != nil {
+				return fmt.Errorf("failed to back up key file: %w", err)
+			}
+		}
+	}
+	m := &Manifest{Version: v.manifest.Version, Files: v.manifest.Files}
+	key, _, err := newKey(m, newPassphrase)
+	if err != nil {
+		return err
+	}
+	if m.KeyCheck, err = keyCheck(key); err != nil {
+		return err
+	}
+
+	for file, plaintext := range plaintexts {
+		sealed, err := seal(key, file, plaintext)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(v.path(file)+SidecarSuffix, sealed, 0o644); err != nil {
+			return fmt.Errorf("failed to write sidecar for %s: %w", file, err)
+		}
+	}
+	v.manifest, v.key = m, key
+	return m.save(v.repoPath)
+}
+
+// State describes a secret file on this machine
+type State string
+
+// Secret file states reported by Status
+const (
+	// StateSynced means the sidecar matches the plaintext
+	StateSynced State = "synced"
+	// StateChanged means the plaintext changed and is encrypted on the next sync
+	StateChanged State = "changed"
+	// StateNotEncrypted means there is no sidecar yet
+	StateNotEncrypted State = "not encrypted"
+	// StateNotDecrypted means the sidecar has not been decrypted on this machine
+	StateNotDecrypted State = "not decrypted"
+	// StateMissing means neither the file nor its sidecar exists
+	StateMissing State = "missing"
+	// StateLocked means the key is not available on this machine
+	StateLocked State = "locked"
+)
+
+// FileStatus is the state of one secret file
+type FileStatus struct {
+	Path  string `json:"path"`
+	State State  `json:"state"`
+}
+
+// Status reports the state of every secret file in the manifest. Without
+// the key every file is reported as locked.
+func Status(repoPath string) ([]FileStatus, error) {
+	m, err := LoadManifest(repoPath)
+	if err != nil || m == nil {
+		return nil, err
+	}
+	v, err := Open(repoPath)
+	if err != nil && !errors.Is(err, ErrNoKey) && !errors.Is(err, ErrWrongKey) {
+		return nil, err
+	}
+
+	statuses := make([]FileStatus, 0, len(m.Files))
+	for _, file := range m.Files {
+		if v == nil {
+			statuses = append(statuses, FileStatus{Path: file, State: StateLocked})
+			continue
+		}
+		statuses = append(statuses, FileStatus{Path: file, State: v.state(file)})
+	}
+	return statuses, nil
+}
+
+func (v *Vault) state(file string) State {
+	plaintext, plainErr := os.ReadFile(v.path(file))
+	decrypted, sidecarErr := v.decrypt(file)
+	switch {
+	case plainErr != nil && sidecarErr != nil:
+		return StateMissing
+	case sidecarErr != nil:
+		return StateNotEncrypted
+	case plainErr != nil:
+		return StateNotDecrypted
+	case !bytes.Equal(plaintext, decrypted):
+		return StateChanged
+	default:
+		return StateSynced
+	}
+}
+
+func (v *Vault) decrypt(file string) ([]byte, error) {
+	sealed, err := os.ReadFile(v.path(file) + SidecarSuffix)
+	if err != nil {
+		return nil, err
+	}
+	return unseal(v.key, file, sealed)
+}
+
+func (v *Vault) path(file string) string {
+	return filepath.Join(v.repoPath, filepath.FromSlash(file))
+}
@@ -0,0 +1,312 @@
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/mfenderov/claude-sync/internal/rules"
+)
+
+// setupRepo returns a repository directory and points the key file at a
+// fresh location. Tests using it cannot run in parallel.
+func setupRepo(t *testing.T) (repo, keyFile string) {
+	t.Helper()
+	keyFile = filepath.Join(t.TempDir(), "secrets.key")
+	t.Setenv(KeyFileEnv, keyFile)
+	t.Setenv(PassphraseEnv, "")
+	return t.TempDir(), keyFile
+}
+
+func writeFile(t *testing.T, repo, name, content string) {
+	t.Helper()
+	path := filepath.Join(repo, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, repo, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(repo, filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestOpen_NoManifest(t *testing.T) {
+	repo, _ := setupRepo(t)
+
+	v, err := Open(repo)
+	if v != nil || err != nil {
+		t.Errorf("Open() = %v, %v, want nil, nil", v, err)
+	}
+}
+
+func TestSealUnsealRoundTrip(t *testing.T) {
+	repo, keyFile := setupRepo(t)
+	writeFile(t, repo, "mcp.json", `{"token": "one"}`)
+
+	v, created, err := Init(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created {
+		t.Error("Init() did not create a key file")
+	}
+	if info, err := os.Stat(keyFile); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("key file = %v, %v, want mode 0600", info, err)
+	}
+	if err := v.Add("mcp.json"); err != nil {
+		t.Fatal(err)
+	}
+	sidecar := readFile(t, repo, "mcp.json"+SidecarSuffix)
+	if sidecar == `{"token": "one"}` || !slices.Equal(v.Files(), []string{"mcp.json"}) {
+		t.Fatalf("Add() did not encrypt: files %v", v.Files())
+	}
+
+	// Nothing changed, so nothing is re-encrypted
+	if written, err := v.Seal(); err != nil || len(written) != 0 {
+		t.Errorf("Seal() = %v, %v, want nothing written", written, err)
+	}
+	writeFile(t, repo, "mcp.json", `{"token": "two"}`)
+	if written, err := v.Seal(); err != nil || !slices.Equal(written, []string{"mcp.json.enc"}) {
+		t.Errorf("Seal() = %v, %v, want mcp.json.enc", written, err)
+	}
+
+	// Another machine with the same key decrypts the sidecar
+	other := t.TempDir()
+	for _, name := range []string{ManifestFile, "mcp.json" + SidecarSuffix} {
+		writeFile(t, other, name, readFile(t, repo, name))
+	}
+	v2, err := Open(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written, err := v2.Unseal(); err != nil || !slices.Equal(written, []string{"mcp.json"}) {
+		t.Fatalf("Unseal() = %v, %v, want mcp.json", written, err)
+	}
+	if got := readFile(t, other, "mcp.json"); got != `{"token": "two"}` {
+		t.Errorf("decrypted = %q", got)
+	}
+	if written, err := v2.Unseal(); err != nil || len(written) != 0 {
+		t.Errorf("second Unseal() = %v, %v, want nothing written", written, err)
+	}
+}
+
+func TestAdd_Rejects(t *testing.T) {
+	repo, _ := setupRepo(t)
+	writeFile(t, repo, "a.enc", "x")
+	v, _, err := Init(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range []string{"../outside", ManifestFile, "a.enc", "missing.json", "."} {
+		if err := v.Add(file); err == nil {
+			t.Errorf("Add(%q) succeeded", file)
+		}
+	}
+}
+
+func TestOpen_MissingAndWrongKey(t *testing.T) {
+	repo, keyFile := setupRepo(t)
+	writeFile(t, repo, "mcp.json", "secret")
+	v, _, err := Init(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Add("mcp.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(KeyFileEnv, filepath.Join(t.TempDir(), "absent.key"))
+	if _, err := Open(repo); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Open() without key = %v, want ErrNoKey", err)
+	}
+	statuses, err := Status(repo)
+	if err != nil || len(statuses) != 1 || statuses[0].State != StateLocked {
+		t.Errorf("Status() without key = %v, %v, want locked", statuses, err)
+	}
+
+	// A different key is detected before anything is decrypted
+	otherKey := filepath.Join(t.TempDir(), "other.key")
+	t.Setenv(KeyFileEnv, otherKey)
+	if _, _, err := Init(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(repo); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("Open() with another key = %v, want ErrWrongKey", err)
+	}
+	t.Setenv(KeyFileEnv, keyFile)
+	if _, err := Open(repo); err != nil {
+		t.Errorf("Open() with the key = %v", err)
+	}
+}
+
+func TestSidecarBoundToPath(t *testing.T) {
+	repo, _ := setupRepo(t)
+	writeFile(t, repo, "a.json", "a")
+	writeFile(t, repo, "b.json", "b")
+	v, _, err := Init(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{"a.json", "b.json"} {
+		if err := v.Add(file); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Swapping sidecars must not decrypt a.json to b.json's content
+	writeFile(t, repo, "a.json.enc", readFile(t, repo, "b.json.enc"))
+	if err := os.Remove(filepath.Join(repo, "a.json")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Unseal(); err == nil {
+		t.Error("Unseal() accepted a sidecar of another file")
+	}
+}
+
+func TestPassphrase(t *testing.T) {
+	repo, keyFile := setupRepo(t)
+	t.Setenv(PassphraseEnv, "correct horse")
+	writeFile(t, repo, "mcp.json", "secret")
+
+	v, created, err := Init(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created {
+		t.Error("Init() with a passphrase created a key file")
+	}
+	if _, err := os.Stat(keyFile); !os.IsNotExist(err) {
+		t.Errorf("key file exists: %v", err)
+	}
+	if err := v.Add("mcp.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(PassphraseEnv, "")
+	if _, err := Open(repo); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Open() without passphrase = %v, want ErrNoKey", err)
+	}
+	t.Setenv(PassphraseEnv, "wrong")
+	if _, err := Open(repo); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("Open() with wrong passphrase = %v, want ErrWrongKey", err)
+	}
+	t.Setenv(PassphraseEnv, "correct horse")
+	if _, err := Open(repo); err != nil {
+		t.Errorf("Open() = %v", err)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	repo, keyFile := setupRepo(t)
+	writeFile(t, repo, "mcp.json", "secret")
+	v, _, err := Init(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Add("mcp.json"); err != nil {
+		t.Fatal(err)
+	}
+	oldSidecar := readFile(t, repo, "mcp.json.enc")
+
+	if err := v.Rotate(""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(keyFile + ".old"); err != nil {
+		t.Errorf("old key file not kept: %v", err)
+	}
+	if readFile(t, repo, "mcp.json.enc") == oldSidecar {
+		t.Error("Rotate() did not re-encrypt the sidecar")
+	}
+
+	// The new key opens the repository, the old one no longer does
+	if err := os.Remove(filepath.Join(repo, "mcp.json")); err != nil {
+		t.Fatal(err)
+	}
+	v2, err := Open(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v2.Unseal(); err != nil || readFile(t, repo, "mcp.json") != "secret" {
+		t.Errorf("Unseal() after rotate = %v", err)
+	}
+	t.Setenv(KeyFileEnv, keyFile+".old")
+	if _, err := Open(repo); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("Open() with the old key = %v, want ErrWrongKey", err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	repo, _ := setupRepo(t)
+	for _, name := range []string{"synced", "changed", "plain", "gone"} {
+		writeFile(t, repo, name, name)
+	}
+	v, _, err := Init(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"synced", "changed", "plain", "gone"} {
+		if err := v.Add(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, repo, "changed", "edited")
+	if err := os.Remove(filepath.Join(repo, "plain.enc")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(repo, "gone")); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err := Status(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FileStatus{
+		{Path: "changed", State: StateChanged},
+		{Path: "gone", State: StateNotDecrypted},
+		{Path: "plain", State: StateNotEncrypted},
+		{Path: "synced", State: StateSynced},
+	}
+	if !slices.Equal(statuses, want) {
+		t.Errorf("Status() = %v, want %v", statuses, want)
+	}
+}
+
+func TestManifestAlwaysSynced(t *testing.T) {
+	t.Parallel()
+
+	set := &rules.Set{Include: []string{"settings.json"}, Exclude: []string{".claude-sync*"}}
+	if d := set.Match(ManifestFile, false); !d.Synced {
+		t.Errorf("Match(%s) = %+v, want synced whatever the rules say", ManifestFile, d)
+	}
+}
+
+func TestOpen_RejectsUnsafeManifest(t *testing.T) {
+	for _, file := range []string{"../outside", "..", "/etc/passwd", "a/../../outside", "./mcp.json", ManifestFile, "mcp.json.enc"} {
+		repo, _ := setupRepo(t)
+		data, err := json.Marshal(Manifest{Version: 1, Files: []string{"mcp.json", file}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, repo, ManifestFile, string(data))
+
+		if v, err := Open(repo); err == nil {
+			t.Errorf("Open() with entry %q = %v, want error", file, v)
+		}
+		if _, err := Status(repo); err == nil {
+			t.Errorf("Status() with entry %q succeeded", file)
+		}
+	}
+}
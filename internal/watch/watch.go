@@ -0,0 +1,189 @@
+// Package watch reports settled file changes under a directory tree.
+//
+// It wraps fsnotify with recursive directory watches and debouncing: bursts
+// of events (an editor saving a file, a plugin install writing many files)
+// are collected and delivered as one batch once the tree has been quiet for
+// the debounce period. Everything inside .git is ignored.
+package watch
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDebounce is how long the tree must be quiet before a batch is delivered
+const DefaultDebounce = 2 * time.Second
+
+// Options configures a Watcher
+type Options struct {
+	// Ignored returns the subset of paths that should not be watched or
+	// reported. Paths are slash-separated and relative to the root;
+	// directories have a trailing slash. May be nil.
+	Ignored func(paths []string) []string
+	// Debounce defaults to DefaultDebounce
+	Debounce time.Duration
+}
+
+// Watcher delivers debounced batches of changed paths
+type Watcher struct {
+	fs      *fsnotify.Watcher
+	changes chan []string
+	opts    Options
+	root    string
+}
+
+// New creates a Watcher with recursive watches on root
+func New(root string, opts Options) (*Watcher, error) {
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultDebounce
+	}
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		fs:      fsw,
+		changes: make(chan []string),
+		opts:    opts,
+		root:    root,
+	}
+	if err := w.addTree(root); err != nil {
+		_ = fsw.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Changes returns the channel of settled batches. Paths are sorted,
+// slash-separated and relative to the root. It is closed when Run returns.
+func (w *Watcher) Changes() <-chan []string {
+	return w.changes
+}
+
+// Run processes events until ctx is cancelled, then releases the watches
+func (w *Watcher) Run(ctx context.Context) error {
+	defer close(w.changes)
+	defer w.fs.Close()
+
+	pending := map[string]bool{}
+	timer := time.NewTimer(w.opts.Debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return nil
+			}
+			rel, ok := w.relative(event.Name)
+			if !ok {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					// New directories need their own watches
+					_ = w.addTree(event.Name)
+				}
+			}
+			pending[rel] = true
+			timer.Reset(w.opts.Debounce)
+
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return nil
+			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				// Events were dropped; report the whole tree as changed
+				pending["."] = true
+				timer.Reset(w.opts.Debounce)
+			}
+
+		case <-timer.C:
+			batch := w.filter(pending)
+			pending = map[string]bool{}
+			if len(batch) == 0 {
+				continue
+			}
+			select {
+			case w.changes <- batch:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// addTree watches dir and every directory below it that is not ignored
+func (w *Watcher) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Directories can vanish while walking
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+
+		if path != w.root {
+			rel, ok := w.relative(path)
+			if !ok || w.ignored(rel+"/") {
+				return filepath.SkipDir
+			}
+		}
+		return w.fs.Add(path)
+	})
+}
+
+// relative converts an absolute path to a slash-separated path relative to
+// the root, reporting false for paths inside .git
+func (w *Watcher) relative(path string) (string, bool) {
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == ".git" || strings.HasPrefix(rel, ".git/") {
+		return "", false
+	}
+	return rel, true
+}
+
+func (w *Watcher) ignored(path string) bool {
+	if w.opts.Ignored == nil {
+		return false
+	}
+	return len(w.opts.Ignored([]string{path})) > 0
+}
+
+// filter drops ignored paths from a pending set and sorts the rest
+func (w *Watcher) filter(pending map[string]bool) []string {
+	paths := make([]string, 0, len(pending))
+	for path := range pending {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	if w.opts.Ignored == nil {
+		return paths
+	}
+	ignored := w.opts.Ignored(paths)
+	return slices.DeleteFunc(paths, func(path string) bool {
+		return slices.Contains(ignored, path)
+	})
+}
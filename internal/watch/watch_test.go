@@ -0,0 +1,107 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func startWatcher(t *testing.T, root string, opts Options) *Watcher {
+	t.Helper()
+
+	w, err := New(root, opts)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = w.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return w
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func nextBatch(t *testing.T, w *Watcher) []string {
+	t.Helper()
+	select {
+	case batch := <-w.Changes():
+		return batch
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for changes")
+		return nil
+	}
+}
+
+func TestWatcher_DebouncesAndFilters(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, dir := range []string{".git/objects", "projects", "hooks"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+
+	w := startWatcher(t, root, Options{
+		Debounce: 100 * time.Millisecond,
+		Ignored: func(paths []string) []string {
+			var ignored []string
+			for _, path := range paths {
+				if strings.HasPrefix(path, "projects/") || strings.HasSuffix(path, ".log") {
+					ignored = append(ignored, path)
+				}
+			}
+			return ignored
+		},
+	})
+
+	writeFile(t, filepath.Join(root, ".git", "index"), "noise")
+	writeFile(t, filepath.Join(root, "projects", "transcript.jsonl"), "noise")
+	writeFile(t, filepath.Join(root, "debug.log"), "noise")
+	writeFile(t, filepath.Join(root, "settings.json"), "{}")
+	writeFile(t, filepath.Join(root, "hooks", "notify.sh"), "#!/bin/sh")
+
+	batch := nextBatch(t, w)
+	want := []string{"hooks/notify.sh", "settings.json"}
+	if !slices.Equal(batch, want) {
+		t.Errorf("batch = %v, want %v", batch, want)
+	}
+}
+
+func TestWatcher_WatchesNewDirectories(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	w := startWatcher(t, root, Options{Debounce: 100 * time.Millisecond})
+
+	if err := os.MkdirAll(filepath.Join(root, "skills", "review"), 0o755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	nextBatch(t, w)
+
+	writeFile(t, filepath.Join(root, "skills", "review", "SKILL.md"), "# Review")
+	batch := nextBatch(t, w)
+	if !slices.Contains(batch, "skills/review/SKILL.md") {
+		t.Errorf("batch = %v, want it to include skills/review/SKILL.md", batch)
+	}
+}